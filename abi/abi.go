@@ -0,0 +1,274 @@
+// Package abi parses Solidity JSON ABI definitions and decodes call data,
+// return data and events with them.
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// Argument is a named parameter of a method or event
+type Argument struct {
+	Name    string
+	Type    *Type
+	Indexed bool // event topics only
+}
+
+// Method is a callable contract function
+type Method struct {
+	Name            string
+	Inputs          []Argument
+	Outputs         []Argument
+	StateMutability string // pure, view, nonpayable or payable
+	Selector        [4]byte
+}
+
+// Event is a log a contract may emit
+type Event struct {
+	Name      string
+	Inputs    []Argument
+	Anonymous bool
+	ID        common.Hash // topic 0 of non-anonymous events
+}
+
+// ABI holds the methods and events of a contract
+type ABI struct {
+	Methods map[string]*Method
+	Events  map[string]*Event
+}
+
+type jsonArgument struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Indexed bool   `json:"indexed"`
+}
+
+type jsonEntry struct {
+	Type      string         `json:"type"`
+	Name      string         `json:"name"`
+	Inputs    []jsonArgument `json:"inputs"`
+	Outputs   []jsonArgument `json:"outputs"`
+	Anonymous bool           `json:"anonymous"`
+
+	StateMutability string `json:"stateMutability"`
+	Constant        bool   `json:"constant"` // used before stateMutability was introduced
+}
+
+// JSON parses a JSON ABI definition. Constructors, fallback and receive
+// entries and errors are ignored.
+func JSON(r io.Reader) (*ABI, error) {
+	var entries []jsonEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	abi := &ABI{Methods: make(map[string]*Method), Events: make(map[string]*Event)}
+	for _, entry := range entries {
+		switch entry.Type {
+		case "function", "":
+			inputs, err := parseArguments(entry.Inputs)
+			if err != nil {
+				return nil, fmt.Errorf("method %s: %v", entry.Name, err)
+			}
+			outputs, err := parseArguments(entry.Outputs)
+			if err != nil {
+				return nil, fmt.Errorf("method %s: %v", entry.Name, err)
+			}
+			m := &Method{Name: entry.Name, Inputs: inputs, Outputs: outputs, StateMutability: entry.StateMutability}
+			if m.StateMutability == "" && entry.Constant {
+				m.StateMutability = "view"
+			}
+			copy(m.Selector[:], crypto.Keccak256([]byte(m.Signature()))[:4])
+			abi.Methods[entry.Name] = m
+		case "event":
+			inputs, err := parseArguments(entry.Inputs)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %v", entry.Name, err)
+			}
+			e := &Event{Name: entry.Name, Inputs: inputs, Anonymous: entry.Anonymous}
+			e.ID = crypto.Keccak256Hash([]byte(e.Signature()))
+			abi.Events[entry.Name] = e
+		}
+	}
+	return abi, nil
+}
+
+func parseArguments(args []jsonArgument) ([]Argument, error) {
+	parsed := make([]Argument, len(args))
+	for i, arg := range args {
+		t, err := NewType(arg.Type)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = Argument{Name: arg.Name, Type: t, Indexed: arg.Indexed}
+	}
+	return parsed, nil
+}
+
+// ParseSignature splits a text signature such as transfer(address,uint256)
+// into its name and unnamed arguments
+func ParseSignature(sig string) (string, []Argument, error) {
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("abi: invalid signature %q", sig)
+	}
+	name, list := sig[:open], sig[open+1:len(sig)-1]
+	if list == "" {
+		return name, nil, nil
+	}
+	var args []Argument
+	for _, typ := range strings.Split(list, ",") {
+		t, err := NewType(strings.TrimSpace(typ))
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, Argument{Type: t})
+	}
+	return name, args, nil
+}
+
+// NewMethod creates a method from its text signature
+func NewMethod(sig string) (*Method, error) {
+	name, inputs, err := ParseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	m := &Method{Name: name, Inputs: inputs}
+	copy(m.Selector[:], crypto.Keccak256([]byte(m.Signature()))[:4])
+	return m, nil
+}
+
+func signature(name string, args []Argument) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return name + "(" + strings.Join(types, ",") + ")"
+}
+
+func argumentTypes(args []Argument) []*Type {
+	types := make([]*Type, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type
+	}
+	return types
+}
+
+// Signature returns the canonical signature, e.g. transfer(address,uint256)
+func (m *Method) Signature() string { return signature(m.Name, m.Inputs) }
+
+// IsConstant reports whether the method is view or pure, and so may be
+// called with STATICCALL
+func (m *Method) IsConstant() bool {
+	return m.StateMutability == "view" || m.StateMutability == "pure"
+}
+
+// DecodeInput decodes call data, including its 4-byte selector
+func (m *Method) DecodeInput(data []byte) ([]interface{}, error) {
+	if len(data) < 4 {
+		return nil, errShortData
+	}
+	return Decode(argumentTypes(m.Inputs), data[4:])
+}
+
+// DecodeOutput decodes the data returned by the method
+func (m *Method) DecodeOutput(data []byte) ([]interface{}, error) {
+	return Decode(argumentTypes(m.Outputs), data)
+}
+
+// Signature returns the canonical signature, e.g. Transfer(address,address,uint256)
+func (e *Event) Signature() string { return signature(e.Name, e.Inputs) }
+
+// Decode decodes the arguments of a log emitted for the event. Indexed
+// arguments of dynamic types decode to the common.Hash stored in the topic.
+func (e *Event) Decode(topics []common.Hash, data []byte) ([]interface{}, error) {
+	if !e.Anonymous {
+		if len(topics) == 0 || topics[0] != e.ID {
+			return nil, fmt.Errorf("abi: log is not a %s event", e.Name)
+		}
+		topics = topics[1:]
+	}
+	var unindexed []*Type
+	for _, arg := range e.Inputs {
+		if !arg.Indexed {
+			unindexed = append(unindexed, arg.Type)
+		}
+	}
+	decoded, err := Decode(unindexed, data)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(e.Inputs))
+	for i, arg := range e.Inputs {
+		if !arg.Indexed {
+			values[i], decoded = decoded[0], decoded[1:]
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("abi: missing topic for %s", arg.Name)
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if arg.Type.dynamic() || arg.Type.Kind == ArrayKind {
+			values[i] = topic
+			continue
+		}
+		if values[i], err = decodeStatic(arg.Type, topic[:], 0); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// MethodBySelector returns the method whose selector starts data
+func (a *ABI) MethodBySelector(data []byte) (*Method, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	for _, m := range a.Methods {
+		if string(m.Selector[:]) == string(data[:4]) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// EventByID returns the non-anonymous event whose topic 0 is id
+func (a *ABI) EventByID(id common.Hash) (*Event, bool) {
+	for _, e := range a.Events {
+		if !e.Anonymous && e.ID == id {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// FormatValue renders a decoded value the way it is written in Solidity
+func FormatValue(v interface{}) string {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			elems[i] = FormatValue(elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
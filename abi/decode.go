@@ -0,0 +1,126 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+var errShortData = errors.New("abi: data too short")
+
+// Decode decodes data encoded as a tuple of the given types. Integers decode
+// to *big.Int, addresses to common.Address, fixed bytes and bytes to []byte,
+// slices and arrays to []interface{}.
+func Decode(types []*Type, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	offset := 0
+	for i, t := range types {
+		v, err := decodeHead(t, data, offset)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		offset += t.headSize()
+	}
+	return values, nil
+}
+
+// decodeHead decodes the value whose head starts at offset within the tuple
+// encoding data
+func decodeHead(t *Type, data []byte, offset int) (interface{}, error) {
+	if !t.dynamic() {
+		return decodeStatic(t, data, offset)
+	}
+	ptr, err := readLength(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDynamic(t, data[ptr:])
+}
+
+func decodeStatic(t *Type, data []byte, offset int) (interface{}, error) {
+	if t.Kind == ArrayKind {
+		if len(data) < offset {
+			return nil, errShortData
+		}
+		return decodeElems(t.Elem, t.Size, data[offset:])
+	}
+	word, err := readWord(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Kind {
+	case UintKind:
+		return new(big.Int).SetBytes(word), nil
+	case IntKind:
+		v := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return v, nil
+	case AddressKind:
+		return common.BytesToAddress(word[12:]), nil
+	case BoolKind:
+		return word[31] == 1, nil
+	case FixedBytesKind:
+		return append([]byte(nil), word[:t.Size]...), nil
+	}
+	return nil, fmt.Errorf("abi: cannot decode %s", t)
+}
+
+// decodeDynamic decodes a dynamic value from the start of data
+func decodeDynamic(t *Type, data []byte) (interface{}, error) {
+	switch t.Kind {
+	case BytesKind, StringKind:
+		n, err := readLength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 32+n {
+			return nil, errShortData
+		}
+		if t.Kind == StringKind {
+			return string(data[32 : 32+n]), nil
+		}
+		return append([]byte(nil), data[32:32+n]...), nil
+	case SliceKind:
+		n, err := readLength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		return decodeElems(t.Elem, n, data[32:])
+	default:
+		return decodeElems(t.Elem, t.Size, data)
+	}
+}
+
+// decodeElems decodes n consecutive elements encoded as a tuple
+func decodeElems(elem *Type, n int, data []byte) ([]interface{}, error) {
+	types := make([]*Type, n)
+	for i := range types {
+		types[i] = elem
+	}
+	return Decode(types, data)
+}
+
+func readWord(data []byte, offset int) ([]byte, error) {
+	if offset < 0 || len(data) < offset+32 {
+		return nil, errShortData
+	}
+	return data[offset : offset+32], nil
+}
+
+// readLength reads a word used as an offset or length, bounded by the data
+func readLength(data []byte, offset int) (int, error) {
+	word, err := readWord(data, offset)
+	if err != nil {
+		return 0, err
+	}
+	n := new(big.Int).SetBytes(word)
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("abi: offset or length %s out of bounds", n)
+	}
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,201 @@
+// Package fourbyte translates function selectors and event topics into
+// text signatures using a local database, optionally backed by the online
+// 4byte.directory.
+package fourbyte
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// DefaultURL is the base URL of the public signature directory
+const DefaultURL = "https://www.4byte.directory"
+
+// Directory maps selectors and event topics to text signatures
+type Directory struct {
+	// Online, when set, is queried for selectors and topics missing from
+	// the directory. Its answers are cached, including misses.
+	Online *Online
+
+	mu        sync.Mutex
+	functions map[[4]byte]string
+	events    map[common.Hash]string
+	missing   map[string]bool // hex keys the online directory does not know
+}
+
+// New creates an empty directory
+func New() *Directory {
+	return &Directory{
+		functions: make(map[[4]byte]string),
+		events:    make(map[common.Hash]string),
+		missing:   make(map[string]bool),
+	}
+}
+
+// LoadFile reads a JSON database mapping hex selectors (8 digits) and event
+// topics (64 digits) to text signatures, in the format of geth's 4byte.json:
+//
+//	{"a9059cbb": "transfer(address,uint256)"}
+func LoadFile(path string) (*Directory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	d := New()
+	for key, sig := range entries {
+		raw, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid key %q", path, key)
+		}
+		switch len(raw) {
+		case 4:
+			var selector [4]byte
+			copy(selector[:], raw)
+			d.functions[selector] = sig
+		case 32:
+			d.events[common.BytesToHash(raw)] = sig
+		default:
+			return nil, fmt.Errorf("%s: key %q is neither a selector nor a topic", path, key)
+		}
+	}
+	return d, nil
+}
+
+// Save writes the known signatures to path in the format read by LoadFile
+func (d *Directory) Save(path string) error {
+	d.mu.Lock()
+	entries := make(map[string]string, len(d.functions)+len(d.events))
+	for selector, sig := range d.functions {
+		entries[hex.EncodeToString(selector[:])] = sig
+	}
+	for topic, sig := range d.events {
+		entries[hex.EncodeToString(topic[:])] = sig
+	}
+	d.mu.Unlock()
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Add registers a text signature both as a function and as an event
+func (d *Directory) Add(sig string) {
+	hash := crypto.Keccak256Hash([]byte(sig))
+	var selector [4]byte
+	copy(selector[:], hash[:4])
+	d.mu.Lock()
+	d.functions[selector] = sig
+	d.events[hash] = sig
+	d.mu.Unlock()
+}
+
+// Function returns the text signature of a function selector
+func (d *Directory) Function(selector [4]byte) (string, bool) {
+	d.mu.Lock()
+	sig, ok := d.functions[selector]
+	d.mu.Unlock()
+	if ok {
+		return sig, true
+	}
+	sig, ok = d.lookup("signatures", selector[:])
+	if ok {
+		d.mu.Lock()
+		d.functions[selector] = sig
+		d.mu.Unlock()
+	}
+	return sig, ok
+}
+
+// Event returns the text signature of an event topic
+func (d *Directory) Event(topic common.Hash) (string, bool) {
+	d.mu.Lock()
+	sig, ok := d.events[topic]
+	d.mu.Unlock()
+	if ok {
+		return sig, true
+	}
+	sig, ok = d.lookup("event-signatures", topic[:])
+	if ok {
+		d.mu.Lock()
+		d.events[topic] = sig
+		d.mu.Unlock()
+	}
+	return sig, ok
+}
+
+// lookup asks the online directory, remembering misses and failures so an
+// unknown key is only requested once
+func (d *Directory) lookup(kind string, key []byte) (string, bool) {
+	if d.Online == nil {
+		return "", false
+	}
+	id := kind + "/" + hex.EncodeToString(key)
+	d.mu.Lock()
+	missing := d.missing[id]
+	d.mu.Unlock()
+	if missing {
+		return "", false
+	}
+	sig, err := d.Online.Lookup(kind, key)
+	if err != nil || sig == "" {
+		d.mu.Lock()
+		d.missing[id] = true
+		d.mu.Unlock()
+		return "", false
+	}
+	return sig, true
+}
+
+// Online queries a 4byte.directory compatible API
+type Online struct {
+	URL    string       // base URL, DefaultURL if empty
+	Client *http.Client // http.DefaultClient if nil
+}
+
+// Lookup returns the oldest text signature registered for key in the
+// "signatures" or "event-signatures" collection, or "" if there is none
+func (o *Online) Lookup(kind string, key []byte) (string, error) {
+	base, client := o.URL, o.Client
+	if base == "" {
+		base = DefaultURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	query := url.Values{"hex_signature": {common.ToHex(key)}, "ordering": {"created_at"}}
+	resp, err := client.Get(strings.TrimSuffix(base, "/") + "/api/v1/" + kind + "/?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("signature directory: %s", resp.Status)
+	}
+	var page struct {
+		Results []struct {
+			TextSignature string `json:"text_signature"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return "", err
+	}
+	if len(page.Results) == 0 {
+		return "", nil
+	}
+	return page.Results[0].TextSignature, nil
+}
@@ -0,0 +1,132 @@
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// StorageLayout is the storage layout solc emits for a contract with
+// --storage-layout: the slot and offset of every state variable
+type StorageLayout struct {
+	Storage []StorageEntry             `json:"storage"`
+	Types   map[string]StorageTypeInfo `json:"types"`
+}
+
+// StorageEntry is a state variable of a storage layout
+type StorageEntry struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"` // byte offset from the low-order end of the slot
+	Slot   string `json:"slot"`   // decimal slot number
+	Type   string `json:"type"`   // key of the layout's Types
+}
+
+// StorageTypeInfo describes a type of a storage layout
+type StorageTypeInfo struct {
+	Encoding      string `json:"encoding"` // inplace, mapping, dynamic_array or bytes
+	Label         string `json:"label"`
+	NumberOfBytes string `json:"numberOfBytes"`
+}
+
+// StorageVariable is a state variable decoded from a slot
+type StorageVariable struct {
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Value string `json:"value"` // decoded value, empty for mappings
+}
+
+// LoadStorageLayout reads a storage layout. Both the layout itself and
+// solc's combined JSON entry holding it under "storageLayout" are accepted.
+func LoadStorageLayout(r io.Reader) (*StorageLayout, error) {
+	var raw struct {
+		StorageLayout
+		Nested *StorageLayout `json:"storageLayout"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	layout := &raw.StorageLayout
+	if raw.Nested != nil {
+		layout = raw.Nested
+	}
+	for _, e := range layout.Storage {
+		if _, ok := new(big.Int).SetString(e.Slot, 10); !ok {
+			return nil, fmt.Errorf("variable %s: invalid slot %q", e.Label, e.Slot)
+		}
+	}
+	return layout, nil
+}
+
+// Decode returns the variables stored in a slot, in declaration order.
+// Variables held in place are decoded from value; mappings and dynamic
+// arrays or strings only declare their base slot, where dynamic arrays
+// keep their length and short strings their data. Slots derived by
+// hashing, such as mapping entries, are not recognized.
+func (l *StorageLayout) Decode(slot, value common.Hash) []StorageVariable {
+	var vars []StorageVariable
+	number := slot.Big()
+	for _, e := range l.Storage {
+		base, _ := new(big.Int).SetString(e.Slot, 10)
+		info := l.Types[e.Type]
+		size, _ := strconv.Atoi(info.NumberOfBytes)
+		label := info.Label
+		if label == "" {
+			label = e.Type
+		}
+		if info.Encoding != "inplace" || size > 32 {
+			if base.Cmp(number) == 0 {
+				vars = append(vars, StorageVariable{Label: e.Label, Type: label, Value: decodeBase(info.Encoding, value)})
+			}
+			continue
+		}
+		if base.Cmp(number) != 0 {
+			continue
+		}
+		end := common.HashLength - e.Offset
+		if size <= 0 || end-size < 0 {
+			continue
+		}
+		vars = append(vars, StorageVariable{Label: e.Label, Type: label, Value: decodeInplace(label, value[end-size:end])})
+	}
+	return vars
+}
+
+// decodeBase describes the base slot of a variable that is not held in
+// place
+func decodeBase(encoding string, value common.Hash) string {
+	switch encoding {
+	case "dynamic_array":
+		return "length " + value.Big().String()
+	case "bytes":
+		// short values keep their data and twice their length in one slot
+		if n := int(value[31] / 2); value[31]&1 == 0 && n < common.HashLength {
+			return fmt.Sprintf("0x%x", value[:n])
+		}
+		return fmt.Sprintf("length %d", new(big.Int).Rsh(value.Big(), 1))
+	}
+	return ""
+}
+
+// decodeInplace formats the bytes of a value type
+func decodeInplace(label string, b []byte) string {
+	switch {
+	case label == "bool":
+		return strconv.FormatBool(new(big.Int).SetBytes(b).Sign() != 0)
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		return common.BytesToAddress(b).Hex()
+	case strings.HasPrefix(label, "uint"):
+		return new(big.Int).SetBytes(b).String()
+	case strings.HasPrefix(label, "int"):
+		v := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+		}
+		return v.String()
+	}
+	return fmt.Sprintf("0x%x", b)
+}
@@ -0,0 +1,68 @@
+package abi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// The errors Solidity reverts with: require and revert messages, and
+// panics such as failed assertions and arithmetic overflows
+var (
+	errorMethod, _ = NewMethod("Error(string)")
+	panicMethod, _ = NewMethod("Panic(uint256)")
+)
+
+// panicReasons describes the Solidity panic codes
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// errUnknownRevert is returned for REVERT data that is neither an
+// Error(string) nor a Panic(uint256)
+var errUnknownRevert = errors.New("abi: revert data is not an Error(string) or Panic(uint256)")
+
+// UnpackRevert returns the reason in REVERT data: the message of an
+// Error(string), as raised by require and revert, or a description of the
+// code of a Panic(uint256). Custom errors cannot be decoded without their
+// ABI and are reported as an error.
+func UnpackRevert(data []byte) (string, error) {
+	switch {
+	case len(data) >= 4 && bytes.Equal(data[:4], errorMethod.Selector[:]):
+		values, err := errorMethod.DecodeInput(data)
+		if err != nil {
+			return "", err
+		}
+		reason, ok := values[0].(string)
+		if !ok {
+			return "", errUnknownRevert
+		}
+		return reason, nil
+	case len(data) >= 4 && bytes.Equal(data[:4], panicMethod.Selector[:]):
+		values, err := panicMethod.DecodeInput(data)
+		if err != nil {
+			return "", err
+		}
+		code, ok := values[0].(*big.Int)
+		if !ok {
+			return "", errUnknownRevert
+		}
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				return fmt.Sprintf("panic: %s (%#x)", reason, code.Uint64()), nil
+			}
+		}
+		return fmt.Sprintf("panic: unknown code %#x", code), nil
+	}
+	return "", errUnknownRevert
+}
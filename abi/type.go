@@ -0,0 +1,122 @@
+package abi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the category of an ABI type
+type Kind int
+
+const (
+	UintKind Kind = iota
+	IntKind
+	AddressKind
+	BoolKind
+	FixedBytesKind
+	BytesKind
+	StringKind
+	SliceKind // T[]
+	ArrayKind // T[k]
+)
+
+// Type is a parsed ABI type
+type Type struct {
+	Kind Kind
+	Size int   // bits for ints, bytes for fixed bytes, length for arrays
+	Elem *Type // element type of slices and arrays
+}
+
+// NewType parses a canonical or shorthand ABI type name such as uint,
+// bytes32 or address[2]. Tuples are not supported.
+func NewType(s string) (*Type, error) {
+	if i := strings.LastIndexByte(s, '['); i >= 0 && strings.HasSuffix(s, "]") {
+		elem, err := NewType(s[:i])
+		if err != nil {
+			return nil, err
+		}
+		length := s[i+1 : len(s)-1]
+		if length == "" {
+			return &Type{Kind: SliceKind, Elem: elem}, nil
+		}
+		n, err := strconv.Atoi(length)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid array length in type %q", s)
+		}
+		return &Type{Kind: ArrayKind, Size: n, Elem: elem}, nil
+	}
+	switch {
+	case s == "address":
+		return &Type{Kind: AddressKind, Size: 20}, nil
+	case s == "bool":
+		return &Type{Kind: BoolKind}, nil
+	case s == "string":
+		return &Type{Kind: StringKind}, nil
+	case s == "bytes":
+		return &Type{Kind: BytesKind}, nil
+	case strings.HasPrefix(s, "bytes"):
+		n, err := strconv.Atoi(s[len("bytes"):])
+		if err != nil || n < 1 || n > 32 {
+			return nil, fmt.Errorf("invalid type %q", s)
+		}
+		return &Type{Kind: FixedBytesKind, Size: n}, nil
+	case strings.HasPrefix(s, "uint"), strings.HasPrefix(s, "int"):
+		kind, bits := UintKind, strings.TrimPrefix(s, "uint")
+		if !strings.HasPrefix(s, "uint") {
+			kind, bits = IntKind, strings.TrimPrefix(s, "int")
+		}
+		if bits == "" {
+			return &Type{Kind: kind, Size: 256}, nil
+		}
+		n, err := strconv.Atoi(bits)
+		if err != nil || n < 8 || n > 256 || n%8 != 0 {
+			return nil, fmt.Errorf("invalid type %q", s)
+		}
+		return &Type{Kind: kind, Size: n}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", s)
+}
+
+// String returns the canonical name of the type, as used in signatures
+func (t *Type) String() string {
+	switch t.Kind {
+	case UintKind:
+		return "uint" + strconv.Itoa(t.Size)
+	case IntKind:
+		return "int" + strconv.Itoa(t.Size)
+	case AddressKind:
+		return "address"
+	case BoolKind:
+		return "bool"
+	case FixedBytesKind:
+		return "bytes" + strconv.Itoa(t.Size)
+	case BytesKind:
+		return "bytes"
+	case StringKind:
+		return "string"
+	case SliceKind:
+		return t.Elem.String() + "[]"
+	default:
+		return t.Elem.String() + "[" + strconv.Itoa(t.Size) + "]"
+	}
+}
+
+// dynamic reports whether values of the type are encoded out of place
+func (t *Type) dynamic() bool {
+	switch t.Kind {
+	case BytesKind, StringKind, SliceKind:
+		return true
+	case ArrayKind:
+		return t.Elem.dynamic()
+	}
+	return false
+}
+
+// headSize is the number of bytes the type takes in the head of a tuple
+func (t *Type) headSize() int {
+	if t.Kind == ArrayKind && !t.dynamic() {
+		return t.Size * t.Elem.headSize()
+	}
+	return 32
+}
@@ -0,0 +1,85 @@
+// Package accounts provides the deterministic dev accounts of test chains:
+// accounts derived from a mnemonic, so their private keys are known in
+// advance, and funded at genesis, so scripted tests can sign and send
+// transactions from them without managing keys. The default mnemonic is
+// the one Hardhat and Anvil use, so the accounts are the same as theirs.
+package accounts
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/crypto/hd"
+)
+
+// DefaultMnemonic is the well-known test mnemonic. Its keys are public:
+// never use them on a real network.
+const DefaultMnemonic = "test test test test test test test test test test test junk"
+
+// DefaultCount is the number of dev accounts created by default
+const DefaultCount = 10
+
+// DefaultBalance is the balance dev accounts are funded with by default,
+// 10000 ether
+var DefaultBalance = new(big.Int).Mul(big.NewInt(10000), big.NewInt(1e18))
+
+// Account is a dev account with its private key
+type Account struct {
+	Address common.Address
+	Key     *crypto.PrivateKey
+	Path    hd.Path // derivation path from the mnemonic
+}
+
+// PrivateKeyHex returns the private key as 0x-prefixed hex, as wallets and
+// scripts import it
+func (a *Account) PrivateKeyHex() string {
+	key := a.Key.Key.Bytes()
+	return hexutil.Encode(key[:])
+}
+
+// Derive returns the first n accounts of the wallet of a mnemonic, at
+// m/44'/60'/0'/0/i for i from 0
+func Derive(mnemonic string, n int) ([]*Account, error) {
+	base, err := hd.ParsePath(hd.DefaultBasePath)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hd.Seed(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	master, err := hd.NewMaster(seed)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := master.Derive(base)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]*Account, n)
+	for i := range accounts {
+		child, err := parent.Child(uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("account %d: %w", i, err)
+		}
+		key := child.PrivateKey()
+		accounts[i] = &Account{
+			Address: crypto.PubkeyToAddress(key.PubKey()),
+			Key:     key,
+			Path:    append(append(hd.Path(nil), base...), uint32(i)),
+		}
+	}
+	return accounts, nil
+}
+
+// Fund credits balance to every account in statedb, creating the accounts
+// that do not exist
+func Fund(statedb *state.StateDB, accounts []*Account, balance *big.Int) {
+	for _, acc := range accounts {
+		statedb.AddBalance(acc.Address, balance)
+	}
+}
@@ -0,0 +1,79 @@
+// Thin wrapper around evm.wasm, the WebAssembly build of cmd/evm-wasm.
+//
+// wasm_exec.js, from $(go env GOROOT)/lib/wasm or the TinyGo distribution
+// matching the compiler that built evm.wasm, must be loaded first: it
+// defines the global Go class.
+//
+//   import { load } from "./evm.js";
+//   const evm = await load("evm.wasm");
+//   const result = evm.run({ code: "0x600a601401", address: "0x...01" });
+//
+// Requests and results are plain objects; see run below.
+
+/**
+ * Instantiates evm.wasm and returns an EVM.
+ * @param {string|URL|BufferSource} source URL of evm.wasm, or its bytes
+ */
+export async function load(source = "evm.wasm") {
+  if (typeof globalThis.Go !== "function") {
+    throw new Error("evm.js: load wasm_exec.js before evm.wasm");
+  }
+  const go = new Go();
+  let instance;
+  if (source instanceof ArrayBuffer || ArrayBuffer.isView(source)) {
+    ({ instance } = await WebAssembly.instantiate(source, go.importObject));
+  } else {
+    ({ instance } = await WebAssembly.instantiateStreaming(fetch(source), go.importObject));
+  }
+  go.run(instance); // returns once main blocks, leaving evmGolang registered
+  return new EVM(globalThis.evmGolang);
+}
+
+export class EVM {
+  #api;
+
+  constructor(api) {
+    this.#api = api;
+  }
+
+  /**
+   * Runs the code at request.address.
+   *
+   * request: { code?, address, sender?, gas?, gasPrice?, blockNumber?,
+   * timestamp?, state? } where code and addresses are 0x-prefixed hex and
+   * state is an account dump as written by `evm --suspend-after`.
+   *
+   * host, if given, owns the state instead of request.state and must
+   * implement exist, getNonce, setNonce, getCode, setCode, getState and
+   * setState with hex string arguments and results (nonces are numbers).
+   *
+   * Returns { returnData, gasUsed, logs, error?, state? }; state is the
+   * post-state dump, omitted when a host is given.
+   */
+  run(request, host) {
+    return JSON.parse(this.#api.run(JSON.stringify(request), host));
+  }
+}
+
+/** A host keeping the state in Maps, for pages that do not have their own. */
+export class MemoryHost {
+  accounts = new Map();
+
+  #account(addr) {
+    addr = addr.toLowerCase();
+    let acc = this.accounts.get(addr);
+    if (!acc) {
+      acc = { nonce: 0, code: "0x", storage: new Map() };
+      this.accounts.set(addr, acc);
+    }
+    return acc;
+  }
+
+  exist(addr) { return this.accounts.has(addr.toLowerCase()); }
+  getNonce(addr) { return this.accounts.get(addr.toLowerCase())?.nonce ?? 0; }
+  setNonce(addr, nonce) { this.#account(addr).nonce = nonce; }
+  getCode(addr) { return this.accounts.get(addr.toLowerCase())?.code ?? "0x"; }
+  setCode(addr, code) { this.#account(addr).code = code; }
+  getState(addr, key) { return this.accounts.get(addr.toLowerCase())?.storage.get(key) ?? "0x"; }
+  setState(addr, key, value) { this.#account(addr).storage.set(key, value); }
+}
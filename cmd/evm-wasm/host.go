@@ -0,0 +1,79 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// hostState is a vm.StateDB backed by a JavaScript object, so that the
+// embedding page owns the state. Addresses, slots and code are passed as
+// 0x-prefixed hex strings and nonces as numbers:
+//
+//	exist(addr) bool
+//	getNonce(addr) number
+//	setNonce(addr, nonce)
+//	getCode(addr) string
+//	setCode(addr, code)
+//	getState(addr, key) string
+//	setState(addr, key, value)
+//
+// Malformed values returned by the host panic; run recovers and reports them.
+type hostState struct {
+	host js.Value
+}
+
+func (h *hostState) Exist(addr common.Address) bool {
+	return h.host.Call("exist", addr.Hex()).Bool()
+}
+
+func (h *hostState) GetNonce(addr common.Address) uint64 {
+	return uint64(h.host.Call("getNonce", addr.Hex()).Int())
+}
+
+func (h *hostState) SetNonce(addr common.Address, nonce uint64) {
+	h.host.Call("setNonce", addr.Hex(), nonce)
+}
+
+func (h *hostState) GetCode(addr common.Address) []byte {
+	return h.bytes(h.host.Call("getCode", addr.Hex()))
+}
+
+// GetCodeHash hashes the code returned by the host, zero for accounts that
+// do not exist
+func (h *hostState) GetCodeHash(addr common.Address) common.Hash {
+	if !h.Exist(addr) {
+		return common.Hash{}
+	}
+	return crypto.Keccak256Hash(h.GetCode(addr))
+}
+
+func (h *hostState) SetCode(addr common.Address, code []byte) {
+	h.host.Call("setCode", addr.Hex(), hexutil.Encode(code))
+}
+
+func (h *hostState) GetState(addr common.Address, key common.Hash) common.Hash {
+	return common.BytesToHash(h.bytes(h.host.Call("getState", addr.Hex(), key.Hex())))
+}
+
+func (h *hostState) SetState(addr common.Address, key, value common.Hash) {
+	h.host.Call("setState", addr.Hex(), key.Hex(), value.Hex())
+}
+
+// bytes decodes a hex string returned by the host; null, undefined and ""
+// are empty
+func (h *hostState) bytes(v js.Value) []byte {
+	if v.IsNull() || v.IsUndefined() || v.String() == "" {
+		return nil
+	}
+	b, err := hexutil.Decode(v.String())
+	if err != nil {
+		panic(fmt.Sprintf("invalid hex %q: %v", v.String(), err))
+	}
+	return b
+}
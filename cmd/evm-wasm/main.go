@@ -0,0 +1,121 @@
+//go:build js && wasm
+
+// Command evm-wasm exposes the interpreter to JavaScript when compiled to
+// WebAssembly with GOOS=js GOARCH=wasm or TinyGo. It registers a global
+// evmGolang object whose run function takes a JSON request and, optionally,
+// a host object providing the state; evm.js wraps it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"syscall/js"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// request is the JSON request passed to run
+type request struct {
+	Code        hexutil.Bytes                        `json:"code,omitempty"` // deployed at address before the run if set
+	Address     common.Address                       `json:"address"`
+	Sender      common.Address                       `json:"sender"`
+	Gas         uint64                               `json:"gas"`
+	GasPrice    uint64                               `json:"gasPrice"`
+	BlockNumber uint64                               `json:"blockNumber"`
+	Timestamp   uint64                               `json:"timestamp"`
+	State       map[common.Address]state.DumpAccount `json:"state,omitempty"` // ignored when a host is given
+}
+
+// response is the JSON result of run
+type response struct {
+	ReturnData hexutil.Bytes                        `json:"returnData"`
+	GasUsed    uint64                               `json:"gasUsed"`
+	Logs       []*types.Log                         `json:"logs"`
+	Error      string                               `json:"error,omitempty"`
+	State      map[common.Address]state.DumpAccount `json:"state,omitempty"` // post-state, omitted when a host is given
+}
+
+func main() {
+	js.Global().Set("evmGolang", js.ValueOf(map[string]any{
+		"run": js.FuncOf(run),
+	}))
+	select {}
+}
+
+// run executes run(requestJSON, host?) and returns the response as JSON
+func run(_ js.Value, args []js.Value) any {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return encode(&response{Error: "run expects a JSON request"})
+	}
+	var req request
+	if err := json.Unmarshal([]byte(args[0].String()), &req); err != nil {
+		return encode(&response{Error: fmt.Sprintf("invalid request: %v", err)})
+	}
+	var host js.Value
+	if len(args) > 1 && args[1].Truthy() {
+		host = args[1]
+	}
+	return encode(execute(&req, host))
+}
+
+// execute runs the request against the host, or against an in-memory state
+// loaded from the request when host is undefined. Exceptions thrown by the
+// host end the run with an error.
+func execute(req *request, host js.Value) (resp *response) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp = &response{Error: fmt.Sprintf("host: %v", r)}
+		}
+	}()
+	var (
+		db      vm.StateDB
+		statedb *state.StateDB
+	)
+	if host.Truthy() {
+		db = &hostState{host: host}
+	} else {
+		statedb = state.NewFromDump(req.State)
+		db = statedb
+	}
+	if len(req.Code) > 0 {
+		db.SetCode(req.Address, req.Code)
+	}
+	gas := req.Gas
+	if gas == 0 {
+		gas = 1000000
+	}
+	blockCtx := &vm.Context{
+		BlockNumber: new(big.Int).SetUint64(req.BlockNumber),
+		Timestamp:   new(big.Int).SetUint64(req.Timestamp),
+		Sender:      req.Sender,
+		GasLimit:    gas,
+		GasPrice:    new(big.Int).SetUint64(req.GasPrice),
+	}
+	result := vm.NewEVM(blockCtx, db, vm.Config{}).Run(context.Background(), req.Address)
+
+	resp = &response{ReturnData: result.ReturnData, GasUsed: result.GasUsed, Logs: result.Logs}
+	if result.Failed() {
+		resp.Error = result.Err.Error()
+	}
+	if statedb != nil {
+		resp.State = statedb.Dump()
+	}
+	return resp
+}
+
+func encode(resp *response) string {
+	if resp.Logs == nil {
+		resp.Logs = []*types.Log{}
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		out, _ = json.Marshal(&response{Error: err.Error()})
+	}
+	return string(out)
+}
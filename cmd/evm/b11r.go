@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// b11rOutput is the block written by `evm b11r`
+type b11rOutput struct {
+	RLP  hexutil.Bytes `json:"rlp"`
+	Hash common.Hash   `json:"hash"`
+}
+
+// runB11r implements `evm b11r`, the block builder of the t8n/b11r tool
+// pair: it assembles the RLP of a block from a header template, typically
+// completed with the roots reported by t8n, and the block's transactions,
+// ommers and withdrawals, in the file formats of geth's b11r. No sealing
+// engine is run, so the template must hold the final mix hash and nonce.
+func runB11r(args []string) int {
+	flags := flag.NewFlagSet("b11r", flag.ContinueOnError)
+	headerFile := flags.String("input.header", "header.json", "JSON header template; a zero ommers hash or transactions root and a missing withdrawals root are computed")
+	txsFile := flags.String("input.txs", "", "JSON string holding the hex RLP list of the transactions, as written by t8n")
+	ommersFile := flags.String("input.ommers", "", "JSON array of hex RLP-encoded ommer headers")
+	withdrawalsFile := flags.String("input.withdrawals", "", "JSON array of withdrawals; a block has a withdrawals list when given")
+	outFile := flags.String("output.block", "block.json", "file the block RLP and hash are written to, or stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Println("Usage: evm b11r [-input.header FILE] [-input.txs FILE] [-input.ommers FILE] [-input.withdrawals FILE] [-output.block FILE | stdout]")
+		return 2
+	}
+
+	var header types.Header
+	if err := readJSON(*headerFile, &header); err != nil {
+		fmt.Println("Error: reading header:", err.Error())
+		return 2
+	}
+	var txs [][]byte
+	if *txsFile != "" {
+		var encoded hexutil.Bytes
+		if err := readJSON(*txsFile, &encoded); err != nil {
+			fmt.Println("Error: reading transactions:", err.Error())
+			return 2
+		}
+		items, err := rlp.SplitList(encoded)
+		if err != nil {
+			fmt.Println("Error: decoding transactions:", err.Error())
+			return 2
+		}
+		for _, item := range items {
+			isList, content, _, _ := rlp.Split(item)
+			if !isList {
+				item = content // typed transactions are wrapped in a string
+			}
+			txs = append(txs, item)
+		}
+	}
+	var ommers []hexutil.Bytes
+	if *ommersFile != "" {
+		if err := readJSON(*ommersFile, &ommers); err != nil {
+			fmt.Println("Error: reading ommers:", err.Error())
+			return 2
+		}
+	}
+	var withdrawals []*types.Withdrawal
+	if *withdrawalsFile != "" {
+		if err := readJSON(*withdrawalsFile, &withdrawals); err != nil {
+			fmt.Println("Error: reading withdrawals:", err.Error())
+			return 2
+		}
+		if withdrawals == nil {
+			withdrawals = []*types.Withdrawal{}
+		}
+	}
+
+	encodedOmmers := make([][]byte, len(ommers))
+	for i, o := range ommers {
+		if _, _, rest, err := rlp.Split(o); err != nil || len(rest) > 0 {
+			fmt.Printf("Error: ommer %d is not a single RLP value\n", i)
+			return 2
+		}
+		encodedOmmers[i] = o
+	}
+	block, err := types.NewBlock(&header, txs, encodedOmmers, withdrawals)
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	enc, err := block.MarshalBinary()
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	out, err := json.MarshalIndent(b11rOutput{RLP: enc, Hash: block.Hash()}, "", "  ")
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	out = append(out, '\n')
+	if *outFile == "stdout" {
+		os.Stdout.Write(out)
+		return 0
+	}
+	if err := os.WriteFile(*outFile, out, 0o644); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// readJSON decodes the JSON file at path into v
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"runtime"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// benchGasLimit is the gas every benchmark run starts with
+const benchGasLimit = 1_000_000_000
+
+// the accounts of benchmark runs
+var (
+	benchAddress = common.Address{19: 0xb0} // runs the workload
+	benchCallee  = common.Address{19: 0xaa} // called by the calls workload
+)
+
+// benchWorkload is a program run by `evm bench`. The loops count down from
+// 255*255 iterations kept on the stack.
+type benchWorkload struct {
+	name        string
+	description string
+	code        string // mnemonics
+	bytecode    []byte // run instead of code if set
+	setup       func(statedb *state.StateDB)
+}
+
+var benchWorkloads = []benchWorkload{
+	{
+		name:        "arith",
+		description: "arithmetic and comparisons",
+		code: `PUSH1 255 PUSH1 255 MUL
+			JUMPDEST
+			PUSH1 3 PUSH1 5 MUL PUSH1 7 ADD PUSH1 2 DIV PUSH1 9 LT POP
+			PUSH1 1 SWAP1 SUB DUP1 PUSH1 5 JUMPI`,
+	},
+	{
+		name:        "keccak",
+		description: "KECCAK256 of the empty string",
+		code: `PUSH1 255 PUSH1 255 MUL
+			JUMPDEST
+			PUSH1 0 PUSH1 0 KECCAK256 POP
+			PUSH1 1 SWAP1 SUB DUP1 PUSH1 5 JUMPI`,
+	},
+	{
+		name:        "transfers",
+		description: "ERC-20 style balance updates, each emitting a log",
+		code: `PUSH1 255 PUSH1 255 MUL
+			JUMPDEST
+			PUSH1 1 PUSH1 1 SLOAD SUB PUSH1 1 SSTORE ; debit the sender
+			PUSH1 2 SLOAD PUSH1 1 ADD PUSH1 2 SSTORE ; credit the recipient
+			PUSH1 0 PUSH1 0 LOG0
+			PUSH1 1 SWAP1 SUB DUP1 PUSH1 5 JUMPI`,
+		setup: func(statedb *state.StateDB) {
+			statedb.SetState(benchAddress, common.Hash{31: 1}, common.BigToHash(new(big.Int).Lsh(big.NewInt(1), 128)))
+		},
+	},
+	{
+		name:        "calls",
+		description: "CALLs to a small contract",
+		code: `PUSH1 255 PUSH1 255 MUL
+			JUMPDEST
+			PUSH1 0 PUSH1 0 PUSH1 0 PUSH1 0 PUSH1 0 PUSH1 0xaa PUSH1 255 CALL POP
+			PUSH1 1 SWAP1 SUB DUP1 PUSH1 5 JUMPI`,
+		setup: func(statedb *state.StateDB) {
+			statedb.SetCode(benchCallee, []byte{0x60, 0x01, 0x60, 0x02, 0x01, 0x50, 0x00}) // PUSH1 1 PUSH1 2 ADD POP STOP
+		},
+	},
+}
+
+// benchResult is the measurement of a workload
+type benchResult struct {
+	Name        string  `json:"name"`
+	Runs        int     `json:"runs,omitempty"`
+	GasPerRun   uint64  `json:"gasPerRun,omitempty"`
+	OpsPerRun   uint64  `json:"opsPerRun,omitempty"`
+	NsPerRun    int64   `json:"nsPerRun,omitempty"`
+	MgasPerSec  float64 `json:"mgasPerSec,omitempty"`
+	MopsPerSec  float64 `json:"mopsPerSec,omitempty"`
+	Unsupported string  `json:"unsupported,omitempty"` // why the interpreter cannot run the workload
+}
+
+// benchReport is the output of `evm bench -json`
+type benchReport struct {
+	GoVersion string         `json:"goVersion"`
+	GOOS      string         `json:"goos"`
+	GOARCH    string         `json:"goarch"`
+	CPUs      int            `json:"cpus"`
+	Results   []*benchResult `json:"results"`
+}
+
+// runBench implements `evm bench [-run REGEXP] [-time DURATION] [-code CODE]
+// [-hash] [-json]`, timing standard workloads and reporting their
+// throughput in gas and instructions per second, to compare versions and
+// machines
+func runBench(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	run := flags.String("run", "", "only run workloads whose name matches this regular expression")
+	benchTime := flags.Duration("time", time.Second, "minimum time spent running each workload")
+	codeFlag := flags.String("code", "", "also run this code as the workload \"code\", in any format --code of evm accepts")
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+	list := flags.Bool("list", false, "list the workloads and exit")
+	hashing := flags.Bool("hash", false, "run the Keccak-256 hashing benchmarks instead of the workloads")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Println("Error: invalid -run pattern:", err.Error())
+		return 2
+	}
+	if *hashing {
+		return runHashBench(filter, *asJSON)
+	}
+	workloads := benchWorkloads
+	if *codeFlag != "" {
+		code, err := readCode(*codeFlag)
+		if err != nil {
+			fmt.Println("Error: invalid code:", err.Error())
+			return 2
+		}
+		workloads = append(workloads[:len(workloads):len(workloads)], benchWorkload{name: "code", description: *codeFlag, bytecode: code})
+	}
+	if *list {
+		for _, w := range workloads {
+			fmt.Printf("%s: %s\n", w.name, w.description)
+		}
+		return 0
+	}
+
+	report := &benchReport{GoVersion: runtime.Version(), GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, CPUs: runtime.NumCPU()}
+	for _, w := range workloads {
+		if !filter.MatchString(w.name) {
+			continue
+		}
+		result, err := w.bench(*benchTime)
+		if err != nil {
+			fmt.Printf("Error: %s: %s\n", w.name, err.Error())
+			return 1
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		return 0
+	}
+	fmt.Printf("%s %s/%s, %d CPUs\n", report.GoVersion, report.GOOS, report.GOARCH, report.CPUs)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "workload\truns\tgas/run\tops/run\ttime/run\tMgas/s\tMops/s\t")
+	for _, r := range report.Results {
+		if r.Unsupported != "" {
+			fmt.Fprintf(w, "%s\tunsupported: %s\t\t\t\t\t\t\n", r.Name, r.Unsupported)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%.1f\t%.1f\t\n", r.Name, r.Runs, r.GasPerRun, r.OpsPerRun, time.Duration(r.NsPerRun), r.MgasPerSec, r.MopsPerSec)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// bench runs the workload repeatedly for at least d. A first run counts
+// the instructions executed; the timed runs execute without hooks.
+func (w *benchWorkload) bench(d time.Duration) (*benchResult, error) {
+	code := w.bytecode
+	if code == nil {
+		var err error
+		if code, err = asm.Parse(w.code); err != nil {
+			return nil, err
+		}
+	}
+	result := &benchResult{Name: w.name}
+	var ops uint64
+	counter := &vm.Hooks{OnOpcode: func(uint64, vm.OpCode, uint64, *vm.ScopeContext, int) { ops++ }}
+	first, _ := w.run(code, vm.Config{Hooks: counter})
+	if errors.Is(first.Err, vm.ErrInvalidOpcode) {
+		result.Unsupported = first.Err.Error()
+		return result, nil
+	}
+	if first.Failed() {
+		return nil, first.Err
+	}
+	result.GasPerRun, result.OpsPerRun = first.GasUsed, ops
+
+	var total time.Duration
+	for total < d || result.Runs == 0 {
+		_, elapsed := w.run(code, vm.Config{})
+		total += elapsed
+		result.Runs++
+	}
+	result.NsPerRun = total.Nanoseconds() / int64(result.Runs)
+	seconds := total.Seconds()
+	result.MgasPerSec = float64(result.GasPerRun) * float64(result.Runs) / seconds / 1e6
+	result.MopsPerSec = float64(result.OpsPerRun) * float64(result.Runs) / seconds / 1e6
+	return result, nil
+}
+
+// run executes the workload once on a fresh state, timing the execution
+// alone
+func (w *benchWorkload) run(code []byte, config vm.Config) (*vm.ExecutionResult, time.Duration) {
+	statedb := state.New()
+	statedb.SetCode(benchAddress, code)
+	if w.setup != nil {
+		w.setup(statedb)
+	}
+	blockCtx := &vm.Context{
+		BlockNumber: big.NewInt(1),
+		Timestamp:   big.NewInt(1),
+		GasLimit:    benchGasLimit,
+		GasPrice:    new(big.Int),
+	}
+	evm := vm.NewEVM(blockCtx, statedb, config)
+	start := time.Now()
+	result := evm.Run(context.Background(), benchAddress)
+	return result, time.Since(start)
+}
+
+// hashBenchmarks are the benchmarks of `evm bench -hash`, comparing the
+// pooled hashing of the crypto package with a hasher allocated per hash
+var hashBenchmarks = []struct {
+	name  string
+	bytes int // hashed per operation, for MB/s
+	fn    func(b *testing.B)
+}{
+	{"keccak256/32", 32, benchKeccak(32)},
+	{"keccak256/85", 85, benchKeccak(85)}, // a CREATE2 preimage
+	{"keccak256/1024", 1024, benchKeccak(1024)},
+	{"keccak256-into/32", 32, func(b *testing.B) {
+		data, out := make([]byte, 32), make([]byte, 32)
+		for b.Loop() {
+			crypto.Keccak256Into(out, data)
+		}
+	}},
+	{"keccak256-unpooled/32", 32, func(b *testing.B) {
+		data := make([]byte, 32)
+		for b.Loop() {
+			h := sha3.NewLegacyKeccak256()
+			h.Write(data)
+			h.Sum(nil)
+		}
+	}},
+	{"create-address", 0, func(b *testing.B) {
+		for b.Loop() {
+			crypto.CreateAddress(benchAddress, 1_000_000)
+		}
+	}},
+}
+
+func benchKeccak(size int) func(b *testing.B) {
+	return func(b *testing.B) {
+		data := make([]byte, size)
+		for b.Loop() {
+			crypto.Keccak256Hash(data)
+		}
+	}
+}
+
+// hashBenchResult is the measurement of a hashing benchmark
+type hashBenchResult struct {
+	Name        string  `json:"name"`
+	NsPerOp     int64   `json:"nsPerOp"`
+	MBPerSec    float64 `json:"mbPerSec,omitempty"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+}
+
+// runHashBench runs the hashing benchmarks whose name matches filter
+func runHashBench(filter *regexp.Regexp, asJSON bool) int {
+	var results []*hashBenchResult
+	for _, bench := range hashBenchmarks {
+		if !filter.MatchString(bench.name) {
+			continue
+		}
+		fn := bench.fn
+		r := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			fn(b)
+		})
+		result := &hashBenchResult{Name: bench.name, NsPerOp: r.NsPerOp(), BytesPerOp: r.AllocedBytesPerOp(), AllocsPerOp: r.AllocsPerOp()}
+		if bench.bytes > 0 && r.T > 0 {
+			result.MBPerSec = float64(bench.bytes) * float64(r.N) / r.T.Seconds() / 1e6
+		}
+		results = append(results, result)
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "benchmark\tns/op\tMB/s\tB/op\tallocs/op\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%d\t%d\t\n", r.Name, r.NsPerOp, r.MBPerSec, r.BytesPerOp, r.AllocsPerOp)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
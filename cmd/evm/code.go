@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// codeFormats are the formats of a code file, forced by a "FORMAT:" prefix
+var codeFormats = []string{"hex", "bin", "solc"}
+
+// readCode loads bytecode given on the command line: 0x-prefixed hex, or a
+// file holding hex (whitespace and # or // comments allowed), raw binary
+// code, or the output of solc --combined-json bin-runtime. The format of a
+// file is detected from its content unless a "hex:", "bin:" or "solc:"
+// prefix forces it. A combined JSON file with several contracts takes the
+// name of the one to load as a ":Name" suffix.
+func readCode(arg string) ([]byte, error) {
+	format, path := "", arg
+	for _, f := range codeFormats {
+		if strings.HasPrefix(arg, f+":") {
+			format, path = f, arg[len(f)+1:]
+		}
+	}
+	var contract string
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && (format == "" || format == "solc") {
+		// the name may be qualified by its source, as in FILE:src/A.sol:A
+		for i := strings.Index(path, ":"); i > 0; i = nextColon(path, i) {
+			if d, e := os.ReadFile(path[:i]); e == nil {
+				data, err, contract = d, nil, path[i+1:]
+				break
+			}
+		}
+	}
+	if err != nil {
+		if format == "" && errors.Is(err, os.ErrNotExist) {
+			// not a file: the code itself
+			return decodeHexCode(arg)
+		}
+		return nil, err
+	}
+	if format == "" {
+		format = detectCodeFormat(data)
+	}
+	switch format {
+	case "hex":
+		return decodeHexCode(string(data))
+	case "solc":
+		return solcCode(data, contract)
+	}
+	return data, nil
+}
+
+// nextColon returns the index of the first colon of s after i, -1 if none
+func nextColon(s string, i int) int {
+	if j := strings.Index(s[i+1:], ":"); j >= 0 {
+		return i + 1 + j
+	}
+	return -1
+}
+
+// detectCodeFormat tells a combined JSON, hex and binary file apart
+func detectCodeFormat(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return "solc"
+	}
+	if _, err := decodeHexCode(string(data)); err == nil {
+		return "hex"
+	}
+	return "bin"
+}
+
+// decodeHexCode decodes hex code, possibly split by whitespace into
+// 0x-prefixed chunks and annotated with # or // comments
+func decodeHexCode(s string) ([]byte, error) {
+	var digits strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		for _, chunk := range strings.Fields(line) {
+			chunk = strings.TrimPrefix(strings.TrimPrefix(chunk, "0x"), "0X")
+			digits.WriteString(chunk)
+		}
+	}
+	if digits.Len()%2 == 1 {
+		return nil, errors.New("odd number of hex digits")
+	}
+	return hex.DecodeString(digits.String())
+}
+
+// solcCode returns the runtime code of a contract of a solc combined JSON
+// file, named by its bare or fully qualified name. The name may be omitted
+// if the file holds a single contract.
+func solcCode(data []byte, name string) ([]byte, error) {
+	var combined solcCombinedJSON
+	if err := json.Unmarshal(data, &combined); err != nil {
+		return nil, fmt.Errorf("invalid combined JSON: %w", err)
+	}
+	var names []string
+	for key := range combined.Contracts {
+		if name == "" || key == name || strings.HasSuffix(key, ":"+name) {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	switch {
+	case len(names) == 0 && name != "":
+		return nil, fmt.Errorf("no contract %s in combined JSON", name)
+	case len(names) == 0:
+		return nil, errors.New("no contracts in combined JSON")
+	case len(names) > 1:
+		return nil, fmt.Errorf("several contracts in combined JSON, select one with FILE:NAME: %s", strings.Join(names, ", "))
+	}
+	c := combined.Contracts[names[0]]
+	if c.BinRuntime == "" {
+		if c.Bin != "" {
+			return nil, fmt.Errorf("%s: only creation code, compile with --combined-json bin-runtime", names[0])
+		}
+		return nil, fmt.Errorf("%s: no runtime code", names[0])
+	}
+	return decodeHexCode(c.BinRuntime)
+}
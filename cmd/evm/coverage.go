@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// solcCombinedJSON is the output of solc --combined-json
+// bin-runtime,srcmap-runtime
+type solcCombinedJSON struct {
+	Contracts map[string]struct {
+		Bin           string `json:"bin"`
+		BinRuntime    string `json:"bin-runtime"`
+		SrcmapRuntime string `json:"srcmap-runtime"`
+	} `json:"contracts"`
+	SourceList []string `json:"sourceList"`
+}
+
+// writeCoverage writes the coverage collected by the run as LCOV: per
+// contract and pc, or per source line of the contracts of solcFile if it
+// is given
+func writeCoverage(file, solcFile string, coverage *tracers.Coverage) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if solcFile == "" {
+		err = coverage.WriteLCOV(out)
+	} else {
+		var lines []*tracers.LineCoverage
+		if lines, err = sourceCoverage(solcFile, coverage); err == nil {
+			err = tracers.WriteLineLCOV(out, lines)
+		}
+	}
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sourceCoverage maps coverage onto the sources of a solc combined JSON
+// file, read from the paths solc recorded. Contracts whose code has
+// unlinked library placeholders are skipped.
+func sourceCoverage(solcFile string, coverage *tracers.Coverage) ([]*tracers.LineCoverage, error) {
+	var combined solcCombinedJSON
+	if err := readJSON(solcFile, &combined); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", solcFile, err)
+	}
+	var contracts []tracers.CompiledContract
+	for _, c := range combined.Contracts {
+		code, err := hex.DecodeString(c.BinRuntime)
+		if err != nil || len(code) == 0 || c.SrcmapRuntime == "" {
+			continue
+		}
+		contracts = append(contracts, tracers.CompiledContract{Code: code, SourceMap: c.SrcmapRuntime})
+	}
+	sources := make([]tracers.SourceFile, len(combined.SourceList))
+	for i, path := range combined.SourceList {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = tracers.SourceFile{Path: path, Content: content}
+	}
+	return coverage.SourceLines(contracts, sources)
+}
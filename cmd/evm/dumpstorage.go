@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/nutcas3/evm-golang/abi"
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/state"
+)
+
+// storageRangePage is the number of slots requested per debug_storageRangeAt
+// call
+const storageRangePage = 1024
+
+// dumpedSlot is a slot written by `evm dump-storage`
+type dumpedSlot struct {
+	Key       common.Hash           `json:"key"`
+	Value     common.Hash           `json:"value"`
+	Variables []abi.StorageVariable `json:"variables,omitempty"`
+}
+
+// runDumpStorage implements `evm dump-storage`, writing every non-empty
+// storage slot of a contract, optionally decoded with a solc storage
+// layout, as JSON or CSV. The state is read from an alloc, session or
+// config file, or from a remote node with debug_storageRangeAt.
+func runDumpStorage(args []string) int {
+	flags := flag.NewFlagSet("dump-storage", flag.ContinueOnError)
+	allocFile := flags.String("alloc", "", "JSON file of accounts, in the format of a saved session's state")
+	sessionFile := flags.String("session", "", "saved session whose state is read")
+	configFile := flags.String("config", "", "TOML file whose accounts are read")
+	rpcURL := flags.String("rpc", "", "JSON-RPC endpoint of a node serving debug_storageRangeAt")
+	block := flags.String("block", "latest", "with -rpc, block number or tag whose state before its transactions is read")
+	layoutFile := flags.String("layout", "", "solc storage layout used to name and decode the slots of state variables")
+	format := flags.String("format", "json", "output format: json or csv")
+	noChecksum := flags.Bool("no-checksum", false, "accept mixed-case addresses with invalid EIP-55 checksums")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	sources := 0
+	for _, f := range []string{*allocFile, *sessionFile, *configFile, *rpcURL} {
+		if f != "" {
+			sources++
+		}
+	}
+	if flags.NArg() != 1 || sources != 1 || (*format != "json" && *format != "csv") {
+		fmt.Println("Usage: evm dump-storage (-alloc FILE | -session FILE | -config FILE | -rpc URL [-block N]) [-layout FILE] [-format json|csv] <address>")
+		return 2
+	}
+	addr, err := common.ParseAddress(flags.Arg(0), !*noChecksum)
+	if err != nil {
+		fmt.Println("Error: invalid address:", err.Error())
+		return 2
+	}
+	var layout *abi.StorageLayout
+	if *layoutFile != "" {
+		f, err := os.Open(*layoutFile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 2
+		}
+		layout, err = abi.LoadStorageLayout(f)
+		f.Close()
+		if err != nil {
+			fmt.Println("Error: invalid storage layout:", err.Error())
+			return 2
+		}
+	}
+
+	var slots []state.StorageSlot
+	if *rpcURL != "" {
+		slots, err = remoteStorage(*rpcURL, *block, addr)
+	} else {
+		var alloc map[common.Address]state.DumpAccount
+		alloc, err = localAlloc(*allocFile, *sessionFile, *configFile)
+		if err == nil {
+			slots, _ = state.NewFromDump(alloc).StorageRange(addr, common.Hash{}, 0)
+		}
+	}
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+
+	dumped := make([]dumpedSlot, len(slots))
+	for i, slot := range slots {
+		dumped[i] = dumpedSlot{Key: slot.Key, Value: slot.Value}
+		if layout != nil {
+			dumped[i].Variables = layout.Decode(slot.Key, slot.Value)
+		}
+	}
+	if *format == "csv" {
+		err = writeStorageCSV(os.Stdout, dumped)
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(struct {
+			Address common.Address `json:"address"`
+			Storage []dumpedSlot   `json:"storage"`
+		}{addr, dumped})
+	}
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// localAlloc reads the accounts of the one local state file given
+func localAlloc(allocFile, sessionFile, configFile string) (map[common.Address]state.DumpAccount, error) {
+	switch {
+	case sessionFile != "":
+		sess, err := loadSession(sessionFile)
+		if err != nil {
+			return nil, err
+		}
+		return sess.State, nil
+	case configFile != "":
+		cfg, err := config.Load(configFile)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Accounts()
+	}
+	var alloc map[common.Address]state.DumpAccount
+	if err := readJSON(allocFile, &alloc); err != nil {
+		return nil, fmt.Errorf("invalid alloc: %w", err)
+	}
+	return alloc, nil
+}
+
+// writeStorageCSV writes one row per decoded variable, or per slot for
+// slots without variables
+func writeStorageCSV(w io.Writer, slots []dumpedSlot) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"key", "value", "label", "type", "decoded"})
+	for _, slot := range slots {
+		if len(slot.Variables) == 0 {
+			cw.Write([]string{slot.Key.Hex(), slot.Value.Hex(), "", "", ""})
+		}
+		for _, v := range slot.Variables {
+			cw.Write([]string{slot.Key.Hex(), slot.Value.Hex(), v.Label, v.Type, v.Value})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// remoteStorage pages through the storage of addr on a remote node with
+// debug_storageRangeAt, at the start of the given block. The node must
+// know the preimages of the hashed slot keys.
+func remoteStorage(url, block string, addr common.Address) ([]state.StorageSlot, error) {
+	tag := block
+	if n, err := strconv.ParseUint(block, 10, 64); err == nil {
+		tag = "0x" + strconv.FormatUint(n, 16)
+	}
+	var header struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := rpcCall(url, "eth_getBlockByNumber", []any{tag, false}, &header); err != nil {
+		return nil, err
+	}
+	if header.Hash.IsZero() {
+		return nil, fmt.Errorf("block %s not found", block)
+	}
+
+	var slots []state.StorageSlot
+	start := common.Hash{}
+	for {
+		var page struct {
+			Storage map[common.Hash]struct {
+				Key   *common.Hash `json:"key"`
+				Value common.Hash  `json:"value"`
+			} `json:"storage"`
+			NextKey *common.Hash `json:"nextKey"`
+		}
+		if err := rpcCall(url, "debug_storageRangeAt", []any{header.Hash, 0, addr, start, storageRangePage}, &page); err != nil {
+			return nil, err
+		}
+		for hash, slot := range page.Storage {
+			if slot.Key == nil {
+				return nil, fmt.Errorf("node has no preimage for slot hash %s", hash.Hex())
+			}
+			if !slot.Value.IsZero() {
+				slots = append(slots, state.StorageSlot{Key: *slot.Key, Value: slot.Value})
+			}
+		}
+		if page.NextKey == nil {
+			break
+		}
+		start = *page.NextKey
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Key.Cmp(slots[j].Key) < 0 })
+	return slots, nil
+}
+
+// rpcCall makes a JSON-RPC request and decodes its result into result
+func rpcCall(url, method string, params []any, result any) error {
+	body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s: %w", method, resp.Status, err)
+	}
+	if reply.Error != nil {
+		return fmt.Errorf("%s: %s", method, reply.Error.Message)
+	}
+	if len(reply.Result) == 0 || string(reply.Result) == "null" {
+		return errors.New(method + ": empty result")
+	}
+	return json.Unmarshal(reply.Result, result)
+}
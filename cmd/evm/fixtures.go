@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/tests"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// fixtureResults counts the outcomes of a fixture run
+type fixtureResults struct {
+	passed, failed, skipped int
+	verbose                 bool
+
+	snapshot *tests.GasSnapshot // records the gas of passed tests, if set
+}
+
+// fixtureJob is a test of a fixture run
+type fixtureJob struct {
+	name    string
+	run     func(vm.Config) error
+	gasUsed uint64 // gas used by the top-level frames of the test
+}
+
+func (r *fixtureResults) report(job *fixtureJob, err error) {
+	name := job.name
+	if err == nil && r.snapshot != nil {
+		r.snapshot.Record(name, job.gasUsed)
+	}
+	switch {
+	case err == nil:
+		r.passed++
+		if r.verbose {
+			fmt.Println("PASS", name)
+		}
+	case errors.Is(err, tests.ErrUnsupported):
+		r.skipped++
+		if r.verbose {
+			fmt.Println("SKIP", name+":", err.Error())
+		}
+	default:
+		r.failed++
+		fmt.Println("FAIL", name+":", err.Error())
+	}
+}
+
+// runFixtures implements `evm fixtures [-fork NAME] [-run REGEXP] PATH...`,
+// running state_test and blockchain_test fixtures and interpreter scripts
+// from files or directories of JSON and YAML files. Tests run on a pool of workers while the
+// files are loaded, and are reported in file and name order.
+func runFixtures(args []string) int {
+	flags := flag.NewFlagSet("fixtures", flag.ContinueOnError)
+	fork := flags.String("fork", "", "only run state test post-states of this fork")
+	run := flags.String("run", "", "only run tests whose name matches this regular expression")
+	verbose := flags.Bool("v", false, "also print passed and skipped tests")
+	stateless := flags.Bool("stateless", false, "also re-execute each block of blockchain tests statelessly from the witness collected for it")
+	parallel := flags.Int("parallel", 0, "number of tests run at once, GOMAXPROCS if zero; one with -gas-report or -coverage")
+	gasReport := flags.Bool("gas-report", false, "print the min, average and max gas used per contract and function across the run")
+	gasReportJSON := flags.String("gas-report.json", "", "write the gas report as JSON to this file")
+	coverageFile := flags.String("coverage", "", "write the bytecode coverage of the run in LCOV format to this file and print a summary")
+	coverageSolc := flags.String("coverage.solc", "", "solc combined JSON with bin-runtime and srcmap-runtime; -coverage then reports the lines of its sources")
+	snapshotFile := flags.String("gas-snapshot", "", "compare the gas used by each passed test with this snapshot file, failing on changes beyond the tolerance")
+	tolerance := flags.Float64("gas-snapshot.tolerance", 0, "percentage by which the gas of a test may change without failing")
+	updateSnapshot := flags.Bool("gas-snapshot.update", false, "write the gas used by the passed tests to the snapshot file instead of comparing")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: evm fixtures [-fork NAME] [-run REGEXP] [-v] [-stateless] [-parallel N] [-gas-report] [-gas-report.json FILE] [-coverage FILE [-coverage.solc FILE]] [-gas-snapshot FILE [-gas-snapshot.tolerance PCT] [-gas-snapshot.update]] <file | dir>...")
+		return 2
+	}
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Println("Error: invalid -run pattern:", err.Error())
+		return 2
+	}
+
+	files, err := fixtureFiles(flags.Args())
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 2
+	}
+
+	var (
+		config   vm.Config
+		report   *tracers.GasReport
+		coverage *tracers.Coverage
+	)
+	workers := *parallel
+	// the gas report and coverage tracers follow one execution at a time
+	if *gasReport || *gasReportJSON != "" {
+		report = tracers.NewGasReport(nil)
+		config.Hooks = report.Hooks()
+		workers = 1
+	}
+	if *coverageFile != "" {
+		coverage = tracers.NewCoverage(nil)
+		config.Hooks = tracers.Combine(config.Hooks, coverage.Hooks())
+		workers = 1
+	}
+	results := &fixtureResults{verbose: *verbose}
+	if *snapshotFile != "" {
+		if results.snapshot, err = tests.LoadGasSnapshot(*snapshotFile, *tolerance/100); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 2
+		}
+	}
+
+	jobs := make(chan *fixtureJob)
+	var loadErr error
+	go func() {
+		defer close(jobs)
+		for _, file := range files {
+			fixtures, err := tests.Load(file)
+			if err != nil {
+				loadErr = err
+				return
+			}
+			for _, name := range sortedKeys(fixtures.State) {
+				if !filter.MatchString(name) {
+					continue
+				}
+				test := fixtures.State[name]
+				for _, subtest := range test.Subtests() {
+					if *fork == "" || subtest.Fork == *fork {
+						jobs <- &fixtureJob{name: name + "/" + subtest.String(), run: func(config vm.Config) error { return test.Run(subtest, config) }}
+					}
+				}
+			}
+			for _, name := range sortedKeys(fixtures.Blockchain) {
+				if filter.MatchString(name) {
+					test := fixtures.Blockchain[name]
+					run := test.Run
+					if *stateless {
+						run = test.RunStateless
+					}
+					jobs <- &fixtureJob{name: name, run: run}
+				}
+			}
+			for _, name := range sortedKeys(fixtures.Script) {
+				if filter.MatchString(name) {
+					jobs <- &fixtureJob{name: name, run: fixtures.Script[name].Run}
+				}
+			}
+			for _, name := range sortedKeys(fixtures.Skipped) {
+				if filter.MatchString(name) {
+					err := fmt.Errorf("%w: %s fixtures", tests.ErrUnsupported, fixtures.Skipped[name])
+					jobs <- &fixtureJob{name: name, run: func(vm.Config) error { return err }}
+				}
+			}
+		}
+	}()
+	tests.RunParallel(jobs, workers, func(job *fixtureJob) error {
+		config := config
+		if results.snapshot != nil {
+			config.Hooks = tracers.Combine(config.Hooks, &vm.Hooks{
+				OnExit: func(depth int, output []byte, gasUsed uint64, err error) {
+					if depth == 0 {
+						job.gasUsed += gasUsed
+					}
+				},
+			})
+		}
+		return job.run(config)
+	}, results.report)
+	if loadErr != nil {
+		fmt.Println("Error:", loadErr.Error())
+		return 1
+	}
+	fmt.Printf("%d passed, %d failed, %d skipped\n", results.passed, results.failed, results.skipped)
+	if *gasReport {
+		fmt.Println()
+		report.WriteTable(os.Stdout)
+	}
+	if *gasReportJSON != "" {
+		if err := writeGasReport(*gasReportJSON, report); err != nil {
+			fmt.Println("Error: writing gas report:", err.Error())
+			return 1
+		}
+	}
+	if coverage != nil {
+		fmt.Println()
+		coverage.WriteSummary(os.Stdout)
+		if err := writeCoverage(*coverageFile, *coverageSolc, coverage); err != nil {
+			fmt.Println("Error: writing coverage:", err.Error())
+			return 1
+		}
+	}
+	if results.snapshot != nil {
+		if code := checkGasSnapshot(results.snapshot, *snapshotFile, *updateSnapshot); code != 0 {
+			return code
+		}
+	}
+	if results.failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkGasSnapshot prints the gas changes of the run and fails on those
+// beyond the tolerance, or saves the snapshot if update is set
+func checkGasSnapshot(snapshot *tests.GasSnapshot, file string, update bool) int {
+	changes := snapshot.Changes()
+	if len(changes) > 0 {
+		fmt.Println()
+	}
+	for _, c := range changes {
+		fmt.Println("GAS", c.String())
+	}
+	if update {
+		if err := snapshot.Save(file); err != nil {
+			fmt.Println("Error: writing gas snapshot:", err.Error())
+			return 1
+		}
+		return 0
+	}
+	if regressions := snapshot.Regressions(); len(regressions) > 0 {
+		fmt.Printf("%d tests changed gas beyond %.2f%%; rerun with -gas-snapshot.update to accept\n", len(regressions), 100*snapshot.Tolerance)
+		return 1
+	}
+	return 0
+}
+
+// fixtureFiles lists the JSON files among paths and in the directories
+// among them
+func fixtureFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && (strings.HasSuffix(file, ".json") || strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml")) {
+				files = append(files, file)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeGasReport writes the entries of the gas report as JSON
+func writeGasReport(file string, report *tracers.GasReport) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// runGasTable implements `evm gastable [-config FILE] [-json] [OPCODE...]`,
+// printing the gas schedule applied to the configured block, or the cost
+// of the given opcodes, named by mnemonic or byte
+func runGasTable(args []string) int {
+	flags := flag.NewFlagSet("gastable", flag.ContinueOnError)
+	configFile := flags.String("config", "", "TOML file whose chain and block select the fork")
+	asJSON := flags.Bool("json", false, "print the table as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	cfg := config.Default()
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+	}
+	ctx := cfg.Context()
+	table := vm.GasTableFor(cfg.Chain, ctx.BlockNumber, ctx.Timestamp.Uint64())
+	if flags.NArg() > 0 {
+		costs := make([]vm.GasCost, 0, flags.NArg())
+		for _, arg := range flags.Args() {
+			op, err := parseOpCode(arg)
+			if err != nil {
+				fmt.Println("Error:", err.Error())
+				return 2
+			}
+			cost, ok := table.Lookup(op)
+			if !ok {
+				fmt.Printf("Error: %s is not implemented\n", op)
+				return 1
+			}
+			costs = append(costs, cost)
+		}
+		table.Costs = costs
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(table); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		return 0
+	}
+	fmt.Println("fork:", table.Fork)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, cost := range table.Costs {
+		fmt.Fprintf(w, "0x%02x\t%s\t%d\t%s\n", byte(cost.Op), cost.Name, cost.Constant, cost.Dynamic)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// parseOpCode parses an opcode given as a mnemonic, such as SSTORE, or as
+// a byte, such as 0x55
+func parseOpCode(s string) (vm.OpCode, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		b, err := strconv.ParseUint(s[2:], 16, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid opcode %q", s)
+		}
+		return vm.OpCode(b), nil
+	}
+	if op, ok := vm.OpCodeByName(s); ok {
+		return op, nil
+	}
+	return 0, fmt.Errorf("unknown opcode %q", s)
+}
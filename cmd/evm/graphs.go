@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// runCFG implements `evm cfg CODE`, printing the static control flow graph
+// of CODE in DOT format
+func runCFG(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("Usage: evm cfg <hex code | file>")
+		return 2
+	}
+	code, err := readCode(args[0])
+	if err != nil {
+		fmt.Println("Error: invalid code:", err.Error())
+		return 2
+	}
+	if err := asm.BuildCFG(code).WriteDOT(os.Stdout); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// writeCFG writes the control flow graph of code, completed with the jumps
+// taken by the run
+func writeCFG(file string, code []byte, address common.Address, jumps *tracers.JumpTracer) error {
+	cfg := asm.BuildCFG(code)
+	jumps.AddTo(cfg, address)
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := cfg.WriteDOT(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeCallGraph writes the call graph of the traced run
+func writeCallGraph(file string, dec *tracers.Decoder, root *tracers.CallFrame) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := tracers.WriteCallGraphDOT(out, root, dec); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/nutcas3/evm-golang/tracers/stream"
+	"github.com/nutcas3/evm-golang/tracers/tracepb"
+	"google.golang.org/grpc"
+)
+
+// traceService is a running gRPC server streaming execution events
+type traceService struct {
+	*stream.Server
+	grpc *grpc.Server
+}
+
+// serveTraceEvents starts a TraceService on addr and waits for a subscriber
+func serveTraceEvents(addr string) (*traceService, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	svc := &traceService{Server: stream.NewServer(), grpc: grpc.NewServer()}
+	tracepb.RegisterTraceServiceServer(svc.grpc, svc.Server)
+	go svc.grpc.Serve(lis)
+
+	fmt.Println("Waiting for a trace subscriber on", lis.Addr().String())
+	if err := svc.WaitForSubscribers(context.Background(), 1); err != nil {
+		svc.grpc.Stop()
+		return nil, err
+	}
+	return svc, nil
+}
+
+// stop ends the subscriptions once their events are delivered
+func (s *traceService) stop() {
+	s.Close()
+	s.grpc.GracefulStop()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// runInspect implements `evm inspect [-abi FILE] [-decompile] CODE`,
+// summarizing bytecode and listing the function selectors its dispatcher
+// handles
+func runInspect(args []string) int {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	abiFile := flags.String("abi", "", "JSON ABI used to name the recovered selectors")
+	signaturesFile := flags.String("4byte", "", "JSON database of signatures used to name the recovered selectors")
+	signaturesOnline := flags.Bool("4byte.online", false, "look up unknown selectors in the online 4byte directory")
+	decompile := flags.Bool("decompile", false, "print the code as pseudocode after the summary")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: evm inspect [-abi FILE] [-decompile] <hex code | file>")
+		return 2
+	}
+	code, err := readCode(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error: invalid code:", err.Error())
+		return 2
+	}
+	dec, err := loadDecoder(*abiFile, *signaturesFile, *signaturesOnline)
+	if err != nil {
+		fmt.Println("Error: loading signatures:", err.Error())
+		return 2
+	}
+
+	fmt.Println("code size:", len(code), "bytes")
+	fmt.Println("code hash:", crypto.Keccak256Hash(code).Hex())
+	functions := asm.Selectors(code)
+	fmt.Println("functions:", len(functions))
+	for _, fn := range functions {
+		line := fmt.Sprintf("  %s  entry %#04x", hexutil.Encode(fn.Selector[:]), fn.Entry)
+		if sig := functionSignature(dec, fn.Selector); sig != "" {
+			line += "  " + sig
+		}
+		fmt.Println(line)
+	}
+	if *decompile {
+		fmt.Print(asm.Decompile(code))
+	}
+	if err := saveSignatures(dec, *signaturesFile, *signaturesOnline); err != nil {
+		fmt.Println("Error: saving signatures:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// functionSignature returns the text signature of the selector, or "" if
+// it is unknown
+func functionSignature(dec *tracers.Decoder, selector [4]byte) string {
+	if dec.ABI != nil {
+		if m, ok := dec.ABI.MethodBySelector(selector[:]); ok {
+			return m.Signature()
+		}
+	}
+	sig, _ := dec.Signatures.Function(selector)
+	return sig
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/invariant"
+)
+
+// invariantFlags collects the values of a repeated --invariant flag
+type invariantFlags []string
+
+func (f *invariantFlags) String() string { return strings.Join(*f, "; ") }
+
+func (f *invariantFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// parseInvariants compiles the predicates, each named after its source
+func parseInvariants(predicates []string) ([]vm.Invariant, error) {
+	invariants := make([]vm.Invariant, 0, len(predicates))
+	for _, src := range predicates {
+		inv, err := invariant.Parse(src, src)
+		if err != nil {
+			return nil, err
+		}
+		invariants = append(invariants, inv)
+	}
+	return invariants, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/core/asm"
+)
+
+// runLint implements `evm lint [-abi FILE] CODE`, printing the analyzer
+// findings for CODE. It exits with 1 when there are findings so it can
+// gate CI jobs.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	abiFile := flags.String("abi", "", "JSON ABI whose view and pure functions are checked for state writes")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: evm lint [-abi FILE] <hex code | file>")
+		return 2
+	}
+	code, err := readCode(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error: invalid code:", err.Error())
+		return 2
+	}
+	contractABI, err := loadABI(*abiFile)
+	if err != nil {
+		fmt.Println("Error: loading ABI:", err.Error())
+		return 2
+	}
+
+	opts := &asm.Options{}
+	if contractABI != nil {
+		// view and pure functions are entered through STATICCALL
+		for _, fn := range asm.Selectors(code) {
+			if m, ok := contractABI.MethodBySelector(fn.Selector[:]); ok && m.IsConstant() {
+				opts.StaticEntries = append(opts.StaticEntries, fn.Entry)
+			}
+		}
+	}
+	findings := asm.Analyze(code, opts)
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,411 @@
+// Command evm executes EVM bytecode.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/internal/debug"
+	"github.com/nutcas3/evm-golang/logging"
+	"github.com/nutcas3/evm-golang/replay"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tracediff":
+			os.Exit(runTraceDiff(os.Args[2:]))
+		case "cfg":
+			os.Exit(runCFG(os.Args[2:]))
+		case "inspect":
+			os.Exit(runInspect(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "symexec":
+			os.Exit(runSymexec(os.Args[2:]))
+		case "optimize":
+			os.Exit(runOptimize(os.Args[2:]))
+		case "fixtures":
+			os.Exit(runFixtures(os.Args[2:]))
+		case "reference":
+			os.Exit(runReference(os.Args[2:]))
+		case "b11r":
+			os.Exit(runB11r(os.Args[2:]))
+		case "dump-storage":
+			os.Exit(runDumpStorage(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "node":
+			os.Exit(runNode(os.Args[2:]))
+		case "gastable":
+			os.Exit(runGasTable(os.Args[2:]))
+		case "tracers":
+			os.Exit(runTracers(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		case "stats":
+			os.Exit(runStats(os.Args[2:]))
+		case "witness":
+			os.Exit(runWitness(os.Args[2:]))
+		}
+	}
+
+	configFile := flag.String("config", "", "TOML file of chain, execution, limit and account settings; flags given explicitly override it")
+	pprofEnabled := flag.Bool("pprof", false, "enable the pprof HTTP server")
+	pprofAddr := flag.String("pprof.addr", "127.0.0.1:6060", "pprof HTTP server listening address")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of the run to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile taken after the run to this file")
+	senderFlag := flag.String("sender", "0x0000000000000000000000000000000000000000", "address of the transaction sender")
+	addressFlag := flag.String("address", "0x0000000000000000000000000000000000000000", "address the contract is deployed at")
+	codeFlag := flag.String("code", "", "code run at the address: hex, or a file of hex, raw binary or solc combined JSON (FILE:CONTRACT); a hex:, bin: or solc: prefix forces the format")
+	noChecksum := flag.Bool("no-checksum", false, "accept mixed-case addresses with invalid EIP-55 checksums")
+	suspendAfter := flag.Uint64("suspend-after", 0, "suspend after executing this many more instructions and save the session")
+	sessionFile := flag.String("session", "evm-session.json", "file the suspended session is saved to and resumed from")
+	resume := flag.Bool("resume", false, "resume the session saved in the session file")
+	recordFile := flag.String("record", "", "record the inputs of the run to this replay file")
+	replayFile := flag.String("replay", "", "re-execute the run recorded in this replay file")
+	traceFile := flag.String("trace", "", "stream a JSON-lines struct log of the run to this file")
+	traceNoStack := flag.Bool("trace.nostack", false, "omit the stack from struct logs")
+	traceNoMemory := flag.Bool("trace.nomemory", false, "omit the memory from struct logs")
+	traceGRPC := flag.String("trace.grpc", "", "serve execution events over gRPC on this address, waiting for a subscriber before running")
+	tracerName := flag.String("tracer", "", "print the result of this registered tracer, such as callTracer or prestateTracer; see evm tracers")
+	tracerConfig := flag.String("tracer.config", "", "JSON config of the --tracer")
+	reportFile := flag.String("report", "", "write an HTML report of the call tree, logs and storage writes to this file")
+	flamegraphFile := flag.String("flamegraph", "", "write gas used per opcode as folded stacks for flame graph tools to this file")
+	cfgFile := flag.String("cfg", "", "write the control flow graph of the contract, with the jumps taken, in DOT format to this file")
+	callGraphFile := flag.String("callgraph", "", "write the graph of calls between contracts in DOT format to this file")
+	abiFile := flag.String("abi", "", "JSON ABI used to decode call data and events in the report and name functions in the flame graph")
+	signaturesFile := flag.String("4byte", "", "JSON database of function and event signatures used to name selectors and topics")
+	signaturesOnline := flag.Bool("4byte.online", false, "look up unknown selectors and topics in the online 4byte directory, caching them in the --4byte file")
+	labelsFile := flag.String("labels", "", "JSON file mapping addresses to names shown in reports and graphs")
+	reentrancy := flag.Bool("reentrancy", false, "report calls that re-enter a contract still executing further up the call stack")
+	loopThreshold := flag.Uint64("loop-threshold", 0, "warn about loops jumping back this many times without changing state; 0 disables the check")
+	loopAbort := flag.Bool("loop-abort", false, "with --loop-threshold, abort the execution when a loop is reported")
+	var invariants invariantFlags
+	flag.Var(&invariants, "invariant", "predicate over the state checked after every storage write and frame exit, e.g. 'storage(0x01, 0) <= 100'; may be repeated")
+	flag.Parse()
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	cfg := config.Default()
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			fmt.Println("Error: loading config:", err.Error())
+			os.Exit(1)
+		}
+	}
+	sender, address := cfg.Execution.Sender, cfg.Execution.Address
+	var err error
+	if set["sender"] || *configFile == "" {
+		if sender, err = common.ParseAddress(*senderFlag, !*noChecksum); err != nil {
+			fmt.Println("Error: invalid sender:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if set["address"] || *configFile == "" {
+		if address, err = common.ParseAddress(*addressFlag, !*noChecksum); err != nil {
+			fmt.Println("Error: invalid address:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if !set["invariant"] {
+		invariants = cfg.Execution.Invariants
+	}
+	if !set["suspend-after"] {
+		*suspendAfter = cfg.Limits.MaxSteps
+	}
+
+	var code []byte
+	if *codeFlag != "" {
+		if *resume || *replayFile != "" {
+			fmt.Println("Error: --code cannot be combined with --resume or --replay")
+			os.Exit(1)
+		}
+		if code, err = readCode(*codeFlag); err != nil {
+			fmt.Println("Error: invalid code:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if *pprofEnabled {
+		if _, err := debug.StartPprofServer(*pprofAddr); err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	blockCtx := cfg.Context()
+	blockCtx.Sender = sender
+
+	logConfig := &logging.Config{
+		Handler: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		Levels:  map[string]slog.Leveler{logging.ComponentInterpreter: slog.LevelDebug},
+	}
+	config := vm.Config{Logging: logConfig, Precompiles: vm.ChainPrecompiles(cfg.Chain, nil), ChainConfig: cfg.Chain}
+	if config.Invariants, err = parseInvariants(invariants); err != nil {
+		fmt.Println("Error:", err.Error())
+		os.Exit(1)
+	}
+	if *loopThreshold > 0 {
+		config.Loops = &vm.LoopConfig{
+			Threshold: *loopThreshold,
+			Abort:     *loopAbort,
+			OnLoop:    func(l *vm.RunawayLoop) { fmt.Println("Warning:", l.String()) },
+		}
+	}
+
+	dec, err := loadDecoder(*abiFile, *signaturesFile, *signaturesOnline)
+	if err != nil {
+		fmt.Println("Error: loading signatures:", err.Error())
+		os.Exit(1)
+	}
+	if *labelsFile != "" {
+		if dec.Labels, err = tracers.LoadLabels(*labelsFile); err != nil {
+			fmt.Println("Error: loading labels:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	var hooks []*vm.Hooks
+	if *traceFile != "" {
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		logger := tracers.NewJSONLogger(f, &tracers.LogConfig{DisableStack: *traceNoStack, DisableMemory: *traceNoMemory})
+		defer func() {
+			if err := logger.Flush(); err != nil {
+				fmt.Println("Error: writing trace:", err.Error())
+			}
+		}()
+		hooks = append(hooks, logger.Hooks())
+	}
+	if *traceGRPC != "" {
+		events, err := serveTraceEvents(*traceGRPC)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+		defer events.stop()
+		hooks = append(hooks, events.Hooks())
+	}
+	var callTracer *tracers.CallTracer
+	if *reportFile != "" || *callGraphFile != "" {
+		callTracer = tracers.NewCallTracer()
+		hooks = append(hooks, callTracer.Hooks())
+	}
+	var jumpTracer *tracers.JumpTracer
+	if *cfgFile != "" {
+		jumpTracer = tracers.NewJumpTracer()
+		hooks = append(hooks, jumpTracer.Hooks())
+	}
+	var reentrancyTracer *tracers.ReentrancyTracer
+	if *reentrancy {
+		reentrancyTracer = tracers.NewReentrancyTracer()
+		hooks = append(hooks, reentrancyTracer.Hooks())
+	}
+	var flameGraph *tracers.FlameGraph
+	if *flamegraphFile != "" {
+		flameGraph = tracers.NewFlameGraph(dec)
+		hooks = append(hooks, flameGraph.Hooks())
+	}
+	config.Hooks = tracers.Combine(hooks...)
+
+	if *replayFile != "" {
+		if *tracerName != "" {
+			fmt.Println("Error: --tracer cannot be combined with --replay")
+			os.Exit(1)
+		}
+		rec, err := replay.Load(*replayFile)
+		if err != nil {
+			fmt.Println("Error: loading replay:", err.Error())
+			os.Exit(1)
+		}
+		if result := replay.Replay(context.Background(), rec, config); result.Failed() {
+			printFailure(result.Err, dec)
+		}
+		fmt.Println("EVM Execution Complete")
+		return
+	}
+
+	var (
+		statedb     *state.StateDB
+		evm         *vm.EVM
+		recorder    *replay.Recorder
+		namedTracer tracers.Tracer
+	)
+	// the named tracer reads the state the run starts from
+	attachTracer := func(db vm.StateDB) {
+		if *tracerName == "" {
+			return
+		}
+		t, err := tracers.New(*tracerName, &tracers.Env{StateDB: db}, json.RawMessage(*tracerConfig))
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+		namedTracer = t
+		config.Hooks = tracers.Combine(config.Hooks, t.Hooks())
+	}
+	if *resume {
+		sess, err := loadSession(*sessionFile)
+		if err != nil {
+			fmt.Println("Error: loading session:", err.Error())
+			os.Exit(1)
+		}
+		statedb = state.NewFromDump(sess.State)
+		attachTracer(statedb)
+		evm = vm.NewEVMFromCheckpoint(sess.Checkpoint, statedb, config)
+		address = sess.Checkpoint.Address
+		if *suspendAfter > 0 {
+			*suspendAfter += uint64(sess.Checkpoint.Steps)
+		}
+	} else {
+		statedb = state.New()
+		if err := cfg.Apply(statedb); err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+		var db vm.StateDB = statedb
+		if *recordFile != "" {
+			recorder = replay.NewRecorder(statedb)
+			db = recorder
+		}
+		attachTracer(db)
+		evm = vm.NewEVM(blockCtx, db, config)
+
+		if code != nil {
+			statedb.SetCode(address, code)
+		} else if len(statedb.GetCode(address)) == 0 {
+			statedb.SetCode(address, []byte{
+				0x60, 0x0a, // PUSH1 0x0a
+				0x60, 0x14, // PUSH1 0x14
+				0x01, // ADD
+				0x00, // STOP
+			})
+		}
+	}
+	evm.SuspendAfter(*suspendAfter)
+	if cfg.Limits.Timeout > 0 {
+		timer := time.AfterFunc(cfg.Limits.Timeout, evm.Suspend)
+		defer timer.Stop()
+	}
+
+	var profiles vm.Profiles
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		profiles.CPU = f
+	}
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+		profiles.Heap = f
+	}
+
+	run := func() *vm.ExecutionResult { return evm.Run(context.Background(), address) }
+	if *resume {
+		run = func() *vm.ExecutionResult { return evm.Resume(context.Background()) }
+	}
+	result, err := vm.Profile(profiles, run)
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+	}
+	if errors.Is(result.Err, vm.ErrSuspended) {
+		sess := &session{Checkpoint: evm.Checkpoint(), State: statedb.Dump()}
+		if err := saveSession(*sessionFile, sess); err != nil {
+			fmt.Println("Error: saving session:", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("Execution suspended at pc", uint64(sess.Checkpoint.PC), "- resume with --resume --session", *sessionFile)
+		return
+	}
+	if result.Failed() {
+		printFailure(result.Err, dec)
+	}
+	if reentrancyTracer != nil {
+		for _, r := range reentrancyTracer.Findings() {
+			fmt.Println("Reentrancy: frame at depth", r.Depth, r.String())
+		}
+		if callTracer != nil {
+			reentrancyTracer.Annotate(callTracer.Root())
+		}
+	}
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, dec, callTracer.Root()); err != nil {
+			fmt.Println("Error: writing report:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if *callGraphFile != "" {
+		if err := writeCallGraph(*callGraphFile, dec, callTracer.Root()); err != nil {
+			fmt.Println("Error: writing call graph:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if jumpTracer != nil {
+		if err := writeCFG(*cfgFile, statedb.GetCode(address), address, jumpTracer); err != nil {
+			fmt.Println("Error: writing control flow graph:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if flameGraph != nil {
+		if err := writeFlameGraph(*flamegraphFile, flameGraph); err != nil {
+			fmt.Println("Error: writing flame graph:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if err := saveSignatures(dec, *signaturesFile, *signaturesOnline); err != nil {
+		fmt.Println("Error: saving signatures:", err.Error())
+		os.Exit(1)
+	}
+	if namedTracer != nil {
+		if err := printTracerResult(namedTracer); err != nil {
+			fmt.Println("Error: tracer:", err.Error())
+			os.Exit(1)
+		}
+	}
+	if recorder != nil {
+		if err := replay.Save(*recordFile, recorder.Recording(blockCtx, address)); err != nil {
+			fmt.Println("Error: saving replay:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println("EVM Execution Complete")
+}
+
+// printFailure prints the error of a failed run followed by the call frames
+// it failed through, innermost first, naming the functions dec knows
+func printFailure(err error, dec *tracers.Decoder) {
+	fmt.Println("Error:", err.Error())
+	for _, f := range vm.Frames(err) {
+		line := "  at " + f.String()
+		if name := dec.FunctionName(f.Selector); name != "" {
+			line += " (" + name + ")"
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/accounts"
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/node"
+	"github.com/nutcas3/evm-golang/params"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/stats"
+)
+
+// runNode implements `evm node [-addr ADDR] [-chain NAME] [-config FILE] [-alloc FILE] [-accounts N] [-mnemonic WORDS] [-balance WEI] [-origins LIST] [-dashboard]`,
+// serving a development node over JSON-RPC and WebSocket, and its stats
+// under /stats, until interrupted. The dev accounts are funded at genesis
+// and printed with their private keys.
+func runNode(args []string) int {
+	flags := flag.NewFlagSet("node", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8545", "address JSON-RPC and WebSocket requests are served on")
+	chain := flags.String("chain", "", "network preset the chain config is taken from, overriding the config file: "+strings.Join(params.PresetNames(), ", "))
+	configFile := flags.String("config", "", "TOML file of the chain config and the accounts to start with")
+	allocFile := flags.String("alloc", "", "JSON file of accounts to start with, in the format of a saved session's state")
+	origins := flags.String("origins", "", "comma-separated origins browsers may send requests from, * for any; localhost ones if empty")
+	dashboard := flags.Bool("dashboard", false, "draw the node's stats in the terminal")
+	devAccounts := flags.Int("accounts", accounts.DefaultCount, "number of dev accounts derived from the mnemonic and funded at genesis")
+	mnemonic := flags.String("mnemonic", accounts.DefaultMnemonic, "BIP-39 mnemonic the dev accounts are derived from")
+	balance := flags.String("balance", accounts.DefaultBalance.String(), "balance of each dev account in wei, decimal or 0x-prefixed hex")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Println("Usage: evm node [-addr ADDR] [-chain NAME] [-config FILE] [-alloc FILE] [-accounts N] [-mnemonic WORDS] [-balance WEI] [-origins LIST] [-dashboard]")
+		return 2
+	}
+	devBalance, ok := new(big.Int).SetString(*balance, 0)
+	if !ok || devBalance.Sign() < 0 {
+		fmt.Println("Error: invalid -balance:", *balance)
+		return 2
+	}
+	devAccs, err := accounts.Derive(*mnemonic, max(*devAccounts, 0))
+	if err != nil {
+		fmt.Println("Error: deriving dev accounts:", err.Error())
+		return 2
+	}
+
+	cfg := config.Default()
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+	}
+	if *chain != "" {
+		var err error
+		if cfg.Chain, err = params.Preset(*chain); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+	}
+	alloc, err := cfg.Accounts()
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	if *allocFile != "" {
+		data, err := os.ReadFile(*allocFile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		if err := json.Unmarshal(data, &alloc); err != nil {
+			fmt.Println("Error: invalid alloc:", err.Error())
+			return 1
+		}
+	}
+	counters := stats.New()
+	dev := node.NewDev(cfg.Chain, vm.Config{Hooks: counters.Hooks()})
+	counters.SetChain(dev)
+	if *origins != "" {
+		dev.SetOrigins(strings.Split(*origins, ","))
+	}
+	dev.AddDevAccounts(devAccs, devBalance)
+	dev.Update(func(sim *simulator.Simulator) {
+		statedb := sim.State()
+		for addr, acc := range alloc {
+			statedb.CreateAccount(addr)
+			statedb.SetNonce(addr, uint64(acc.Nonce))
+			if acc.Balance != nil {
+				statedb.AddBalance(addr, acc.Balance.ToInt())
+			}
+			if len(acc.Code) > 0 {
+				statedb.SetCode(addr, acc.Code)
+			}
+			for key, value := range acc.Storage {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	})
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", dev.Handler())
+	mux.Handle("/stats", counters.Handler())
+	srv := &http.Server{Handler: mux}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-interrupt
+		cancel()
+		srv.Close()
+	}()
+
+	printDevAccounts(devAccs, devBalance, *mnemonic)
+	fmt.Println("Dev node serving http://" + lis.Addr().String() + " and ws://" + lis.Addr().String())
+	if *dashboard {
+		go watch(ctx, counters)
+	}
+	if err := srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// printDevAccounts lists the dev accounts with their private keys
+func printDevAccounts(accs []*accounts.Account, balance *big.Int, mnemonic string) {
+	if len(accs) == 0 {
+		return
+	}
+	fmt.Printf("Dev accounts, each funded with %s wei, derived from the mnemonic %q:\n", balance, mnemonic)
+	for i, acc := range accs {
+		fmt.Printf("(%d) %s %s\n", i, acc.Address.Hex(), acc.PrivateKeyHex())
+	}
+	fmt.Println()
+}
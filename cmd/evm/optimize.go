@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/asm"
+)
+
+// runOptimize implements `evm optimize [-check] CODE`, printing CODE after
+// peephole optimization
+func runOptimize(args []string) int {
+	flags := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	check := flags.Bool("check", false, "run the original and the optimized code and compare their results")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: evm optimize [-check] <hex code | file>")
+		return 2
+	}
+	code, err := readCode(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error: invalid code:", err.Error())
+		return 2
+	}
+
+	optimized := asm.Optimize(code)
+	fmt.Println(hexutil.Encode(optimized))
+	fmt.Println("size:", len(code), "->", len(optimized), "bytes")
+	if *check {
+		if err := asm.Equivalent(code, optimized); err != nil {
+			fmt.Println("Error: optimized code differs:", err.Error())
+			return 1
+		}
+		fmt.Println("execution results match")
+	}
+	return 0
+}
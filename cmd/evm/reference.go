@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/tests"
+)
+
+// runReference implements `evm reference [-t8n CMD] PATH...`, running the
+// transactions of state test fixtures with this VM and with a reference
+// transition tool and printing where the results differ
+func runReference(args []string) int {
+	flags := flag.NewFlagSet("reference", flag.ContinueOnError)
+	t8n := flags.String("t8n", strings.Join(tests.DefaultT8n, " "), "transition tool command of the reference implementation")
+	fork := flags.String("fork", tests.DefaultFork, "fork whose post-states are compared")
+	run := flags.String("run", "", "only compare tests whose name matches this regular expression")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: evm reference [-t8n CMD] [-fork NAME] [-run REGEXP] <file | dir>...")
+		return 2
+	}
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Println("Error: invalid -run pattern:", err.Error())
+		return 2
+	}
+	files, err := fixtureFiles(flags.Args())
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 2
+	}
+
+	ref := &tests.Reference{Command: strings.Fields(*t8n)}
+	matched, differed, skipped := 0, 0, 0
+	for _, file := range files {
+		fixtures, err := tests.Load(file)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		for _, name := range sortedKeys(fixtures.State) {
+			if !filter.MatchString(name) {
+				continue
+			}
+			test := fixtures.State[name]
+			for _, subtest := range test.Subtests() {
+				if subtest.Fork != *fork {
+					continue
+				}
+				label := name + "/" + subtest.String()
+				def, err := test.Definition(subtest)
+				var diffs []tests.Difference
+				if err == nil {
+					diffs, err = ref.Compare(def, vm.Config{})
+				}
+				switch {
+				case errors.Is(err, tests.ErrUnsupported):
+					skipped++
+				case err != nil:
+					fmt.Println("Error:", label+":", err.Error())
+					return 1
+				case len(diffs) > 0:
+					differed++
+					fmt.Println("DIFF", label)
+					for _, d := range diffs {
+						fmt.Println("    " + d.String())
+					}
+				default:
+					matched++
+				}
+			}
+		}
+	}
+	fmt.Printf("%d matched, %d differed, %d skipped\n", matched, differed, skipped)
+	if differed > 0 {
+		return 1
+	}
+	return 0
+}
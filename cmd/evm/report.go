@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+
+	"github.com/nutcas3/evm-golang/abi"
+	"github.com/nutcas3/evm-golang/abi/fourbyte"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// loadABI reads the JSON ABI in file, returning nil if file is empty
+func loadABI(file string) (*abi.ABI, error) {
+	if file == "" {
+		return nil, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return abi.JSON(f)
+}
+
+// loadDecoder builds the decoder used to name functions and events from an
+// ABI file and a signature database, either of which may be empty. With
+// online set, unknown signatures are looked up in the 4byte directory.
+func loadDecoder(abiFile, signaturesFile string, online bool) (*tracers.Decoder, error) {
+	contractABI, err := loadABI(abiFile)
+	if err != nil {
+		return nil, err
+	}
+	signatures := fourbyte.New()
+	if signaturesFile != "" {
+		if _, err := os.Stat(signaturesFile); err == nil || !online {
+			if signatures, err = fourbyte.LoadFile(signaturesFile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if online {
+		signatures.Online = &fourbyte.Online{}
+	}
+	return &tracers.Decoder{ABI: contractABI, Signatures: signatures}, nil
+}
+
+// saveSignatures stores the signatures learned online back to the database
+func saveSignatures(dec *tracers.Decoder, signaturesFile string, online bool) error {
+	if !online || signaturesFile == "" {
+		return nil
+	}
+	return dec.Signatures.Save(signaturesFile)
+}
+
+// writeReport writes the HTML report of the traced call tree
+func writeReport(file string, dec *tracers.Decoder, root *tracers.CallFrame) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := tracers.WriteHTMLReport(out, root, dec); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeFlameGraph writes the folded stacks collected by the flame graph
+func writeFlameGraph(file string, flameGraph *tracers.FlameGraph) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := flameGraph.WriteFolded(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/execution"
+	"github.com/nutcas3/evm-golang/execution/executionpb"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/stats"
+	"google.golang.org/grpc"
+)
+
+// runServe implements `evm serve [-grpc ADDR] [-http ADDR] [-cache N] [-config FILE] [-dashboard]`,
+// running the execution services until interrupted
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	grpcAddr := flags.String("grpc", "127.0.0.1:9090", "address the gRPC ExecutionService listens on, empty to disable")
+	httpAddr := flags.String("http", "", "address the HTTP execution endpoint and the stats endpoint listen on, empty to disable")
+	dashboard := flags.Bool("dashboard", false, "draw the service's stats in the terminal")
+	cacheSize := flags.Int("cache", 4096, "number of execution results cached for identical requests, 0 to disable")
+	configFile := flags.String("config", "", "TOML configuration file whose limits.maxGas caps the gas of requests")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 || (*grpcAddr == "" && *httpAddr == "") {
+		fmt.Println("Usage: evm serve [-grpc ADDR] [-http ADDR] [-cache N] [-config FILE] [-dashboard]")
+		return 2
+	}
+	// requests never get more gas than a block holds unless configured
+	limits := config.Limits{MaxGas: simulator.DefaultGasLimit}
+	if *configFile != "" {
+		cfg, err := config.Load(*configFile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		if cfg.Limits.MaxGas > 0 {
+			limits.MaxGas = cfg.Limits.MaxGas
+		}
+	}
+
+	counters := stats.New()
+	config := vm.Config{Hooks: counters.Hooks()}
+	var cache *execution.ResultCache
+	if *cacheSize > 0 {
+		cache = execution.NewResultCache(*cacheSize, counters)
+	}
+	errc := make(chan error, 2)
+	var stops []func()
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		srv := grpc.NewServer()
+		server := execution.NewServer(config)
+		server.SetLimits(limits)
+		server.SetCache(cache)
+		executionpb.RegisterExecutionServiceServer(srv, server)
+		stops = append(stops, srv.GracefulStop)
+		fmt.Println("Serving ExecutionService on", lis.Addr().String())
+		go func() { errc <- srv.Serve(lis) }()
+	}
+	if *httpAddr != "" {
+		lis, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/execute", execution.NewCachedHandler(config, limits, cache))
+		mux.Handle("/batch", execution.NewBatchHandler(config, limits))
+		mux.Handle("/stats", counters.Handler())
+		srv := &http.Server{Handler: mux}
+		stops = append(stops, func() { srv.Shutdown(context.Background()) })
+		fmt.Println("Serving HTTP execution on", "http://"+lis.Addr().String()+"/execute")
+		go func() {
+			if err := srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+				errc <- err
+				return
+			}
+			errc <- nil
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *dashboard {
+		go watch(ctx, counters)
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	var err error
+	select {
+	case <-interrupt:
+	case err = <-errc:
+	}
+	for _, stop := range stops {
+		stop()
+	}
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
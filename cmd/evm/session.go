@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// session is a suspended execution persisted between invocations
+type session struct {
+	Checkpoint *vm.Checkpoint                       `json:"checkpoint"`
+	State      map[common.Address]state.DumpAccount `json:"state"`
+}
+
+func loadSession(path string) (*session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sess session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func saveSession(path string, sess *session) error {
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
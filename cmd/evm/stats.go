@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/nutcas3/evm-golang/stats"
+)
+
+// dashboardInterval is how often the stats dashboard is redrawn
+const dashboardInterval = time.Second
+
+// runStats implements `evm stats [-interval DURATION] URL`, drawing the
+// stats of a running node or execution service in the terminal until
+// interrupted. URL is the instance's address or its /stats endpoint.
+func runStats(args []string) int {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	interval := flags.Duration("interval", dashboardInterval, "time between refreshes")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *interval <= 0 {
+		fmt.Println("Usage: evm stats [-interval DURATION] URL")
+		return 2
+	}
+	url := flags.Arg(0)
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	if !strings.HasSuffix(url, "/stats") {
+		url = strings.TrimSuffix(url, "/") + "/stats"
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := stats.Dashboard(ctx, os.Stdout, *interval, stats.Fetch(nil, url)); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// watch draws the dashboard of local counters until ctx is done
+func watch(ctx context.Context, counters *stats.Counters) {
+	stats.Dashboard(ctx, os.Stdout, dashboardInterval, func(context.Context) (*stats.Snapshot, error) {
+		return counters.Snapshot(), nil
+	})
+}
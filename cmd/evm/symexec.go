@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/symbolic"
+)
+
+// runSymexec implements `evm symexec [-smt CMD] [-all] CODE`, listing the
+// reverts and failed assertions reachable in CODE with call data reaching
+// each of them
+func runSymexec(args []string) int {
+	flags := flag.NewFlagSet("symexec", flag.ContinueOnError)
+	smt := flags.String("smt", "", "SMT solver command reading SMT-LIB 2 on stdin, e.g. \"z3 -in\"; a built-in guessing solver is used if empty")
+	all := flags.Bool("all", false, "list every explored path, not only failing ones")
+	maxPaths := flags.Int("max-paths", symbolic.DefaultMaxPaths, "number of paths explored before giving up")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: evm symexec [-smt CMD] [-all] <hex code | file>")
+		return 2
+	}
+	code, err := readCode(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error: invalid code:", err.Error())
+		return 2
+	}
+
+	cfg := &symbolic.Config{MaxPaths: *maxPaths}
+	if *smt != "" {
+		cfg.Solver = symbolic.SMTSolver{Command: strings.Fields(*smt)}
+	}
+	report := symbolic.Explore(code, cfg)
+	paths := report.Failures()
+	if *all {
+		paths = report.Paths
+	}
+	fmt.Printf("%d paths explored, %d failing\n", len(report.Paths), len(report.Failures()))
+	for _, p := range paths {
+		line := fmt.Sprintf("%#04x: %s", p.PC, p.Outcome)
+		if p.Reason != "" {
+			line += " (" + p.Reason + ")"
+		}
+		switch p.Feasible {
+		case symbolic.Sat:
+			line += " with calldata " + hexutil.Encode(p.Calldata())
+		case symbolic.Unknown:
+			line += ", feasibility unknown"
+		}
+		fmt.Println(line)
+		for _, c := range p.Constraints {
+			fmt.Println("    " + c.String())
+		}
+	}
+	if report.SolverErr != nil {
+		fmt.Println("Error: solver:", report.SolverErr.Error())
+	}
+	if report.Truncated {
+		fmt.Println("exploration stopped after", len(report.Paths), "paths")
+	}
+	return 0
+}
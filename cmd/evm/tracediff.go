@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// runTraceDiff implements `evm tracediff a.jsonl b.jsonl`. It exits with 1
+// when the traces diverge and 2 on errors, like diff.
+func runTraceDiff(args []string) int {
+	if len(args) != 2 {
+		fmt.Println("Usage: evm tracediff a.jsonl b.jsonl")
+		return 2
+	}
+	a, err := os.Open(args[0])
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 2
+	}
+	defer a.Close()
+	b, err := os.Open(args[1])
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 2
+	}
+	defer b.Close()
+
+	div, err := tracers.DiffTraces(a, b)
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 2
+	}
+	if div != nil {
+		fmt.Println(div)
+		return 1
+	}
+	fmt.Println("traces are identical")
+	return 0
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// runTracers implements `evm tracers`, listing the registered tracers
+// selectable with --tracer and the options of their --tracer.config
+func runTracers(args []string) int {
+	flags := flag.NewFlagSet("tracers", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	for _, def := range tracers.Registered() {
+		fmt.Printf("%s: %s\n", def.Name, def.Description)
+		if len(def.Options) > 0 {
+			fmt.Println("  options:", string(def.Options))
+		}
+	}
+	return 0
+}
+
+// printTracerResult prints the result of a named tracer as indented JSON
+func printTracerResult(t tracers.Tracer) error {
+	result, err := t.Result()
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, result, "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(out.String())
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/tests"
+	"github.com/nutcas3/evm-golang/witness"
+)
+
+// runWitness implements `evm witness`, writing the execution witness of a
+// block of a blockchain_test fixture as JSON, or with -verify re-executing
+// the block statelessly from a witness file and checking the state root
+// it yields.
+func runWitness(args []string) int {
+	flags := flag.NewFlagSet("witness", flag.ContinueOnError)
+	name := flags.String("test", "", "blockchain test of the file; required when it holds several")
+	index := flags.Int("block", 0, "index of the block among the test's blocks")
+	verifyFile := flags.String("verify", "", "re-execute the block from the witness in this file instead of collecting one")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: evm witness [-test NAME] [-block N] [-verify WITNESS] <fixture>")
+		return 2
+	}
+	fixtures, err := tests.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 2
+	}
+	test := fixtures.Blockchain[*name]
+	if *name == "" && len(fixtures.Blockchain) == 1 {
+		for _, t := range fixtures.Blockchain {
+			test = t
+		}
+	}
+	if test == nil {
+		fmt.Printf("Error: %d blockchain tests in %s, select one with -test\n", len(fixtures.Blockchain), flags.Arg(0))
+		for _, name := range sortedKeys(fixtures.Blockchain) {
+			fmt.Println(" ", name)
+		}
+		return 2
+	}
+	if *index < 0 || *index >= len(test.Blocks) {
+		fmt.Printf("Error: block %d out of range, the test has %d\n", *index, len(test.Blocks))
+		return 2
+	}
+
+	if *verifyFile != "" {
+		data, err := os.ReadFile(*verifyFile)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return 2
+		}
+		w := new(witness.Witness)
+		if err := json.Unmarshal(data, w); err != nil {
+			fmt.Println("Error: invalid witness:", err.Error())
+			return 2
+		}
+		if err := test.VerifyWitness(vm.Config{}, *index, w); err != nil {
+			fmt.Println("Error:", err.Error())
+			return 1
+		}
+		fmt.Printf("block %d: state root %s verified with %d nodes and %d codes\n", *index, test.Blocks[*index].Header.StateRoot.Hex(), len(w.Nodes), len(w.Codes))
+		return 0
+	}
+
+	witnesses, err := test.Witnesses(vm.Config{})
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(witnesses[*index]); err != nil {
+		fmt.Println("Error:", err.Error())
+		return 1
+	}
+	return 0
+}
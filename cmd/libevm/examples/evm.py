@@ -0,0 +1,75 @@
+"""Minimal Python binding for libevm using ctypes.
+
+Build the library first:
+
+    go build -buildmode=c-shared -o libevm.so ./cmd/libevm
+
+then run this file with LIBEVM pointing at it (default ./libevm.so).
+"""
+
+import ctypes
+import json
+import os
+
+_lib = ctypes.CDLL(os.environ.get("LIBEVM", "./libevm.so"))
+_lib.evm_new.restype = ctypes.c_size_t
+_lib.evm_free.argtypes = [ctypes.c_size_t]
+_lib.evm_free_string.argtypes = [ctypes.c_void_p]
+for name, nargs in (("evm_create_account", 3), ("evm_deploy", 3), ("evm_call", 2)):
+    fn = getattr(_lib, name)
+    fn.argtypes = [ctypes.c_size_t] + [ctypes.c_char_p] * (nargs - 1)
+    fn.restype = ctypes.c_void_p
+_lib.evm_run.argtypes = [ctypes.c_char_p]
+_lib.evm_run.restype = ctypes.c_void_p
+
+
+def _take(ptr):
+    """Copies and frees a string returned by the library."""
+    if not ptr:
+        return None
+    try:
+        return ctypes.string_at(ptr).decode()
+    finally:
+        _lib.evm_free_string(ptr)
+
+
+def run(request):
+    """Runs code once against a throwaway state, see evm_run."""
+    return json.loads(_take(_lib.evm_run(json.dumps(request).encode())))
+
+
+class Simulator:
+    def __init__(self):
+        self._h = _lib.evm_new()
+
+    def close(self):
+        if self._h:
+            _lib.evm_free(self._h)
+            self._h = 0
+
+    def create_account(self, addr, balance=0):
+        err = _take(_lib.evm_create_account(self._h, addr.encode(), str(balance).encode()))
+        if err:
+            raise ValueError(err)
+
+    def deploy(self, sender, code):
+        out = json.loads(_take(_lib.evm_deploy(self._h, sender.encode(), code.encode())))
+        if "error" in out:
+            raise ValueError(out["error"])
+        return out["address"]
+
+    def call(self, sender, to, value=0, gas=0, trace=False):
+        request = {"from": sender, "to": to, "value": hex(value), "gas": gas, "trace": trace}
+        return json.loads(_take(_lib.evm_call(self._h, json.dumps(request).encode())))
+
+
+if __name__ == "__main__":
+    alice = "0x00000000000000000000000000000000000000a1"
+    sim = Simulator()
+    sim.create_account(alice, 10**18)
+    contract = sim.deploy(alice, "0x600a601401")  # PUSH1 10, PUSH1 20, ADD
+    result = sim.call(alice, contract, trace=True)
+    print("gas used:", result["gasUsed"])
+    for step in result["trace"]:
+        print(step)
+    sim.close()
@@ -0,0 +1,224 @@
+// Command libevm exports the simulator through a C ABI for embedding the VM
+// in other languages without an RPC hop. Build it as a shared library:
+//
+//	go build -buildmode=c-shared -o libevm.so ./cmd/libevm
+//
+// which also writes libevm.h. Simulators are referred to by opaque handles.
+// Requests and results are JSON strings; every returned string is allocated
+// by the library and must be released with evm_free_string. Results carry
+// an "error" field instead of failing the call.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+func main() {}
+
+// runRequest is the request of evm_run: code executed once against a
+// throwaway state
+type runRequest struct {
+	Code    hexutil.Bytes                        `json:"code"`
+	Address common.Address                       `json:"address"`
+	Sender  common.Address                       `json:"sender"`
+	Gas     uint64                               `json:"gas"`
+	State   map[common.Address]state.DumpAccount `json:"state,omitempty"`
+	Trace   bool                                 `json:"trace"`
+}
+
+// callRequest is the request of evm_call
+type callRequest struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value,omitempty"`
+	Gas   uint64         `json:"gas"`
+	Trace bool           `json:"trace"`
+}
+
+// result is the JSON result of evm_run and evm_call
+type result struct {
+	ReturnData hexutil.Bytes                        `json:"returnData"`
+	GasUsed    uint64                               `json:"gasUsed"`
+	Logs       []*types.Log                         `json:"logs"`
+	Error      string                               `json:"error,omitempty"`
+	Trace      []json.RawMessage                    `json:"trace,omitempty"` // struct logs as written by evm --trace
+	State      map[common.Address]state.DumpAccount `json:"state,omitempty"` // post-state of evm_run
+}
+
+//export evm_new
+func evm_new() C.uintptr_t {
+	return C.uintptr_t(cgo.NewHandle(simulator.New(nil, vm.Config{})))
+}
+
+//export evm_free
+func evm_free(h C.uintptr_t) {
+	cgo.Handle(h).Delete()
+}
+
+//export evm_free_string
+func evm_free_string(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+// evm_create_account creates an account holding balance, a hex or decimal
+// string that may be NULL. It returns NULL or an error message.
+//
+//export evm_create_account
+func evm_create_account(h C.uintptr_t, addr, balance *C.char) *C.char {
+	a, err := common.HexToAddressUnchecked(C.GoString(addr))
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	amount := new(big.Int)
+	if balance != nil {
+		if _, ok := amount.SetString(C.GoString(balance), 0); !ok {
+			return C.CString(fmt.Sprintf("invalid balance %q", C.GoString(balance)))
+		}
+	}
+	cgo.Handle(h).Value().(*simulator.Simulator).CreateAccount(a, amount)
+	return nil
+}
+
+// evm_deploy installs hex code as a contract created by from and returns
+// {"address": ...} or {"error": ...}
+//
+//export evm_deploy
+func evm_deploy(h C.uintptr_t, from, code *C.char) *C.char {
+	var out struct {
+		Address *common.Address `json:"address,omitempty"`
+		Error   string          `json:"error,omitempty"`
+	}
+	sender, err := common.HexToAddressUnchecked(C.GoString(from))
+	if err != nil {
+		out.Error = err.Error()
+		return encode(out)
+	}
+	b, err := hexutil.Decode(C.GoString(code))
+	if err != nil {
+		out.Error = fmt.Sprintf("invalid code: %v", err)
+		return encode(out)
+	}
+	addr, err := cgo.Handle(h).Value().(*simulator.Simulator).Deploy(sender, b)
+	if err != nil {
+		out.Error = err.Error()
+		return encode(out)
+	}
+	out.Address = &addr
+	return encode(out)
+}
+
+// evm_call executes a callRequest against the simulator
+//
+//export evm_call
+func evm_call(h C.uintptr_t, request *C.char) *C.char {
+	var req callRequest
+	if err := json.Unmarshal([]byte(C.GoString(request)), &req); err != nil {
+		return encode(&result{Error: fmt.Sprintf("invalid request: %v", err)})
+	}
+	sim := cgo.Handle(h).Value().(*simulator.Simulator)
+	msg := simulator.CallMsg{From: req.From, To: req.To, Value: req.Value.ToInt(), Gas: req.Gas}
+	var logger *tracers.JSONLogger
+	var trace bytes.Buffer
+	if req.Trace {
+		logger = tracers.NewJSONLogger(&trace, nil)
+		sim.SetHooks(logger.Hooks())
+		defer sim.SetHooks(nil)
+	}
+	res, err := sim.Call(context.Background(), msg)
+	if err != nil {
+		return encode(&result{Error: err.Error()})
+	}
+	return encode(newResult(res, logger, &trace))
+}
+
+// evm_run executes a runRequest: the code is deployed at the request
+// address of a state loaded from the request and run once. The result
+// includes the post-state.
+//
+//export evm_run
+func evm_run(request *C.char) *C.char {
+	var req runRequest
+	if err := json.Unmarshal([]byte(C.GoString(request)), &req); err != nil {
+		return encode(&result{Error: fmt.Sprintf("invalid request: %v", err)})
+	}
+	statedb := state.NewFromDump(req.State)
+	if len(req.Code) > 0 {
+		statedb.SetCode(req.Address, req.Code)
+	}
+	gas := req.Gas
+	if gas == 0 {
+		gas = simulator.DefaultGasLimit
+	}
+	blockCtx := &vm.Context{
+		BlockNumber: big.NewInt(1),
+		Timestamp:   big.NewInt(0),
+		Sender:      req.Sender,
+		GasLimit:    gas,
+		GasPrice:    new(big.Int),
+	}
+	var config vm.Config
+	var logger *tracers.JSONLogger
+	var trace bytes.Buffer
+	if req.Trace {
+		logger = tracers.NewJSONLogger(&trace, nil)
+		config.Hooks = logger.Hooks()
+	}
+	res := newResult(vm.NewEVM(blockCtx, statedb, config).Run(context.Background(), req.Address), logger, &trace)
+	res.State = statedb.Dump()
+	return encode(res)
+}
+
+// newResult converts an execution result, attaching the struct logs written
+// by logger if it is not nil
+func newResult(r *vm.ExecutionResult, logger *tracers.JSONLogger, trace *bytes.Buffer) *result {
+	res := &result{ReturnData: r.ReturnData, GasUsed: r.GasUsed, Logs: r.Logs}
+	if r.Failed() {
+		res.Error = r.Err.Error()
+	}
+	if logger == nil {
+		return res
+	}
+	if err := logger.Flush(); err != nil {
+		res.Error = fmt.Sprintf("trace: %v", err)
+		return res
+	}
+	res.Trace = []json.RawMessage{}
+	for _, line := range bytes.Split(bytes.TrimSpace(trace.Bytes()), []byte("\n")) {
+		if len(line) > 0 {
+			res.Trace = append(res.Trace, json.RawMessage(line))
+		}
+	}
+	return res
+}
+
+// encode returns v as JSON in a C string owned by the caller
+func encode(v any) *C.char {
+	if r, ok := v.(*result); ok && r.Logs == nil {
+		r.Logs = []*types.Log{}
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		out, _ = json.Marshal(&result{Error: err.Error(), Logs: []*types.Log{}})
+	}
+	return C.CString(string(out))
+}
@@ -0,0 +1,57 @@
+package common
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrInvalidChecksum is returned when a mixed-case address fails EIP-55
+// checksum validation
+var ErrInvalidChecksum = errors.New("invalid address checksum")
+
+// checksumHex returns the EIP-55 mixed-case hex encoding of the address,
+// without the 0x prefix
+func checksumHex(a Address) []byte {
+	buf := make([]byte, AddressLength*2)
+	hex.Encode(buf, a[:])
+
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(buf)
+	hash := hasher.Sum(nil)
+
+	for i := range buf {
+		if buf[i] < 'a' {
+			continue // digits have no case
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			buf[i] -= 'a' - 'A'
+		}
+	}
+	return buf
+}
+
+// ValidateChecksum verifies the EIP-55 checksum of a hex address. Addresses
+// written entirely in lower or upper case carry no checksum and are accepted.
+func ValidateChecksum(s string) error {
+	if Has0xPrefix(s) {
+		s = s[2:]
+	}
+	if s == strings.ToLower(s) || s == strings.ToUpper(s) {
+		return nil
+	}
+	a, err := HexToAddressUnchecked(s)
+	if err != nil {
+		return err
+	}
+	if string(checksumHex(a)) != s {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
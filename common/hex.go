@@ -0,0 +1,34 @@
+package common
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// ErrMissingPrefix is returned when a hex string lacks the 0x prefix
+var ErrMissingPrefix = errors.New("hex string without 0x prefix")
+
+// Has0xPrefix reports whether s starts with 0x or 0X
+func Has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+// FromHex decodes a hex string with an optional 0x prefix. Odd-length input
+// is treated as if it had a leading zero nibble.
+func FromHex(s string) ([]byte, error) {
+	if Has0xPrefix(s) {
+		s = s[2:]
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+// ToHex encodes b as a 0x-prefixed hex string
+func ToHex(b []byte) string {
+	enc := make([]byte, len(b)*2+2)
+	copy(enc, "0x")
+	hex.Encode(enc[2:], b)
+	return string(enc)
+}
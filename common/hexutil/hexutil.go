@@ -0,0 +1,175 @@
+// Package hexutil implements the 0x-prefixed hex encoding used by the
+// Ethereum JSON-RPC API. Byte slices are encoded as even-length hex strings,
+// quantities (integers) as hex without leading zeroes.
+package hexutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Decoding errors
+var (
+	ErrEmptyString   = errors.New("empty hex string")
+	ErrMissingPrefix = errors.New("hex string without 0x prefix")
+	ErrOddLength     = errors.New("hex string of odd length")
+	ErrEmptyNumber   = errors.New("hex string \"0x\"")
+	ErrLeadingZero   = errors.New("hex number with leading zero digits")
+	ErrUint64Range   = errors.New("hex number > 64 bits")
+	ErrBig256Range   = errors.New("hex number > 256 bits")
+)
+
+func has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+// Encode encodes b as a 0x-prefixed hex string
+func Encode(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// Decode decodes a 0x-prefixed, even-length hex string
+func Decode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, ErrEmptyString
+	}
+	if !has0xPrefix(s) {
+		return nil, ErrMissingPrefix
+	}
+	if len(s)%2 != 0 {
+		return nil, ErrOddLength
+	}
+	return hex.DecodeString(s[2:])
+}
+
+// checkNumber validates a hex quantity and returns its digits
+func checkNumber(s string) (string, error) {
+	if s == "" {
+		return "", ErrEmptyString
+	}
+	if !has0xPrefix(s) {
+		return "", ErrMissingPrefix
+	}
+	digits := s[2:]
+	if digits == "" {
+		return "", ErrEmptyNumber
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return "", ErrLeadingZero
+	}
+	return digits, nil
+}
+
+// EncodeUint64 encodes i as a hex quantity
+func EncodeUint64(i uint64) string {
+	return "0x" + strconv.FormatUint(i, 16)
+}
+
+// DecodeUint64 decodes a hex quantity into a uint64
+func DecodeUint64(s string) (uint64, error) {
+	digits, err := checkNumber(s)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrUint64Range
+		}
+		return 0, fmt.Errorf("invalid hex number %q", s)
+	}
+	return i, nil
+}
+
+// EncodeBig encodes a non-negative big integer as a hex quantity
+func EncodeBig(b *big.Int) string {
+	if b.Sign() == 0 {
+		return "0x0"
+	}
+	return "0x" + b.Text(16)
+}
+
+// DecodeBig decodes a hex quantity of at most 256 bits into a big integer
+func DecodeBig(s string) (*big.Int, error) {
+	digits, err := checkNumber(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(digits) > 64 {
+		return nil, ErrBig256Range
+	}
+	b, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex number %q", s)
+	}
+	return b, nil
+}
+
+// Bytes marshals/unmarshals as a 0x-prefixed hex string
+type Bytes []byte
+
+// MarshalText implements encoding.TextMarshaler
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte(Encode(b)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *Bytes) UnmarshalText(input []byte) error {
+	dec, err := Decode(string(input))
+	if err != nil {
+		return err
+	}
+	*b = dec
+	return nil
+}
+
+// String returns the hex encoding of b
+func (b Bytes) String() string { return Encode(b) }
+
+// Big marshals/unmarshals as a hex quantity
+type Big big.Int
+
+// MarshalText implements encoding.TextMarshaler
+func (b Big) MarshalText() ([]byte, error) {
+	return []byte(EncodeBig((*big.Int)(&b))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *Big) UnmarshalText(input []byte) error {
+	dec, err := DecodeBig(string(input))
+	if err != nil {
+		return err
+	}
+	*b = Big(*dec)
+	return nil
+}
+
+// ToInt converts b to a big.Int
+func (b *Big) ToInt() *big.Int { return (*big.Int)(b) }
+
+// String returns the hex encoding of b
+func (b *Big) String() string { return EncodeBig(b.ToInt()) }
+
+// Uint64 marshals/unmarshals as a hex quantity
+type Uint64 uint64
+
+// MarshalText implements encoding.TextMarshaler
+func (i Uint64) MarshalText() ([]byte, error) {
+	return []byte(EncodeUint64(uint64(i))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (i *Uint64) UnmarshalText(input []byte) error {
+	dec, err := DecodeUint64(string(input))
+	if err != nil {
+		return err
+	}
+	*i = Uint64(dec)
+	return nil
+}
+
+// String returns the hex encoding of i
+func (i Uint64) String() string { return EncodeUint64(uint64(i)) }
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+)
+
+// Lengths of addresses and hashes in bytes
+const (
+	AddressLength = 20
+	HashLength    = 32
+)
+
+// Address represents the 20 byte address of an Ethereum account
+type Address [AddressLength]byte
+
+// BytesToAddress returns the address with value b. If b is larger than an
+// address, only its last 20 bytes are used.
+func BytesToAddress(b []byte) Address {
+	var a Address
+	a.SetBytes(b)
+	return a
+}
+
+// BigToAddress returns the address whose big-endian value is b
+func BigToAddress(b *big.Int) Address {
+	return BytesToAddress(b.Bytes())
+}
+
+// HexToAddress parses a hex string with an optional 0x prefix into an
+// address. Mixed-case input must carry a valid EIP-55 checksum.
+func HexToAddress(s string) (Address, error) {
+	if err := ValidateChecksum(s); err != nil {
+		return Address{}, err
+	}
+	return HexToAddressUnchecked(s)
+}
+
+// ParseAddress parses a hex address, validating its EIP-55 checksum only when
+// checksum is set
+func ParseAddress(s string, checksum bool) (Address, error) {
+	if checksum {
+		return HexToAddress(s)
+	}
+	return HexToAddressUnchecked(s)
+}
+
+// HexToAddressUnchecked parses a hex address without checksum validation
+func HexToAddressUnchecked(s string) (Address, error) {
+	b, err := FromHex(s)
+	if err != nil {
+		return Address{}, err
+	}
+	if len(b) != AddressLength {
+		return Address{}, fmt.Errorf("invalid address length %d, want %d", len(b), AddressLength)
+	}
+	return BytesToAddress(b), nil
+}
+
+// SetBytes sets the address to the value of b, left-padding with zeroes or
+// keeping only the last 20 bytes as needed
+func (a *Address) SetBytes(b []byte) {
+	if len(b) > len(a) {
+		b = b[len(b)-AddressLength:]
+	}
+	*a = Address{}
+	copy(a[AddressLength-len(b):], b)
+}
+
+// Bytes returns the byte representation of the address
+func (a Address) Bytes() []byte { return a[:] }
+
+// Big returns the address interpreted as a big-endian integer
+func (a Address) Big() *big.Int { return new(big.Int).SetBytes(a[:]) }
+
+// Hash left-pads the address into a hash
+func (a Address) Hash() Hash { return BytesToHash(a[:]) }
+
+// Hex returns the 0x-prefixed EIP-55 checksummed representation of the
+// address
+func (a Address) Hex() string { return "0x" + string(checksumHex(a)) }
+
+// String implements fmt.Stringer
+func (a Address) String() string { return a.Hex() }
+
+// Cmp compares two addresses lexicographically
+func (a Address) Cmp(other Address) int { return bytes.Compare(a[:], other[:]) }
+
+// IsZero reports whether the address is the zero address
+func (a Address) IsZero() bool { return a == Address{} }
+
+// Hash represents a 32 byte Keccak-256 hash or storage word
+type Hash [HashLength]byte
+
+// BytesToHash returns the hash with value b. If b is larger than a hash,
+// only its last 32 bytes are used.
+func BytesToHash(b []byte) Hash {
+	var h Hash
+	h.SetBytes(b)
+	return h
+}
+
+// BigToHash returns the hash whose big-endian value is b
+func BigToHash(b *big.Int) Hash {
+	return BytesToHash(b.Bytes())
+}
+
+// HexToHash parses a hex string with an optional 0x prefix into a hash
+func HexToHash(s string) (Hash, error) {
+	b, err := FromHex(s)
+	if err != nil {
+		return Hash{}, err
+	}
+	if len(b) != HashLength {
+		return Hash{}, fmt.Errorf("invalid hash length %d, want %d", len(b), HashLength)
+	}
+	return BytesToHash(b), nil
+}
+
+// SetBytes sets the hash to the value of b, left-padding with zeroes or
+// keeping only the last 32 bytes as needed
+func (h *Hash) SetBytes(b []byte) {
+	if len(b) > len(h) {
+		b = b[len(b)-HashLength:]
+	}
+	*h = Hash{}
+	copy(h[HashLength-len(b):], b)
+}
+
+// Bytes returns the byte representation of the hash
+func (h Hash) Bytes() []byte { return h[:] }
+
+// Big returns the hash interpreted as a big-endian integer
+func (h Hash) Big() *big.Int { return new(big.Int).SetBytes(h[:]) }
+
+// Hex returns the 0x-prefixed hex representation of the hash
+func (h Hash) Hex() string { return ToHex(h[:]) }
+
+// String implements fmt.Stringer
+func (h Hash) String() string { return h.Hex() }
+
+// Cmp compares two hashes lexicographically
+func (h Hash) Cmp(other Hash) int { return bytes.Compare(h[:], other[:]) }
+
+// IsZero reports whether all bytes of the hash are zero
+func (h Hash) IsZero() bool { return h == Hash{} }
+
+// MarshalText encodes the address in its checksummed hex form
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText decodes a 0x-prefixed hex address, validating its checksum
+func (a *Address) UnmarshalText(input []byte) error {
+	if !Has0xPrefix(string(input)) {
+		return ErrMissingPrefix
+	}
+	dec, err := HexToAddress(string(input))
+	if err != nil {
+		return err
+	}
+	*a = dec
+	return nil
+}
+
+// MarshalText encodes the hash as 0x-prefixed hex
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+// UnmarshalText decodes a 0x-prefixed hex hash
+func (h *Hash) UnmarshalText(input []byte) error {
+	if !Has0xPrefix(string(input)) {
+		return ErrMissingPrefix
+	}
+	dec, err := HexToHash(string(input))
+	if err != nil {
+		return err
+	}
+	*h = dec
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package word converts between 256-bit EVM words, held in big.Int, and
+// the big-endian byte slices, hashes, addresses and uint64s the
+// instructions read and write. Unlike big.Int.Bytes, which drops leading
+// zeros, conversions to bytes always produce full-width values, and values
+// wider than their destination are truncated to their low-order bytes as
+// the EVM does.
+package word
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// Size is the size of a word in bytes
+const Size = 32
+
+var (
+	// MaxUint256 is the largest word, 2^256 - 1
+	MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	// two256 is 2^256, the modulus of word arithmetic
+	two256 = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// SetBytes sets z to the big-endian value of b and returns z. Shorter
+// inputs are left-padded with zeros; of longer ones, only the last 32
+// bytes are used.
+func SetBytes(z *big.Int, b []byte) *big.Int {
+	if len(b) > Size {
+		b = b[len(b)-Size:]
+	}
+	return z.SetBytes(b)
+}
+
+// FromBytes returns the word whose big-endian value is b, as SetBytes
+func FromBytes(b []byte) *big.Int { return SetBytes(new(big.Int), b) }
+
+// FromUint64 returns the word holding n
+func FromUint64(n uint64) *big.Int { return new(big.Int).SetUint64(n) }
+
+// FromAddress returns the word holding an address in its low 20 bytes
+func FromAddress(a common.Address) *big.Int { return new(big.Int).SetBytes(a[:]) }
+
+// FromHash returns the word whose big-endian value is h
+func FromHash(h common.Hash) *big.Int { return new(big.Int).SetBytes(h[:]) }
+
+// PutUint256 writes x into the first 32 bytes of buf, big-endian and
+// left-padded with zeros, keeping the low 256 bits of wider values. It
+// panics if buf is shorter than 32 bytes.
+func PutUint256(buf []byte, x *big.Int) {
+	_ = buf[Size-1] // bounds check
+	if x.Sign() < 0 || x.BitLen() > 256 {
+		x = U256(new(big.Int).Set(x))
+	}
+	x.FillBytes(buf[:Size])
+}
+
+// Bytes32 returns x as 32 big-endian bytes, as PutUint256 writes it
+func Bytes32(x *big.Int) [Size]byte {
+	var b [Size]byte
+	PutUint256(b[:], x)
+	return b
+}
+
+// Bytes returns x as a 32-byte big-endian slice, as PutUint256 writes it
+func Bytes(x *big.Int) []byte {
+	b := Bytes32(x)
+	return b[:]
+}
+
+// ToHash returns x as a hash, as PutUint256 writes it
+func ToHash(x *big.Int) common.Hash { return common.Hash(Bytes32(x)) }
+
+// ToAddress returns the low 20 bytes of x, the address a word names
+func ToAddress(x *big.Int) common.Address {
+	b := Bytes32(x)
+	return common.BytesToAddress(b[Size-common.AddressLength:])
+}
+
+// ToUint64 returns x as a uint64 and whether it fits. Words that do not
+// fit return math.MaxUint64, so an offset or size taken from them is
+// certain to fail bounds and gas checks rather than wrapping around.
+func ToUint64(x *big.Int) (uint64, bool) {
+	if x.Sign() < 0 || !x.IsUint64() {
+		return math.MaxUint64, false
+	}
+	return x.Uint64(), true
+}
+
+// Uint64 returns x as a uint64, saturating at math.MaxUint64 as ToUint64
+func Uint64(x *big.Int) uint64 {
+	n, _ := ToUint64(x)
+	return n
+}
+
+// U256 reduces x modulo 2^256 in place and returns it, turning negative
+// values into their two's complement
+func U256(x *big.Int) *big.Int {
+	if x.Sign() >= 0 && x.BitLen() <= 256 {
+		return x
+	}
+	return x.Mod(x, two256)
+}
+
+// S256 interprets the word x as a two's complement signed integer,
+// setting and returning z
+func S256(z, x *big.Int) *big.Int {
+	if x.BitLen() < 256 {
+		return z.Set(x)
+	}
+	return z.Sub(x, two256)
+}
+
+// Slice returns size bytes of data starting at offset, right-padded with
+// zeros where they run past the end of data, as calldata and code are
+// read by the instructions. The result never aliases data; size must
+// have been bounded by the caller.
+func Slice(data []byte, offset, size uint64) []byte {
+	out := make([]byte, size)
+	if offset < uint64(len(data)) {
+		copy(out, data[offset:])
+	}
+	return out
+}
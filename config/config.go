@@ -0,0 +1,211 @@
+// Package config loads the TOML configuration file of the evm command and
+// the dev node, so chain, execution, limit and account settings need not be
+// passed as ever more flags.
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// Config is the content of a configuration file:
+//
+//	network = "sepolia"
+//
+//	[chain]
+//	chainId = 1337
+//	cancunTime = 0
+//
+//	[execution]
+//	sender = "0x00000000000000000000000000000000000000a1"
+//	gasLimit = 1000000
+//	invariants = ["storage(0x01, 0) <= 100"]
+//
+//	[limits]
+//	maxSteps = 100000
+//	timeout = "2s"
+//
+//	[alloc."0x00000000000000000000000000000000000000a1"]
+//	balance = "1000000000000000000"
+type Config struct {
+	Network   string              `toml:"network"` // preset of params.Preset the chain section overrides, dev if empty
+	Chain     *params.ChainConfig `toml:"chain"`   // fields of params.ChainConfig by their JSON names
+	Execution Execution           `toml:"execution"`
+	Limits    Limits              `toml:"limits"`
+	Alloc     map[string]Account  `toml:"alloc"` // by address
+}
+
+// Execution holds the block context and settings of executions
+type Execution struct {
+	Sender      common.Address `toml:"sender"`
+	Address     common.Address `toml:"address"` // contract run by the evm command
+	GasLimit    uint64         `toml:"gasLimit"`
+	GasPrice    uint64         `toml:"gasPrice"`
+	BlockNumber uint64         `toml:"blockNumber"`
+	Timestamp   uint64         `toml:"timestamp"`
+	Invariants  []string       `toml:"invariants"` // predicates of the invariant package
+}
+
+// Limits are resource quotas of a single run. Runs exceeding them are
+// suspended. Zero values disable a limit.
+type Limits struct {
+	MaxGas   uint64        `toml:"maxGas"`   // cap on the gas limit of a run
+	MaxSteps uint64        `toml:"maxSteps"` // instructions executed by the outermost frame
+	Timeout  time.Duration `toml:"timeout"`  // wall-clock time, e.g. "500ms"
+}
+
+// CapGas returns gas, or MaxGas if that is set and lower
+func (l Limits) CapGas(gas uint64) uint64 {
+	if l.MaxGas > 0 && gas > l.MaxGas {
+		return l.MaxGas
+	}
+	return gas
+}
+
+// Account is an account of the allocation. Numbers are decimal or
+// 0x-prefixed hex strings.
+type Account struct {
+	Nonce   uint64            `toml:"nonce"`
+	Balance string            `toml:"balance"`
+	Code    string            `toml:"code"`
+	Storage map[string]string `toml:"storage"`
+}
+
+// Default returns the settings used when no file is given
+func Default() *Config {
+	return &Config{
+		Chain: params.DevChainConfig(),
+		Execution: Execution{
+			GasLimit:    1000000,
+			GasPrice:    1,
+			BlockNumber: 1,
+			Timestamp:   1,
+		},
+	}
+}
+
+// Load reads a configuration file over the defaults. Keys the file sets
+// replace the defaults; unknown keys are an error, so typos are not
+// silently ignored.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	var network struct {
+		Network string `toml:"network"`
+	}
+	if _, err := toml.DecodeFile(path, &network); err != nil {
+		return nil, err
+	}
+	if network.Network != "" {
+		chain, err := params.Preset(network.Network)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		cfg.Chain = chain
+	}
+	md, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("%s: unknown keys %s", path, strings.Join(keys, ", "))
+	}
+	if cfg.Chain.ChainID == nil {
+		cfg.Chain.ChainID = new(big.Int).Set(params.DefaultChainID)
+	}
+	if _, err := cfg.Accounts(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Context returns the block context of executions, with the gas limit
+// capped by the limits
+func (c *Config) Context() *vm.Context {
+	gas := c.Limits.CapGas(c.Execution.GasLimit)
+	return &vm.Context{
+		BlockNumber: new(big.Int).SetUint64(c.Execution.BlockNumber),
+		Timestamp:   new(big.Int).SetUint64(c.Execution.Timestamp),
+		Sender:      c.Execution.Sender,
+		GasLimit:    gas,
+		GasPrice:    new(big.Int).SetUint64(c.Execution.GasPrice),
+	}
+}
+
+// Accounts returns the allocation as a state dump
+func (c *Config) Accounts() (map[common.Address]state.DumpAccount, error) {
+	dump := make(map[common.Address]state.DumpAccount, len(c.Alloc))
+	addrs := make([]string, 0, len(c.Alloc))
+	for addr := range c.Alloc {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, key := range addrs {
+		acc := c.Alloc[key]
+		addr, err := common.HexToAddressUnchecked(key)
+		if err != nil {
+			return nil, fmt.Errorf("alloc %q: %w", key, err)
+		}
+		balance := new(big.Int)
+		if acc.Balance != "" {
+			if _, ok := balance.SetString(acc.Balance, 0); !ok {
+				return nil, fmt.Errorf("alloc %s: invalid balance %q", key, acc.Balance)
+			}
+		}
+		var code []byte
+		if acc.Code != "" {
+			if code, err = hexutil.Decode(acc.Code); err != nil {
+				return nil, fmt.Errorf("alloc %s: code: %w", key, err)
+			}
+		}
+		storage := make(map[common.Hash]common.Hash, len(acc.Storage))
+		for k, v := range acc.Storage {
+			slot, ok1 := new(big.Int).SetString(k, 0)
+			value, ok2 := new(big.Int).SetString(v, 0)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("alloc %s: invalid storage slot %q = %q", key, k, v)
+			}
+			storage[common.BigToHash(slot)] = common.BigToHash(value)
+		}
+		dump[addr] = state.DumpAccount{
+			Nonce:   hexutil.Uint64(acc.Nonce),
+			Balance: (*hexutil.Big)(balance),
+			Code:    code,
+			Storage: storage,
+		}
+	}
+	return dump, nil
+}
+
+// Apply adds the allocation to statedb
+func (c *Config) Apply(statedb *state.StateDB) error {
+	dump, err := c.Accounts()
+	if err != nil {
+		return err
+	}
+	for addr, acc := range dump {
+		statedb.CreateAccount(addr)
+		statedb.SetNonce(addr, uint64(acc.Nonce))
+		statedb.AddBalance(addr, acc.Balance.ToInt())
+		if len(acc.Code) > 0 {
+			statedb.SetCode(addr, acc.Code)
+		}
+		for key, value := range acc.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
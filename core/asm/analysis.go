@@ -0,0 +1,216 @@
+package asm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// maxStack is the maximum number of words on the stack
+const maxStack = vm.MaxStackDepth
+
+// wideningLimit is the number of times a block's entry heights may change
+// before they are widened to every possible height, so loops terminate
+const wideningLimit = 4
+
+// FindingKind classifies analyzer findings
+type FindingKind int
+
+const (
+	StackUnderflow   FindingKind = iota // the instruction always underflows
+	StackOverflow                       // the instruction always overflows
+	UnreachableCode                     // the block cannot be reached
+	UndefinedOpcode                     // the reachable instruction is not defined
+	InvalidJump                         // the jump's pushed target is not a JUMPDEST
+	DeadJumpdest                        // no jump can reach the JUMPDEST
+	StaticStateWrite                    // the state write only runs in a static context
+)
+
+func (k FindingKind) String() string {
+	switch k {
+	case StackUnderflow:
+		return "stack underflow"
+	case StackOverflow:
+		return "stack overflow"
+	case UnreachableCode:
+		return "unreachable code"
+	case UndefinedOpcode:
+		return "undefined opcode"
+	case InvalidJump:
+		return "invalid jump"
+	case DeadJumpdest:
+		return "dead jumpdest"
+	default:
+		return "state write in static context"
+	}
+}
+
+// Finding is a problem found in bytecode before executing it
+type Finding struct {
+	PC      uint64
+	Kind    FindingKind
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%#04x: %s: %s", f.PC, f.Kind, f.Message)
+}
+
+// heights is the range of stack heights a block may be entered with
+type heights struct {
+	lo, hi int
+}
+
+func (h heights) join(o heights) heights {
+	return heights{lo: min(h.lo, o.lo), hi: max(h.hi, o.hi)}
+}
+
+// Options configures Analyze
+type Options struct {
+	// StaticEntries are the entry pcs of functions only ever called with
+	// STATICCALL, such as the view functions of a contract
+	StaticEntries []uint64
+}
+
+// Analyze checks code without executing it. It walks the control flow
+// graph from pc 0 tracking the range of stack heights each basic block may
+// be entered with, and reports instructions that underflow or overflow the
+// stack on every path reaching them, undefined opcodes, and blocks that
+// cannot be reached. A jump whose target is not known statically is
+// assumed to reach every JUMPDEST. It also lints jumps to pushed targets
+// that are not JUMPDESTs, JUMPDESTs that no jump can target, and, given
+// static entry points in opts, state writes reachable only from them.
+// opts may be nil.
+func Analyze(code []byte, opts *Options) []Finding {
+	cfg := BuildCFG(code)
+	findings := checkStack(cfg)
+	findings = append(findings, checkJumps(code, cfg)...)
+	if opts != nil && len(opts.StaticEntries) > 0 {
+		findings = append(findings, checkStaticWrites(code, cfg, opts.StaticEntries)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].PC < findings[j].PC })
+	return findings
+}
+
+// checkStack runs the stack height analysis and reports unreachable blocks
+func checkStack(cfg *CFG) []Finding {
+	entry := make(map[uint64]heights)
+	changes := make(map[uint64]int)
+	var findings []Finding
+	reported := make(map[uint64]bool)
+
+	var jumpdests []uint64
+	for start, b := range cfg.Blocks {
+		if b.Instructions[0].Op == opJumpdest {
+			jumpdests = append(jumpdests, start)
+		}
+	}
+
+	work := []uint64{}
+	enter := func(start uint64, h heights) {
+		if h.lo < 0 {
+			h.lo = 0
+		}
+		if h.hi > maxStack {
+			h.hi = maxStack
+		}
+		old, seen := entry[start]
+		if seen {
+			h = old.join(h)
+			if h == old {
+				return
+			}
+			if changes[start]++; changes[start] >= wideningLimit {
+				h = heights{lo: 0, hi: maxStack}
+			}
+		}
+		entry[start] = h
+		work = append(work, start)
+	}
+	if _, ok := cfg.Blocks[0]; ok {
+		enter(0, heights{})
+	}
+
+	for len(work) > 0 {
+		start := work[len(work)-1]
+		work = work[:len(work)-1]
+		b := cfg.Blocks[start]
+
+		exit, finding := walkBlock(b, entry[start])
+		if finding != nil {
+			if !reported[finding.PC] {
+				reported[finding.PC] = true
+				findings = append(findings, *finding)
+			}
+			continue
+		}
+		for _, e := range b.Succs {
+			enter(e.To, exit)
+		}
+		if b.Unresolved {
+			for _, dest := range jumpdests {
+				enter(dest, exit)
+			}
+		}
+	}
+
+	// adjacent unreachable blocks are reported as a single range
+	var from, end uint64
+	open := false
+	for _, start := range cfg.sortedStarts() {
+		last := cfg.Blocks[start].last()
+		if _, ok := entry[start]; ok {
+			if open {
+				findings = append(findings, unreachable(from, end))
+				open = false
+			}
+			continue
+		}
+		if !open {
+			from, open = start, true
+		}
+		end = last.PC + 1 + uint64(len(last.Arg))
+	}
+	if open {
+		findings = append(findings, unreachable(from, end))
+	}
+	return findings
+}
+
+func unreachable(from, end uint64) Finding {
+	return Finding{
+		PC:      from,
+		Kind:    UnreachableCode,
+		Message: fmt.Sprintf("%d bytes up to %#04x are never executed", end-from, end-1),
+	}
+}
+
+// walkBlock applies the stack effects of the block's instructions to the
+// entry heights and returns the exit heights, or the first instruction
+// that fails on every path
+func walkBlock(b *BasicBlock, h heights) (heights, *Finding) {
+	for _, in := range b.Instructions {
+		effect, ok := stackEffects[in.Op]
+		if !ok {
+			return h, &Finding{PC: in.PC, Kind: UndefinedOpcode, Message: in.Op.String()}
+		}
+		if h.hi < effect.pop {
+			return h, &Finding{
+				PC:      in.PC,
+				Kind:    StackUnderflow,
+				Message: fmt.Sprintf("%s needs %d words but the stack holds at most %d", in.Op, effect.pop, h.hi),
+			}
+		}
+		h.lo, h.hi = max(h.lo-effect.pop, 0)+effect.push, h.hi-effect.pop+effect.push
+		if h.lo > maxStack {
+			return h, &Finding{
+				PC:      in.PC,
+				Kind:    StackOverflow,
+				Message: fmt.Sprintf("%s grows the stack to at least %d words", in.Op, h.lo),
+			}
+		}
+		h.hi = min(h.hi, maxStack)
+	}
+	return h, nil
+}
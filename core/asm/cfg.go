@@ -0,0 +1,191 @@
+package asm
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Opcodes that affect control flow
+const (
+	opStop         vm.OpCode = 0x00
+	opJump         vm.OpCode = 0x56
+	opJumpi        vm.OpCode = 0x57
+	opJumpdest     vm.OpCode = 0x5b
+	opReturn       vm.OpCode = 0xf3
+	opRevert       vm.OpCode = 0xfd
+	opInvalid      vm.OpCode = 0xfe
+	opSelfdestruct vm.OpCode = 0xff
+)
+
+// EdgeKind is the reason control passes between two blocks
+type EdgeKind int
+
+const (
+	Fallthrough EdgeKind = iota // execution continues into the next block
+	StaticJump                  // jump whose target is the preceding PUSH
+	DynamicJump                 // jump observed while executing
+)
+
+// Edge is a transfer of control to the block starting at To
+type Edge struct {
+	To   uint64
+	Kind EdgeKind
+}
+
+// BasicBlock is a straight-line run of instructions entered only at its
+// first instruction and left only after its last
+type BasicBlock struct {
+	Start        uint64
+	Instructions []Instruction
+	Succs        []Edge
+	Unresolved   bool // ends in a jump whose target is not known statically
+}
+
+// last returns the final instruction of the block
+func (b *BasicBlock) last() Instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// CFG is the intra-contract control-flow graph of a piece of code
+type CFG struct {
+	Blocks map[uint64]*BasicBlock // keyed by start pc
+}
+
+// terminates reports whether execution never continues past op
+func terminates(op vm.OpCode) bool {
+	switch op {
+	case opStop, opJump, opReturn, opRevert, opInvalid, opSelfdestruct:
+		return true
+	}
+	return false
+}
+
+// BuildCFG splits code into basic blocks and links them with fall-through
+// edges and the jumps whose target is pushed right before them. Jump
+// targets follow the standard EVM semantics: the destination is on top of
+// the stack.
+func BuildCFG(code []byte) *CFG {
+	cfg := &CFG{Blocks: make(map[uint64]*BasicBlock)}
+	var block *BasicBlock
+	for _, in := range Disassemble(code) {
+		if block != nil && in.Op == opJumpdest {
+			block = nil
+		}
+		if block == nil {
+			block = &BasicBlock{Start: in.PC}
+			cfg.Blocks[in.PC] = block
+		}
+		block.Instructions = append(block.Instructions, in)
+		if terminates(in.Op) || in.Op == opJumpi {
+			block = nil
+		}
+	}
+
+	for _, b := range cfg.Blocks {
+		last := b.last()
+		if last.Op == opJump || last.Op == opJumpi {
+			if target, ok := staticTarget(b); ok && cfg.Blocks[target] != nil {
+				b.Succs = append(b.Succs, Edge{To: target, Kind: StaticJump})
+			} else {
+				b.Unresolved = true
+			}
+		}
+		if !terminates(last.Op) {
+			next := last.PC + 1 + uint64(len(last.Arg))
+			if cfg.Blocks[next] != nil {
+				b.Succs = append(b.Succs, Edge{To: next, Kind: Fallthrough})
+			}
+		}
+	}
+	return cfg
+}
+
+// staticTarget returns the jump target pushed by the instruction before
+// the block's final jump
+func staticTarget(b *BasicBlock) (uint64, bool) {
+	if len(b.Instructions) < 2 {
+		return 0, false
+	}
+	push := b.Instructions[len(b.Instructions)-2]
+	if !push.Op.IsPush() {
+		return 0, false
+	}
+	target := new(big.Int).SetBytes(push.Arg)
+	if !target.IsUint64() {
+		return 0, false
+	}
+	return target.Uint64(), true
+}
+
+// AddEdge records a jump from the block containing pc to the block
+// starting at target, typically observed in an execution trace
+func (cfg *CFG) AddEdge(pc, target uint64) {
+	from := cfg.blockAt(pc)
+	if from == nil || cfg.Blocks[target] == nil {
+		return
+	}
+	for _, e := range from.Succs {
+		if e.To == target {
+			return
+		}
+	}
+	from.Succs = append(from.Succs, Edge{To: target, Kind: DynamicJump})
+}
+
+// blockAt returns the block containing the instruction at pc
+func (cfg *CFG) blockAt(pc uint64) *BasicBlock {
+	for _, b := range cfg.Blocks {
+		if pc >= b.Start && pc <= b.last().PC {
+			return b
+		}
+	}
+	return nil
+}
+
+// sortedStarts returns the block start pcs in ascending order
+func (cfg *CFG) sortedStarts() []uint64 {
+	starts := make([]uint64, 0, len(cfg.Blocks))
+	for start := range cfg.Blocks {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Static jumps are solid,
+// fall-through edges dashed, and jumps observed at runtime blue; blocks
+// ending in an unresolved jump are drawn in red.
+func (cfg *CFG) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph cfg {\n\tnode [shape=box fontname=\"monospace\"];\n")
+	for _, start := range cfg.sortedStarts() {
+		block := cfg.Blocks[start]
+		var label strings.Builder
+		for _, in := range block.Instructions {
+			fmt.Fprintf(&label, "%#04x: %s\\l", in.PC, in)
+		}
+		color := ""
+		if block.Unresolved {
+			color = " color=red"
+		}
+		fmt.Fprintf(&b, "\tb%d [label=\"%s\"%s];\n", start, label.String(), color)
+		for _, e := range block.Succs {
+			style := ""
+			switch e.Kind {
+			case Fallthrough:
+				style = " [style=dashed]"
+			case DynamicJump:
+				style = " [color=blue]"
+			}
+			fmt.Fprintf(&b, "\tb%d -> b%d%s;\n", start, e.To, style)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
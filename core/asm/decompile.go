@@ -0,0 +1,432 @@
+package asm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// infixOps are the opcodes rendered as infix operators
+var infixOps = map[vm.OpCode]string{
+	0x01: "+", 0x02: "*", 0x03: "-", 0x04: "/", 0x06: "%", 0x0a: "**",
+	0x10: "<", 0x11: ">", 0x14: "==", 0x16: "&", 0x17: "|", 0x18: "^",
+}
+
+// boundOps are the opcodes whose results depend on state that later
+// instructions may change, so they are bound to temporaries where they run
+var boundOps = map[vm.OpCode]bool{
+	0x31: true, 0x3a: true, 0x3b: true, 0x3d: true, 0x3f: true, 0x47: true,
+	0x51: true, 0x54: true, 0x59: true, 0x5a: true, 0x5c: true,
+	0xf0: true, 0xf1: true, 0xf2: true, 0xf4: true, 0xf5: true, 0xfa: true,
+}
+
+// liftedBlock is a basic block translated to pseudocode statements
+type liftedBlock struct {
+	block *BasicBlock
+	stmts []string
+	exit  []string // stack at the end of the block, top last
+	cond  string   // condition of a final JUMPI
+	dest  string   // destination of a final jump when it is not static
+}
+
+// lifter translates blocks, numbering temporaries across the whole code
+type lifter struct {
+	temps int
+}
+
+// lift runs the block over a stack of expressions. entry may be nil when
+// the stack the block is entered with is unknown; words below it are then
+// named $s0 (the top of the entry stack), $s1, ...
+func (l *lifter) lift(b *BasicBlock, entry []string) *liftedBlock {
+	out := &liftedBlock{block: b}
+	stack := append([]string(nil), entry...)
+	missing := 0
+	pop := func() string {
+		if len(stack) == 0 {
+			name := fmt.Sprintf("$s%d", missing)
+			missing++
+			return name
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return top
+	}
+	pops := func(n int) []string {
+		args := make([]string, n)
+		for i := range args {
+			args[i] = pop()
+		}
+		return args
+	}
+	emit := func(format string, args ...interface{}) {
+		out.stmts = append(out.stmts, fmt.Sprintf(format, args...))
+	}
+	bind := func(expr string) string {
+		name := fmt.Sprintf("t%d", l.temps)
+		l.temps++
+		emit("%s = %s", name, expr)
+		return name
+	}
+
+	for _, in := range b.Instructions {
+		op := in.Op
+		switch {
+		case op.IsPush():
+			stack = append(stack, formatWord(in.Arg))
+		case op == 0x5f: // PUSH0
+			stack = append(stack, "0x0")
+		case op >= 0x80 && op <= 0x8f: // DUPn
+			n := int(op - 0x7f)
+			for len(stack) < n {
+				stack = append([]string{fmt.Sprintf("$s%d", missing)}, stack...)
+				missing++
+			}
+			stack = append(stack, stack[len(stack)-n])
+		case op >= 0x90 && op <= 0x9f: // SWAPn
+			n := int(op-0x90) + 2
+			for len(stack) < n {
+				stack = append([]string{fmt.Sprintf("$s%d", missing)}, stack...)
+				missing++
+			}
+			top, other := len(stack)-1, len(stack)-n
+			stack[top], stack[other] = stack[other], stack[top]
+		case infixOps[op] != "":
+			a, b := pop(), pop()
+			stack = append(stack, "("+a+" "+infixOps[op]+" "+b+")")
+		case op == 0x15: // ISZERO
+			stack = append(stack, negate(pop()))
+		case op == 0x19: // NOT
+			stack = append(stack, "~"+pop())
+		case op == 0x1b: // SHL
+			shift, value := pop(), pop()
+			stack = append(stack, "("+value+" << "+shift+")")
+		case op == 0x1c: // SHR
+			shift, value := pop(), pop()
+			stack = append(stack, "("+value+" >> "+shift+")")
+		case op == 0x35: // CALLDATALOAD
+			stack = append(stack, "calldata["+pop()+"]")
+		case op == 0x50: // POP
+			pop()
+		case op == 0x51: // MLOAD
+			stack = append(stack, bind("memory["+pop()+"]"))
+		case op == 0x52: // MSTORE
+			offset, value := pop(), pop()
+			emit("memory[%s] = %s", offset, value)
+		case op == 0x53: // MSTORE8
+			offset, value := pop(), pop()
+			emit("memory8[%s] = %s", offset, value)
+		case op == 0x54: // SLOAD
+			stack = append(stack, bind("storage["+pop()+"]"))
+		case op == 0x55: // SSTORE
+			key, value := pop(), pop()
+			emit("storage[%s] = %s", key, value)
+		case op == 0x56: // JUMP
+			dest := pop()
+			if _, ok := staticTarget(b); !ok {
+				out.dest = dest
+			}
+		case op == 0x57: // JUMPI
+			dest := pop()
+			out.cond = pop()
+			if _, ok := staticTarget(b); !ok {
+				out.dest = dest
+			}
+		case op == 0x58: // PC
+			stack = append(stack, fmt.Sprintf("%#x", in.PC))
+		case op == 0x5b: // JUMPDEST
+		case op >= 0xa0 && op <= 0xa4: // LOGn
+			args := pops(int(op-0xa0) + 2)
+			emit("log%d(memory[%s:+%s]%s)", op-0xa0, args[0], args[1], joinArgs(args[2:]))
+		case op == 0x00:
+			emit("stop")
+		case op == 0xf3, op == 0xfd: // RETURN, REVERT
+			args := pops(2)
+			word := "return"
+			if op == 0xfd {
+				word = "revert"
+			}
+			if args[1] == "0x0" {
+				emit("%s", word)
+			} else {
+				emit("%s memory[%s:+%s]", word, args[0], args[1])
+			}
+		case op == 0xfe:
+			emit("invalid")
+		case op == 0xff:
+			emit("selfdestruct(%s)", pop())
+		default:
+			pop, push, ok := StackEffect(op)
+			if !ok {
+				emit("invalid // %s", op)
+				continue
+			}
+			call := strings.ToLower(op.String()) + "(" + strings.Join(pops(pop), ", ") + ")"
+			switch {
+			case push == 0:
+				emit("%s", call)
+			case boundOps[op]:
+				stack = append(stack, bind(call))
+			default:
+				stack = append(stack, call)
+			}
+		}
+	}
+	out.exit = stack
+	switch {
+	case missing == 1:
+		out.stmts = append([]string{"// reads $s0 from the entry stack"}, out.stmts...)
+	case missing > 1:
+		out.stmts = append([]string{fmt.Sprintf("// reads $s0..$s%d from the entry stack", missing-1)}, out.stmts...)
+	}
+	return out
+}
+
+// formatWord renders a pushed immediate in hex
+func formatWord(arg []byte) string {
+	return fmt.Sprintf("%#x", new(big.Int).SetBytes(arg))
+}
+
+func joinArgs(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(args, ", ")
+}
+
+// negate returns the logical negation of a condition, removing a double
+// negation
+func negate(cond string) string {
+	if strings.HasPrefix(cond, "!(") && strings.HasSuffix(cond, ")") && balanced(cond[2:len(cond)-1]) {
+		return cond[2 : len(cond)-1]
+	}
+	return "!(" + cond + ")"
+}
+
+// unwrap removes the parentheses around a whole expression
+func unwrap(expr string) string {
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") && balanced(expr[1:len(expr)-1]) {
+		return expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+// balanced reports whether the parentheses of s match up
+func balanced(s string) bool {
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// halts reports whether the block ends execution
+func halts(b *BasicBlock) bool {
+	op := b.last().Op
+	return op != opJump && op != opJumpi && terminates(op)
+}
+
+// Decompile renders code as pseudocode. Basic blocks become labelled
+// sequences of statements over expressions recovered from the stack; a
+// block entered from a single predecessor continues with its stack. A
+// conditional jump around a halting block that nothing else reaches is
+// rendered as an if statement, which turns solc's require checks into
+// if (!(cond)) { revert }, and jumps back to earlier blocks are marked as
+// loops. Function entry points found by Selectors are annotated.
+func Decompile(code []byte) string {
+	cfg := BuildCFG(code)
+	starts := cfg.sortedStarts()
+	preds := make(map[uint64]int)
+	for _, b := range cfg.Blocks {
+		for _, e := range b.Succs {
+			preds[e.To]++
+		}
+	}
+	functions := make(map[uint64][]string)
+	for _, fn := range Selectors(code) {
+		functions[fn.Entry] = append(functions[fn.Entry], fmt.Sprintf("%#x", fn.Selector[:]))
+	}
+
+	// lift blocks depth first so single-predecessor blocks inherit stacks
+	l := &lifter{}
+	lifted := make(map[uint64]*liftedBlock)
+	var visit func(start uint64, entry []string)
+	visit = func(start uint64, entry []string) {
+		if lifted[start] != nil {
+			return
+		}
+		lb := l.lift(cfg.Blocks[start], entry)
+		lifted[start] = lb
+		for _, e := range lb.block.Succs {
+			var next []string
+			if preds[e.To] == 1 {
+				next = lb.exit
+			}
+			visit(e.To, next)
+		}
+	}
+	if len(starts) > 0 && starts[0] == 0 {
+		visit(0, []string{})
+	}
+	for _, start := range starts {
+		visit(start, nil)
+	}
+
+	loops := make(map[uint64]bool)
+	for _, b := range cfg.Blocks {
+		for _, e := range b.Succs {
+			if e.To <= b.Start && reaches(cfg, e.To, b.Start) {
+				loops[e.To] = true
+			}
+		}
+	}
+
+	var w strings.Builder
+	inlined := make(map[uint64]bool)
+	for i, start := range starts {
+		if inlined[start] {
+			continue
+		}
+		lb := lifted[start]
+		if sels, ok := functions[start]; ok {
+			fmt.Fprintf(&w, "\n// function %s\n", strings.Join(sels, ", "))
+		}
+		fmt.Fprintf(&w, "block_%#04x:", start)
+		if loops[start] {
+			w.WriteString(" // loop header")
+		}
+		w.WriteString("\n")
+		writeStmts(&w, lb.stmts, "    ")
+
+		// the block printed next, used to omit gotos to it
+		var next uint64 = ^uint64(0)
+		for _, s := range starts[i+1:] {
+			if !inlined[s] {
+				next = s
+				break
+			}
+		}
+		writeJump(&w, lb, cfg, lifted, preds, loops, inlined, next)
+	}
+	return w.String()
+}
+
+func writeStmts(w *strings.Builder, stmts []string, indent string) {
+	for _, stmt := range stmts {
+		w.WriteString(indent + stmt + "\n")
+	}
+}
+
+// writeJump renders how control leaves a block
+func writeJump(w *strings.Builder, lb *liftedBlock, cfg *CFG, lifted map[uint64]*liftedBlock, preds map[uint64]int, loops, inlined map[uint64]bool, next uint64) {
+	b := lb.block
+	last := b.last()
+	gotoLabel := func(target uint64) string {
+		s := fmt.Sprintf("goto block_%#04x", target)
+		if loops[target] && target <= b.Start {
+			s += " // loop"
+		}
+		return s
+	}
+	// a dynamic destination the stack resolved to a constant
+	if target, ok := new(big.Int).SetString(strings.TrimPrefix(lb.dest, "0x"), 16); ok && strings.HasPrefix(lb.dest, "0x") && target.IsUint64() && cfg.Blocks[target.Uint64()] != nil {
+		lb.dest = fmt.Sprintf("block_%#04x", target.Uint64())
+	} else if lb.dest != "" {
+		lb.dest = "*" + lb.dest
+	}
+	cond := unwrap(lb.cond)
+	target, static := staticTarget(b)
+	if static && cfg.Blocks[target] == nil {
+		static = false
+	}
+	fallthroughTo := last.PC + 1 + uint64(len(last.Arg))
+
+	switch last.Op {
+	case opJump:
+		switch {
+		case lb.dest != "":
+			fmt.Fprintf(w, "    goto %s\n", lb.dest)
+		case static && target != next:
+			fmt.Fprintf(w, "    %s\n", gotoLabel(target))
+		case !static:
+			fmt.Fprintf(w, "    goto invalid\n")
+		}
+	case opJumpi:
+		fall := cfg.Blocks[fallthroughTo]
+		switch {
+		case lb.dest != "":
+			fmt.Fprintf(w, "    if (%s) goto %s\n", cond, lb.dest)
+		case !static:
+			fmt.Fprintf(w, "    if (%s) goto invalid\n", cond)
+		case fall != nil && halts(fall) && preds[fallthroughTo] == 1 && target > b.Start:
+			// require(cond): the halting fall-through is the failure branch
+			fmt.Fprintf(w, "    if (%s) {\n", unwrap(negate(lb.cond)))
+			writeStmts(w, lifted[fallthroughTo].stmts, "        ")
+			w.WriteString("    }\n")
+			inlined[fallthroughTo] = true
+			if target != nextAfter(next, fallthroughTo, cfg) {
+				fmt.Fprintf(w, "    %s\n", gotoLabel(target))
+			}
+			return
+		case halts(cfg.Blocks[target]) && preds[target] == 1 && target > b.Start:
+			fmt.Fprintf(w, "    if (%s) {\n", cond)
+			writeStmts(w, lifted[target].stmts, "        ")
+			w.WriteString("    }\n")
+			inlined[target] = true
+			return
+		default:
+			fmt.Fprintf(w, "    if (%s) %s\n", cond, gotoLabel(target))
+		}
+		if fall != nil && fallthroughTo != next {
+			fmt.Fprintf(w, "    goto block_%#04x\n", fallthroughTo)
+		}
+	default:
+		if !terminates(last.Op) && cfg.Blocks[fallthroughTo] != nil && fallthroughTo != next {
+			fmt.Fprintf(w, "    goto block_%#04x\n", fallthroughTo)
+		}
+	}
+}
+
+// reaches reports whether the block at to can be reached from the block at
+// from along edges of the graph
+func reaches(cfg *CFG, from, to uint64) bool {
+	seen := map[uint64]bool{from: true}
+	work := []uint64{from}
+	for len(work) > 0 {
+		start := work[len(work)-1]
+		work = work[:len(work)-1]
+		if start == to {
+			return true
+		}
+		for _, e := range cfg.Blocks[start].Succs {
+			if !seen[e.To] {
+				seen[e.To] = true
+				work = append(work, e.To)
+			}
+		}
+	}
+	return false
+}
+
+// nextAfter returns the block printed after the inlined one, which is the
+// next block unless that block is the one being inlined
+func nextAfter(next, inlined uint64, cfg *CFG) uint64 {
+	if next != inlined {
+		return next
+	}
+	starts := cfg.sortedStarts()
+	for i, s := range starts {
+		if s == inlined && i+1 < len(starts) {
+			return starts[i+1]
+		}
+	}
+	return ^uint64(0)
+}
@@ -0,0 +1,118 @@
+// Package asm disassembles EVM bytecode and recovers its control flow.
+package asm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Instruction is a decoded instruction
+type Instruction struct {
+	PC  uint64
+	Op  vm.OpCode
+	Arg []byte // immediate of PUSH instructions, truncated at the end of code
+}
+
+// String renders the instruction as e.g. "PUSH1 0x0a"
+func (in Instruction) String() string {
+	if in.Op.IsPush() {
+		return in.Op.String() + " " + hexutil.Encode(in.Arg)
+	}
+	return in.Op.String()
+}
+
+// Disassemble decodes code into instructions
+func Disassemble(code []byte) []Instruction {
+	var instructions []Instruction
+	for pc := uint64(0); pc < uint64(len(code)); {
+		op := vm.OpCode(code[pc])
+		in := Instruction{PC: pc, Op: op}
+		if n := uint64(op.PushSize()); n > 0 {
+			end := pc + 1 + n
+			if end > uint64(len(code)) {
+				end = uint64(len(code))
+			}
+			in.Arg = code[pc+1 : end]
+		}
+		instructions = append(instructions, in)
+		pc += 1 + uint64(len(in.Arg))
+	}
+	return instructions
+}
+
+// Assemble encodes instructions back into bytecode. PCs are ignored.
+func Assemble(instructions []Instruction) []byte {
+	var code []byte
+	for _, in := range instructions {
+		code = append(code, byte(in.Op))
+		code = append(code, in.Arg...)
+	}
+	return code
+}
+
+// Format returns the disassembly of code, one "pc: instruction" per line
+func Format(code []byte) string {
+	var b strings.Builder
+	for _, in := range Disassemble(code) {
+		fmt.Fprintf(&b, "%#04x: %s\n", in.PC, in)
+	}
+	return b.String()
+}
+
+// Parse assembles mnemonics such as "PUSH1 0x0a PUSH1 20 ADD" into
+// bytecode. PUSH immediates are hex or decimal numbers, left-padded to the
+// size of the push. Instructions are separated by whitespace, text from
+// "//" or ";" to the end of a line is a comment, and the "pc:" prefixes
+// written by Format are skipped, so its output parses back.
+func Parse(src string) ([]byte, error) {
+	var code []byte
+	for n, line := range strings.Split(src, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			if strings.HasSuffix(field, ":") {
+				continue
+			}
+			op, ok := vm.OpCodeByName(field)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown instruction %q", n+1, field)
+			}
+			code = append(code, byte(op))
+			if !op.IsPush() {
+				continue
+			}
+			if i+1 == len(fields) {
+				return nil, fmt.Errorf("line %d: %s without immediate", n+1, op)
+			}
+			i++
+			arg, err := immediate(fields[i], op.PushSize())
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", n+1, op, err)
+			}
+			code = append(code, arg...)
+		}
+	}
+	return code, nil
+}
+
+// immediate parses a PUSH immediate into size bytes
+func immediate(s string, size int) ([]byte, error) {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("invalid immediate %q", s)
+	}
+	if v.BitLen() > 8*size {
+		return nil, fmt.Errorf("immediate %s exceeds %d bytes", s, size)
+	}
+	return v.FillBytes(make([]byte, size)), nil
+}
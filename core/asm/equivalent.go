@@ -0,0 +1,62 @@
+package asm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// equivalenceAddress is where the compared code is deployed
+var equivalenceAddress = common.BytesToAddress([]byte{0x0e, 0x00, 0x01})
+
+// Equivalent runs a and b in fresh states and returns an error describing
+// the first observable difference between the two executions: how they
+// ended, the data they returned, the logs they emitted or the storage they
+// wrote. Gas use is not compared, since saving gas is the point of
+// rewriting code.
+func Equivalent(a, b []byte) error {
+	resultA, storageA := execute(a)
+	resultB, storageB := execute(b)
+	if fmt.Sprint(resultA.Err) != fmt.Sprint(resultB.Err) {
+		return fmt.Errorf("execution ended with %v, then with %v", resultA.Err, resultB.Err)
+	}
+	if !bytes.Equal(resultA.ReturnData, resultB.ReturnData) {
+		return fmt.Errorf("returned %#x, then %#x", resultA.ReturnData, resultB.ReturnData)
+	}
+	if len(resultA.Logs) != len(resultB.Logs) {
+		return fmt.Errorf("emitted %d logs, then %d", len(resultA.Logs), len(resultB.Logs))
+	}
+	for i := range resultA.Logs {
+		if !reflect.DeepEqual(resultA.Logs[i], resultB.Logs[i]) {
+			return fmt.Errorf("log %d differs", i)
+		}
+	}
+	if !reflect.DeepEqual(storageA, storageB) {
+		return fmt.Errorf("storage differs: %v, then %v", storageA, storageB)
+	}
+	return nil
+}
+
+// execute runs code and returns the result with the storage it left
+func execute(code []byte) (*vm.ExecutionResult, map[common.Hash]common.Hash) {
+	statedb := state.New()
+	statedb.SetCode(equivalenceAddress, code)
+	blockCtx := &vm.Context{
+		BlockNumber: big.NewInt(1),
+		Timestamp:   big.NewInt(1),
+		GasLimit:    10_000_000,
+		GasPrice:    big.NewInt(1),
+	}
+	result := vm.NewEVM(blockCtx, statedb, vm.Config{}).Run(context.Background(), equivalenceAddress)
+	storage := statedb.Dump()[equivalenceAddress].Storage
+	if len(storage) == 0 {
+		storage = nil
+	}
+	return result, storage
+}
@@ -0,0 +1,143 @@
+package asm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// jumpdests returns the pcs of the JUMPDEST instructions of code
+func jumpdests(code []byte) map[uint64]bool {
+	dests := make(map[uint64]bool)
+	for _, in := range Disassemble(code) {
+		if in.Op == opJumpdest {
+			dests[in.PC] = true
+		}
+	}
+	return dests
+}
+
+// pushedValue returns the immediate of a PUSH instruction if it fits a pc
+func pushedValue(in Instruction) (uint64, bool) {
+	if !in.Op.IsPush() {
+		return 0, false
+	}
+	v := new(big.Int).SetBytes(in.Arg)
+	return v.Uint64(), v.IsUint64()
+}
+
+// checkJumps reports jumps whose pushed target is not a JUMPDEST and
+// JUMPDESTs whose pc is never pushed, so that no jump can target them
+// unless its target is computed
+func checkJumps(code []byte, cfg *CFG) []Finding {
+	dests := jumpdests(code)
+	var findings []Finding
+	for _, b := range cfg.Blocks {
+		last := b.last()
+		if last.Op != opJump && last.Op != opJumpi {
+			continue
+		}
+		target, ok := staticTarget(b)
+		if ok && !dests[target] {
+			findings = append(findings, Finding{
+				PC:      last.PC,
+				Kind:    InvalidJump,
+				Message: fmt.Sprintf("%s to %#04x, which is not a JUMPDEST", last.Op, target),
+			})
+		}
+	}
+
+	pushed := make(map[uint64]bool)
+	for _, in := range Disassemble(code) {
+		if v, ok := pushedValue(in); ok {
+			pushed[v] = true
+		}
+	}
+	fallenInto := make(map[uint64]bool)
+	for _, b := range cfg.Blocks {
+		for _, e := range b.Succs {
+			if e.Kind == Fallthrough {
+				fallenInto[e.To] = true
+			}
+		}
+	}
+	for pc := range dests {
+		if pc != 0 && !pushed[pc] && !fallenInto[pc] {
+			findings = append(findings, Finding{
+				PC:      pc,
+				Kind:    DeadJumpdest,
+				Message: fmt.Sprintf("no instruction pushes %#04x, so no jump targets it", pc),
+			})
+		}
+	}
+	return findings
+}
+
+// isStateWrite reports whether op modifies state and so fails under
+// STATICCALL
+func isStateWrite(op vm.OpCode) bool {
+	switch op {
+	case 0x55, 0x5d, 0xf0, 0xf5, 0xff: // SSTORE, TSTORE, CREATE, CREATE2, SELFDESTRUCT
+		return true
+	}
+	return op >= 0xa0 && op <= 0xa4 // LOG0 to LOG4
+}
+
+// reach returns the blocks reachable from the given starts without
+// entering the blocked ones. It follows static edges, and treats a JUMPDEST
+// whose pc is pushed in a reached block as reached too, which covers the
+// return addresses of internal function calls.
+func reach(code []byte, cfg *CFG, starts []uint64, blocked map[uint64]bool) map[uint64]bool {
+	dests := jumpdests(code)
+	reached := make(map[uint64]bool)
+	work := append([]uint64(nil), starts...)
+	for len(work) > 0 {
+		start := work[len(work)-1]
+		work = work[:len(work)-1]
+		b := cfg.Blocks[start]
+		if b == nil || reached[start] {
+			continue
+		}
+		reached[start] = true
+		for _, e := range b.Succs {
+			if !blocked[e.To] {
+				work = append(work, e.To)
+			}
+		}
+		for _, in := range b.Instructions {
+			if v, ok := pushedValue(in); ok && dests[v] && !blocked[v] {
+				work = append(work, v)
+			}
+		}
+	}
+	return reached
+}
+
+// checkStaticWrites reports state writes reachable from the static entry
+// points but not from the rest of the code, which therefore always fail
+func checkStaticWrites(code []byte, cfg *CFG, entries []uint64) []Finding {
+	blocked := make(map[uint64]bool, len(entries))
+	for _, pc := range entries {
+		blocked[pc] = true
+	}
+	dynamic := reach(code, cfg, []uint64{0}, blocked)
+	static := reach(code, cfg, entries, nil)
+
+	var findings []Finding
+	for start := range static {
+		if dynamic[start] {
+			continue
+		}
+		for _, in := range cfg.Blocks[start].Instructions {
+			if isStateWrite(in.Op) {
+				findings = append(findings, Finding{
+					PC:      in.PC,
+					Kind:    StaticStateWrite,
+					Message: fmt.Sprintf("%s is only reachable from static entry points and always fails", in.Op),
+				})
+			}
+		}
+	}
+	return findings
+}
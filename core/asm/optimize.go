@@ -0,0 +1,281 @@
+package asm
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// maxThreading bounds how many jumps are followed when threading a jump
+const maxThreading = 16
+
+var tt256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// foldable are the operations evaluated at compile time when all their
+// operands are pushed constants. a is the word below the top, b the top.
+var foldable = map[vm.OpCode]func(a, b *big.Int) *big.Int{
+	0x01: func(a, b *big.Int) *big.Int { return new(big.Int).Add(b, a) },
+	0x02: func(a, b *big.Int) *big.Int { return new(big.Int).Mul(b, a) },
+	0x03: func(a, b *big.Int) *big.Int { return new(big.Int).Sub(b, a) },
+	0x04: func(a, b *big.Int) *big.Int {
+		if a.Sign() == 0 {
+			return new(big.Int)
+		}
+		return new(big.Int).Div(b, a)
+	},
+	0x06: func(a, b *big.Int) *big.Int {
+		if a.Sign() == 0 {
+			return new(big.Int)
+		}
+		return new(big.Int).Mod(b, a)
+	},
+	0x0a: func(a, b *big.Int) *big.Int { return new(big.Int).Exp(b, a, tt256) },
+	0x10: func(a, b *big.Int) *big.Int { return boolWord(b.Cmp(a) < 0) },
+	0x11: func(a, b *big.Int) *big.Int { return boolWord(b.Cmp(a) > 0) },
+	0x14: func(a, b *big.Int) *big.Int { return boolWord(b.Cmp(a) == 0) },
+	0x16: func(a, b *big.Int) *big.Int { return new(big.Int).And(b, a) },
+	0x17: func(a, b *big.Int) *big.Int { return new(big.Int).Or(b, a) },
+	0x18: func(a, b *big.Int) *big.Int { return new(big.Int).Xor(b, a) },
+	0x1b: func(a, b *big.Int) *big.Int {
+		if !b.IsUint64() || b.Uint64() > 255 {
+			return new(big.Int)
+		}
+		return new(big.Int).Lsh(a, uint(b.Uint64()))
+	},
+	0x1c: func(a, b *big.Int) *big.Int {
+		if !b.IsUint64() || b.Uint64() > 255 {
+			return new(big.Int)
+		}
+		return new(big.Int).Rsh(a, uint(b.Uint64()))
+	},
+}
+
+func boolWord(v bool) *big.Int {
+	if v {
+		return big.NewInt(1)
+	}
+	return new(big.Int)
+}
+
+// Optimize applies peephole rewrites to code and returns the new code:
+//
+//   - PUSH a, PUSH b, OP and PUSH a, ISZERO/NOT are folded into a single PUSH
+//     of the result when that is not longer
+//   - PUSH/DUP followed by POP, and a SWAP repeated twice, are removed
+//   - a jump to a block that only jumps on is retargeted to the final
+//     destination
+//
+// Removing instructions moves the code after them, so jump targets pushed
+// right before a JUMP or JUMPI are relocated. When the layout of the code
+// cannot be changed safely, because it has jumps whose target is computed,
+// or it reads its own code or pc, only jump threading is applied, in place.
+// The rewrites assume the code does not underflow the stack: an underflow
+// in a removed DUP or SWAP no longer happens after optimization. Check the
+// result with Equivalent.
+func Optimize(code []byte) []byte {
+	ins := Disassemble(code)
+	if len(ins) == 0 || len(ins[len(ins)-1].Arg) < ins[len(ins)-1].Op.PushSize() {
+		return append([]byte(nil), code...)
+	}
+	dests := jumpdests(code)
+	ins = threadJumps(ins, dests)
+	if fixedLayout(ins, dests) {
+		return Assemble(ins)
+	}
+	for changed := true; changed; {
+		var folded, removed bool
+		ins, folded = foldConstants(ins)
+		ins, removed = removeDeadStack(ins)
+		changed = folded || removed
+	}
+	return Assemble(relocate(ins))
+}
+
+// pushValue returns the value pushed by in
+func pushValue(in Instruction) (*big.Int, bool) {
+	switch {
+	case in.Op == 0x5f:
+		return new(big.Int), true
+	case in.Op.IsPush():
+		return new(big.Int).SetBytes(in.Arg), true
+	}
+	return nil, false
+}
+
+// pushOf returns the shortest instruction pushing v
+func pushOf(pc uint64, v *big.Int) Instruction {
+	arg := v.Bytes()
+	if len(arg) == 0 {
+		arg = []byte{0}
+	}
+	return Instruction{PC: pc, Op: vm.OpCode(0x5f + len(arg)), Arg: arg}
+}
+
+func size(ins []Instruction) int {
+	n := 0
+	for _, in := range ins {
+		n += 1 + len(in.Arg)
+	}
+	return n
+}
+
+// fixedLayout reports whether instructions cannot be moved: a jump target
+// is not the pushed pc of a JUMPDEST, or the code observes its own layout
+func fixedLayout(ins []Instruction, jumpdests map[uint64]bool) bool {
+	for i, in := range ins {
+		switch in.Op {
+		case 0x38, 0x39, 0x58: // CODESIZE, CODECOPY, PC
+			return true
+		case opJump, opJumpi:
+			if i == 0 {
+				return true
+			}
+			target, ok := pushValue(ins[i-1])
+			if !ok || !target.IsUint64() || !jumpdests[target.Uint64()] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// threadJumps retargets PUSH T, JUMP(I) when the block at T is only
+// JUMPDEST, PUSH U, JUMP, as long as U fits in the original push
+func threadJumps(ins []Instruction, jumpdests map[uint64]bool) []Instruction {
+	at := make(map[uint64]int, len(ins))
+	for i, in := range ins {
+		at[in.PC] = i
+	}
+	// hop returns where a jump to the JUMPDEST at pc ends up
+	hop := func(pc uint64) (uint64, bool) {
+		i := at[pc]
+		if i+2 >= len(ins) || ins[i+2].Op != opJump {
+			return 0, false
+		}
+		next, ok := pushValue(ins[i+1])
+		if !ok || !next.IsUint64() || !jumpdests[next.Uint64()] {
+			return 0, false
+		}
+		return next.Uint64(), true
+	}
+	out := append([]Instruction(nil), ins...)
+	for i := 1; i < len(out); i++ {
+		if out[i].Op != opJump && out[i].Op != opJumpi || !out[i-1].Op.IsPush() {
+			continue
+		}
+		target, _ := pushValue(out[i-1])
+		if !target.IsUint64() || !jumpdests[target.Uint64()] {
+			continue
+		}
+		dest := target.Uint64()
+		seen := map[uint64]bool{dest: true}
+		for n := 0; n < maxThreading; n++ {
+			next, ok := hop(dest)
+			if !ok || seen[next] {
+				break
+			}
+			seen[next] = true
+			dest = next
+		}
+		width := len(out[i-1].Arg)
+		if dest == target.Uint64() || width < 8 && dest >= 1<<(8*width) {
+			continue
+		}
+		arg := make([]byte, width)
+		new(big.Int).SetUint64(dest).FillBytes(arg)
+		out[i-1].Arg = arg
+	}
+	return out
+}
+
+// foldConstants evaluates operations on pushed constants
+func foldConstants(ins []Instruction) ([]Instruction, bool) {
+	var out []Instruction
+	changed := false
+	for i := 0; i < len(ins); i++ {
+		if i+1 < len(ins) {
+			if a, ok := pushValue(ins[i]); ok {
+				var v *big.Int
+				switch ins[i+1].Op {
+				case 0x15: // ISZERO
+					v = boolWord(a.Sign() == 0)
+				case 0x19: // NOT
+					v = new(big.Int).Sub(new(big.Int).Sub(tt256, big.NewInt(1)), a)
+				}
+				if v != nil && size([]Instruction{pushOf(0, v)}) <= size(ins[i:i+2]) {
+					out = append(out, pushOf(ins[i].PC, v))
+					i++
+					changed = true
+					continue
+				}
+			}
+		}
+		if i+2 < len(ins) {
+			a, okA := pushValue(ins[i])
+			b, okB := pushValue(ins[i+1])
+			if op, ok := foldable[ins[i+2].Op]; ok && okA && okB {
+				v := new(big.Int).Mod(op(a, b), tt256)
+				if folded := pushOf(ins[i].PC, v); size([]Instruction{folded}) <= size(ins[i:i+3]) {
+					out = append(out, folded)
+					i += 2
+					changed = true
+					continue
+				}
+			}
+		}
+		out = append(out, ins[i])
+	}
+	return out, changed
+}
+
+// removeDeadStack drops stack shuffling whose effect is undone right away
+func removeDeadStack(ins []Instruction) ([]Instruction, bool) {
+	var out []Instruction
+	changed := false
+	for i := 0; i < len(ins); i++ {
+		if i+1 < len(ins) {
+			first, second := ins[i].Op, ins[i+1].Op
+			_, pushes := pushValue(ins[i])
+			dup := first >= 0x80 && first <= 0x8f
+			swap := first >= 0x90 && first <= 0x9f
+			if (pushes || dup) && second == 0x50 || swap && second == first {
+				i++
+				changed = true
+				continue
+			}
+		}
+		out = append(out, ins[i])
+	}
+	return out, changed
+}
+
+// relocate updates the targets pushed before jumps to the new pcs of the
+// JUMPDESTs they name, keeping the width of each push
+func relocate(ins []Instruction) []Instruction {
+	moved := make(map[uint64]uint64)
+	pc := uint64(0)
+	for _, in := range ins {
+		if in.Op == opJumpdest {
+			moved[in.PC] = pc
+		}
+		pc += 1 + uint64(len(in.Arg))
+	}
+	out := append([]Instruction(nil), ins...)
+	for i := 1; i < len(out); i++ {
+		if out[i].Op != opJump && out[i].Op != opJumpi {
+			continue
+		}
+		target, ok := pushValue(out[i-1])
+		if !ok || !target.IsUint64() {
+			continue
+		}
+		dest, ok := moved[target.Uint64()]
+		if !ok {
+			continue
+		}
+		arg := make([]byte, len(out[i-1].Arg))
+		new(big.Int).SetUint64(dest).FillBytes(arg)
+		out[i-1].Arg = arg
+	}
+	return out
+}
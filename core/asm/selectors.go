@@ -0,0 +1,59 @@
+package asm
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+const opEq vm.OpCode = 0x14
+
+// Function is a function dispatched by a contract
+type Function struct {
+	Selector [4]byte
+	Entry    uint64 // pc the dispatcher jumps to for the selector
+}
+
+// Selectors recovers the functions handled by the dispatcher of code by
+// matching the sequences solc emits for each selector:
+//
+//	PUSH4 selector, [DUPn,] EQ, PUSHn entry, JUMPI
+//	DUPn, PUSH4 selector, EQ, PUSHn entry, JUMPI
+//
+// Functions are returned in the order their selectors appear in the code.
+// Dispatchers that compare selectors in other ways are not recognized.
+func Selectors(code []byte) []Function {
+	instructions := Disassemble(code)
+	seen := make(map[[4]byte]bool)
+	var functions []Function
+	for i, in := range instructions {
+		if in.Op != 0x63 || len(in.Arg) != 4 { // PUSH4
+			continue
+		}
+		j := i + 1
+		if j < len(instructions) && isDup(instructions[j].Op) {
+			j++
+		}
+		if j+2 >= len(instructions) || instructions[j].Op != opEq {
+			continue
+		}
+		push, jumpi := instructions[j+1], instructions[j+2]
+		if !push.Op.IsPush() || jumpi.Op != opJumpi {
+			continue
+		}
+		entry := new(big.Int).SetBytes(push.Arg)
+		if !entry.IsUint64() {
+			continue
+		}
+		var fn Function
+		copy(fn.Selector[:], in.Arg)
+		fn.Entry = entry.Uint64()
+		if !seen[fn.Selector] {
+			seen[fn.Selector] = true
+			functions = append(functions, fn)
+		}
+	}
+	return functions
+}
+
+func isDup(op vm.OpCode) bool { return op >= 0x80 && op <= 0x8f }
@@ -0,0 +1,72 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SourceRange is the source code an instruction was compiled from, an
+// entry of a solc source map
+type SourceRange struct {
+	Start  int  // byte offset in the source file
+	Length int  // length in bytes
+	File   int  // index of the source file, -1 for code generated by the compiler
+	Jump   byte // 'i' into a function, 'o' out of a function, or '-' for a regular jump or none
+}
+
+// ParseSourceMap decodes a solc source map, such as srcmap-runtime, into
+// the range of each instruction, in instruction order. Fields left empty
+// in an entry repeat those of the previous entry, as the compressed format
+// requires; the modifier depth is ignored.
+func ParseSourceMap(srcmap string) ([]SourceRange, error) {
+	var ranges []SourceRange
+	prev := SourceRange{File: -1, Jump: '-'}
+	for i, entry := range strings.Split(srcmap, ";") {
+		r := prev
+		for j, field := range strings.Split(entry, ":") {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if len(field) != 1 || !strings.Contains("io-", field) {
+					return nil, fmt.Errorf("source map entry %d: invalid jump type %q", i, field)
+				}
+				r.Jump = field[0]
+				continue
+			}
+			if j > 3 {
+				continue
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("source map entry %d: %w", i, err)
+			}
+			switch j {
+			case 0:
+				r.Start = n
+			case 1:
+				r.Length = n
+			case 2:
+				r.File = n
+			}
+		}
+		ranges = append(ranges, r)
+		prev = r
+	}
+	return ranges, nil
+}
+
+// SourceRangesByPC keys the ranges of a source map by the pc of their
+// instruction in code. Instructions past the end of the map, such as the
+// metadata appended to solc output, have no range.
+func SourceRangesByPC(code []byte, ranges []SourceRange) map[uint64]SourceRange {
+	byPC := make(map[uint64]SourceRange, len(ranges))
+	for i, in := range Disassemble(code) {
+		if i >= len(ranges) {
+			break
+		}
+		byPC[in.PC] = ranges[i]
+	}
+	return byPC
+}
@@ -0,0 +1,51 @@
+// Package eip4844 implements the blob gas market of EIP-4844: the excess
+// blob gas carried from block to block and the blob base fee derived from
+// it.
+package eip4844
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// Blob gas constants
+const (
+	GasPerBlob           = 1 << 17 // blob gas used by each blob
+	MinBaseFeePerBlobGas = 1       // wei
+)
+
+// CalcExcessBlobGas returns the excess blob gas of a block from the excess
+// and the blob gas used of its parent: the gas used beyond the per-block
+// target, accumulated over blocks and never negative
+func CalcExcessBlobGas(config *params.BlobConfig, parentExcessBlobGas, parentBlobGasUsed uint64) uint64 {
+	target := config.Target * GasPerBlob
+	if parentExcessBlobGas+parentBlobGasUsed < target {
+		return 0
+	}
+	return parentExcessBlobGas + parentBlobGasUsed - target
+}
+
+// CalcBlobFee returns the blob base fee of a block with the given excess
+// blob gas: MinBaseFeePerBlobGas * e^(excess / BaseFeeUpdateFraction)
+func CalcBlobFee(config *params.BlobConfig, excessBlobGas uint64) *big.Int {
+	return fakeExponential(big.NewInt(MinBaseFeePerBlobGas), new(big.Int).SetUint64(excessBlobGas), new(big.Int).SetUint64(config.BaseFeeUpdateFraction))
+}
+
+// MaxBlobGasPerBlock returns the blob gas a block may use
+func MaxBlobGasPerBlock(config *params.BlobConfig) uint64 {
+	return config.Max * GasPerBlob
+}
+
+// fakeExponential approximates factor * e^(numerator / denominator) with
+// the Taylor expansion specified by EIP-4844
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	output := new(big.Int)
+	accum := new(big.Int).Mul(factor, denominator)
+	for i := int64(1); accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+		accum.Mul(accum, numerator)
+		accum.Div(accum, new(big.Int).Mul(denominator, big.NewInt(i)))
+	}
+	return output.Div(output, denominator)
+}
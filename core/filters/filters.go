@@ -0,0 +1,214 @@
+// Package filters selects logs the way eth_getLogs does: by emitting
+// address and topics, over a range of blocks, skipping the blocks whose
+// logs bloom rules out a match.
+package filters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/types"
+)
+
+// Filter errors
+var (
+	ErrInvalidRange  = errors.New("invalid block range")
+	ErrBlockNotFound = errors.New("block not found")
+	ErrRangeAndHash  = errors.New("cannot specify both blockHash and fromBlock/toBlock")
+)
+
+// Criteria selects logs by block, emitting address and topics, as the
+// filter object of eth_getLogs and eth_subscribe("logs") does
+type Criteria struct {
+	BlockHash *common.Hash // only the logs of this block; excludes FromBlock and ToBlock
+	FromBlock *uint64      // first block searched, the latest if nil
+	ToBlock   *uint64      // last block searched, the latest if nil
+
+	Addresses []common.Address // any of these, every address if empty
+	Topics    [][]common.Hash  // topic i must be one of Topics[i]; an empty position matches any topic
+}
+
+// Matches reports whether l passes the address and topic criteria. The
+// block criteria are not checked.
+func (c *Criteria) Matches(l *types.Log) bool {
+	if len(c.Addresses) > 0 && !contains(c.Addresses, l.Address) {
+		return false
+	}
+	if len(c.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, alternatives := range c.Topics {
+		if len(alternatives) > 0 && !contains(alternatives, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// MayMatch reports whether a block with the given logs bloom can hold a
+// log passing the address and topic criteria: one of the addresses and,
+// for every topic position, one of its alternatives must be in the bloom
+func (c *Criteria) MayMatch(bloom *types.Bloom) bool {
+	if len(c.Addresses) > 0 && !anyInBloom(bloom, c.Addresses) {
+		return false
+	}
+	for _, alternatives := range c.Topics {
+		if len(alternatives) > 0 && !anyInBloom(bloom, alternatives) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyInBloom[T interface{ Bytes() []byte }](bloom *types.Bloom, values []T) bool {
+	for _, v := range values {
+		if bloom.Test(v.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains[T comparable](list []T, v T) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Backend is a chain whose logs can be filtered
+type Backend interface {
+	// HeadNumber returns the number of the latest block
+	HeadNumber() uint64
+	// BlockNumber returns the number of the block with the given hash
+	BlockNumber(hash common.Hash) (uint64, bool)
+	// Bloom returns the logs bloom of a block no later than the head
+	Bloom(number uint64) types.Bloom
+	// Logs returns the logs of a block no later than the head, in order
+	Logs(number uint64) []*types.Log
+}
+
+// Match is a log passing a filter, with its position in the chain
+type Match struct {
+	BlockNumber uint64
+	Index       int // position of the log in the logs of its block
+	Log         *types.Log
+}
+
+// Filter returns the logs of b passing c, in chain order. Blocks whose
+// bloom rules out a match are skipped without reading their logs.
+func Filter(b Backend, c *Criteria) ([]Match, error) {
+	head := b.HeadNumber()
+	from, to := head, head
+	if c.BlockHash != nil {
+		if c.FromBlock != nil || c.ToBlock != nil {
+			return nil, ErrRangeAndHash
+		}
+		number, ok := b.BlockNumber(*c.BlockHash)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, c.BlockHash.Hex())
+		}
+		from, to = number, number
+	}
+	if c.FromBlock != nil {
+		from = *c.FromBlock
+	}
+	if c.ToBlock != nil {
+		to = *c.ToBlock
+	}
+	if from > to {
+		return nil, fmt.Errorf("%w: from block %d after to block %d", ErrInvalidRange, from, to)
+	}
+	if to > head {
+		to = head
+	}
+
+	var matches []Match
+	for number := from; number <= to; number++ {
+		bloom := b.Bloom(number)
+		if !c.MayMatch(&bloom) {
+			continue
+		}
+		for i, l := range b.Logs(number) {
+			if c.Matches(l) {
+				matches = append(matches, Match{BlockNumber: number, Index: i, Log: l})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// UnmarshalJSON decodes a JSON-RPC filter object, where fromBlock and
+// toBlock are block numbers or tags, address is one address or a list and
+// each topic is null, one hash or a list of hashes
+func (c *Criteria) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		BlockHash *common.Hash      `json:"blockHash"`
+		FromBlock *string           `json:"fromBlock"`
+		ToBlock   *string           `json:"toBlock"`
+		Address   json.RawMessage   `json:"address"`
+		Topics    []json.RawMessage `json:"topics"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*c = Criteria{BlockHash: dec.BlockHash}
+	var err error
+	if c.FromBlock, err = blockNumber(dec.FromBlock); err != nil {
+		return fmt.Errorf("fromBlock: %w", err)
+	}
+	if c.ToBlock, err = blockNumber(dec.ToBlock); err != nil {
+		return fmt.Errorf("toBlock: %w", err)
+	}
+	if err := oneOrMany(dec.Address, &c.Addresses); err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+	for i, raw := range dec.Topics {
+		var topics []common.Hash
+		if err := oneOrMany(raw, &topics); err != nil {
+			return fmt.Errorf("topic %d: %w", i, err)
+		}
+		c.Topics = append(c.Topics, topics)
+	}
+	return nil
+}
+
+// blockNumber decodes a block number or tag; the tags other than earliest
+// select the latest block and decode to nil
+func blockNumber(tag *string) (*uint64, error) {
+	if tag == nil {
+		return nil, nil
+	}
+	switch *tag {
+	case "latest", "pending", "safe", "finalized":
+		return nil, nil
+	case "earliest":
+		return new(uint64), nil
+	}
+	n, err := hexutil.DecodeUint64(*tag)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// oneOrMany decodes null, a single value or a list of values into list
+func oneOrMany[T any](raw json.RawMessage, list *[]T) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if raw[0] == '[' {
+		return json.Unmarshal(raw, list)
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	*list = append(*list, v)
+	return nil
+}
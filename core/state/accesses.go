@@ -0,0 +1,61 @@
+package state
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// Accesses records the accounts and storage slots read or written through a
+// StateDB, which is what a witness of an execution must prove. Whole-storage
+// iteration is not recorded.
+type Accesses struct {
+	// Slots holds the accounts accessed, with the storage keys accessed
+	// of each
+	Slots map[common.Address]map[common.Hash]struct{}
+	// Reset holds the accounts created or deleted over whatever they held:
+	// their storage is wholly that of the state afterwards
+	Reset map[common.Address]struct{}
+}
+
+// RecordAccesses starts recording the accesses made through s, replacing
+// any recording in progress, and returns the set they are added to.
+// Reverting to a snapshot does not forget accesses.
+func (s *StateDB) RecordAccesses() *Accesses {
+	s.accesses = &Accesses{
+		Slots: make(map[common.Address]map[common.Hash]struct{}),
+		Reset: make(map[common.Address]struct{}),
+	}
+	return s.accesses
+}
+
+// StopRecording stops recording accesses
+func (s *StateDB) StopRecording() {
+	s.accesses = nil
+}
+
+// touch records an access to the account at addr
+func (s *StateDB) touch(addr common.Address) {
+	if s.accesses == nil {
+		return
+	}
+	if _, ok := s.accesses.Slots[addr]; !ok {
+		s.accesses.Slots[addr] = make(map[common.Hash]struct{})
+	}
+}
+
+// touchSlot records an access to a storage slot of addr
+func (s *StateDB) touchSlot(addr common.Address, key common.Hash) {
+	if s.accesses == nil {
+		return
+	}
+	s.touch(addr)
+	s.accesses.Slots[addr][key] = struct{}{}
+}
+
+// reset records that the account at addr was replaced
+func (s *StateDB) reset(addr common.Address) {
+	if s.accesses == nil {
+		return
+	}
+	s.touch(addr)
+	s.accesses.Reset[addr] = struct{}{}
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// CodeStore holds contract code once per code hash. Contracts deployed with
+// identical bytecode share a single copy of it.
+type CodeStore struct {
+	codes map[common.Hash][]byte
+}
+
+// NewCodeStore creates an empty code store
+func NewCodeStore() *CodeStore {
+	return &CodeStore{codes: make(map[common.Hash][]byte)}
+}
+
+// Put stores code under its hash, returning the hash and the canonical copy
+// held by the store. The caller's slice is never retained.
+func (s *CodeStore) Put(code []byte) (common.Hash, []byte) {
+	hash := crypto.Keccak256Hash(code)
+	if stored, ok := s.codes[hash]; ok {
+		return hash, stored
+	}
+	stored := make([]byte, len(code))
+	copy(stored, code)
+	s.codes[hash] = stored
+	return hash, stored
+}
+
+// Get returns the code stored under the given hash
+func (s *CodeStore) Get(hash common.Hash) ([]byte, bool) {
+	code, ok := s.codes[hash]
+	return code, ok
+}
+
+// Len returns the number of unique codes in the store
+func (s *CodeStore) Len() int {
+	return len(s.codes)
+}
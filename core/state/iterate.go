@@ -0,0 +1,84 @@
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// StorageSlot is a non-empty storage slot of an account
+type StorageSlot struct {
+	Key   common.Hash `json:"key"`
+	Value common.Hash `json:"value"`
+}
+
+// StorageIterator is implemented by state backends whose storage can be
+// listed, for dumps, debuggers and migrations. Slots are visited in
+// ascending key order and only non-empty slots are visited.
+type StorageIterator interface {
+	// ForEachStorage calls fn for every slot of addr until fn returns
+	// false
+	ForEachStorage(addr common.Address, fn func(key, value common.Hash) bool)
+	// ForEachStoragePrefix calls fn for every slot of addr whose key starts
+	// with prefix until fn returns false
+	ForEachStoragePrefix(addr common.Address, prefix []byte, fn func(key, value common.Hash) bool)
+	// StorageRange returns at most limit slots of addr from start onwards,
+	// and the key to continue from, nil once the last slot is returned
+	StorageRange(addr common.Address, start common.Hash, limit int) ([]StorageSlot, *common.Hash)
+}
+
+var _ StorageIterator = (*StateDB)(nil)
+
+// sortedKeys returns the storage keys of addr in ascending order
+func (s *StateDB) sortedKeys(addr common.Address) []common.Hash {
+	acc, ok := s.accounts[addr]
+	if !ok {
+		return nil
+	}
+	keys := make([]common.Hash, 0, len(acc.storage))
+	for key := range acc.storage {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+	return keys
+}
+
+// ForEachStorage calls fn for every slot of addr, in ascending key order,
+// until fn returns false. fn must not modify the storage of addr.
+func (s *StateDB) ForEachStorage(addr common.Address, fn func(key, value common.Hash) bool) {
+	for _, key := range s.sortedKeys(addr) {
+		if !fn(key, s.accounts[addr].storage[key]) {
+			return
+		}
+	}
+}
+
+// ForEachStoragePrefix calls fn for every slot of addr whose key starts
+// with prefix, in ascending key order, until fn returns false
+func (s *StateDB) ForEachStoragePrefix(addr common.Address, prefix []byte, fn func(key, value common.Hash) bool) {
+	keys := s.sortedKeys(addr)
+	i := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i][:], prefix) >= 0 })
+	for ; i < len(keys) && bytes.HasPrefix(keys[i][:], prefix); i++ {
+		if !fn(keys[i], s.accounts[addr].storage[keys[i]]) {
+			return
+		}
+	}
+}
+
+// StorageRange returns at most limit slots of addr with keys from start
+// onwards, in ascending key order, and the key of the next slot, nil if
+// there is none. A limit of zero or less returns every remaining slot.
+func (s *StateDB) StorageRange(addr common.Address, start common.Hash, limit int) ([]StorageSlot, *common.Hash) {
+	keys := s.sortedKeys(addr)
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].Cmp(start) >= 0 })
+	var slots []StorageSlot
+	for ; i < len(keys); i++ {
+		if limit > 0 && len(slots) == limit {
+			next := keys[i]
+			return slots, &next
+		}
+		slots = append(slots, StorageSlot{Key: keys[i], Value: s.accounts[addr].storage[keys[i]]})
+	}
+	return slots, nil
+}
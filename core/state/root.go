@@ -0,0 +1,50 @@
+package state
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/rlp"
+	"github.com/nutcas3/evm-golang/trie"
+)
+
+// Root returns the Merkle Patricia root of the state, as found in block
+// headers. Every account in the state is included, empty or not.
+func (s *StateDB) Root() common.Hash {
+	return s.Commit(nil)
+}
+
+// Commit returns the root of the state like Root and calls onNode with
+// every node of the account and storage tries referenced by hash, as
+// trie.Trie.Commit does, so the tries can be opened with trie.Open. A nil
+// onNode only computes the root.
+func (s *StateDB) Commit(onNode func(hash common.Hash, node []byte)) common.Hash {
+	t := trie.New()
+	for addr, acc := range s.accounts {
+		storageRoot := commitStorage(acc, onNode)
+		t.Update(crypto.Keccak256(addr[:]), rlp.EncodeList(
+			rlp.EncodeUint(acc.nonce),
+			rlp.EncodeBig(acc.balance),
+			rlp.EncodeBytes(storageRoot[:]),
+			rlp.EncodeBytes(acc.codeHash[:]),
+		))
+	}
+	return t.Commit(onNode)
+}
+
+// StorageRoot returns the root of the storage trie of addr
+func (s *StateDB) StorageRoot(addr common.Address) common.Hash {
+	if acc, ok := s.accounts[addr]; ok {
+		return commitStorage(acc, nil)
+	}
+	return trie.EmptyRoot
+}
+
+// commitStorage returns the root of the storage trie of acc, reporting its
+// nodes to onNode unless nil
+func commitStorage(acc *account, onNode func(hash common.Hash, node []byte)) common.Hash {
+	t := trie.New()
+	for key, value := range acc.storage {
+		t.Update(crypto.Keccak256(key[:]), rlp.EncodeBig(value.Big()))
+	}
+	return t.Commit(onNode)
+}
@@ -0,0 +1,253 @@
+// Package state implements an in-memory world state for the EVM.
+package state
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+// account is the state of a single address
+type account struct {
+	nonce    uint64
+	balance  *big.Int
+	codeHash common.Hash
+	code     []byte
+	storage  map[common.Hash]common.Hash
+}
+
+// copy returns a deep copy of the account. Code is immutable and shared.
+func (a *account) copy() *account {
+	cpy := *a
+	cpy.balance = new(big.Int).Set(a.balance)
+	cpy.storage = make(map[common.Hash]common.Hash, len(a.storage))
+	for key, value := range a.storage {
+		cpy.storage[key] = value
+	}
+	return &cpy
+}
+
+// StateDB is an in-memory world state. Contract code is deduplicated through
+// a content-addressed CodeStore.
+type StateDB struct {
+	accounts  map[common.Address]*account
+	codes     *CodeStore
+	snapshots []map[common.Address]*account
+
+	accesses *Accesses // recorded accesses, nil unless recording
+}
+
+// New creates an empty state
+func New() *StateDB {
+	return &StateDB{
+		accounts: make(map[common.Address]*account),
+		codes:    NewCodeStore(),
+	}
+}
+
+// getOrNewAccount returns the account at addr, creating it if needed
+func (s *StateDB) getOrNewAccount(addr common.Address) *account {
+	s.touch(addr)
+	acc, ok := s.accounts[addr]
+	if !ok {
+		acc = s.newAccount(addr)
+	}
+	return acc
+}
+
+func (s *StateDB) newAccount(addr common.Address) *account {
+	codeHash, code := s.codes.Put(nil)
+	acc := &account{
+		balance:  new(big.Int),
+		codeHash: codeHash,
+		code:     code,
+		storage:  make(map[common.Hash]common.Hash),
+	}
+	s.accounts[addr] = acc
+	return acc
+}
+
+// CreateAccount creates an empty account at addr, replacing any existing one
+func (s *StateDB) CreateAccount(addr common.Address) {
+	s.reset(addr)
+	s.newAccount(addr)
+}
+
+// DeleteAccount removes the account at addr with its balance, code and
+// storage
+func (s *StateDB) DeleteAccount(addr common.Address) {
+	s.reset(addr)
+	delete(s.accounts, addr)
+}
+
+// Exist reports whether an account exists at addr
+func (s *StateDB) Exist(addr common.Address) bool {
+	s.touch(addr)
+	_, ok := s.accounts[addr]
+	return ok
+}
+
+// GetNonce returns the nonce of addr
+func (s *StateDB) GetNonce(addr common.Address) uint64 {
+	s.touch(addr)
+	if acc, ok := s.accounts[addr]; ok {
+		return acc.nonce
+	}
+	return 0
+}
+
+// SetNonce sets the nonce of addr
+func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	s.getOrNewAccount(addr).nonce = nonce
+}
+
+// GetBalance returns the balance of addr
+func (s *StateDB) GetBalance(addr common.Address) *big.Int {
+	s.touch(addr)
+	if acc, ok := s.accounts[addr]; ok {
+		return new(big.Int).Set(acc.balance)
+	}
+	return new(big.Int)
+}
+
+// TotalBalance returns the sum of the balances of all accounts, the
+// supply of ether held in the state
+func (s *StateDB) TotalBalance() *big.Int {
+	total := new(big.Int)
+	for _, acc := range s.accounts {
+		total.Add(total, acc.balance)
+	}
+	return total
+}
+
+// AddBalance adds amount to the balance of addr
+func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
+	acc := s.getOrNewAccount(addr)
+	acc.balance.Add(acc.balance, amount)
+}
+
+// SubBalance subtracts amount from the balance of addr. Callers are expected
+// to check that the balance suffices.
+func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
+	acc := s.getOrNewAccount(addr)
+	acc.balance.Sub(acc.balance, amount)
+}
+
+// GetCode returns the code deployed at addr
+func (s *StateDB) GetCode(addr common.Address) []byte {
+	s.touch(addr)
+	if acc, ok := s.accounts[addr]; ok {
+		return acc.code
+	}
+	return nil
+}
+
+// GetCodeHash returns the Keccak-256 hash of the code at addr, or the zero
+// hash if the account does not exist
+func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
+	s.touch(addr)
+	if acc, ok := s.accounts[addr]; ok {
+		return acc.codeHash
+	}
+	return common.Hash{}
+}
+
+// SetCode deploys code at addr
+func (s *StateDB) SetCode(addr common.Address, code []byte) {
+	acc := s.getOrNewAccount(addr)
+	acc.codeHash, acc.code = s.codes.Put(code)
+}
+
+// GetState returns the value of a storage slot of addr
+func (s *StateDB) GetState(addr common.Address, key common.Hash) common.Hash {
+	s.touchSlot(addr, key)
+	if acc, ok := s.accounts[addr]; ok {
+		return acc.storage[key]
+	}
+	return common.Hash{}
+}
+
+// SetState sets the value of a storage slot of addr
+func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	s.touchSlot(addr, key)
+	acc := s.getOrNewAccount(addr)
+	if value.IsZero() {
+		delete(acc.storage, key)
+		return
+	}
+	acc.storage[key] = value
+}
+
+// Snapshot records the current state and returns an identifier that can be
+// passed to RevertToSnapshot
+func (s *StateDB) Snapshot() int {
+	accounts := make(map[common.Address]*account, len(s.accounts))
+	for addr, acc := range s.accounts {
+		accounts[addr] = acc.copy()
+	}
+	s.snapshots = append(s.snapshots, accounts)
+	return len(s.snapshots) - 1
+}
+
+// RevertToSnapshot restores the state recorded by Snapshot. Snapshots taken
+// after id are discarded; id itself stays valid for further reverts.
+func (s *StateDB) RevertToSnapshot(id int) {
+	if id < 0 || id >= len(s.snapshots) {
+		return
+	}
+	s.accounts = make(map[common.Address]*account, len(s.snapshots[id]))
+	for addr, acc := range s.snapshots[id] {
+		s.accounts[addr] = acc.copy()
+	}
+	s.snapshots = s.snapshots[:id+1]
+}
+
+// Codes returns the code store backing the state
+func (s *StateDB) Codes() *CodeStore {
+	return s.codes
+}
+
+// DumpAccount is the JSON representation of an account in a state dump
+type DumpAccount struct {
+	Nonce    hexutil.Uint64              `json:"nonce"`
+	Balance  *hexutil.Big                `json:"balance,omitempty"`
+	CodeHash common.Hash                 `json:"codeHash"`
+	Code     hexutil.Bytes               `json:"code,omitempty"`
+	Storage  map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// Dump returns a JSON-serializable copy of every account in the state
+func (s *StateDB) Dump() map[common.Address]DumpAccount {
+	dump := make(map[common.Address]DumpAccount, len(s.accounts))
+	for addr, acc := range s.accounts {
+		storage := make(map[common.Hash]common.Hash, len(acc.storage))
+		for key, value := range acc.storage {
+			storage[key] = value
+		}
+		dump[addr] = DumpAccount{
+			Nonce:    hexutil.Uint64(acc.nonce),
+			Balance:  (*hexutil.Big)(new(big.Int).Set(acc.balance)),
+			CodeHash: acc.codeHash,
+			Code:     acc.code,
+			Storage:  storage,
+		}
+	}
+	return dump
+}
+
+// NewFromDump creates a state holding the accounts of a dump
+func NewFromDump(dump map[common.Address]DumpAccount) *StateDB {
+	s := New()
+	for addr, acc := range dump {
+		s.SetNonce(addr, uint64(acc.Nonce))
+		if acc.Balance != nil {
+			s.AddBalance(addr, acc.Balance.ToInt())
+		}
+		s.SetCode(addr, acc.Code)
+		for key, value := range acc.Storage {
+			s.SetState(addr, key, value)
+		}
+	}
+	return s
+}
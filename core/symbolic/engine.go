@@ -0,0 +1,436 @@
+// Package symbolic explores EVM bytecode with symbolic call data and
+// storage, forking at every JUMPI whose condition depends on them, to find
+// the reverts and failed assertions reachable from some input.
+package symbolic
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Defaults for the exploration bounds of Config
+const (
+	DefaultMaxPaths  = 256
+	DefaultMaxSteps  = 10000
+	DefaultMaxVisits = 4
+)
+
+// Config bounds the exploration. Zero values select the defaults.
+type Config struct {
+	Solver    Solver // decides which branches are feasible, GuessSolver if nil
+	MaxPaths  int    // number of paths explored before giving up
+	MaxSteps  int    // instructions executed per path
+	MaxVisits int    // times a path may pass the same JUMPI, bounding loops
+}
+
+// Outcome is the way a path ends
+type Outcome int
+
+const (
+	Stopped       Outcome = iota // STOP or SELFDESTRUCT
+	Returned                     // RETURN
+	Reverted                     // REVERT
+	InvalidOpcode                // INVALID or an undefined opcode, how older compilers fail assertions
+	InvalidJump                  // a jump to something other than a JUMPDEST
+	StackError                   // a stack underflow or overflow
+	Abandoned                    // a bound was hit or the path left what the engine models
+)
+
+func (o Outcome) String() string {
+	return [...]string{"stop", "return", "revert", "invalid opcode", "invalid jump", "stack error", "abandoned"}[o]
+}
+
+// Path is a path through the code from its entry to a halt
+type Path struct {
+	Outcome     Outcome
+	PC          uint64 // pc of the instruction that ended the path
+	Reason      string // why the path was abandoned or failed
+	Constraints []Constraint
+	Feasible    Result              // whether some input follows the path
+	Model       map[string]*big.Int // variable values following the path, when found
+}
+
+// Calldata returns call data following the path, built from the model's
+// values for the call data words. Words are read independently of each
+// other, so where they overlap the word at the higher offset wins.
+func (p *Path) Calldata() []byte {
+	var offsets []uint64
+	for name := range p.Model {
+		var offset uint64
+		if _, err := fmt.Sscanf(name, "calldata[%d]", &offset); err == nil && offset <= 1<<16 {
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	var data []byte
+	for _, offset := range offsets {
+		if need := offset + 32; uint64(len(data)) < need {
+			data = append(data, make([]byte, need-uint64(len(data)))...)
+		}
+		p.Model[fmt.Sprintf("calldata[%d]", offset)].FillBytes(data[offset : offset+32])
+	}
+	if size, ok := p.Model["calldatasize"]; ok && size.IsUint64() && size.Uint64() < uint64(len(data)) {
+		data = data[:size.Uint64()]
+	}
+	return data
+}
+
+// Report is the result of an exploration
+type Report struct {
+	Paths     []*Path
+	Truncated bool  // MaxPaths was reached before every path was explored
+	SolverErr error // first error reported by the solver, whose checks then count as Unknown
+}
+
+// Failures returns the paths ending in a revert or an invalid opcode
+func (r *Report) Failures() []*Path {
+	var failures []*Path
+	for _, p := range r.Paths {
+		if p.Outcome == Reverted || p.Outcome == InvalidOpcode {
+			failures = append(failures, p)
+		}
+	}
+	return failures
+}
+
+// state is the machine state along one path
+type state struct {
+	pc          uint64
+	stack       []Expr
+	memory      map[uint64]Expr // 32-byte words stored at constant offsets
+	havoc       bool            // memory was written at an unknown offset
+	storage     map[string]Expr // slots written along the path, by key expression
+	constraints []Constraint
+	visits      map[uint64]int
+	steps       int
+}
+
+func (s *state) clone() *state {
+	c := *s
+	c.stack = append([]Expr(nil), s.stack...)
+	c.memory = make(map[uint64]Expr, len(s.memory))
+	for k, v := range s.memory {
+		c.memory[k] = v
+	}
+	c.storage = make(map[string]Expr, len(s.storage))
+	for k, v := range s.storage {
+		c.storage[k] = v
+	}
+	c.constraints = append([]Constraint(nil), s.constraints...)
+	c.visits = make(map[uint64]int, len(s.visits))
+	for k, v := range s.visits {
+		c.visits[k] = v
+	}
+	return &c
+}
+
+func (s *state) push(e Expr) bool {
+	if len(s.stack) >= vm.MaxStackDepth {
+		return false
+	}
+	s.stack = append(s.stack, e)
+	return true
+}
+
+func (s *state) pop() Expr {
+	e := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return e
+}
+
+// binaryOps and unaryOps map opcodes to the operations they perform
+var (
+	binaryOps = map[vm.OpCode]OpKind{
+		0x01: OpAdd, 0x02: OpMul, 0x03: OpSub, 0x04: OpDiv, 0x06: OpMod,
+		0x10: OpLt, 0x11: OpGt, 0x14: OpEq, 0x16: OpAnd, 0x17: OpOr, 0x18: OpXor,
+		0x1b: OpShl, 0x1c: OpShr,
+	}
+	unaryOps = map[vm.OpCode]OpKind{0x15: OpIsZero, 0x19: OpNot}
+)
+
+// explorer holds the exploration of one piece of code
+type explorer struct {
+	code      []byte
+	jumpdests map[uint64]bool
+	cfg       Config
+	report    *Report
+	work      []*state
+}
+
+// Explore executes code along every feasible path within the bounds of
+// cfg, which may be nil. Call data words, the call data size, storage
+// slots never written on the path and the results of environment opcodes
+// are symbolic; memory is tracked for words at constant offsets. Opcodes
+// follow the standard EVM semantics.
+func Explore(code []byte, cfg *Config) *Report {
+	e := &explorer{code: code, jumpdests: make(map[uint64]bool), report: &Report{}}
+	if cfg != nil {
+		e.cfg = *cfg
+	}
+	if e.cfg.Solver == nil {
+		e.cfg.Solver = GuessSolver{}
+	}
+	if e.cfg.MaxPaths <= 0 {
+		e.cfg.MaxPaths = DefaultMaxPaths
+	}
+	if e.cfg.MaxSteps <= 0 {
+		e.cfg.MaxSteps = DefaultMaxSteps
+	}
+	if e.cfg.MaxVisits <= 0 {
+		e.cfg.MaxVisits = DefaultMaxVisits
+	}
+	for _, in := range asm.Disassemble(code) {
+		if in.Op == 0x5b {
+			e.jumpdests[in.PC] = true
+		}
+	}
+
+	e.work = []*state{{memory: map[uint64]Expr{}, storage: map[string]Expr{}, visits: map[uint64]int{}}}
+	for len(e.work) > 0 {
+		if len(e.report.Paths) >= e.cfg.MaxPaths {
+			e.report.Truncated = true
+			break
+		}
+		s := e.work[len(e.work)-1]
+		e.work = e.work[:len(e.work)-1]
+		e.run(s)
+	}
+	return e.report
+}
+
+// finish records the end of a path
+func (e *explorer) finish(s *state, outcome Outcome, reason string) {
+	p := &Path{Outcome: outcome, PC: s.pc, Reason: reason, Constraints: s.constraints}
+	p.Feasible, p.Model = e.check(s.constraints)
+	if len(s.constraints) == 0 {
+		p.Feasible = Sat
+	}
+	e.report.Paths = append(e.report.Paths, p)
+}
+
+// check asks the solver about constraints, keeping its first error
+func (e *explorer) check(constraints []Constraint) (Result, map[string]*big.Int) {
+	result, model, err := e.cfg.Solver.Check(constraints)
+	if err != nil {
+		if e.report.SolverErr == nil {
+			e.report.SolverErr = err
+		}
+		return Unknown, nil
+	}
+	return result, model
+}
+
+// run executes a path until it halts or forks
+func (e *explorer) run(s *state) {
+	for {
+		if s.steps++; s.steps > e.cfg.MaxSteps {
+			e.finish(s, Abandoned, "step limit reached")
+			return
+		}
+		if s.pc >= uint64(len(e.code)) {
+			e.finish(s, Stopped, "")
+			return
+		}
+		op := vm.OpCode(e.code[s.pc])
+		pops, _, defined := asm.StackEffect(op)
+		if !defined {
+			e.finish(s, InvalidOpcode, op.String())
+			return
+		}
+		if len(s.stack) < pops {
+			e.finish(s, StackError, "stack underflow")
+			return
+		}
+		if !e.step(s, op) {
+			return
+		}
+	}
+}
+
+// step executes one instruction, returning false when the path ended or
+// forked
+func (e *explorer) step(s *state, op vm.OpCode) bool {
+	pc := s.pc
+	next := pc + 1
+	ok := true
+	switch {
+	case op.IsPush():
+		arg := make([]byte, op.PushSize())
+		copy(arg, e.code[min(pc+1, uint64(len(e.code))):])
+		ok = s.push(Const{new(big.Int).SetBytes(arg)})
+		next += uint64(op.PushSize())
+	case op == 0x5f: // PUSH0
+		ok = s.push(NewConst(0))
+	case op >= 0x80 && op <= 0x8f: // DUPn
+		ok = s.push(s.stack[len(s.stack)-int(op-0x7f)])
+	case op >= 0x90 && op <= 0x9f: // SWAPn
+		top, other := len(s.stack)-1, len(s.stack)-2-int(op-0x90)
+		s.stack[top], s.stack[other] = s.stack[other], s.stack[top]
+	case binaryOps[op] != "":
+		a, b := s.pop(), s.pop()
+		ok = s.push(NewOp(binaryOps[op], a, b))
+	case unaryOps[op] != "":
+		ok = s.push(NewOp(unaryOps[op], s.pop()))
+	case op == 0x50: // POP
+		s.pop()
+	case op == 0x58: // PC
+		ok = s.push(NewConst(pc))
+	case op == 0x5b: // JUMPDEST
+	case op == 0x35: // CALLDATALOAD
+		ok = s.push(e.calldata(s.pop(), pc))
+	case op == 0x36: // CALLDATASIZE
+		ok = s.push(Var{"calldatasize"})
+	case op == 0x51: // MLOAD
+		ok = s.push(s.mload(s.pop(), pc))
+	case op == 0x52: // MSTORE
+		offset, value := s.pop(), s.pop()
+		s.mstore(offset, value)
+	case op == 0x54: // SLOAD
+		key := s.pop()
+		if v, written := s.storage[key.String()]; written {
+			ok = s.push(v)
+		} else {
+			ok = s.push(Var{"storage[" + key.String() + "]"})
+		}
+	case op == 0x55: // SSTORE
+		key, value := s.pop(), s.pop()
+		s.storage[key.String()] = value
+	case op == 0x56: // JUMP
+		target, valid := e.jumpTarget(s, s.pop())
+		if !valid {
+			return false
+		}
+		next = target
+	case op == 0x57: // JUMPI
+		return e.jumpi(s, s.pop(), s.pop())
+	case op == 0x00, op == 0xff: // STOP, SELFDESTRUCT
+		e.finish(s, Stopped, "")
+		return false
+	case op == 0xf3: // RETURN
+		e.finish(s, Returned, "")
+		return false
+	case op == 0xfd: // REVERT
+		e.finish(s, Reverted, "")
+		return false
+	case op == 0xfe: // INVALID
+		e.finish(s, InvalidOpcode, op.String())
+		return false
+	default:
+		// any other instruction yields unknown words; environment reads
+		// without arguments share a variable along the path
+		pops, pushes, _ := asm.StackEffect(op)
+		for i := 0; i < pops; i++ {
+			s.pop()
+		}
+		name := strings.ToLower(op.String())
+		if pops > 0 {
+			name = fmt.Sprintf("%s@%d", name, pc)
+		}
+		for i := 0; i < pushes && ok; i++ {
+			ok = s.push(Var{name})
+		}
+		if op == 0xf0 || op == 0xf5 || op == 0xf1 || op == 0xf2 || op == 0xf4 || op == 0xfa {
+			s.havoc = true // the callee may write into returned memory
+		}
+	}
+	if !ok {
+		e.finish(s, StackError, "stack overflow")
+		return false
+	}
+	s.pc = next
+	return true
+}
+
+// calldata returns the call data word at offset
+func (e *explorer) calldata(offset Expr, pc uint64) Expr {
+	if c, ok := offset.(Const); ok && c.V.IsUint64() {
+		return Var{fmt.Sprintf("calldata[%d]", c.V.Uint64())}
+	}
+	return Var{fmt.Sprintf("calldata[%s]@%d", offset, pc)}
+}
+
+func (s *state) mload(offset Expr, pc uint64) Expr {
+	c, ok := offset.(Const)
+	if !ok || !c.V.IsUint64() || s.havoc {
+		return Var{fmt.Sprintf("memory@%d", pc)}
+	}
+	off := c.V.Uint64()
+	if v, ok := s.memory[off]; ok {
+		return v
+	}
+	for k := range s.memory {
+		if k < off+32 && off < k+32 {
+			return Var{fmt.Sprintf("memory@%d", pc)} // partially overlapping words
+		}
+	}
+	return NewConst(0)
+}
+
+func (s *state) mstore(offset, value Expr) {
+	c, ok := offset.(Const)
+	if !ok || !c.V.IsUint64() {
+		s.havoc = true
+		return
+	}
+	off := c.V.Uint64()
+	for k := range s.memory {
+		if k != off && k < off+32 && off < k+32 {
+			delete(s.memory, k)
+		}
+	}
+	s.memory[off] = value
+}
+
+// jumpTarget checks a jump destination, ending the path if it is not a
+// known JUMPDEST
+func (e *explorer) jumpTarget(s *state, dest Expr) (uint64, bool) {
+	c, ok := dest.(Const)
+	if !ok {
+		e.finish(s, Abandoned, "jump to a symbolic destination")
+		return 0, false
+	}
+	if !c.V.IsUint64() || !e.jumpdests[c.V.Uint64()] {
+		e.finish(s, InvalidJump, "jump to "+c.String())
+		return 0, false
+	}
+	return c.V.Uint64(), true
+}
+
+// jumpi follows the feasible branches of a conditional jump, queueing a
+// copy of the state for each
+func (e *explorer) jumpi(s *state, dest, cond Expr) bool {
+	if c, ok := cond.(Const); ok {
+		if c.V.Sign() == 0 {
+			s.pc++
+			return true
+		}
+		target, valid := e.jumpTarget(s, dest)
+		s.pc = target
+		return valid
+	}
+	if s.visits[s.pc]++; s.visits[s.pc] > e.cfg.MaxVisits {
+		e.finish(s, Abandoned, "loop bound reached")
+		return false
+	}
+
+	notTaken := s.clone()
+	notTaken.constraints = append(notTaken.constraints, Constraint{Expr: cond, Negated: true})
+	if result, _ := e.check(notTaken.constraints); result != Unsat {
+		notTaken.pc++
+		e.work = append(e.work, notTaken)
+	}
+	s.constraints = append(s.constraints, Constraint{Expr: cond})
+	if result, _ := e.check(s.constraints); result != Unsat {
+		if target, valid := e.jumpTarget(s, dest); valid {
+			s.pc = target
+			e.work = append(e.work, s)
+		}
+	}
+	return false
+}
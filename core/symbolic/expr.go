@@ -0,0 +1,200 @@
+package symbolic
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var (
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+)
+
+// Expr is a 256-bit symbolic value
+type Expr interface {
+	String() string
+}
+
+// Const is a concrete word
+type Const struct{ V *big.Int }
+
+// Var is an unconstrained word, such as a calldata word or storage slot
+type Var struct{ Name string }
+
+// OpKind is an operation on words
+type OpKind string
+
+const (
+	OpAdd    OpKind = "add"
+	OpSub    OpKind = "sub"
+	OpMul    OpKind = "mul"
+	OpDiv    OpKind = "div"
+	OpMod    OpKind = "mod"
+	OpLt     OpKind = "lt"
+	OpGt     OpKind = "gt"
+	OpEq     OpKind = "eq"
+	OpIsZero OpKind = "iszero"
+	OpAnd    OpKind = "and"
+	OpOr     OpKind = "or"
+	OpXor    OpKind = "xor"
+	OpNot    OpKind = "not"
+	OpShl    OpKind = "shl" // Args: shift, value
+	OpShr    OpKind = "shr" // Args: shift, value
+)
+
+// Op applies an operation to its arguments, in EVM stack order
+type Op struct {
+	Kind OpKind
+	Args []Expr
+}
+
+func (c Const) String() string { return "0x" + c.V.Text(16) }
+func (v Var) String() string   { return v.Name }
+func (o Op) String() string {
+	args := make([]string, len(o.Args))
+	for i, a := range o.Args {
+		args[i] = a.String()
+	}
+	return string(o.Kind) + "(" + strings.Join(args, ", ") + ")"
+}
+
+// NewConst returns the constant x
+func NewConst(x uint64) Const { return Const{new(big.Int).SetUint64(x)} }
+
+// NewOp builds an operation, folding it when every argument is constant
+func NewOp(kind OpKind, args ...Expr) Expr {
+	values := make([]*big.Int, len(args))
+	for i, a := range args {
+		c, ok := a.(Const)
+		if !ok {
+			return Op{Kind: kind, Args: args}
+		}
+		values[i] = c.V
+	}
+	return Const{apply(kind, values)}
+}
+
+// apply computes an operation on concrete words modulo 2^256
+func apply(kind OpKind, x []*big.Int) *big.Int {
+	z := new(big.Int)
+	boolean := func(b bool) *big.Int {
+		if b {
+			return z.SetUint64(1)
+		}
+		return z
+	}
+	switch kind {
+	case OpAdd:
+		z.Add(x[0], x[1])
+	case OpSub:
+		z.Sub(x[0], x[1])
+	case OpMul:
+		z.Mul(x[0], x[1])
+	case OpDiv:
+		if x[1].Sign() != 0 {
+			z.Div(x[0], x[1])
+		}
+	case OpMod:
+		if x[1].Sign() != 0 {
+			z.Mod(x[0], x[1])
+		}
+	case OpLt:
+		return boolean(x[0].Cmp(x[1]) < 0)
+	case OpGt:
+		return boolean(x[0].Cmp(x[1]) > 0)
+	case OpEq:
+		return boolean(x[0].Cmp(x[1]) == 0)
+	case OpIsZero:
+		return boolean(x[0].Sign() == 0)
+	case OpAnd:
+		z.And(x[0], x[1])
+	case OpOr:
+		z.Or(x[0], x[1])
+	case OpXor:
+		z.Xor(x[0], x[1])
+	case OpNot:
+		z.Xor(x[0], tt256m1)
+	case OpShl:
+		if x[0].Cmp(big.NewInt(256)) < 0 {
+			z.Lsh(x[1], uint(x[0].Uint64()))
+		}
+	case OpShr:
+		if x[0].Cmp(big.NewInt(256)) < 0 {
+			z.Rsh(x[1], uint(x[0].Uint64()))
+		}
+	default:
+		panic(fmt.Sprintf("symbolic: unknown operation %s", kind))
+	}
+	return z.Mod(z, tt256)
+}
+
+// Eval evaluates e under model, reporting false if a variable is missing
+func Eval(e Expr, model map[string]*big.Int) (*big.Int, bool) {
+	switch e := e.(type) {
+	case Const:
+		return e.V, true
+	case Var:
+		v, ok := model[e.Name]
+		return v, ok
+	case Op:
+		values := make([]*big.Int, len(e.Args))
+		for i, a := range e.Args {
+			v, ok := Eval(a, model)
+			if !ok {
+				return nil, false
+			}
+			values[i] = v
+		}
+		return apply(e.Kind, values), true
+	}
+	return nil, false
+}
+
+// Vars returns the names of the variables in the expressions, sorted
+func Vars(exprs ...Expr) []string {
+	seen := make(map[string]bool)
+	var walk func(Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case Var:
+			seen[e.Name] = true
+		case Op:
+			for _, a := range e.Args {
+				walk(a)
+			}
+		}
+	}
+	for _, e := range exprs {
+		walk(e)
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Constraint requires an expression to be non-zero, or zero if Negated
+type Constraint struct {
+	Expr    Expr
+	Negated bool
+}
+
+func (c Constraint) String() string {
+	if c.Negated {
+		return c.Expr.String() + " == 0"
+	}
+	return c.Expr.String() + " != 0"
+}
+
+// holds reports whether the constraint holds under model
+func (c Constraint) holds(model map[string]*big.Int) (bool, bool) {
+	v, ok := Eval(c.Expr, model)
+	if !ok {
+		return false, false
+	}
+	return (v.Sign() != 0) != c.Negated, true
+}
@@ -0,0 +1,249 @@
+package symbolic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/big"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Result is the outcome of a satisfiability check
+type Result int
+
+const (
+	Unknown Result = iota
+	Sat
+	Unsat
+)
+
+func (r Result) String() string {
+	switch r {
+	case Sat:
+		return "sat"
+	case Unsat:
+		return "unsat"
+	}
+	return "unknown"
+}
+
+// Solver decides whether constraints can hold together, returning values
+// for their variables when they can
+type Solver interface {
+	Check(constraints []Constraint) (Result, map[string]*big.Int, error)
+}
+
+// maxGuesses bounds the number of assignments GuessSolver evaluates
+const maxGuesses = 1 << 14
+
+// GuessSolver is a dependency-free solver that tries assignments built from
+// the constants appearing in the constraints, such as the selectors a
+// dispatcher compares call data with. It proves unsatisfiability only for
+// constraints without variables and otherwise reports Unknown when no
+// guess works.
+type GuessSolver struct{}
+
+// Check implements Solver
+func (GuessSolver) Check(constraints []Constraint) (Result, map[string]*big.Int, error) {
+	exprs := make([]Expr, len(constraints))
+	for i, c := range constraints {
+		exprs[i] = c.Expr
+	}
+	names := Vars(exprs...)
+	candidates := guessCandidates(exprs)
+
+	model := make(map[string]*big.Int, len(names))
+	tries := 0
+	var search func(i int) bool
+	search = func(i int) bool {
+		if i == len(names) {
+			tries++
+			for _, c := range constraints {
+				if ok, _ := c.holds(model); !ok {
+					return false
+				}
+			}
+			return true
+		}
+		for _, v := range candidates {
+			if tries >= maxGuesses {
+				return false
+			}
+			model[names[i]] = v
+			if search(i + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	if search(0) {
+		return Sat, model, nil
+	}
+	if len(names) == 0 {
+		return Unsat, nil, nil
+	}
+	return Unknown, nil, nil
+}
+
+// guessCandidates returns 0, 1 and each constant with its neighbours and
+// its value shifted into the top four bytes, where selectors live
+func guessCandidates(exprs []Expr) []*big.Int {
+	seen := make(map[string]bool)
+	var candidates []*big.Int
+	add := func(v *big.Int) {
+		v = new(big.Int).Mod(v, tt256)
+		if key := v.String(); !seen[key] {
+			seen[key] = true
+			candidates = append(candidates, v)
+		}
+	}
+	add(big.NewInt(0))
+	add(big.NewInt(1))
+	var walk func(Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case Const:
+			add(e.V)
+			add(new(big.Int).Add(e.V, big.NewInt(1)))
+			add(new(big.Int).Sub(e.V, big.NewInt(1)))
+			add(new(big.Int).Lsh(e.V, 224))
+		case Op:
+			for _, a := range e.Args {
+				walk(a)
+			}
+		}
+	}
+	for _, e := range exprs {
+		walk(e)
+	}
+	return candidates
+}
+
+// SMTSolver checks constraints with an external SMT solver that reads
+// SMT-LIB 2 on its standard input, such as `z3 -in` or `cvc5 --lang smt2`.
+// Words are modelled as 256-bit bit-vectors.
+type SMTSolver struct {
+	Command []string // command line, defaults to z3 -in
+}
+
+var modelValue = regexp.MustCompile(`\(define-fun (v\d+) \(\) \(_ BitVec 256\)\s+#(x[0-9a-fA-F]+|b[01]+)\)`)
+
+// Check implements Solver
+func (s SMTSolver) Check(constraints []Constraint) (Result, map[string]*big.Int, error) {
+	script, names := SMTLib(constraints)
+	command := s.Command
+	if len(command) == 0 {
+		command = []string{"z3", "-in"}
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.Output()
+	if err != nil && len(out) == 0 {
+		return Unknown, nil, fmt.Errorf("running %s: %v", command[0], err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if !scanner.Scan() {
+		return Unknown, nil, fmt.Errorf("%s produced no answer", command[0])
+	}
+	switch strings.TrimSpace(scanner.Text()) {
+	case "unsat":
+		return Unsat, nil, nil
+	case "sat":
+	default:
+		return Unknown, nil, nil
+	}
+	model := make(map[string]*big.Int)
+	for _, m := range modelValue.FindAllStringSubmatch(string(out), -1) {
+		var index int
+		fmt.Sscanf(m[1], "v%d", &index)
+		base := 16
+		if m[2][0] == 'b' {
+			base = 2
+		}
+		v, _ := new(big.Int).SetString(m[2][1:], base)
+		model[names[index]] = v
+	}
+	return Sat, model, nil
+}
+
+// SMTLib renders constraints as an SMT-LIB 2 script over 256-bit
+// bit-vectors that checks them and prints a model. Variables are declared
+// as v0, v1, ...; names maps these indices back to variable names.
+func SMTLib(constraints []Constraint) (script string, names []string) {
+	exprs := make([]Expr, len(constraints))
+	for i, c := range constraints {
+		exprs[i] = c.Expr
+	}
+	names = Vars(exprs...)
+	index := make(map[string]int, len(names))
+	var b strings.Builder
+	b.WriteString("(set-logic QF_BV)\n")
+	for i, name := range names {
+		index[name] = i
+		fmt.Fprintf(&b, "(declare-const v%d (_ BitVec 256)) ; %s\n", i, name)
+	}
+	for _, c := range constraints {
+		op := "distinct"
+		if c.Negated {
+			op = "="
+		}
+		fmt.Fprintf(&b, "(assert (%s %s %s))\n", op, smtExpr(c.Expr, index), smtConst(new(big.Int)))
+	}
+	b.WriteString("(check-sat)\n(get-model)\n")
+	return b.String(), names
+}
+
+func smtConst(v *big.Int) string {
+	return fmt.Sprintf("(_ bv%s 256)", v.String())
+}
+
+func smtExpr(e Expr, index map[string]int) string {
+	switch e := e.(type) {
+	case Const:
+		return smtConst(e.V)
+	case Var:
+		return fmt.Sprintf("v%d", index[e.Name])
+	}
+	op := e.(Op)
+	a := make([]string, len(op.Args))
+	for i, arg := range op.Args {
+		a[i] = smtExpr(arg, index)
+	}
+	zero, one := smtConst(new(big.Int)), smtConst(big.NewInt(1))
+	boolean := func(cond string) string { return fmt.Sprintf("(ite %s %s %s)", cond, one, zero) }
+	switch op.Kind {
+	case OpAdd:
+		return "(bvadd " + a[0] + " " + a[1] + ")"
+	case OpSub:
+		return "(bvsub " + a[0] + " " + a[1] + ")"
+	case OpMul:
+		return "(bvmul " + a[0] + " " + a[1] + ")"
+	case OpDiv:
+		return fmt.Sprintf("(ite (= %s %s) %s (bvudiv %s %s))", a[1], zero, zero, a[0], a[1])
+	case OpMod:
+		return fmt.Sprintf("(ite (= %s %s) %s (bvurem %s %s))", a[1], zero, zero, a[0], a[1])
+	case OpLt:
+		return boolean("(bvult " + a[0] + " " + a[1] + ")")
+	case OpGt:
+		return boolean("(bvugt " + a[0] + " " + a[1] + ")")
+	case OpEq:
+		return boolean("(= " + a[0] + " " + a[1] + ")")
+	case OpIsZero:
+		return boolean("(= " + a[0] + " " + zero + ")")
+	case OpAnd:
+		return "(bvand " + a[0] + " " + a[1] + ")"
+	case OpOr:
+		return "(bvor " + a[0] + " " + a[1] + ")"
+	case OpXor:
+		return "(bvxor " + a[0] + " " + a[1] + ")"
+	case OpNot:
+		return "(bvnot " + a[0] + ")"
+	case OpShl:
+		return "(bvshl " + a[1] + " " + a[0] + ")"
+	default: // OpShr
+		return "(bvlshr " + a[1] + " " + a[0] + ")"
+	}
+}
@@ -0,0 +1,144 @@
+// Package system runs the system calls the protocol makes around the
+// transactions of a block, such as storing the parent beacon root before
+// them (EIP-4788) or collecting execution layer requests after them
+// (EIP-7002, EIP-7251). The calls are data driven: each one declares its
+// phase, target and the forks it is active in, so block processors run
+// them without knowing the EIPs.
+package system
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// Address is the sender of system calls
+var Address = common.Address{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+}
+
+// GasLimit is the gas available to a system call, which does not count
+// against the block gas limit
+const GasLimit = 30_000_000
+
+// System contracts of the default calls
+var (
+	BeaconRootsAddress        = common.Address{0x00, 0x0f, 0x3d, 0xf6, 0xd7, 0x32, 0x80, 0x7e, 0xf1, 0x31, 0x9f, 0xb7, 0xb8, 0xbb, 0x85, 0x22, 0xd0, 0xbe, 0xac, 0x02}
+	HistoryStorageAddress     = common.Address{0x00, 0x00, 0xf9, 0x08, 0x27, 0xf1, 0xc5, 0x3a, 0x10, 0xcb, 0x7a, 0x02, 0x33, 0x5b, 0x17, 0x53, 0x20, 0x00, 0x29, 0x35}
+	WithdrawalQueueAddress    = common.Address{0x00, 0x00, 0x09, 0x61, 0xef, 0x48, 0x0e, 0xb5, 0x5e, 0x80, 0xd1, 0x9a, 0xd8, 0x35, 0x79, 0xa6, 0x4c, 0x00, 0x70, 0x02}
+	ConsolidationQueueAddress = common.Address{0x00, 0x00, 0xbb, 0xdd, 0xc7, 0xce, 0x48, 0x86, 0x42, 0xfb, 0x57, 0x9f, 0x8b, 0x00, 0xf3, 0xa5, 0x90, 0x00, 0x72, 0x51}
+)
+
+// Phase is when a system call is made
+type Phase int
+
+const (
+	PreBlock  Phase = iota // before the first transaction
+	PostBlock              // after the transactions and withdrawals
+)
+
+func (p Phase) String() string {
+	if p == PreBlock {
+		return "pre-block"
+	}
+	return "post-block"
+}
+
+// Block is what system calls know of the block being processed
+type Block struct {
+	Number           *big.Int
+	Time             uint64
+	ParentHash       common.Hash
+	ParentBeaconRoot common.Hash
+}
+
+// Call is a system call
+type Call struct {
+	Name   string
+	Phase  Phase
+	To     common.Address
+	Active func(config *params.ChainConfig, number *big.Int, time uint64) bool
+	Input  func(b *Block) []byte // call data, nil for none
+}
+
+// DefaultCalls returns the system calls of the Ethereum forks: the beacon
+// root (Cancun) and block hash history (Prague) before the transactions,
+// and the withdrawal and consolidation request queues (Prague) after them
+func DefaultCalls() []Call {
+	return []Call{
+		{
+			Name:   "beacon roots",
+			Phase:  PreBlock,
+			To:     BeaconRootsAddress,
+			Active: (*params.ChainConfig).IsCancun,
+			Input:  func(b *Block) []byte { return b.ParentBeaconRoot.Bytes() },
+		},
+		{
+			Name:   "history storage",
+			Phase:  PreBlock,
+			To:     HistoryStorageAddress,
+			Active: (*params.ChainConfig).IsPrague,
+			Input:  func(b *Block) []byte { return b.ParentHash.Bytes() },
+		},
+		{Name: "withdrawal requests", Phase: PostBlock, To: WithdrawalQueueAddress, Active: (*params.ChainConfig).IsPrague},
+		{Name: "consolidation requests", Phase: PostBlock, To: ConsolidationQueueAddress, Active: (*params.ChainConfig).IsPrague},
+	}
+}
+
+// Result is the outcome of a system call
+type Result struct {
+	Call   *Call
+	Output []byte // return data, such as the requests collected by a queue contract
+}
+
+// Processor makes the system calls of blocks
+type Processor struct {
+	chain  *params.ChainConfig
+	config vm.Config
+	calls  []Call
+}
+
+// NewProcessor creates a processor making the given calls, DefaultCalls if
+// nil, in blocks of chain. The calls execute with config.
+func NewProcessor(chain *params.ChainConfig, config vm.Config, calls []Call) *Processor {
+	if calls == nil {
+		calls = DefaultCalls()
+	}
+	return &Processor{chain: chain, config: config, calls: calls}
+}
+
+// Run makes the calls of a phase that are active in the block, in order.
+// Calls to a contract that is not deployed are skipped, as pre-states of
+// tests and dev chains often lack system contracts. A failing call fails
+// the block.
+func (p *Processor) Run(ctx context.Context, phase Phase, statedb vm.StateDB, b *Block) ([]Result, error) {
+	var results []Result
+	for i := range p.calls {
+		call := &p.calls[i]
+		if call.Phase != phase || !call.Active(p.chain, b.Number, b.Time) || len(statedb.GetCode(call.To)) == 0 {
+			continue
+		}
+		blockCtx := &vm.Context{
+			BlockNumber: b.Number,
+			Timestamp:   new(big.Int).SetUint64(b.Time),
+			Sender:      Address,
+			GasLimit:    GasLimit,
+			GasPrice:    new(big.Int),
+		}
+		evm := vm.NewEVM(blockCtx, statedb, p.config)
+		if call.Input != nil {
+			evm.SetInput(call.Input(b))
+		}
+		result := evm.Run(ctx, call.To)
+		if result.Failed() {
+			return nil, fmt.Errorf("%s system call: %w", call.Name, result.Err)
+		}
+		results = append(results, Result{Call: call, Output: result.ReturnData})
+	}
+	return results, nil
+}
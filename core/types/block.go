@@ -0,0 +1,244 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/rlp"
+	"github.com/nutcas3/evm-golang/trie"
+)
+
+// BloomLength and NonceLength are the sizes of the logs bloom and nonce of
+// a header
+const (
+	BloomLength = 256
+	NonceLength = 8
+)
+
+// EmptyUncleHash is the ommers hash of a block without ommers
+var EmptyUncleHash = crypto.Keccak256Hash(rlp.EmptyList)
+
+// Header is an Ethereum block header. The optional fields, nil before the
+// fork introducing them, are encoded in order after the legacy fields. The
+// JSON field names are those of b11r header templates.
+type Header struct {
+	ParentHash  common.Hash    `json:"parentHash"`
+	UncleHash   common.Hash    `json:"ommersHash"`
+	Coinbase    common.Address `json:"miner"`
+	Root        common.Hash    `json:"stateRoot"`
+	TxHash      common.Hash    `json:"transactionsRoot"`
+	ReceiptHash common.Hash    `json:"receiptsRoot"`
+	Bloom       hexutil.Bytes  `json:"logsBloom"` // zero if empty
+	Difficulty  *hexutil.Big   `json:"difficulty"`
+	Number      *hexutil.Big   `json:"number"`
+	GasLimit    hexutil.Uint64 `json:"gasLimit"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Time        hexutil.Uint64 `json:"timestamp"`
+	Extra       hexutil.Bytes  `json:"extraData"`
+	MixDigest   common.Hash    `json:"mixHash"`
+	Nonce       hexutil.Bytes  `json:"nonce"` // zero if empty
+
+	BaseFee          *hexutil.Big    `json:"baseFeePerGas"`         // London
+	WithdrawalsHash  *common.Hash    `json:"withdrawalsRoot"`       // Shanghai
+	BlobGasUsed      *hexutil.Uint64 `json:"blobGasUsed"`           // Cancun
+	ExcessBlobGas    *hexutil.Uint64 `json:"excessBlobGas"`         // Cancun
+	ParentBeaconRoot *common.Hash    `json:"parentBeaconBlockRoot"` // Cancun
+	RequestsHash     *common.Hash    `json:"requestsHash"`          // Prague
+}
+
+// MarshalBinary returns the RLP encoding of the header. It fails if the
+// bloom or nonce has the wrong size, or if an optional field is set while
+// an earlier one is not.
+func (h *Header) MarshalBinary() ([]byte, error) {
+	bloom, err := fixed(h.Bloom, BloomLength, "logs bloom")
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := fixed(h.Nonce, NonceLength, "nonce")
+	if err != nil {
+		return nil, err
+	}
+	var difficulty, number *big.Int
+	if h.Difficulty != nil {
+		difficulty = h.Difficulty.ToInt()
+	}
+	if h.Number != nil {
+		number = h.Number.ToInt()
+	}
+	fields := [][]byte{
+		rlp.EncodeBytes(h.ParentHash[:]),
+		rlp.EncodeBytes(h.UncleHash[:]),
+		rlp.EncodeBytes(h.Coinbase[:]),
+		rlp.EncodeBytes(h.Root[:]),
+		rlp.EncodeBytes(h.TxHash[:]),
+		rlp.EncodeBytes(h.ReceiptHash[:]),
+		rlp.EncodeBytes(bloom),
+		rlp.EncodeBig(difficulty),
+		rlp.EncodeBig(number),
+		rlp.EncodeUint(uint64(h.GasLimit)),
+		rlp.EncodeUint(uint64(h.GasUsed)),
+		rlp.EncodeUint(uint64(h.Time)),
+		rlp.EncodeBytes(h.Extra),
+		rlp.EncodeBytes(h.MixDigest[:]),
+		rlp.EncodeBytes(nonce),
+	}
+	// optional fields in encoding order, nil if not set
+	var optional [6][]byte
+	if h.BaseFee != nil {
+		optional[0] = rlp.EncodeBig(h.BaseFee.ToInt())
+	}
+	if h.WithdrawalsHash != nil {
+		optional[1] = rlp.EncodeBytes(h.WithdrawalsHash[:])
+	}
+	if h.BlobGasUsed != nil {
+		optional[2] = rlp.EncodeUint(uint64(*h.BlobGasUsed))
+	}
+	if h.ExcessBlobGas != nil {
+		optional[3] = rlp.EncodeUint(uint64(*h.ExcessBlobGas))
+	}
+	if h.ParentBeaconRoot != nil {
+		optional[4] = rlp.EncodeBytes(h.ParentBeaconRoot[:])
+	}
+	if h.RequestsHash != nil {
+		optional[5] = rlp.EncodeBytes(h.RequestsHash[:])
+	}
+	for i, enc := range optional {
+		if enc == nil {
+			for j := i + 1; j < len(optional); j++ {
+				if optional[j] != nil {
+					return nil, fmt.Errorf("header has %s but no %s", optionalNames[j], optionalNames[i])
+				}
+			}
+			break
+		}
+		fields = append(fields, enc)
+	}
+	return rlp.EncodeList(fields...), nil
+}
+
+// optionalNames are the JSON names of the optional header fields, in
+// encoding order
+var optionalNames = [...]string{"baseFeePerGas", "withdrawalsRoot", "blobGasUsed", "excessBlobGas", "parentBeaconBlockRoot", "requestsHash"}
+
+// Hash returns the block hash, the Keccak-256 hash of the RLP encoding of
+// the header
+func (h *Header) Hash() common.Hash {
+	enc, _ := h.MarshalBinary()
+	return crypto.Keccak256Hash(enc)
+}
+
+// fixed returns b, or size zero bytes if b is empty, failing if b has
+// another size
+func fixed(b []byte, size int, name string) ([]byte, error) {
+	if len(b) == 0 {
+		return make([]byte, size), nil
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("%s has %d bytes, want %d", name, len(b), size)
+	}
+	return b, nil
+}
+
+// Withdrawal is an EIP-4895 validator withdrawal of Amount gwei
+type Withdrawal struct {
+	Index     hexutil.Uint64 `json:"index"`
+	Validator hexutil.Uint64 `json:"validatorIndex"`
+	Address   common.Address `json:"address"`
+	Amount    hexutil.Uint64 `json:"amount"`
+}
+
+// MarshalBinary returns the RLP encoding of the withdrawal
+func (w *Withdrawal) MarshalBinary() ([]byte, error) {
+	return rlp.EncodeList(
+		rlp.EncodeUint(uint64(w.Index)),
+		rlp.EncodeUint(uint64(w.Validator)),
+		rlp.EncodeBytes(w.Address[:]),
+		rlp.EncodeUint(uint64(w.Amount)),
+	), nil
+}
+
+// DeriveSha returns the root of the trie mapping the RLP encoding of each
+// index to the item at that index, as used for the transactions,
+// receipts and withdrawals of a block
+func DeriveSha(items [][]byte) common.Hash {
+	t := trie.New()
+	for i, item := range items {
+		t.Update(rlp.EncodeUint(uint64(i)), item)
+	}
+	return t.Hash()
+}
+
+// Block is a block as encoded on the wire and in block RLP files
+type Block struct {
+	Header       *Header
+	Transactions [][]byte      // EIP-2718 encodings: an RLP list for legacy transactions, the type byte and payload otherwise
+	Ommers       [][]byte      // RLP-encoded ommer headers
+	Withdrawals  []*Withdrawal // nil before Shanghai
+}
+
+// NewBlock assembles a block from a header template and a body. The
+// ommers hash and the transactions and withdrawals roots are computed from
+// the body when they are zero or nil in the template; a withdrawals root is
+// only computed when withdrawals is not nil. The template is not modified.
+func NewBlock(header *Header, txs, ommers [][]byte, withdrawals []*Withdrawal) (*Block, error) {
+	h := *header
+	if h.UncleHash == (common.Hash{}) {
+		h.UncleHash = crypto.Keccak256Hash(rlp.EncodeList(ommers...))
+	}
+	if h.TxHash == (common.Hash{}) {
+		h.TxHash = DeriveSha(txs)
+	}
+	if withdrawals != nil && h.WithdrawalsHash == nil {
+		items := make([][]byte, len(withdrawals))
+		for i, w := range withdrawals {
+			items[i], _ = w.MarshalBinary()
+		}
+		root := DeriveSha(items)
+		h.WithdrawalsHash = &root
+	}
+	if h.WithdrawalsHash != nil && withdrawals == nil {
+		withdrawals = []*Withdrawal{}
+	}
+	for i, tx := range txs {
+		if len(tx) == 0 {
+			return nil, fmt.Errorf("transaction %d is empty", i)
+		}
+	}
+	return &Block{Header: &h, Transactions: txs, Ommers: ommers, Withdrawals: withdrawals}, nil
+}
+
+// MarshalBinary returns the RLP encoding of the block: the list of its
+// header, transactions, ommers and, from Shanghai, withdrawals
+func (b *Block) MarshalBinary() ([]byte, error) {
+	if b.Header == nil {
+		return nil, errors.New("block without header")
+	}
+	header, err := b.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	txs := make([][]byte, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		if tx[0] >= 0xc0 {
+			txs[i] = tx // legacy transactions are lists
+		} else {
+			txs[i] = rlp.EncodeBytes(tx)
+		}
+	}
+	fields := [][]byte{header, rlp.EncodeList(txs...), rlp.EncodeList(b.Ommers...)}
+	if b.Withdrawals != nil {
+		items := make([][]byte, len(b.Withdrawals))
+		for i, w := range b.Withdrawals {
+			items[i], _ = w.MarshalBinary()
+		}
+		fields = append(fields, rlp.EncodeList(items...))
+	}
+	return rlp.EncodeList(fields...), nil
+}
+
+// Hash returns the hash of the block's header
+func (b *Block) Hash() common.Hash { return b.Header.Hash() }
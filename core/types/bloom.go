@@ -0,0 +1,57 @@
+package types
+
+import (
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// Bloom is the 2048-bit logs bloom of a block or receipt. Each log address
+// and topic sets three bits chosen by its Keccak-256 hash, so a bloom
+// without one of those bits proves the value was not logged.
+type Bloom [BloomLength]byte
+
+// Add sets the three bits of data
+func (b *Bloom) Add(data []byte) {
+	for _, i := range bloomBits(data) {
+		b[BloomLength-1-i/8] |= 1 << (i % 8)
+	}
+}
+
+// Test reports whether the three bits of data are set. A false positive is
+// possible, a false negative is not.
+func (b *Bloom) Test(data []byte) bool {
+	for _, i := range bloomBits(data) {
+		if b[BloomLength-1-i/8]&(1<<(i%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalText encodes the bloom as 0x-prefixed hex
+func (b Bloom) MarshalText() ([]byte, error) {
+	return hexutil.Bytes(b[:]).MarshalText()
+}
+
+// LogsBloom returns the bloom of the addresses and topics of logs
+func LogsBloom(logs []*Log) Bloom {
+	var b Bloom
+	for _, l := range logs {
+		b.Add(l.Address.Bytes())
+		for _, topic := range l.Topics {
+			b.Add(topic.Bytes())
+		}
+	}
+	return b
+}
+
+// bloomBits returns the indices of the bits data sets: the low 11 bits of
+// the first three pairs of bytes of its hash
+func bloomBits(data []byte) [3]uint {
+	hash := crypto.Keccak256(data)
+	var bits [3]uint
+	for i := range bits {
+		bits[i] = (uint(hash[2*i])<<8 | uint(hash[2*i+1])) & 2047
+	}
+	return bits
+}
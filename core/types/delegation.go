@@ -0,0 +1,26 @@
+package types
+
+import (
+	"bytes"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// DelegationPrefix starts the code of an EOA that delegated its code to a
+// contract with an EIP-7702 authorization
+var DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// ParseDelegation returns the address a delegation designator points to,
+// false if code is not a designator
+func ParseDelegation(code []byte) (common.Address, bool) {
+	if len(code) != len(DelegationPrefix)+common.AddressLength || !bytes.HasPrefix(code, DelegationPrefix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(code[len(DelegationPrefix):]), true
+}
+
+// AddressToDelegation returns the designator delegating an EOA's code to
+// addr, the code an applied authorization installs
+func AddressToDelegation(addr common.Address) []byte {
+	return append(append([]byte(nil), DelegationPrefix...), addr[:]...)
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// DepositTxType is the EIP-2718 type of OP Stack deposit transactions
+const DepositTxType = 0x7e
+
+// L1InfoDepositorAddress is the system sender of the L1 attributes deposit
+// that starts every OP Stack block
+var L1InfoDepositorAddress = common.Address{
+	0xde, 0xad, 0xde, 0xad, 0xde, 0xad, 0xde, 0xad, 0xde, 0xad,
+	0xde, 0xad, 0xde, 0xad, 0xde, 0xad, 0xde, 0xad, 0x00, 0x01,
+}
+
+// DepositTx is an OP Stack deposit transaction: a message derived from L1
+// that the L2 executes without a signature, gas purchase or fee. Mint is
+// credited to From before execution and kept even if execution fails.
+type DepositTx struct {
+	SourceHash          common.Hash // uniquely identifies the origin of the deposit on L1
+	From                common.Address
+	To                  *common.Address // nil for contract creation
+	Mint                *big.Int        // ETH minted on L2, may be nil
+	Value               *big.Int        // may be nil
+	Gas                 uint64
+	IsSystemTransaction bool // system deposits do not use block gas
+	Data                []byte
+}
+
+// MarshalBinary returns the typed encoding of the deposit: its type byte
+// followed by the RLP list of its fields
+func (tx *DepositTx) MarshalBinary() ([]byte, error) {
+	to := rlp.EmptyString
+	if tx.To != nil {
+		to = rlp.EncodeBytes(tx.To[:])
+	}
+	system := rlp.EmptyString
+	if tx.IsSystemTransaction {
+		system = rlp.EncodeUint(1)
+	}
+	payload := rlp.EncodeList(
+		rlp.EncodeBytes(tx.SourceHash[:]),
+		rlp.EncodeBytes(tx.From[:]),
+		to,
+		rlp.EncodeBig(tx.Mint),
+		rlp.EncodeBig(tx.Value),
+		rlp.EncodeUint(tx.Gas),
+		system,
+		rlp.EncodeBytes(tx.Data),
+	)
+	return append([]byte{DepositTxType}, payload...), nil
+}
+
+// Hash returns the transaction hash, the Keccak-256 hash of its typed
+// encoding
+func (tx *DepositTx) Hash() common.Hash {
+	enc, _ := tx.MarshalBinary()
+	return crypto.Keccak256Hash(enc)
+}
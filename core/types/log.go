@@ -0,0 +1,14 @@
+// Package types contains the data types shared by the EVM and its callers.
+package types
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+// Log represents an event log
+type Log struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/params"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// Sender recovery errors
+var (
+	// ErrInvalidChainID is returned for a replay-protected signature bound
+	// to a chain other than the one it is recovered on
+	ErrInvalidChainID = errors.New("invalid chain id for signer")
+	// ErrUnprotectedTx is returned for a signature without a chain id
+	// where EIP-155 requires one
+	ErrUnprotectedTx = errors.New("transaction not replay-protected (EIP-155)")
+	// ErrInvalidSig is returned for signature values no key can produce
+	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
+)
+
+// LegacyTx is a transaction of the original, untyped format. V holds the
+// recovery id: 27 or 28 for signatures made without a chain id, and
+// chainID*2+35 or chainID*2+36 for EIP-155 signatures that bind one.
+type LegacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address // nil for contract creation
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// DecodeLegacyTx decodes the RLP encoding of a signed legacy transaction
+func DecodeLegacyTx(b []byte) (*LegacyTx, error) {
+	items, err := rlp.SplitList(b)
+	if err != nil {
+		return nil, fmt.Errorf("legacy transaction: %w", err)
+	}
+	if len(items) != 9 {
+		return nil, fmt.Errorf("legacy transaction: %d fields, want 9", len(items))
+	}
+	var fields [9][]byte
+	for i, item := range items {
+		isList, content, _, err := rlp.Split(item)
+		if err != nil {
+			return nil, fmt.Errorf("legacy transaction: %w", err)
+		}
+		if isList {
+			return nil, fmt.Errorf("legacy transaction: field %d is a list", i)
+		}
+		fields[i] = content
+	}
+	if len(fields[0]) > 8 || len(fields[2]) > 8 {
+		return nil, errors.New("legacy transaction: nonce or gas exceeds 64 bits")
+	}
+	tx := &LegacyTx{
+		Nonce:    new(big.Int).SetBytes(fields[0]).Uint64(),
+		GasPrice: new(big.Int).SetBytes(fields[1]),
+		Gas:      new(big.Int).SetBytes(fields[2]).Uint64(),
+		Value:    new(big.Int).SetBytes(fields[4]),
+		Data:     fields[5],
+		V:        new(big.Int).SetBytes(fields[6]),
+		R:        new(big.Int).SetBytes(fields[7]),
+		S:        new(big.Int).SetBytes(fields[8]),
+	}
+	switch len(fields[3]) {
+	case 0:
+	case common.AddressLength:
+		to := common.BytesToAddress(fields[3])
+		tx.To = &to
+	default:
+		return nil, fmt.Errorf("legacy transaction: recipient of %d bytes", len(fields[3]))
+	}
+	return tx, nil
+}
+
+// fields returns the encoded fields of the transaction preceding the
+// signature
+func (tx *LegacyTx) fields() [][]byte {
+	to := rlp.EmptyString
+	if tx.To != nil {
+		to = rlp.EncodeBytes(tx.To[:])
+	}
+	return [][]byte{
+		rlp.EncodeUint(tx.Nonce),
+		rlp.EncodeBig(tx.GasPrice),
+		rlp.EncodeUint(tx.Gas),
+		to,
+		rlp.EncodeBig(tx.Value),
+		rlp.EncodeBytes(tx.Data),
+	}
+}
+
+// MarshalBinary returns the RLP encoding of the signed transaction
+func (tx *LegacyTx) MarshalBinary() ([]byte, error) {
+	return rlp.EncodeList(append(tx.fields(), rlp.EncodeBig(tx.V), rlp.EncodeBig(tx.R), rlp.EncodeBig(tx.S))...), nil
+}
+
+// Hash returns the transaction hash, the Keccak-256 hash of its encoding
+func (tx *LegacyTx) Hash() common.Hash {
+	enc, _ := tx.MarshalBinary()
+	return crypto.Keccak256Hash(enc)
+}
+
+// SigHash returns the hash a signature covers. EIP-155 signatures append
+// the chain id and two empty values to the fields; a nil chainID gives the
+// hash of signatures made without one.
+func (tx *LegacyTx) SigHash(chainID *big.Int) common.Hash {
+	fields := tx.fields()
+	if chainID != nil {
+		fields = append(fields, rlp.EncodeBig(chainID), rlp.EmptyString, rlp.EmptyString)
+	}
+	return crypto.Keccak256Hash(rlp.EncodeList(fields...))
+}
+
+// Protected reports whether the signature binds a chain id, that is
+// whether V is neither 27 nor 28
+func (tx *LegacyTx) Protected() bool {
+	return tx.V != nil && !(tx.V.BitLen() <= 8 && (tx.V.Uint64() == 27 || tx.V.Uint64() == 28))
+}
+
+// ChainID returns the chain id an EIP-155 signature is bound to, nil for
+// signatures without one
+func (tx *LegacyTx) ChainID() *big.Int {
+	if !tx.Protected() {
+		return nil
+	}
+	id := new(big.Int).Sub(tx.V, big.NewInt(35))
+	if id.Sign() < 0 {
+		return nil
+	}
+	return id.Rsh(id, 1)
+}
+
+// SignLegacyTx signs tx with key. A non-nil chainID gives an EIP-155
+// signature bound to that chain; with a nil one the signature is valid on
+// any chain.
+func SignLegacyTx(tx *LegacyTx, chainID *big.Int, key *crypto.PrivateKey) error {
+	hash := tx.SigHash(chainID)
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return err
+	}
+	tx.R, tx.S = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if chainID == nil {
+		tx.V = big.NewInt(27 + int64(sig[64]))
+	} else {
+		tx.V = new(big.Int).Add(new(big.Int).Lsh(chainID, 1), big.NewInt(35+int64(sig[64])))
+	}
+	return nil
+}
+
+// Sender recovers the address that signed tx, as the chain described by
+// config validates it at the given block. From SpuriousDragon on,
+// signatures must bind the chain id of config (EIP-155), unless
+// config.AllowUnprotectedTxs accepts signatures without one; before it,
+// only signatures without a chain id exist. A signature bound to another
+// chain fails with ErrInvalidChainID.
+func Sender(config *params.ChainConfig, number *big.Int, tx *LegacyTx) (common.Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return common.Address{}, ErrInvalidSig
+	}
+	var (
+		chainID *big.Int
+		recid   uint64
+	)
+	if tx.Protected() {
+		if !config.IsEIP155(number) {
+			return common.Address{}, fmt.Errorf("%w: v %s before EIP-155", ErrInvalidSig, tx.V)
+		}
+		chainID = tx.ChainID()
+		if chainID == nil {
+			return common.Address{}, fmt.Errorf("%w: v %s", ErrInvalidSig, tx.V)
+		}
+		if config.ChainID == nil || chainID.Cmp(config.ChainID) != 0 {
+			return common.Address{}, fmt.Errorf("%w: have %s, want %s", ErrInvalidChainID, chainID, config.ChainID)
+		}
+		recid = new(big.Int).Sub(tx.V, new(big.Int).Lsh(chainID, 1)).Uint64() - 35
+	} else {
+		if config.IsEIP155(number) && !config.AllowUnprotectedTxs {
+			return common.Address{}, ErrUnprotectedTx
+		}
+		recid = tx.V.Uint64() - 27
+	}
+	if !crypto.ValidateSignatureValues(tx.R, tx.S, config.IsHomestead(number)) {
+		return common.Address{}, ErrInvalidSig
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	tx.R.FillBytes(sig[:32])
+	tx.S.FillBytes(sig[32:64])
+	sig[64] = byte(recid)
+	hash := tx.SigHash(chainID)
+	addr, err := crypto.Ecrecover(hash[:], sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %w", ErrInvalidSig, err)
+	}
+	return addr, nil
+}
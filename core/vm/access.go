@@ -0,0 +1,39 @@
+package vm
+
+import "github.com/nutcas3/evm-golang/common"
+
+// AccessSet is the set of warm accounts and storage slots of an execution
+// (EIP-2929). An execution starts with an empty set unless given one with
+// SetAccessSet, which lets a series of executions share what the first
+// ones warmed, as the calls of a multicall do within one transaction.
+type AccessSet struct {
+	accounts map[common.Address]bool
+	slots    map[storageKey]bool
+}
+
+// NewAccessSet creates an empty access set
+func NewAccessSet() *AccessSet {
+	return &AccessSet{accounts: make(map[common.Address]bool), slots: make(map[storageKey]bool)}
+}
+
+// AddAddress marks an account warm
+func (a *AccessSet) AddAddress(addr common.Address) { a.accounts[addr] = true }
+
+// AddSlot marks a storage slot warm
+func (a *AccessSet) AddSlot(addr common.Address, slot common.Hash) {
+	a.slots[storageKey{addr, slot}] = true
+}
+
+// ContainsAddress reports whether an account is warm
+func (a *AccessSet) ContainsAddress(addr common.Address) bool { return a.accounts[addr] }
+
+// ContainsSlot reports whether a storage slot is warm
+func (a *AccessSet) ContainsSlot(addr common.Address, slot common.Hash) bool {
+	return a.slots[storageKey{addr, slot}]
+}
+
+// SetAccessSet makes the execution start with, and add to, the warm
+// accounts and slots of set. Call it before Run.
+func (evm *EVM) SetAccessSet(set *AccessSet) {
+	evm.accessed, evm.warmSlots = set.accounts, set.slots
+}
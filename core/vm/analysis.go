@@ -0,0 +1,51 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// bitvec marks the positions of code holding JUMPDEST instructions, one
+// bit per byte of code
+type bitvec []byte
+
+func (b bitvec) set(pos uint64) { b[pos/8] |= 1 << (pos % 8) }
+
+func (b bitvec) isSet(pos uint64) bool { return b[pos/8]&(1<<(pos%8)) != 0 }
+
+// analyzeJumpdests returns the jump destinations of code: the positions of
+// its JUMPDEST instructions, skipping the immediates of PUSH instructions,
+// which hold data even where a byte is 0x5b
+func analyzeJumpdests(code []byte) bitvec {
+	dests := make(bitvec, len(code)/8+1)
+	for pc := uint64(0); pc < uint64(len(code)); pc++ {
+		op := OpCode(code[pc])
+		if op == 0x5b {
+			dests.set(pc)
+		}
+		pc += uint64(op.PushSize())
+	}
+	return dests
+}
+
+// validJumpdest reports whether dest is a JUMPDEST instruction of the
+// executing code. The analysis is done once per contract and cached by code
+// hash for the rest of the EVM's lifetime, Reset included.
+func (evm *EVM) validJumpdest(dest *big.Int) bool {
+	contract := evm.contract
+	if !dest.IsUint64() || dest.Uint64() >= uint64(len(contract.Code)) {
+		return false
+	}
+	if contract.jumpdests == nil {
+		if contract.CodeHash == (common.Hash{}) {
+			contract.jumpdests = analyzeJumpdests(contract.Code)
+		} else if dests, ok := evm.jumpdests[contract.CodeHash]; ok {
+			contract.jumpdests = dests
+		} else {
+			contract.jumpdests = analyzeJumpdests(contract.Code)
+			evm.jumpdests[contract.CodeHash] = contract.jumpdests
+		}
+	}
+	return contract.jumpdests.isSet(dest.Uint64())
+}
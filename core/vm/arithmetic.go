@@ -0,0 +1,135 @@
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common/word"
+)
+
+// Gas charged by EXP per byte of the exponent, before and from Spurious
+// Dragon (EIP-160)
+const (
+	ExpByteGasFrontier = 10
+	ExpByteGas         = 50
+)
+
+// The arithmetic instructions below take their operands from
+// binaryOperation in Yellow Paper order: a is the word on top of the
+// stack, b the one below it. Results are reduced modulo 2^256 by
+// binaryOperation, and the signed instructions read their operands as
+// two's complement.
+
+func sdiv(z, a, b *big.Int) *big.Int {
+	x, y := s256(a), s256(b)
+	if y.Sign() == 0 {
+		return z
+	}
+	// Quo truncates toward zero, as SDIV does; -2^255 / -1 wraps back to
+	// -2^255
+	return z.Quo(x, y)
+}
+
+func mod(z, a, b *big.Int) *big.Int {
+	x, y := u256(a), u256(b)
+	if y.Sign() == 0 {
+		return z
+	}
+	return z.Mod(x, y)
+}
+
+func smod(z, a, b *big.Int) *big.Int {
+	x, y := s256(a), s256(b)
+	if y.Sign() == 0 {
+		return z
+	}
+	// Rem keeps the sign of the dividend, as SMOD does
+	return z.Rem(x, y)
+}
+
+// signExtend extends the sign bit of byte a, counted from the least
+// significant, of the word b below it
+func signExtend(z, a, b *big.Int) *big.Int {
+	x := u256(b)
+	if a.Sign() < 0 || !a.IsUint64() || a.Uint64() >= 31 {
+		return z.Set(x)
+	}
+	bit := uint(a.Uint64()*8 + 7)
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+	if x.Bit(int(bit)) == 1 {
+		return z.Or(x, new(big.Int).Xor(word.MaxUint256, mask))
+	}
+	return z.And(x, mask)
+}
+
+func addmod(z, a, b, n *big.Int) *big.Int {
+	m := u256(n)
+	if m.Sign() == 0 {
+		return z
+	}
+	// the sum is taken before the reduction, so it may exceed 2^256
+	z.Add(u256(a), u256(b))
+	return z.Mod(z, m)
+}
+
+func mulmod(z, a, b, n *big.Int) *big.Int {
+	m := u256(n)
+	if m.Sign() == 0 {
+		return z
+	}
+	z.Mul(u256(a), u256(b))
+	return z.Mod(z, m)
+}
+
+// ternaryOperation applies op to the three topmost stack words, the top
+// one first, reducing the result modulo 2^256 as binaryOperation does for
+// two
+func (evm *EVM) ternaryOperation(op func(z, a, b, c *big.Int) *big.Int, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	var operands [3]*big.Int
+	for i := range operands {
+		v, err := evm.stack.pop()
+		if err != nil {
+			return err
+		}
+		value, ok := v.Value.(*big.Int)
+		if !ok {
+			return errors.New("ternaryOperation assertion failed")
+		}
+		operands[i] = value
+	}
+	result := word.U256(op(evm.intPool.get(), operands[0], operands[1], operands[2]))
+	evm.intPool.put(operands[:]...)
+	return evm.stack.push(Value{Type: Uint256, Value: result})
+}
+
+// exp raises the top word to the power of the one below it, modulo
+// 2^256, charging for every byte of the exponent
+func (evm *EVM) exp(gasCost uint64) error {
+	if len(evm.stack.data) < 2 {
+		return ErrStackUnderflow
+	}
+	exponent, ok := evm.stack.data[len(evm.stack.data)-2].Value.(*big.Int)
+	if !ok {
+		return errors.New("exp assertion failed")
+	}
+	perByte := uint64(ExpByteGas)
+	if !evm.isSpuriousDragon() {
+		perByte = ExpByteGasFrontier
+	}
+	bytes := uint64(u256(exponent).BitLen()+7) / 8
+	return evm.binaryOperation(func(z, a, b *big.Int) *big.Int {
+		return z.Exp(u256(a), u256(b), twoTo256)
+	}, gasCost+perByte*bytes)
+}
+
+// twoTo256 is the modulus of word arithmetic
+var twoTo256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// u256 returns a copy of the word x reduced modulo 2^256
+func u256(x *big.Int) *big.Int { return word.U256(new(big.Int).Set(x)) }
+
+// s256 returns the word x as a two's complement signed integer
+func s256(x *big.Int) *big.Int { return word.S256(new(big.Int), u256(x)) }
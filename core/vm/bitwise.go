@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common/word"
+)
+
+// BYTE and the shifts take their operands in Yellow Paper order, like the
+// arithmetic instructions (EIP-145): the byte number or shift amount is the
+// word on top of the stack, applied to the word below it.
+
+func and(z, a, b *big.Int) *big.Int { return z.And(u256(a), u256(b)) }
+
+func or(z, a, b *big.Int) *big.Int { return z.Or(u256(a), u256(b)) }
+
+func xor(z, a, b *big.Int) *big.Int { return z.Xor(u256(a), u256(b)) }
+
+func not(z, a *big.Int) *big.Int { return z.Xor(u256(a), word.MaxUint256) }
+
+// byteOp returns byte a of the word b below it, counted from the most
+// significant, zero past the 32nd
+func byteOp(z, a, b *big.Int) *big.Int {
+	if a.Sign() < 0 || !a.IsUint64() || a.Uint64() >= word.Size {
+		return z
+	}
+	return z.SetUint64(uint64(word.Bytes32(b)[a.Uint64()]))
+}
+
+// shl shifts the word b left by a bits, dropping the bits shifted past 256
+func shl(z, a, b *big.Int) *big.Int {
+	if a.Sign() < 0 || !a.IsUint64() || a.Uint64() >= 256 {
+		return z
+	}
+	return z.Lsh(u256(b), uint(a.Uint64()))
+}
+
+// shr shifts the word b right by a bits, filling with zeros
+func shr(z, a, b *big.Int) *big.Int {
+	if a.Sign() < 0 || !a.IsUint64() || a.Uint64() >= 256 {
+		return z
+	}
+	return z.Rsh(u256(b), uint(a.Uint64()))
+}
+
+// sar shifts the word b right by a bits, filling with its sign bit
+func sar(z, a, b *big.Int) *big.Int {
+	x := s256(b)
+	if a.Sign() < 0 || !a.IsUint64() || a.Uint64() >= 256 {
+		if x.Sign() < 0 {
+			return z.SetInt64(-1)
+		}
+		return z
+	}
+	// Rsh of a negative big.Int rounds toward negative infinity, as an
+	// arithmetic shift does
+	return z.Rsh(x, uint(a.Uint64()))
+}
+
+// unaryOperation applies op to the topmost stack word, reducing the
+// result modulo 2^256 as binaryOperation does
+func (evm *EVM) unaryOperation(op func(z, a *big.Int) *big.Int, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	a, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	aValue, ok := a.Value.(*big.Int)
+	if !ok {
+		return errors.New("unaryOperation assertion failed")
+	}
+	result := word.U256(op(evm.intPool.get(), aValue))
+	evm.intPool.put(aValue)
+	return evm.stack.push(Value{Type: Uint256, Value: result})
+}
@@ -0,0 +1,60 @@
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// BlockHashWindow is the number of most recent blocks BLOCKHASH answers
+// for; older blocks, and the executing block itself, hash to zero
+const BlockHashWindow = 256
+
+// BlockHashFunc returns the hash of the block with the given number. It is
+// only asked for the BlockHashWindow blocks preceding the executing one.
+type BlockHashFunc func(number uint64) common.Hash
+
+// blockHash replaces the block number on top of the stack with the hash of
+// that block, zero outside the window BLOCKHASH answers for or without a
+// Context.GetHash
+func (evm *EVM) blockHash(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	numberWord, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	number, ok := numberWord.Value.(*big.Int)
+	if !ok {
+		return errors.New("blockHash assertion failed")
+	}
+	current := evm.blockNumber()
+	lowest := new(big.Int).Sub(current, big.NewInt(BlockHashWindow))
+	inWindow := number.Cmp(current) < 0 && number.Cmp(lowest) >= 0 && number.IsUint64()
+	if !inWindow || evm.context.GetHash == nil {
+		return evm.stack.push(Value{Type: Uint256, Value: number.SetUint64(0)})
+	}
+	hash := evm.context.GetHash(number.Uint64())
+	return evm.stack.push(Value{Type: Uint256, Value: number.SetBytes(hash[:])})
+}
+
+// chainID returns the chain id CHAINID pushes: that of the context, or of
+// the chain config if the context has none
+func (evm *EVM) chainID() *big.Int {
+	if evm.context.ChainID == nil && evm.chainConfig != nil {
+		return evm.chainConfig.ChainID
+	}
+	return evm.context.ChainID
+}
+
+// selfBalance pushes the balance of the executing contract, zero on states
+// without balances
+func (evm *EVM) selfBalance(gasCost uint64) error {
+	var balance *big.Int
+	if db, ok := evm.statedb.(BalanceStateDB); ok {
+		balance = db.GetBalance(evm.contract.Address)
+	}
+	return evm.pushWord(balance, gasCost)
+}
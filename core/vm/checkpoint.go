@@ -0,0 +1,182 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/types"
+)
+
+// ErrSuspended is reported by Run and Resume when execution was suspended
+// before the contract halted. The machine state can then be captured with
+// Checkpoint.
+var ErrSuspended = errors.New("execution suspended")
+
+// Suspend asks a running EVM to stop at the next instruction boundary of the
+// outermost frame. Nested calls always run to completion first, so a
+// checkpoint never has to capture more than one frame. Safe for concurrent
+// use.
+func (evm *EVM) Suspend() {
+	evm.suspended.Store(true)
+}
+
+// SuspendAfter makes the EVM suspend once the outermost frame has executed
+// the given number of instructions, counted across resumes. Zero disables
+// the limit.
+func (evm *EVM) SuspendAfter(steps uint64) {
+	evm.stepLimit = steps
+}
+
+func (evm *EVM) shouldSuspend() bool {
+	if evm.suspended.Load() {
+		return true
+	}
+	return evm.stepLimit > 0 && evm.steps >= evm.stepLimit
+}
+
+// Checkpoint is the serializable machine state of a suspended execution.
+// World state is not included; persist it alongside the checkpoint, e.g. with
+// a state dump.
+type Checkpoint struct {
+	Context    *Context       `json:"context"`
+	Address    common.Address `json:"address"`
+	Input      hexutil.Bytes  `json:"input,omitempty"`
+	Value      *hexutil.Big   `json:"value,omitempty"`
+	PC         hexutil.Uint64 `json:"pc"`
+	Gas        hexutil.Uint64 `json:"gas"`
+	InitialGas hexutil.Uint64 `json:"initialGas"`
+	Steps      hexutil.Uint64 `json:"steps"`
+	Stack      []Value        `json:"stack"`
+	Memory     hexutil.Bytes  `json:"memory"`
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []*types.Log   `json:"logs"`
+
+	// the refund counter, the warm accounts and slots (EIP-2929) and the
+	// values of slots before their first write, which price SSTORE and
+	// account accesses
+	Refund    hexutil.Uint64                                 `json:"refund"`
+	Accessed  []common.Address                               `json:"accessed,omitempty"`
+	WarmSlots map[common.Address][]common.Hash               `json:"warmSlots,omitempty"`
+	Originals map[common.Address]map[common.Hash]common.Hash `json:"originals,omitempty"`
+
+	// the contracts created and the accounts self-destructed so far, which
+	// EIP-6780 and the deletions at the end of the execution depend on
+	Created    []common.Address `json:"created,omitempty"`
+	Destructed []common.Address `json:"destructed,omitempty"`
+}
+
+// Checkpoint captures the machine state of the outermost frame. It can only
+// be resumed after Run or Resume reported ErrSuspended; after a halt it
+// holds the final stack and memory of the frame.
+func (evm *EVM) Checkpoint() *Checkpoint {
+	stack := make([]Value, len(evm.stack.data))
+	for i, v := range evm.stack.data {
+		stack[i] = v
+		if word, ok := v.Value.(*big.Int); ok {
+			stack[i].Value = new(big.Int).Set(word)
+		}
+	}
+	return &Checkpoint{
+		Context:    evm.context,
+		Address:    evm.contract.Address,
+		Input:      append([]byte(nil), evm.input...),
+		Value:      (*hexutil.Big)(new(big.Int).Set(evm.value)),
+		PC:         hexutil.Uint64(evm.pc),
+		Gas:        hexutil.Uint64(evm.gas),
+		InitialGas: hexutil.Uint64(evm.initialGas),
+		Steps:      hexutil.Uint64(evm.steps),
+		Stack:      stack,
+		Memory:     append([]byte(nil), evm.memory.data...),
+		ReturnData: append([]byte(nil), evm.returnData...),
+		Logs:       append([]*types.Log(nil), evm.logs...),
+		Refund:     hexutil.Uint64(*evm.refund),
+		Accessed:   sortedAddresses(evm.accessed),
+		WarmSlots:  warmSlotsOf(evm.warmSlots),
+		Originals:  originalsOf(evm.originals),
+		Created:    sortedAddresses(evm.created),
+		Destructed: sortedAddresses(evm.destructed),
+	}
+}
+
+// NewEVMFromCheckpoint recreates a suspended EVM. The state must match the
+// world state at the time the checkpoint was taken.
+func NewEVMFromCheckpoint(cp *Checkpoint, statedb StateDB, config Config) *EVM {
+	evm := NewEVM(cp.Context, statedb, config)
+	evm.contract = evm.contractAt(cp.Address)
+	evm.input = cp.Input
+	evm.SetValue(cp.Value.ToInt())
+	evm.pc = uint64(cp.PC)
+	evm.gas = uint64(cp.Gas)
+	evm.initialGas = uint64(cp.InitialGas)
+	evm.steps = uint64(cp.Steps)
+	evm.stack.data = append(evm.stack.data, cp.Stack...)
+	evm.memory.data = append([]byte(nil), cp.Memory...)
+	evm.returnData = cp.ReturnData
+	evm.logs = cp.Logs
+	*evm.refund = uint64(cp.Refund)
+	for _, addr := range cp.Accessed {
+		evm.accessed[addr] = true
+	}
+	for addr, slots := range cp.WarmSlots {
+		for _, slot := range slots {
+			evm.warmSlots[storageKey{addr, slot}] = true
+		}
+	}
+	for _, addr := range cp.Created {
+		evm.created[addr] = true
+	}
+	for _, addr := range cp.Destructed {
+		evm.destructed[addr] = true
+	}
+	for addr, slots := range cp.Originals {
+		for slot, value := range slots {
+			evm.originals[storageKey{addr, slot}] = value
+		}
+	}
+	return evm
+}
+
+// sortedAddresses returns the addresses of a set in ascending order
+func sortedAddresses(set map[common.Address]bool) []common.Address {
+	addrs := make([]common.Address, 0, len(set))
+	for addr := range set {
+		addrs = append(addrs, addr)
+	}
+	slices.SortFunc(addrs, common.Address.Cmp)
+	return addrs
+}
+
+// warmSlotsOf groups warm slots by account, in ascending order
+func warmSlotsOf(set map[storageKey]bool) map[common.Address][]common.Hash {
+	slots := make(map[common.Address][]common.Hash)
+	for key := range set {
+		slots[key.addr] = append(slots[key.addr], key.slot)
+	}
+	for _, s := range slots {
+		slices.SortFunc(s, common.Hash.Cmp)
+	}
+	return slots
+}
+
+// originalsOf groups the original values of slots by account
+func originalsOf(originals map[storageKey]common.Hash) map[common.Address]map[common.Hash]common.Hash {
+	values := make(map[common.Address]map[common.Hash]common.Hash)
+	for key, value := range originals {
+		if values[key.addr] == nil {
+			values[key.addr] = make(map[common.Hash]common.Hash)
+		}
+		values[key.addr][key.slot] = value
+	}
+	return values
+}
+
+// Resume continues a suspended execution from its program counter and
+// ends it as Run does
+func (evm *EVM) Resume(ctx context.Context) *ExecutionResult {
+	evm.suspended.Store(false)
+	return evm.finish(evm.run(ctx, SpanTransaction))
+}
@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+var checkpointAddress = common.Address{19: 0xc0}
+
+// checkpointTests are programs suspended after the given number of
+// instructions, which must end as they do when run straight through
+var checkpointTests = []struct {
+	name  string
+	code  []byte
+	steps uint64
+}{
+	{
+		name:  "second write to a slot",
+		code:  []byte{0x60, 0x01, 0x60, 0x00, 0x55, 0x60, 0x02, 0x60, 0x00, 0x55}, // PUSH1 1 PUSH1 0 SSTORE PUSH1 2 PUSH1 0 SSTORE
+		steps: 3,
+	},
+	{
+		name:  "slot restored to its original value",
+		code:  []byte{0x60, 0x01, 0x60, 0x00, 0x55, 0x60, 0x00, 0x60, 0x00, 0x55}, // PUSH1 1 PUSH1 0 SSTORE PUSH1 0 PUSH1 0 SSTORE
+		steps: 3,
+	},
+	{
+		name:  "write to a slot warmed by a read",
+		code:  []byte{0x60, 0x00, 0x54, 0x50, 0x60, 0x01, 0x60, 0x00, 0x55}, // PUSH1 0 SLOAD POP PUSH1 1 PUSH1 0 SSTORE
+		steps: 3,
+	},
+}
+
+// runCheckpoint runs code at checkpointAddress in a fresh state, suspending
+// after steps instructions and resuming from a JSON copy of the checkpoint
+// unless steps is zero
+func runCheckpoint(t *testing.T, code []byte, steps uint64) (*ExecutionResult, *state.StateDB) {
+	t.Helper()
+	statedb := state.New()
+	statedb.SetCode(checkpointAddress, code)
+	blockCtx := &Context{BlockNumber: big.NewInt(1), Timestamp: big.NewInt(1), GasLimit: 1_000_000, GasPrice: new(big.Int)}
+	evm := NewEVM(blockCtx, statedb, Config{})
+	evm.SuspendAfter(steps)
+	result := evm.Run(context.Background(), checkpointAddress)
+	if steps == 0 {
+		return result, statedb
+	}
+	if !errors.Is(result.Err, ErrSuspended) {
+		t.Fatalf("got %v, want a suspension", result.Err)
+	}
+	data, err := json.Marshal(evm.Checkpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		t.Fatal(err)
+	}
+	return NewEVMFromCheckpoint(&cp, statedb, Config{}).Resume(context.Background()), statedb
+}
+
+func TestCheckpointResumeMatchesRun(t *testing.T) {
+	for _, tt := range checkpointTests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, _ := runCheckpoint(t, tt.code, 0)
+			got, _ := runCheckpoint(t, tt.code, tt.steps)
+			if want.Err != nil || got.Err != nil {
+				t.Fatalf("run: %v, resumed: %v", want.Err, got.Err)
+			}
+			if got.GasUsed != want.GasUsed || got.Refund != want.Refund {
+				t.Errorf("resumed used %d gas and refunded %d, want %d and %d", got.GasUsed, got.Refund, want.GasUsed, want.Refund)
+			}
+		})
+	}
+}
+
+func TestCheckpointResumeDeletesDestructed(t *testing.T) {
+	// creates a contract running PUSH1 0xaa SELFDESTRUCT, suspends, and
+	// calls it: EIP-6780 deletes it as it was created by the execution
+	code := []byte{
+		0x62, 0x60, 0xaa, 0xff, 0x60, 0x00, 0x52, // PUSH3 0x60aaff PUSH1 0 MSTORE
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, // the return and argument ranges and the value of the call
+		0x60, 0x03, 0x60, 0x1d, 0x60, 0x00, 0xf0, // PUSH1 3 PUSH1 29 PUSH1 0 CREATE
+		0x61, 0xff, 0xff, 0xf1, // PUSH2 0xffff CALL
+	}
+	child := crypto.CreateAddress(checkpointAddress, 0)
+	for _, steps := range []uint64{0, 12} {
+		result, statedb := runCheckpoint(t, code, steps)
+		if result.Err != nil {
+			t.Fatalf("suspended after %d steps: %v", steps, result.Err)
+		}
+		if statedb.Exist(child) {
+			t.Errorf("suspended after %d steps: the destructed contract still exists", steps)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// CustomOpcode is an instruction added to the interpreter through
+// Config.CustomOpcodes, so research forks and appchains can prototype new
+// instructions without patching the interpreter. A custom opcode takes
+// precedence over a built-in instruction with the same byte.
+type CustomOpcode struct {
+	Op     OpCode
+	Name   string // mnemonic returned by ScopeContext.OpName
+	Gas    uint64 // constant gas charged before Execute runs
+	Pops   int    // stack words popped and passed to Execute
+	Pushes int    // stack words Execute returns, pushed in order
+	// Execute runs the instruction. Returning an error halts the frame
+	// like any other exceptional halt.
+	Execute func(call *CustomCall) ([]*big.Int, error)
+}
+
+// CustomCall is what a custom opcode sees of the executing frame
+type CustomCall struct {
+	Scope *ScopeContext // read-only view of the frame
+	State StateDB       // world state, which the instruction may modify
+	Args  []*big.Int    // popped words, top of the stack first
+}
+
+// customTable indexes custom opcodes by byte. It panics on invalid
+// definitions, which are programming errors of the embedder.
+func customTable(opcodes []CustomOpcode) map[OpCode]*CustomOpcode {
+	if len(opcodes) == 0 {
+		return nil
+	}
+	table := make(map[OpCode]*CustomOpcode, len(opcodes))
+	for i := range opcodes {
+		op := &opcodes[i]
+		switch {
+		case op.Execute == nil:
+			panic(fmt.Sprintf("vm: custom opcode 0x%02x has no Execute function", byte(op.Op)))
+		case op.Pops < 0 || op.Pushes < 0:
+			panic(fmt.Sprintf("vm: custom opcode 0x%02x has negative stack effects", byte(op.Op)))
+		case op.Op.IsPush():
+			panic(fmt.Sprintf("vm: custom opcode 0x%02x would change code layout", byte(op.Op)))
+		case table[op.Op] != nil:
+			panic(fmt.Sprintf("vm: custom opcode 0x%02x registered twice", byte(op.Op)))
+		}
+		table[op.Op] = op
+	}
+	return table
+}
+
+// opName returns the mnemonic of op, which is the name of the custom opcode
+// of the EVM with that byte if it has one
+func (evm *EVM) opName(op OpCode) string {
+	if custom := evm.custom[op]; custom != nil && custom.Name != "" {
+		return custom.Name
+	}
+	return op.String()
+}
+
+// executeCustom runs a custom opcode: it charges the gas, checks the stack
+// effects and passes the popped words to Execute
+func (evm *EVM) executeCustom(op *CustomOpcode) error {
+	if err := evm.useGas(op.Gas); err != nil {
+		return err
+	}
+	if len(evm.stack.data) < op.Pops {
+		return fmt.Errorf("%s: stack underflow", evm.opName(op.Op))
+	}
+	if len(evm.stack.data)-op.Pops+op.Pushes > MaxStackDepth {
+		return fmt.Errorf("%s: stack overflow", evm.opName(op.Op))
+	}
+	args := make([]*big.Int, op.Pops)
+	for i := range args {
+		v, _ := evm.stack.pop()
+		word, ok := v.Value.(*big.Int)
+		if !ok {
+			return fmt.Errorf("%s: non-numeric stack word", evm.opName(op.Op))
+		}
+		args[i] = new(big.Int).Set(word)
+	}
+	results, err := op.Execute(&CustomCall{Scope: evm.scope, State: evm.statedb, Args: args})
+	if err != nil {
+		return err
+	}
+	if len(results) != op.Pushes {
+		return fmt.Errorf("%s: returned %d words, declared %d", evm.opName(op.Op), len(results), op.Pushes)
+	}
+	for _, word := range results {
+		if word == nil || word.Sign() < 0 || word.BitLen() > 256 {
+			return fmt.Errorf("%s: returned a word outside the 256-bit range", evm.opName(op.Op))
+		}
+		if err := evm.stack.push(Value{Type: Uint256, Value: new(big.Int).Set(word)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package vm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/core/state"
+)
+
+// runCustomNamed runs opcode 0x0c, registered under name, and returns the
+// mnemonic hooks see for it
+func runCustomNamed(t *testing.T, name string) string {
+	t.Helper()
+	statedb := state.New()
+	statedb.SetCode(RunAddress, []byte{0x0c})
+	var got string
+	config := Config{
+		CustomOpcodes: []CustomOpcode{{
+			Op:      0x0c,
+			Name:    name,
+			Execute: func(*CustomCall) ([]*big.Int, error) { return nil, nil },
+		}},
+		Hooks: &Hooks{OnOpcode: func(pc uint64, op OpCode, gas uint64, scope *ScopeContext, depth int) {
+			got = scope.OpName(op)
+		}},
+	}
+	evm := NewEVM(&Context{BlockNumber: big.NewInt(1), GasLimit: 100000}, statedb, config)
+	if result := evm.Run(context.Background(), RunAddress); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	return got
+}
+
+func TestCustomOpcodeNamesStayWithTheirEVM(t *testing.T) {
+	if got := runCustomNamed(t, "FIRST"); got != "FIRST" {
+		t.Errorf("first EVM named the opcode %s", got)
+	}
+	if got := runCustomNamed(t, "SECOND"); got != "SECOND" {
+		t.Errorf("second EVM named the opcode %s", got)
+	}
+	if got, want := OpCode(0x0c).String(), "opcode 0x0c not defined"; got != want {
+		t.Errorf("OpCode.String returned %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/word"
+)
+
+// CopyWordGas is charged by CALLDATACOPY, CODECOPY and RETURNDATACOPY for
+// every 32-byte word copied, rounded up
+const CopyWordGas = 3
+
+// ErrReturnDataOutOfBounds is returned by RETURNDATACOPY for a range
+// reaching past the end of the return data (EIP-211)
+var ErrReturnDataOutOfBounds = errors.New("return data out of bounds")
+
+// SetValue sets the value transferred to the top-level frame, read by
+// CALLVALUE. Transferring it is up to the caller. Call it before Run.
+func (evm *EVM) SetValue(value *big.Int) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	evm.value = value
+}
+
+// pushAddress pushes an address word
+func (evm *EVM) pushAddress(addr common.Address, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	return evm.stack.push(Value{Type: Address, Value: evm.intPool.get().SetBytes(addr[:])})
+}
+
+// pushWord pushes a copy of x, zero if x is nil
+func (evm *EVM) pushWord(x *big.Int, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	value := evm.intPool.get()
+	if x != nil {
+		value.Set(x)
+	}
+	return evm.stack.push(Value{Type: Uint256, Value: value})
+}
+
+// pushUint64 pushes a small word
+func (evm *EVM) pushUint64(n uint64, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	return evm.stack.push(Value{Type: Uint256, Value: evm.intPool.get().SetUint64(n)})
+}
+
+// callDataLoad replaces the offset on top of the stack with the 32 bytes
+// of call data starting there, padded with zeros past its end
+func (evm *EVM) callDataLoad(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	offset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	offsetValue, ok := offset.Value.(*big.Int)
+	if !ok {
+		return errors.New("callDataLoad assertion failed")
+	}
+	data := word.Slice(evm.input, word.Uint64(offsetValue), word.Size)
+	return evm.stack.push(Value{Type: Uint256, Value: offsetValue.SetBytes(data)})
+}
+
+// copyToMemory implements CALLDATACOPY, CODECOPY and RETURNDATACOPY. They
+// take the memory offset from the top of the stack, the offset in src below
+// it and the size below that. Bytes past the end of src read as zeros,
+// except for return data, whose end must not be passed.
+func (evm *EVM) copyToMemory(src []byte, returnData bool, gasCost uint64) error {
+	if len(evm.stack.data) < 3 {
+		return ErrStackUnderflow
+	}
+	var operands [3]*big.Int // size, source offset, memory offset
+	for i := range operands {
+		value, ok := evm.stack.data[len(evm.stack.data)-3+i].Value.(*big.Int)
+		if !ok {
+			return errors.New("copyToMemory assertion failed")
+		}
+		operands[i] = value
+	}
+	size := word.Uint64(operands[0])
+	words := toWordSize(size)
+	if words > (math.MaxUint64-gasCost)/CopyWordGas {
+		return ErrOutOfGas
+	}
+	if err := evm.useGas(gasCost + CopyWordGas*words); err != nil {
+		return err
+	}
+	evm.stack.data = evm.stack.data[:len(evm.stack.data)-3]
+	defer evm.intPool.put(operands[:]...)
+
+	offset := word.Uint64(operands[1])
+	if returnData {
+		if end := offset + size; end < offset || end > uint64(len(src)) {
+			return fmt.Errorf("%w: %d bytes from %s, return data of %d bytes", ErrReturnDataOutOfBounds, size, operands[1], len(src))
+		}
+	}
+	if size == 0 {
+		return nil
+	}
+	if size > MaxMemorySize {
+		return fmt.Errorf("memory size exceeded")
+	}
+	return evm.storeMemory(word.Uint64(operands[2]), word.Slice(src, offset, size))
+}
+
+// toWordSize returns the number of 32-byte words holding size bytes
+func toWordSize(size uint64) uint64 {
+	if size > math.MaxUint64-(word.Size-1) {
+		return math.MaxUint64/word.Size + 1
+	}
+	return (size + word.Size - 1) / word.Size
+}
@@ -0,0 +1,345 @@
+// Package vm implements the Ethereum Virtual Machine interpreter.
+package vm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/logging"
+	"github.com/nutcas3/evm-golang/params"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	MaxStackDepth = 1024
+	MaxMemorySize = 1 << 25 // 32 MB
+
+	// SstoreSentryGas is the call stipend: SSTORE fails unless more gas
+	// than this is left (EIP-2200)
+	SstoreSentryGas = 2300
+)
+
+// ErrStop is used internally to halt execution on STOP, RETURN and
+// SELFDESTRUCT. Run reports such a halt as a successful execution.
+var ErrStop = errors.New("STOP")
+
+// Context represents the block and transaction context of an execution
+type Context struct {
+	BlockNumber *big.Int
+	Timestamp   *big.Int
+	Sender      common.Address
+	GasLimit    uint64 // of the block, read by GASLIMIT
+	Gas         uint64 // available to the execution, GasLimit if zero
+	GasPrice    *big.Int
+
+	Coinbase common.Address
+	BaseFee  *big.Int     // nil before London
+	Random   *common.Hash // PREVRANDAO, nil before the merge
+	ChainID  *big.Int     // for CHAINID; nil takes that of the chain config
+
+	// Difficulty is the proof-of-work difficulty returned by DIFFICULTY
+	// before the merge, nil after it
+	Difficulty *big.Int
+
+	// GetHash returns the hash of a block by number for BLOCKHASH; nil
+	// answers zero hashes. It is not serialized with the context.
+	GetHash BlockHashFunc
+}
+
+// gas returns the gas an execution in the context starts with
+func (c *Context) gas() uint64 {
+	if c.Gas != 0 {
+		return c.Gas
+	}
+	return c.GasLimit
+}
+
+// Contract is the code executing in a call frame
+type Contract struct {
+	Address  common.Address
+	CodeHash common.Hash
+	Code     []byte
+
+	jumpdests bitvec // analysis of Code, computed at the first jump
+}
+
+// Config holds optional settings for the EVM
+type Config struct {
+	Logging *logging.Config
+	Tracer  trace.Tracer // optional OpenTelemetry tracer for transaction and call frame spans
+	Hooks   *Hooks       // optional execution hooks used by tracers
+
+	// Invariants are checked after every SSTORE and every successful frame
+	// exit. A violation aborts the execution with an *InvariantError.
+	Invariants []Invariant
+
+	// CustomOpcodes adds instructions to the interpreter. NewEVM panics if
+	// one is invalid or registered twice.
+	CustomOpcodes []CustomOpcode
+
+	// Interceptors run around the instructions they register for and may
+	// veto or replace them. NewEVM panics if one is invalid.
+	Interceptors []Interceptor
+
+	// Precompiles are contracts implemented in Go at the given addresses.
+	// ChainPrecompiles adds stubs for those a chain config declares.
+	Precompiles map[common.Address]PrecompiledContract
+
+	// Loops, if set, reports and optionally aborts loops that run without
+	// changing state
+	Loops *LoopConfig
+
+	// ChainConfig selects the fork rules of the block being executed, for
+	// instructions whose behavior changed over time such as SELFDESTRUCT.
+	// If nil, the rules of the latest fork apply.
+	ChainConfig *params.ChainConfig
+}
+
+// ExecutionResult is the outcome of running a contract
+type ExecutionResult struct {
+	ReturnData []byte       // data passed to RETURN, or REVERT data on revert
+	Logs       []*types.Log // logs emitted by the execution and its successful calls
+	GasUsed    uint64
+	Refund     uint64 // gas refund earned, not deducted from GasUsed; zero on failure
+	Err        error  // execution error, nil if the contract halted normally
+	// Frames are the call frames a failed execution failed through,
+	// innermost first, as attached to Err by a *FrameError
+	Frames []Frame
+}
+
+// Failed reports whether the execution ended with an error
+func (r *ExecutionResult) Failed() bool { return r.Err != nil }
+
+// Status reports how the execution halted
+func (r *ExecutionResult) Status() Status { return HaltStatus(r.Err) }
+
+// EVM represents the Ethereum Virtual Machine
+type EVM struct {
+	stack      *Stack
+	memory     *Memory
+	contract   *Contract
+	caller     common.Address // address that called the contract
+	input      []byte         // call data of the frame
+	value      *big.Int       // value transferred to the frame
+	pc         uint64         // Program Counter
+	gas        uint64
+	context    *Context
+	statedb    StateDB
+	returnData []byte // output of the last call made by the frame
+	output     []byte // data of the frame's RETURN or REVERT
+	logs       []*types.Log
+	depth      int
+	readOnly   bool // set in frames entered by STATICCALL and their children
+
+	logger      *slog.Logger // interpreter logger
+	stateLogger *slog.Logger
+	tracer      trace.Tracer
+	ctx         context.Context // context of the running frame, carries its span
+	intPool     *intPool
+	hooks       *Hooks
+	scope       *ScopeContext
+	invariants  []Invariant
+	custom      map[OpCode]*CustomOpcode
+	intercepts  map[OpCode][]*Interceptor
+	precompiles map[common.Address]PrecompiledContract
+	accessed    map[common.Address]bool    // warm accounts, shared by the frames of an execution
+	created     map[common.Address]bool    // contracts created by the execution, shared like accessed
+	destructed  map[common.Address]bool    // accounts self-destructed by the execution, shared like accessed
+	refund      *uint64                    // gas refund counter, shared like accessed
+	originals   map[storageKey]common.Hash // values of the slots written by the execution before their first write
+	warmSlots   map[storageKey]bool        // slots accessed by the execution (EIP-2929)
+	jumpdests   map[common.Hash]bitvec     // jump destinations by code hash, shared like accessed and kept by Reset
+	chainConfig *params.ChainConfig
+	loops       *LoopConfig
+	loopCounts  map[uint64]uint64 // backward jumps by destination since the frame last changed state
+
+	initialGas uint64      // gas available when the transaction started
+	suspended  atomic.Bool // set by Suspend, checked at instruction boundaries
+	stepLimit  uint64      // suspend after this many top-level steps, 0 for no limit
+	steps      uint64      // top-level instructions executed
+}
+
+// NewEVM creates a new instance of EVM executing against the given state
+func NewEVM(blockCtx *Context, statedb StateDB, config Config) *EVM {
+	hooks := config.Hooks
+	if hooks == nil {
+		hooks = &Hooks{}
+	}
+	return &EVM{
+		stack:       newStack(),
+		memory:      &Memory{},
+		pc:          0,
+		gas:         blockCtx.gas(),
+		caller:      blockCtx.Sender,
+		value:       new(big.Int),
+		context:     blockCtx,
+		statedb:     statedb,
+		depth:       0,
+		logger:      config.Logging.Logger(logging.ComponentInterpreter),
+		stateLogger: config.Logging.Logger(logging.ComponentState),
+		tracer:      spanTracer(config),
+		ctx:         context.Background(),
+		intPool:     newIntPool(),
+		hooks:       hooks,
+		invariants:  config.Invariants,
+		custom:      customTable(config.CustomOpcodes),
+		intercepts:  interceptTable(config.Interceptors),
+		precompiles: config.Precompiles,
+		accessed:    make(map[common.Address]bool),
+		created:     make(map[common.Address]bool),
+		destructed:  make(map[common.Address]bool),
+		refund:      new(uint64),
+		originals:   make(map[storageKey]common.Hash),
+		warmSlots:   make(map[storageKey]bool),
+		jumpdests:   make(map[common.Hash]bitvec),
+		chainConfig: config.ChainConfig,
+		loops:       config.Loops,
+	}
+}
+
+// contractAt returns the contract deployed at address in the EVM's state.
+// The code of an EOA holding an EIP-7702 delegation designator is that of
+// the designated contract, executing in the EOA's context.
+func (evm *EVM) contractAt(address common.Address) *Contract {
+	codeAddress := address
+	if target, ok := types.ParseDelegation(evm.statedb.GetCode(address)); ok {
+		codeAddress = target
+	}
+	return &Contract{
+		Address:  address,
+		CodeHash: evm.statedb.GetCodeHash(codeAddress),
+		Code:     evm.statedb.GetCode(codeAddress),
+	}
+}
+
+// Run executes the code deployed at address until it halts, emitting a
+// transaction span when a tracer is configured
+func (evm *EVM) Run(ctx context.Context, address common.Address) *ExecutionResult {
+	evm.contract = evm.contractAt(address)
+	evm.initialGas = evm.gas
+	return evm.finish(evm.run(ctx, SpanTransaction))
+}
+
+// SetInput sets the call data of the top-level frame, passed to OnEnter
+// hooks and ScopeContext.Input. Call it before Run.
+func (evm *EVM) SetInput(input []byte) {
+	evm.input = input
+}
+
+// finish ends an execution that halted with err: the accounts it
+// self-destructed are deleted if it succeeded
+func (evm *EVM) finish(err error) *ExecutionResult {
+	if err == nil {
+		evm.deleteDestructed()
+	}
+	return evm.result(err)
+}
+
+func (evm *EVM) result(err error) *ExecutionResult {
+	gasUsed := evm.initialGas - evm.gas
+	if HaltStatus(err).Exceptional() {
+		// exceptional halts consume all the gas, unlike reverts
+		gasUsed = evm.initialGas
+	}
+	result := &ExecutionResult{
+		ReturnData: evm.output,
+		Logs:       evm.logs,
+		GasUsed:    gasUsed,
+		Err:        err,
+		Frames:     Frames(err),
+	}
+	if err == nil {
+		result.Refund = *evm.refund
+	}
+	return result
+}
+
+// contextCheckSteps is the number of instructions a frame executes between
+// checks of its context, so a canceled or expired context stops it
+const contextCheckSteps = 1024
+
+// run executes the frame's contract, treating STOP as a normal halt
+func (evm *EVM) run(ctx context.Context, spanName string) error {
+	ctx, span := evm.tracer.Start(ctx, spanName, trace.WithAttributes(evm.frameAttributes()...))
+	evm.ctx = ctx
+	evm.scope = &ScopeContext{evm: evm}
+	startGas := evm.gas
+	if evm.hooks.OnEnter != nil {
+		evm.hooks.OnEnter(evm.depth, evm.caller, evm.contract.Address, evm.input, evm.gas)
+	}
+
+	var err error
+	for n := 0; evm.pc < uint64(len(evm.contract.Code)); n++ {
+		if evm.depth == 0 && evm.shouldSuspend() {
+			err = ErrSuspended
+			break
+		}
+		if n%contextCheckSteps == 0 {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+		}
+		op, pc := evm.contract.Code[evm.pc], evm.pc
+		if evm.hooks.OnOpcode != nil {
+			evm.hooks.OnOpcode(evm.pc, OpCode(op), evm.gas, evm.scope, evm.depth)
+		}
+		if evm.intercepts != nil {
+			err = evm.executeIntercepted(op)
+		} else {
+			err = evm.ExecuteOpcode(op)
+		}
+		if err != nil {
+			break
+		}
+		if evm.loops != nil {
+			if err = evm.checkLoop(OpCode(op), pc); err != nil {
+				break
+			}
+		}
+		evm.pc++
+		evm.steps++
+	}
+	if errors.Is(err, ErrStop) {
+		err = nil
+	}
+	if err == nil {
+		err = evm.checkInvariants("frame exit")
+	}
+	if err != nil {
+		err = evm.frameError(err)
+	}
+	if evm.hooks.OnExit != nil {
+		evm.hooks.OnExit(evm.depth, evm.output, startGas-evm.gas, err)
+	}
+	endSpan(span, startGas-evm.gas, err)
+	return err
+}
+
+func (evm *EVM) useGas(cost uint64) error {
+	if evm.gas < cost {
+		return ErrOutOfGas
+	}
+	evm.gas -= cost
+	return nil
+}
+
+// Gas charged for loading an account, the first time in an execution and
+// afterwards (EIP-2929)
+const (
+	ColdAccountAccessCost = 2600
+	WarmAccountAccessCost = 100
+)
+
+// accessCost returns the gas of accessing addr and marks it warm
+func (evm *EVM) accessCost(addr common.Address) uint64 {
+	if evm.accessed[addr] {
+		return WarmAccountAccessCost
+	}
+	evm.accessed[addr] = true
+	return ColdAccountAccessCost
+}
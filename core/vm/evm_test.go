@@ -0,0 +1,30 @@
+package vm
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/nutcas3/evm-golang/core/state"
+)
+
+func TestRunStopsWhenTheContextIsDone(t *testing.T) {
+	statedb := state.New()
+	statedb.SetCode(RunAddress, []byte{0x5b, 0x60, 0x00, 0x56}) // JUMPDEST PUSH1 0 JUMP
+	evm := NewEVM(&Context{BlockNumber: big.NewInt(1), GasLimit: math.MaxUint64}, statedb, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	done := make(chan *ExecutionResult, 1)
+	go func() { done <- evm.Run(ctx, RunAddress) }()
+	select {
+	case result := <-done:
+		if result.Status() != StatusAborted {
+			t.Errorf("status %s, want %s", result.Status(), StatusAborted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the execution ran past its deadline")
+	}
+}
@@ -0,0 +1,97 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/abi"
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+// Frame is a call frame an execution failed in, as it was when the failure
+// left it
+type Frame struct {
+	Address  common.Address
+	Selector hexutil.Bytes // first 4 bytes of the frame's input, if it has them
+	PC       uint64
+	Op       OpCode
+	Depth    int
+	// Reason is the decoded revert reason of the frame that reverted,
+	// empty if its data is not an Error(string) or Panic(uint256)
+	Reason string
+}
+
+// String renders the frame on one line
+func (f *Frame) String() string {
+	s := fmt.Sprintf("%s pc %d %s", f.Address.Hex(), f.PC, f.Op)
+	if len(f.Selector) > 0 {
+		s += " selector " + f.Selector.String()
+	}
+	if f.Reason != "" {
+		s += fmt.Sprintf(" reason %q", f.Reason)
+	}
+	return s
+}
+
+// FrameError is the error of a failed execution with the call frames it
+// failed through, innermost first, like a stack trace: the frame where the
+// failure happened, then the frames of the calls leading to it. It wraps
+// the halt error, so errors.Is and HaltStatus see through it.
+type FrameError struct {
+	Err    error
+	Frames []Frame
+}
+
+// Error returns the message of the halt error alone, so error strings,
+// such as those of tracer outputs, stay those of the halt; Trace renders
+// the frames
+func (e *FrameError) Error() string { return e.Err.Error() }
+
+func (e *FrameError) Unwrap() error { return e.Err }
+
+// Trace renders the halt error followed by every frame, one per line
+func (e *FrameError) Trace() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	for i := range e.Frames {
+		fmt.Fprintf(&b, "\n\tat %s", &e.Frames[i])
+	}
+	return b.String()
+}
+
+// Frames returns the call frames attached to err, nil if it has none
+func Frames(err error) []Frame {
+	var fe *FrameError
+	if errors.As(err, &fe) {
+		return fe.Frames
+	}
+	return nil
+}
+
+// frameError attaches the current frame to the error the frame failed
+// with: a new trace for failures of its own, the outermost frame so far of
+// failures propagated from a call. Suspensions are not failures and are
+// returned as they are.
+func (evm *EVM) frameError(err error) error {
+	if errors.Is(err, ErrSuspended) {
+		return err
+	}
+	frame := Frame{Address: evm.contract.Address, PC: evm.pc, Depth: evm.depth}
+	if evm.pc < uint64(len(evm.contract.Code)) {
+		frame.Op = OpCode(evm.contract.Code[evm.pc])
+	}
+	if len(evm.input) >= 4 {
+		frame.Selector = append(hexutil.Bytes(nil), evm.input[:4]...)
+	}
+	var fe *FrameError
+	if errors.As(err, &fe) {
+		fe.Frames = append(fe.Frames, frame)
+		return fe
+	}
+	if errors.Is(err, ErrExecutionReverted) {
+		frame.Reason, _ = abi.UnpackRevert(evm.output)
+	}
+	return &FrameError{Err: err, Frames: []Frame{frame}}
+}
@@ -0,0 +1,219 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// constantGas is the gas each implemented opcode charges before its
+// dynamic components. ExecuteOpcode charges from this table, so the
+// schedule returned by GasTableFor is the one the interpreter applies.
+var constantGas = map[OpCode]uint64{
+	0x00: 0,     // STOP
+	0x01: 3,     // ADD
+	0x02: 5,     // MUL
+	0x03: 3,     // SUB
+	0x04: 5,     // DIV
+	0x05: 5,     // SDIV
+	0x06: 5,     // MOD
+	0x07: 5,     // SMOD
+	0x08: 8,     // ADDMOD
+	0x09: 8,     // MULMOD
+	0x0a: 10,    // EXP
+	0x0b: 5,     // SIGNEXTEND
+	0x10: 3,     // LT
+	0x11: 3,     // GT
+	0x14: 3,     // EQ
+	0x16: 3,     // AND
+	0x17: 3,     // OR
+	0x18: 3,     // XOR
+	0x19: 3,     // NOT
+	0x1a: 3,     // BYTE
+	0x1b: 3,     // SHL
+	0x1c: 3,     // SHR
+	0x1d: 3,     // SAR
+	0x20: 30,    // KECCAK256
+	0x30: 2,     // ADDRESS
+	0x32: 2,     // ORIGIN
+	0x33: 2,     // CALLER
+	0x34: 2,     // CALLVALUE
+	0x35: 3,     // CALLDATALOAD
+	0x36: 2,     // CALLDATASIZE
+	0x37: 3,     // CALLDATACOPY
+	0x38: 2,     // CODESIZE
+	0x39: 3,     // CODECOPY
+	0x3a: 2,     // GASPRICE
+	0x3d: 2,     // RETURNDATASIZE
+	0x3e: 3,     // RETURNDATACOPY
+	0x40: 20,    // BLOCKHASH
+	0x41: 2,     // COINBASE
+	0x42: 2,     // TIMESTAMP
+	0x43: 2,     // NUMBER
+	0x44: 2,     // DIFFICULTY, PREVRANDAO
+	0x45: 2,     // GASLIMIT
+	0x46: 2,     // CHAINID
+	0x47: 5,     // SELFBALANCE
+	0x48: 2,     // BASEFEE
+	0x50: 2,     // POP
+	0x51: 3,     // MLOAD
+	0x52: 3,     // MSTORE
+	0x53: 3,     // MSTORE8
+	0x54: 200,   // SLOAD
+	0x55: 0,     // SSTORE, priced by SstoreRules
+	0x56: 8,     // JUMP
+	0x57: 10,    // JUMPI
+	0x59: 2,     // MSIZE
+	0x5b: 1,     // JUMPDEST
+	0x5e: 3,     // MCOPY
+	0x5f: 2,     // PUSH0
+	0x80: 3,     // DUP1
+	0x90: 3,     // SWAP1
+	0xa0: 375,   // LOG0
+	0xf0: 32000, // CREATE
+	0xf1: 40,    // CALL
+	0xf3: 0,     // RETURN
+	0xf4: 40,    // DELEGATECALL
+	0xfa: 40,    // STATICCALL
+	0xfd: 0,     // REVERT
+	0xff: SelfdestructGas,
+}
+
+func init() {
+	for op := OpCode(0x60); op <= 0x7f; op++ {
+		constantGas[op] = 3 // PUSH1 to PUSH32
+	}
+}
+
+// latestFork names the rules applied without a chain config
+const latestFork = "Prague"
+
+// GasCost is what an opcode costs under the rules of a fork
+type GasCost struct {
+	Op       OpCode `json:"opcode"`
+	Name     string `json:"name"`
+	Constant uint64 `json:"constant"`
+	// Dynamic describes the gas charged or refunded on top of the
+	// constant, depending on the operands and the state. It is empty for
+	// opcodes with a constant cost.
+	Dynamic string `json:"dynamic,omitempty"`
+}
+
+// GasTable is the gas schedule of the interpreter at one block: the cost
+// of every opcode it implements. Opcodes missing from the table halt with
+// ErrInvalidOpcode; custom opcodes are not listed.
+type GasTable struct {
+	Fork  string    `json:"fork"`
+	Costs []GasCost `json:"costs"` // ordered by opcode
+}
+
+// GasTableFor returns the gas schedule applied to the block with the given
+// number and timestamp, that of the latest fork without a chain config
+func GasTableFor(config *params.ChainConfig, number *big.Int, time uint64) *GasTable {
+	if number == nil {
+		number = new(big.Int)
+	}
+	table := &GasTable{Fork: latestFork}
+	if config != nil {
+		table.Fork = config.Fork(number, time)
+	}
+	merge := config == nil || config.IsMerge(number)
+	shanghai := config == nil || config.IsShanghai(number, time)
+	istanbul := config == nil || config.IsIstanbul(number)
+	cancun := config == nil || config.IsCancun(number, time)
+	london := config == nil || config.IsLondon(number)
+	for op, gas := range constantGas {
+		if op == 0x5f && !shanghai || op == 0x5e && !cancun || (op == 0x46 || op == 0x47) && !istanbul || op == 0x48 && !london {
+			continue
+		}
+		cost := GasCost{Op: op, Name: op.String(), Constant: gas, Dynamic: dynamicGas(op, config, number)}
+		if op == 0x44 && !merge {
+			cost.Name = "DIFFICULTY"
+		}
+		table.Costs = append(table.Costs, cost)
+	}
+	sort.Slice(table.Costs, func(i, j int) bool { return table.Costs[i].Op < table.Costs[j].Op })
+	return table
+}
+
+// Lookup returns the cost of op, false if the interpreter does not
+// implement it
+func (t *GasTable) Lookup(op OpCode) (GasCost, bool) {
+	i := sort.Search(len(t.Costs), func(i int) bool { return t.Costs[i].Op >= op })
+	if i == len(t.Costs) || t.Costs[i].Op != op {
+		return GasCost{}, false
+	}
+	return t.Costs[i], true
+}
+
+// memoryOps are the opcodes that may grow memory and pay for it
+var memoryOps = map[OpCode]bool{
+	0x20: true, 0x37: true, 0x39: true, 0x3e: true, 0x51: true, 0x52: true, 0x53: true, 0x5e: true,
+	0xa0: true, 0xf0: true, 0xf1: true, 0xf3: true, 0xf4: true, 0xfa: true, 0xfd: true,
+}
+
+// dynamicGas describes the dynamic components of the cost of op
+func dynamicGas(op OpCode, config *params.ChainConfig, number *big.Int) string {
+	desc := opDynamicGas(op, config, number)
+	if !memoryOps[op] {
+		return desc
+	}
+	expansion := fmt.Sprintf("plus memory expansion, the growth of %d*words + words*words/%d", MemoryGas, QuadCoeffDiv)
+	if desc == "" {
+		return expansion
+	}
+	return desc + "; " + expansion
+}
+
+// opDynamicGas describes the dynamic components of the cost of op other
+// than memory expansion
+func opDynamicGas(op OpCode, config *params.ChainConfig, number *big.Int) string {
+	switch op {
+	case 0x0a:
+		perByte := ExpByteGas
+		if config != nil && !config.IsEIP155(number) {
+			perByte = ExpByteGasFrontier
+		}
+		return fmt.Sprintf("plus %d per byte of the exponent", perByte)
+	case 0x20:
+		return fmt.Sprintf("plus %d per word hashed", Keccak256WordGas)
+	case 0x37, 0x39, 0x3e, 0x5e:
+		return fmt.Sprintf("plus %d per word copied", CopyWordGas)
+	case 0x55:
+		return sstoreGas(SstoreRulesFor(config, number))
+	case 0xf1, 0xf4, 0xfa:
+		return fmt.Sprintf("plus %d, or %d if already accessed, to load the target of an EIP-7702 delegation; a precompile charges its RequiredGas from the forwarded gas",
+			ColdAccountAccessCost, WarmAccountAccessCost)
+	case 0xff:
+		desc := fmt.Sprintf("plus %d when sending a nonzero balance to an account that does not exist", SelfdestructNewAccountGas)
+		if config != nil && !config.IsLondon(number) {
+			desc += fmt.Sprintf("; refunds %d the first time an account is destructed", SelfdestructRefundGas)
+		}
+		return desc
+	}
+	return ""
+}
+
+// sstoreGas describes the price of SSTORE under rules
+func sstoreGas(r *SstoreRules) string {
+	var desc string
+	if r.NetMetering {
+		desc = fmt.Sprintf("%s: %d for no-op stores and slots already changed in the transaction, otherwise %d to set a zero slot and %d for other changes",
+			r.Name, r.ReadGas, r.SetGas, r.ResetGas)
+	} else {
+		desc = fmt.Sprintf("%s: %d to set a zero slot, %d for other stores", r.Name, r.SetGas, r.ResetGas)
+	}
+	if r.ColdGas > 0 {
+		desc += fmt.Sprintf(", plus %d for a slot not yet accessed", r.ColdGas)
+	}
+	desc += fmt.Sprintf("; refunds %d for clearing a slot", r.ClearRefund)
+	if r.NetMetering {
+		desc += " and the difference when restoring its original value"
+	}
+	if r.Sentry {
+		desc += fmt.Sprintf("; fails with %d gas or less left", SstoreSentryGas)
+	}
+	return desc
+}
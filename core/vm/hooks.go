@@ -0,0 +1,69 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/types"
+)
+
+// Hooks are callbacks invoked by the interpreter as execution progresses.
+// Any of them may be nil. Hooks observe execution and must not modify the
+// frame they are given.
+type Hooks struct {
+	// OnEnter is called when a frame starts executing
+	OnEnter func(depth int, from, to common.Address, input []byte, gas uint64)
+	// OnExit is called when a frame halts, err being nil on a normal halt
+	OnExit func(depth int, output []byte, gasUsed uint64, err error)
+	// OnOpcode is called before each instruction is executed
+	OnOpcode func(pc uint64, op OpCode, gas uint64, scope *ScopeContext, depth int)
+	// OnLog is called when a contract emits a log
+	OnLog func(log *types.Log)
+	// OnStorageChange is called when a storage slot is written
+	OnStorageChange func(address common.Address, key, prev, value common.Hash)
+}
+
+// ScopeContext gives hooks read access to the executing frame. Slices and
+// ints it returns alias interpreter state and are only valid during the hook
+// call.
+type ScopeContext struct {
+	evm *EVM
+}
+
+// Address returns the address of the executing contract
+func (s *ScopeContext) Address() common.Address { return s.evm.contract.Address }
+
+// Caller returns the address that called the executing contract
+func (s *ScopeContext) Caller() common.Address { return s.evm.caller }
+
+// Input returns the call data of the frame
+func (s *ScopeContext) Input() []byte { return s.evm.input }
+
+// Value returns the value transferred to the frame
+func (s *ScopeContext) Value() *big.Int { return s.evm.value }
+
+// Code returns the code of the executing contract
+func (s *ScopeContext) Code() []byte { return s.evm.contract.Code }
+
+// ReadOnly reports whether the frame is executing in a static context,
+// where state modifications fail with ErrWriteProtection
+func (s *ScopeContext) ReadOnly() bool { return s.evm.readOnly }
+
+// OpName returns the mnemonic of op, naming the custom opcodes of the
+// executing EVM, which OpCode.String does not know
+func (s *ScopeContext) OpName(op OpCode) string { return s.evm.opName(op) }
+
+// Gas returns the gas currently left in the frame
+func (s *ScopeContext) Gas() uint64 { return s.evm.gas }
+
+// StackLen returns the number of words on the stack
+func (s *ScopeContext) StackLen() int { return len(s.evm.stack.data) }
+
+// StackAt returns the n-th stack word counted from the bottom
+func (s *ScopeContext) StackAt(n int) *big.Int {
+	word, _ := s.evm.stack.data[n].Value.(*big.Int)
+	return word
+}
+
+// Memory returns the frame's memory
+func (s *ScopeContext) Memory() []byte { return s.evm.memory.data }
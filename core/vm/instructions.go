@@ -0,0 +1,838 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/word"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// ExecuteOpcode executes a single opcode
+func (evm *EVM) ExecuteOpcode(opcode byte) error {
+	if evm.logger.Enabled(evm.ctx, slog.LevelDebug) {
+		evm.logger.Debug("executing opcode", "pc", evm.pc, "opcode", fmt.Sprintf("0x%02x", opcode), "gas", evm.gas, "depth", evm.depth)
+	}
+	if op, ok := evm.custom[OpCode(opcode)]; ok {
+		return evm.executeCustom(op)
+	}
+	gas := constantGas[OpCode(opcode)]
+	if op := OpCode(opcode); op.IsPush() {
+		return evm.push(uint64(op.PushSize()), gas)
+	}
+	switch opcode {
+	case 0x00: // STOP
+		return ErrStop
+	case 0x01: // ADD
+		return evm.binaryOperation(func(z, a, b *big.Int) *big.Int { return z.Add(a, b) }, gas)
+	case 0x02: // MUL
+		return evm.binaryOperation(func(z, a, b *big.Int) *big.Int { return z.Mul(a, b) }, gas)
+	case 0x03: // SUB
+		return evm.binaryOperation(func(z, a, b *big.Int) *big.Int { return z.Sub(a, b) }, gas)
+	case 0x04: // DIV
+		return evm.binaryOperation(func(z, a, b *big.Int) *big.Int {
+			if b.Sign() == 0 {
+				return z
+			}
+			return z.Div(a, b)
+		}, gas)
+	case 0x05: // SDIV
+		return evm.binaryOperation(sdiv, gas)
+	case 0x06: // MOD
+		return evm.binaryOperation(mod, gas)
+	case 0x07: // SMOD
+		return evm.binaryOperation(smod, gas)
+	case 0x08: // ADDMOD
+		return evm.ternaryOperation(addmod, gas)
+	case 0x09: // MULMOD
+		return evm.ternaryOperation(mulmod, gas)
+	case 0x0a: // EXP
+		return evm.exp(gas)
+	case 0x0b: // SIGNEXTEND
+		return evm.binaryOperation(signExtend, gas)
+	case 0x10: // LT
+		return evm.compareOperation(func(a, b *big.Int) bool { return a.Cmp(b) < 0 }, gas)
+	case 0x11: // GT
+		return evm.compareOperation(func(a, b *big.Int) bool { return a.Cmp(b) > 0 }, gas)
+	case 0x14: // EQ
+		return evm.compareOperation(func(a, b *big.Int) bool { return a.Cmp(b) == 0 }, gas)
+	case 0x16: // AND
+		return evm.binaryOperation(and, gas)
+	case 0x17: // OR
+		return evm.binaryOperation(or, gas)
+	case 0x18: // XOR
+		return evm.binaryOperation(xor, gas)
+	case 0x19: // NOT
+		return evm.unaryOperation(not, gas)
+	case 0x1a: // BYTE
+		return evm.binaryOperation(byteOp, gas)
+	case 0x1b: // SHL
+		return evm.binaryOperation(shl, gas)
+	case 0x1c: // SHR
+		return evm.binaryOperation(shr, gas)
+	case 0x1d: // SAR
+		return evm.binaryOperation(sar, gas)
+	case 0x20: // KECCAK256
+		return evm.keccak256(gas)
+	case 0x30: // ADDRESS
+		return evm.pushAddress(evm.contract.Address, gas)
+	case 0x32: // ORIGIN
+		return evm.pushAddress(evm.context.Sender, gas)
+	case 0x33: // CALLER
+		return evm.pushAddress(evm.caller, gas)
+	case 0x34: // CALLVALUE
+		return evm.pushWord(evm.value, gas)
+	case 0x35: // CALLDATALOAD
+		return evm.callDataLoad(gas)
+	case 0x36: // CALLDATASIZE
+		return evm.pushUint64(uint64(len(evm.input)), gas)
+	case 0x37: // CALLDATACOPY
+		return evm.copyToMemory(evm.input, false, gas)
+	case 0x38: // CODESIZE
+		return evm.pushUint64(uint64(len(evm.contract.Code)), gas)
+	case 0x39: // CODECOPY
+		return evm.copyToMemory(evm.contract.Code, false, gas)
+	case 0x3a: // GASPRICE
+		return evm.pushWord(evm.context.GasPrice, gas)
+	case 0x3d: // RETURNDATASIZE
+		return evm.pushUint64(uint64(len(evm.returnData)), gas)
+	case 0x3e: // RETURNDATACOPY
+		return evm.copyToMemory(evm.returnData, true, gas)
+	case 0x40: // BLOCKHASH
+		return evm.blockHash(gas)
+	case 0x41: // COINBASE
+		return evm.pushAddress(evm.context.Coinbase, gas)
+	case 0x42: // TIMESTAMP
+		return evm.pushWord(evm.context.Timestamp, gas)
+	case 0x43: // NUMBER
+		return evm.pushWord(evm.context.BlockNumber, gas)
+	case 0x44: // DIFFICULTY, PREVRANDAO since the merge
+		return evm.prevRandao(gas)
+	case 0x45: // GASLIMIT
+		return evm.pushUint64(evm.context.GasLimit, gas)
+	case 0x46: // CHAINID, from Istanbul (EIP-1344)
+		if !evm.isIstanbul() {
+			return fmt.Errorf("%w: 0x%x", ErrInvalidOpcode, opcode)
+		}
+		return evm.pushWord(evm.chainID(), gas)
+	case 0x47: // SELFBALANCE, from Istanbul (EIP-1884)
+		if !evm.isIstanbul() {
+			return fmt.Errorf("%w: 0x%x", ErrInvalidOpcode, opcode)
+		}
+		return evm.selfBalance(gas)
+	case 0x48: // BASEFEE, from London (EIP-3198)
+		if !evm.isLondon() {
+			return fmt.Errorf("%w: 0x%x", ErrInvalidOpcode, opcode)
+		}
+		return evm.pushWord(evm.context.BaseFee, gas)
+	case 0x50: // POP
+		return evm.pop(gas)
+	case 0x51: // MLOAD
+		return evm.mload(gas)
+	case 0x52: // MSTORE
+		return evm.mstore(word.Size, gas)
+	case 0x53: // MSTORE8
+		return evm.mstore(1, gas)
+	case 0x54: // SLOAD
+		return evm.sload(gas)
+	case 0x55: // SSTORE
+		return evm.sstore()
+	case 0x56: // JUMP
+		return evm.jump(gas)
+	case 0x57: // JUMPI
+		return evm.jumpi(gas)
+	case 0x59: // MSIZE
+		return evm.pushUint64(uint64(len(evm.memory.data)), gas)
+	case 0x5b: // JUMPDEST
+		return evm.useGas(gas)
+	case 0x5e: // MCOPY, from Cancun (EIP-5656)
+		if !evm.isCancun() {
+			return fmt.Errorf("%w: 0x%x", ErrInvalidOpcode, opcode)
+		}
+		return evm.mcopy(gas)
+	case 0x5f: // PUSH0, from Shanghai (EIP-3855)
+		if !evm.isShanghai() {
+			return fmt.Errorf("%w: 0x%x", ErrInvalidOpcode, opcode)
+		}
+		return evm.push(0, gas)
+	case 0x80: // DUP1
+		return evm.dup(1, gas)
+	case 0x90: // SWAP1
+		return evm.swap(1, gas)
+	case 0xa0: // LOG0
+		return evm.log(0, gas)
+	case 0xf0: // CREATE
+		return evm.create(gas)
+	case 0xf1: // CALL
+		return evm.call(gas)
+	case 0xf3: // RETURN
+		return evm.returnOp(gas)
+	case 0xf4: // DELEGATECALL
+		return evm.delegateCall(gas)
+	case 0xfa: // STATICCALL
+		return evm.staticCall(gas)
+	case 0xfd: // REVERT
+		return evm.revert(gas)
+	case 0xff: // SELFDESTRUCT
+		return evm.selfdestruct(gas)
+	default:
+		return fmt.Errorf("%w: 0x%x", ErrInvalidOpcode, opcode)
+	}
+}
+
+// binaryOperation applies op to the two topmost stack words, in Yellow
+// Paper order: a is the word on top of the stack and b the one below it.
+// op stores its result in z, a zeroed int taken from the frame's pool; the
+// result is reduced modulo 2^256, so ops may compute on unbounded integers.
+func (evm *EVM) binaryOperation(op func(z, a, b *big.Int) *big.Int, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	a, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	b, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	aValue, ok := a.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	bValue, ok := b.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	result := word.U256(op(evm.intPool.get(), aValue, bValue))
+	evm.intPool.put(aValue, bValue)
+	return evm.stack.push(Value{Type: Uint256, Value: result})
+}
+
+// compareOperation pushes 1 if op holds for the two topmost stack words,
+// in the order of binaryOperation, and 0 if not
+func (evm *EVM) compareOperation(op func(*big.Int, *big.Int) bool, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	a, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	b, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	aValue, ok := a.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	bValue, ok := b.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	result := op(aValue, bValue)
+	evm.intPool.put(aValue, bValue)
+	if result {
+		return evm.stack.push(Value{Type: Uint256, Value: evm.intPool.get().SetUint64(1)})
+	}
+	return evm.stack.push(Value{Type: Uint256, Value: evm.intPool.get()})
+}
+
+func (evm *EVM) sload(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	key, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	keyValue, ok := key.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	value := evm.statedb.GetState(evm.contract.Address, word.ToHash(keyValue))
+	evm.warmSlots[storageKey{evm.contract.Address, word.ToHash(keyValue)}] = true
+	result := evm.intPool.get().SetBytes(value[:])
+	evm.intPool.put(keyValue)
+	return evm.stack.push(Value{Type: Uint256, Value: result})
+}
+
+// sstore writes a storage slot, priced by the SSTORE rules of the fork
+func (evm *EVM) sstore() error {
+	if err := evm.writable(OpCode(0x55)); err != nil {
+		return err
+	}
+	rules := SstoreRulesFor(evm.chainConfig, evm.blockNumber())
+	// a frame called with only the stipend must not be able to write
+	if rules.Sentry && evm.gas <= SstoreSentryGas {
+		return fmt.Errorf("%w: %d gas left", ErrSstoreSentry, evm.gas)
+	}
+	key, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	value, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	keyValue, ok := key.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	valueValue, ok := value.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	slot, slotValue := word.ToHash(keyValue), word.ToHash(valueValue)
+	sk := storageKey{evm.contract.Address, slot}
+	current := evm.statedb.GetState(evm.contract.Address, slot)
+	original, ok := evm.originals[sk]
+	if !ok {
+		original = current
+	}
+	gas, refund := rules.Price(original, current, slotValue, evm.warmSlots[sk])
+	if err := evm.useGas(gas); err != nil {
+		return err
+	}
+	if refund < 0 {
+		*evm.refund -= uint64(-refund)
+	} else {
+		*evm.refund += uint64(refund)
+	}
+	evm.originals[sk] = original
+	evm.warmSlots[sk] = true
+	if evm.hooks.OnStorageChange != nil {
+		evm.hooks.OnStorageChange(evm.contract.Address, slot, current, slotValue)
+	}
+	evm.statedb.SetState(evm.contract.Address, slot, slotValue)
+	evm.stateLogger.Debug("storage write", "address", evm.contract.Address.Hex(), "key", keyValue)
+	evm.intPool.put(keyValue, valueValue)
+	return evm.checkInvariants("SSTORE")
+}
+
+func (evm *EVM) jump(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	dest, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	destValue, ok := dest.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	if !evm.validJumpdest(destValue) {
+		return fmt.Errorf("%w: %s", ErrInvalidJump, destValue)
+	}
+	evm.pc = destValue.Uint64() - 1 // -1 because pc will be incremented after this
+	evm.intPool.put(destValue)
+	return nil
+}
+
+func (evm *EVM) jumpi(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	dest, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	condition, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	cValue, ok := condition.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	destValue, ok := dest.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	if cValue.Sign() != 0 {
+		if !evm.validJumpdest(destValue) {
+			return fmt.Errorf("%w: %s", ErrInvalidJump, destValue)
+		}
+		evm.pc = destValue.Uint64() - 1 // -1 because pc will be incremented after this
+	}
+	evm.intPool.put(cValue, destValue)
+	return nil
+}
+
+// prevRandao pushes the randao mix of the block after the merge (EIP-4399)
+// and its difficulty before it. Either is zero if the context lacks it.
+func (evm *EVM) prevRandao(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	value := evm.intPool.get()
+	if evm.isMerge() {
+		if evm.context.Random != nil {
+			value.SetBytes(evm.context.Random[:])
+		}
+	} else if evm.context.Difficulty != nil {
+		value.Set(evm.context.Difficulty)
+	}
+	return evm.stack.push(Value{Type: Uint256, Value: value})
+}
+
+func (evm *EVM) pop(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	value, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	if v, ok := value.Value.(*big.Int); ok {
+		evm.intPool.put(v)
+	}
+	return nil
+}
+
+func (evm *EVM) push(size uint64, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	// code is read as if followed by zeros, so a PUSH cut off by the end
+	// of the code pushes its immediate right-padded
+	value := word.SetBytes(evm.intPool.get(), word.Slice(evm.contract.Code, evm.pc+1, size))
+	evm.pc += size
+	return evm.stack.push(Value{Type: Uint256, Value: value})
+}
+
+func (evm *EVM) dup(pos uint64, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	if uint64(len(evm.stack.data)) < pos {
+		return fmt.Errorf("dup: stack underflow")
+	}
+	value := evm.stack.data[uint64(len(evm.stack.data))-pos]
+	if v, ok := value.Value.(*big.Int); ok {
+		// push a copy: stack words must not share ints that may be recycled
+		return evm.stack.push(Value{Type: value.Type, Value: evm.intPool.get().Set(v)})
+	}
+	return evm.stack.push(value)
+}
+
+func (evm *EVM) swap(pos uint64, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	if uint64(len(evm.stack.data)) <= pos {
+		return fmt.Errorf("swap: stack underflow")
+	}
+	evm.stack.data[uint64(len(evm.stack.data))-1], evm.stack.data[uint64(len(evm.stack.data))-1-pos] = evm.stack.data[uint64(len(evm.stack.data))-1-pos], evm.stack.data[uint64(len(evm.stack.data))-1]
+	return nil
+}
+
+func (evm *EVM) log(topicCount uint64, gasCost uint64) error {
+	if err := evm.writable(OpCode(0xa0 + topicCount)); err != nil {
+		return err
+	}
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	offset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	size, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	offsetValue, ok := offset.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	sizeValue, ok := size.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	data, err := evm.loadMemory(word.Uint64(offsetValue), word.Uint64(sizeValue))
+	if err != nil {
+		return err
+	}
+	topics := make([]common.Hash, topicCount)
+	for i := uint64(0); i < topicCount; i++ {
+		topic, err := evm.stack.pop()
+		if err != nil {
+			return err
+		}
+		topicValue, ok := topic.Value.(*big.Int)
+		if !ok {
+			return errors.New("compareOperation assertion failed")
+		}
+		topics[i] = word.ToHash(topicValue)
+	}
+	log := &types.Log{
+		Address: evm.contract.Address,
+		Topics:  topics,
+		Data:    append([]byte(nil), data...),
+	}
+	evm.logs = append(evm.logs, log)
+	if evm.hooks.OnLog != nil {
+		evm.hooks.OnLog(log)
+	}
+	return nil
+}
+
+func (evm *EVM) create(gasCost uint64) error {
+	if err := evm.writable(OpCode(0xf0)); err != nil {
+		return err
+	}
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	// the value is not transferred
+	if _, err := evm.stack.pop(); err != nil {
+		return err
+	}
+	offset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	size, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	offsetValue, ok := offset.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	sizeValue, ok := size.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	code, err := evm.loadMemory(word.Uint64(offsetValue), word.Uint64(sizeValue))
+	if err != nil {
+		return err
+	}
+	nonce := evm.statedb.GetNonce(evm.contract.Address)
+	evm.statedb.SetNonce(evm.contract.Address, nonce+1)
+	address := crypto.CreateAddress(evm.contract.Address, nonce)
+	evm.statedb.SetCode(address, code)
+	evm.created[address] = true
+	evm.stateLogger.Debug("contract created", "address", address.Hex(), "codeSize", len(code))
+	return evm.stack.push(Value{Type: Address, Value: address.Big()})
+}
+
+// the instructions executed by callFrame
+const (
+	opCall         OpCode = 0xf1
+	opDelegateCall OpCode = 0xf4
+	opStaticCall   OpCode = 0xfa
+)
+
+func (evm *EVM) call(gasCost uint64) error {
+	return evm.callFrame(opCall, gasCost)
+}
+
+func (evm *EVM) delegateCall(gasCost uint64) error {
+	return evm.callFrame(opDelegateCall, gasCost)
+}
+
+func (evm *EVM) staticCall(gasCost uint64) error {
+	return evm.callFrame(opStaticCall, gasCost)
+}
+
+// callFrame executes CALL, DELEGATECALL or STATICCALL. CALL pops 7 words,
+// the others 6 as they take no value, and each pushes 1 when the call
+// succeeds and 0 when it fails. The callee frame is read-only if the caller
+// is or if kind is STATICCALL: write protection is inherited by every
+// nested frame, DELEGATECALLs included. A read-only frame may still CALL
+// other contracts, as long as it transfers no value.
+func (evm *EVM) callFrame(kind OpCode, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	// Pop arguments from stack: gas, address, [value], argsOffset,
+	// argsSize, retOffset, retSize
+	gasLimit, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	address, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	callValue := new(big.Int)
+	if kind == opCall {
+		value, err := evm.stack.pop()
+		if err != nil {
+			return err
+		}
+		valueValue, ok := value.Value.(*big.Int)
+		if !ok {
+			return errors.New("compareOperation assertion failed")
+		}
+		if evm.readOnly && valueValue.Sign() != 0 {
+			return fmt.Errorf("%w: CALL with value", ErrWriteProtection)
+		}
+		callValue = valueValue
+	}
+	argsOffset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	argsSize, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	retOffset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	retSize, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	argsOffsetValue, ok := argsOffset.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	argsSizeValue, ok := argsSize.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	// Load call data from memory
+	input, err := evm.loadMemory(word.Uint64(argsOffsetValue), word.Uint64(argsSizeValue))
+	if err != nil {
+		return err
+	}
+
+	gasLimitValue, ok := gasLimit.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	retOffsetValue, ok := retOffset.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	retSizeValue, ok := retSize.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	// the return range is paid for before the call, whatever it returns
+	if err := evm.expandMemory(word.Uint64(retOffsetValue), word.Uint64(retSizeValue)); err != nil {
+		return err
+	}
+
+	// Get the contract to call
+	addr, ok := address.Value.(*big.Int)
+	if !ok {
+		return fmt.Errorf("contract not found")
+	}
+	if p, ok := evm.precompiles[word.ToAddress(addr)]; ok {
+		gas, err := evm.callGas(word.Uint64(gasLimitValue))
+		if err != nil {
+			return err
+		}
+		evm.gas -= gas
+		output, left, err := evm.callPrecompile(p, word.ToAddress(addr), input, gas)
+		evm.gas += left
+		if err != nil {
+			evm.returnData = nil
+			return evm.pushSuccess(false)
+		}
+		if err := evm.setCallOutput(output, retOffsetValue, retSizeValue); err != nil {
+			return err
+		}
+		return evm.pushSuccess(true)
+	}
+	// accounts without code, externally owned ones among them, just receive
+	// the value
+	if len(evm.statedb.GetCode(word.ToAddress(addr))) == 0 {
+		gas, err := evm.callGas(word.Uint64(gasLimitValue))
+		if err != nil {
+			return err
+		}
+		return evm.callCodeless(word.ToAddress(addr), callValue, input, gas)
+	}
+	if target, ok := types.ParseDelegation(evm.statedb.GetCode(word.ToAddress(addr))); ok {
+		if err := evm.useGas(evm.accessCost(target)); err != nil {
+			return err
+		}
+	}
+	gas, err := evm.callGas(word.Uint64(gasLimitValue))
+	if err != nil {
+		return err
+	}
+	contract := evm.contractAt(word.ToAddress(addr))
+	snapshot := evm.snapshot()
+	caller, value := evm.contract.Address, callValue
+	if kind == opDelegateCall {
+		// run the callee's code in the context of the calling frame
+		contract.Address, caller, value = evm.contract.Address, evm.caller, evm.value
+	} else if !evm.canTransfer(caller, callValue) {
+		evm.returnData = nil
+		return evm.pushSuccess(false)
+	} else if err := evm.transfer(caller, contract.Address, callValue); err != nil {
+		return err
+	}
+	evm.gas -= gas
+
+	// Execute the code of the called contract
+	calleeEVM := &EVM{
+		stack:       newStack(),
+		memory:      &Memory{},
+		contract:    contract,
+		caller:      caller,
+		value:       value,
+		input:       append([]byte(nil), input...),
+		pc:          0,
+		gas:         gas,
+		context:     evm.context,
+		statedb:     evm.statedb,
+		depth:       evm.depth + 1,
+		readOnly:    evm.readOnly || kind == opStaticCall,
+		logger:      evm.logger,
+		stateLogger: evm.stateLogger,
+		tracer:      evm.tracer,
+		intPool:     newIntPool(),
+		hooks:       evm.hooks,
+		invariants:  evm.invariants,
+		custom:      evm.custom,
+		intercepts:  evm.intercepts,
+		precompiles: evm.precompiles,
+		accessed:    evm.accessed,
+		created:     evm.created,
+		destructed:  evm.destructed,
+		refund:      evm.refund,
+		originals:   evm.originals,
+		warmSlots:   evm.warmSlots,
+		jumpdests:   evm.jumpdests,
+		chainConfig: evm.chainConfig,
+		loops:       evm.loops,
+	}
+
+	// Run the callee contract's code. A callee that reverts or halts
+	// exceptionally fails the call alone: its changes are rolled back and
+	// the caller continues. Executions aborted by the tooling stop the
+	// caller too.
+	if err := calleeEVM.run(evm.ctx, SpanCall); err != nil {
+		if HaltStatus(err) == StatusAborted {
+			return err
+		}
+		evm.revertToSnapshot(snapshot)
+		evm.returnData = nil
+		if errors.Is(err, ErrExecutionReverted) {
+			evm.gas += calleeEVM.gas
+			if err := evm.setCallOutput(calleeEVM.output, retOffsetValue, retSizeValue); err != nil {
+				return err
+			}
+		}
+		return evm.pushSuccess(false)
+	}
+	evm.gas += calleeEVM.gas
+	evm.logs = append(evm.logs, calleeEVM.logs...)
+
+	if err := evm.setCallOutput(calleeEVM.output, retOffsetValue, retSizeValue); err != nil {
+		return err
+	}
+	return evm.pushSuccess(true)
+}
+
+// callGas returns the gas a call forwards of the requested amount. From
+// Tangerine Whistle (EIP-150) it is capped at all but a 64th of the gas
+// left; before, requesting more than is left runs out of gas. The caller is
+// charged the forwarded gas and gets back what the callee leaves unused.
+func (evm *EVM) callGas(requested uint64) (uint64, error) {
+	if evm.isEIP150() {
+		return min(requested, evm.gas-evm.gas/64), nil
+	}
+	if requested > evm.gas {
+		return 0, ErrOutOfGas
+	}
+	return requested, nil
+}
+
+// setCallOutput makes the output of a call the return data of the frame
+// and copies as much of it as fits in retSize bytes to memory at retOffset
+func (evm *EVM) setCallOutput(output []byte, retOffset, retSize *big.Int) error {
+	evm.returnData = output
+	n := min(word.Uint64(retSize), uint64(len(output)))
+	return evm.memory.store(word.Uint64(retOffset), output[:n])
+}
+
+// pushSuccess pushes the outcome of a call: 1 if it succeeded, 0 if not
+func (evm *EVM) pushSuccess(ok bool) error {
+	value := evm.intPool.get()
+	if ok {
+		value.SetUint64(1)
+	}
+	return evm.stack.push(Value{Type: Uint256, Value: value})
+}
+
+// returnOp halts the frame successfully, with the memory range on the
+// stack as its output
+func (evm *EVM) returnOp(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	offset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	size, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	offsetValue, ok := offset.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	sizeValue, ok := size.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	data, err := evm.loadMemory(word.Uint64(offsetValue), word.Uint64(sizeValue))
+	if err != nil {
+		return err
+	}
+	evm.output = data
+	return ErrStop
+}
+
+func (evm *EVM) revert(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	offset, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	size, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	offsetValue, ok := offset.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	sizeValue, ok := size.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	data, err := evm.loadMemory(word.Uint64(offsetValue), word.Uint64(sizeValue))
+	if err != nil {
+		return err
+	}
+	evm.output = data
+	return ErrExecutionReverted
+}
@@ -0,0 +1,146 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrOpcodeDenied is wrapped by the errors of interceptors vetoing an
+// instruction. A veto is an exceptional halt.
+var ErrOpcodeDenied = errors.New("opcode denied")
+
+// Interceptor is middleware around the execution of instructions,
+// registered through Config.Interceptors. Unlike hooks, which only
+// observe, interceptors may veto an instruction, charge extra gas or
+// replace the instruction altogether, to enforce policies or experiment
+// with instruction semantics without patching the interpreter.
+//
+// For each instruction, the Before functions of the matching interceptors
+// run in registration order, then the instruction, then the After
+// functions of the interceptors reached, in reverse order. Interceptors
+// registered after one that fails or skips the instruction are not
+// reached.
+type Interceptor struct {
+	Name string   // identifies the interceptor in errors
+	Ops  []OpCode // intercepted opcodes, every opcode if empty
+
+	// Before runs before the instruction. Returning an error halts the
+	// frame with it, wrapping ErrOpcodeDenied to veto the instruction.
+	// Calling OpCall.Skip replaces the instruction: neither it nor the
+	// remaining Before functions run.
+	Before func(call *OpCall) error
+	// After runs after the instruction, or after the Before function that
+	// failed or skipped it, with the error the frame would halt with. The
+	// error it returns replaces err.
+	After func(call *OpCall, err error) error
+}
+
+// OpCall is what an interceptor sees of an intercepted instruction
+type OpCall struct {
+	Op    OpCode
+	PC    uint64
+	Depth int
+	Scope *ScopeContext // read-only view of the frame
+	State StateDB       // world state, which the interceptor may modify
+
+	evm     *EVM
+	skipped bool
+}
+
+// Skip replaces the instruction by the interceptor: the interpreter moves
+// on to the next instruction, and the interceptor is responsible for the
+// gas and stack effects of the one it replaced
+func (c *OpCall) Skip() { c.skipped = true }
+
+// Skipped reports whether an interceptor replaced the instruction
+func (c *OpCall) Skipped() bool { return c.skipped }
+
+// UseGas charges gas to the frame, failing with ErrOutOfGas if not enough
+// is left
+func (c *OpCall) UseGas(gas uint64) error { return c.evm.useGas(gas) }
+
+// Pop removes the top word of the stack
+func (c *OpCall) Pop() (*big.Int, error) {
+	v, err := c.evm.stack.pop()
+	if err != nil {
+		return nil, err
+	}
+	word, ok := v.Value.(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("%s: non-numeric stack word", c.Op)
+	}
+	return new(big.Int).Set(word), nil
+}
+
+// Push pushes a word on the stack
+func (c *OpCall) Push(word *big.Int) error {
+	if word == nil || word.Sign() < 0 || word.BitLen() > 256 {
+		return fmt.Errorf("%s: pushed a word outside the 256-bit range", c.Op)
+	}
+	return c.evm.stack.push(Value{Type: Uint256, Value: new(big.Int).Set(word)})
+}
+
+// interceptTable indexes interceptors by opcode, in registration order. It
+// panics on invalid definitions, which are programming errors of the
+// embedder.
+func interceptTable(interceptors []Interceptor) map[OpCode][]*Interceptor {
+	if len(interceptors) == 0 {
+		return nil
+	}
+	table := make(map[OpCode][]*Interceptor)
+	for i := range interceptors {
+		ic := &interceptors[i]
+		if ic.Before == nil && ic.After == nil {
+			panic(fmt.Sprintf("vm: interceptor %q has neither Before nor After", ic.Name))
+		}
+		if len(ic.Ops) == 0 {
+			for op := 0; op < 256; op++ {
+				table[OpCode(op)] = append(table[OpCode(op)], ic)
+			}
+			continue
+		}
+		for _, op := range ic.Ops {
+			table[op] = append(table[op], ic)
+		}
+	}
+	return table
+}
+
+// executeIntercepted executes an instruction through the interceptors
+// registered for it
+func (evm *EVM) executeIntercepted(opcode byte) error {
+	chain := evm.intercepts[OpCode(opcode)]
+	if len(chain) == 0 {
+		return evm.ExecuteOpcode(opcode)
+	}
+	call := &OpCall{Op: OpCode(opcode), PC: evm.pc, Depth: evm.depth, Scope: evm.scope, State: evm.statedb, evm: evm}
+	var err error
+	ran := 0
+	for _, ic := range chain {
+		ran++
+		if ic.Before == nil {
+			continue
+		}
+		if err = ic.Before(call); err != nil {
+			err = fmt.Errorf("interceptor %s: %w", ic.Name, err)
+			break
+		}
+		if call.skipped {
+			break
+		}
+	}
+	switch {
+	case err == nil && !call.skipped:
+		err = evm.ExecuteOpcode(opcode)
+	case call.skipped && call.Op.IsPush():
+		// the immediate bytes of a replaced PUSH are still not code
+		evm.pc += uint64(call.Op.PushSize())
+	}
+	for i := ran - 1; i >= 0; i-- {
+		if after := chain[i].After; after != nil {
+			err = after(call, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// StateDB is the world state accessed by the EVM
+type StateDB interface {
+	Exist(addr common.Address) bool
+
+	GetNonce(addr common.Address) uint64
+	SetNonce(addr common.Address, nonce uint64)
+
+	GetCode(addr common.Address) []byte
+	GetCodeHash(addr common.Address) common.Hash
+	SetCode(addr common.Address, code []byte)
+
+	GetState(addr common.Address, key common.Hash) common.Hash
+	SetState(addr common.Address, key, value common.Hash)
+}
+
+// BalanceStateDB is implemented by states that hold balances and can
+// delete accounts. SELFDESTRUCT moves balances and deletes the destructed
+// accounts only in such states.
+type BalanceStateDB interface {
+	StateDB
+	GetBalance(addr common.Address) *big.Int
+	AddBalance(addr common.Address, amount *big.Int)
+	SubBalance(addr common.Address, amount *big.Int)
+	DeleteAccount(addr common.Address)
+}
+
+// SnapshotStateDB is implemented by states that can roll back changes, as
+// state.StateDB does. The changes of a failed call are undone only in such
+// states.
+type SnapshotStateDB interface {
+	StateDB
+	Snapshot() int
+	RevertToSnapshot(id int)
+}
@@ -0,0 +1,48 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// Invariant is a property of the world state that must hold throughout an
+// execution. Check returns an error describing the violation, or nil.
+type Invariant struct {
+	Name  string
+	Check func(statedb StateDB) error
+}
+
+// InvariantError aborts an execution that violated an invariant
+type InvariantError struct {
+	Invariant string         // name of the violated invariant
+	Err       error          // violation reported by the invariant
+	Address   common.Address // contract executing when the violation was detected
+	PC        uint64
+	Depth     int
+	After     string // "SSTORE" or "frame exit"
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("invariant %q violated after %s at pc %d in %s (depth %d): %v", e.Invariant, e.After, e.PC, e.Address.Hex(), e.Depth, e.Err)
+}
+
+func (e *InvariantError) Unwrap() error { return e.Err }
+
+// checkInvariants evaluates the configured invariants, returning an
+// InvariantError for the first one that does not hold
+func (evm *EVM) checkInvariants(after string) error {
+	for _, inv := range evm.invariants {
+		if err := inv.Check(evm.statedb); err != nil {
+			return &InvariantError{
+				Invariant: inv.Name,
+				Err:       err,
+				Address:   evm.contract.Address,
+				PC:        evm.pc,
+				Depth:     evm.depth,
+				After:     after,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/types"
+)
+
+// MarshalJSON encodes the word as a hex quantity, or as an address for
+// address-typed values
+func (v Value) MarshalJSON() ([]byte, error) {
+	word, ok := v.Value.(*big.Int)
+	if !ok {
+		return nil, errors.New("value is not a word")
+	}
+	if v.Type == Address {
+		return json.Marshal(common.BigToAddress(word))
+	}
+	return json.Marshal((*hexutil.Big)(word))
+}
+
+// UnmarshalJSON decodes a hex quantity into a Uint256 value
+func (v *Value) UnmarshalJSON(input []byte) error {
+	var word hexutil.Big
+	if err := json.Unmarshal(input, &word); err != nil {
+		return err
+	}
+	*v = Value{Type: Uint256, Value: word.ToInt()}
+	return nil
+}
+
+type executionResultJSON struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []*types.Log   `json:"logs"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Refund     hexutil.Uint64 `json:"refund,omitempty"`
+	Status     Status         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Frames     []Frame        `json:"frames,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, rendering the error as a string
+func (r *ExecutionResult) MarshalJSON() ([]byte, error) {
+	enc := executionResultJSON{
+		ReturnData: r.ReturnData,
+		Logs:       r.Logs,
+		GasUsed:    hexutil.Uint64(r.GasUsed),
+		Refund:     hexutil.Uint64(r.Refund),
+		Status:     r.Status(),
+		Frames:     r.Frames,
+	}
+	if r.Err != nil {
+		enc.Error = r.Err.Error()
+	}
+	return json.Marshal(enc)
+}
+
+type frameJSON struct {
+	Address  common.Address `json:"address"`
+	Selector hexutil.Bytes  `json:"selector,omitempty"`
+	PC       uint64         `json:"pc"`
+	Op       string         `json:"op"`
+	Depth    int            `json:"depth"`
+	Reason   string         `json:"reason,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, naming the opcode
+func (f Frame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(frameJSON{
+		Address:  f.Address,
+		Selector: f.Selector,
+		PC:       f.PC,
+		Op:       f.Op.String(),
+		Depth:    f.Depth,
+		Reason:   f.Reason,
+	})
+}
+
+type contextJSON struct {
+	BlockNumber *hexutil.Big   `json:"number"`
+	Timestamp   *hexutil.Big   `json:"timestamp"`
+	Sender      common.Address `json:"sender"`
+	GasLimit    hexutil.Uint64 `json:"gasLimit"`
+	Gas         hexutil.Uint64 `json:"gas,omitempty"`
+	GasPrice    *hexutil.Big   `json:"gasPrice"`
+	Coinbase    common.Address `json:"coinbase"`
+	BaseFee     *hexutil.Big   `json:"baseFee,omitempty"`
+	Random      *common.Hash   `json:"random,omitempty"`
+	Difficulty  *hexutil.Big   `json:"difficulty,omitempty"`
+	ChainID     *hexutil.Big   `json:"chainId,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (c *Context) MarshalJSON() ([]byte, error) {
+	return json.Marshal(contextJSON{
+		BlockNumber: (*hexutil.Big)(c.BlockNumber),
+		Timestamp:   (*hexutil.Big)(c.Timestamp),
+		Sender:      c.Sender,
+		GasLimit:    hexutil.Uint64(c.GasLimit),
+		Gas:         hexutil.Uint64(c.Gas),
+		GasPrice:    (*hexutil.Big)(c.GasPrice),
+		Coinbase:    c.Coinbase,
+		BaseFee:     (*hexutil.Big)(c.BaseFee),
+		Random:      c.Random,
+		Difficulty:  (*hexutil.Big)(c.Difficulty),
+		ChainID:     (*hexutil.Big)(c.ChainID),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (c *Context) UnmarshalJSON(input []byte) error {
+	var dec contextJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	c.BlockNumber = dec.BlockNumber.ToInt()
+	c.Timestamp = dec.Timestamp.ToInt()
+	c.Sender = dec.Sender
+	c.GasLimit = uint64(dec.GasLimit)
+	c.Gas = uint64(dec.Gas)
+	c.GasPrice = dec.GasPrice.ToInt()
+	c.Coinbase = dec.Coinbase
+	c.BaseFee = dec.BaseFee.ToInt()
+	c.Random = dec.Random
+	c.Difficulty = dec.Difficulty.ToInt()
+	c.ChainID = dec.ChainID.ToInt()
+	return nil
+}
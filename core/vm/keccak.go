@@ -0,0 +1,49 @@
+package vm
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common/word"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// Keccak256WordGas is charged by KECCAK256 for every 32-byte word hashed,
+// rounded up
+const Keccak256WordGas = 6
+
+// keccak256 pushes the Keccak-256 hash of a memory range. Like RETURN, it
+// takes the offset from the top of the stack and the size from below.
+func (evm *EVM) keccak256(gasCost uint64) error {
+	if len(evm.stack.data) < 2 {
+		return ErrStackUnderflow
+	}
+	sizeValue, ok := evm.stack.data[len(evm.stack.data)-2].Value.(*big.Int)
+	if !ok {
+		return errors.New("keccak256 assertion failed")
+	}
+	size := word.Uint64(sizeValue)
+	words := toWordSize(size)
+	if words > (math.MaxUint64-gasCost)/Keccak256WordGas {
+		return ErrOutOfGas
+	}
+	if err := evm.useGas(gasCost + Keccak256WordGas*words); err != nil {
+		return err
+	}
+	offset, _ := evm.stack.pop()
+	evm.stack.pop()
+	offsetValue, ok := offset.Value.(*big.Int)
+	if !ok {
+		return errors.New("keccak256 assertion failed")
+	}
+	data, err := evm.loadMemory(word.Uint64(offsetValue), size)
+	if err != nil {
+		return err
+	}
+	var hash [word.Size]byte
+	crypto.Keccak256Into(hash[:], data)
+	result := evm.intPool.get().SetBytes(hash[:])
+	evm.intPool.put(offsetValue, sizeValue)
+	return evm.stack.push(Value{Type: Uint256, Value: result})
+}
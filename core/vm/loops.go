@@ -0,0 +1,88 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// DefaultLoopThreshold is the number of iterations without state progress
+// after which a loop is reported when LoopConfig.Threshold is zero
+const DefaultLoopThreshold = 1_000_000
+
+// ErrRunawayLoop is the error of executions aborted by loop detection. It
+// is distinct from running out of gas, which such loops only reach with
+// the gas limit of a block.
+var ErrRunawayLoop = errors.New("runaway loop")
+
+// LoopConfig enables the detection of loops that make no state progress,
+// for debugging with gas limits too large to stop them. A loop is a
+// backward jump to the same destination; its count is reset whenever the
+// frame writes storage, emits a log, calls or creates a contract, or
+// self-destructs.
+type LoopConfig struct {
+	Threshold uint64             // iterations before a loop is reported, DefaultLoopThreshold if zero
+	Abort     bool               // fail the frame with ErrRunawayLoop when a loop is reported
+	OnLoop    func(*RunawayLoop) // called once a loop reaches the threshold, may be nil
+}
+
+// RunawayLoop is a loop that reached the detection threshold
+type RunawayLoop struct {
+	Address    common.Address // contract running the loop
+	Depth      int            // depth of its frame
+	PC         uint64         // pc of the backward jump
+	Target     uint64         // destination of the jump, the start of the loop
+	Iterations uint64
+}
+
+func (l *RunawayLoop) String() string {
+	return fmt.Sprintf("loop at pc %d of %s jumped back to %d %d times without changing state", l.PC, l.Address.Hex(), l.Target, l.Iterations)
+}
+
+// makesProgress reports whether op can change state outside of the frame's
+// stack and memory
+func makesProgress(op OpCode) bool {
+	switch {
+	case op == 0x55, op == 0x5d: // SSTORE, TSTORE
+		return true
+	case op >= 0xa0 && op <= 0xa4: // LOG0-LOG4
+		return true
+	case op >= 0xf0 && op != 0xf3 && op != 0xfd && op != 0xfe: // calls, creates and SELFDESTRUCT
+		return true
+	}
+	return false
+}
+
+// checkLoop counts the backward jump op made from pc, if any, and reports
+// the loop once it reaches the threshold
+func (evm *EVM) checkLoop(op OpCode, pc uint64) error {
+	if makesProgress(op) {
+		evm.loopCounts = nil
+		return nil
+	}
+	target := evm.pc + 1
+	if (op != 0x56 && op != 0x57) || target > pc { // JUMP, JUMPI
+		return nil
+	}
+	if evm.loopCounts == nil {
+		evm.loopCounts = make(map[uint64]uint64)
+	}
+	evm.loopCounts[target]++
+	threshold := evm.loops.Threshold
+	if threshold == 0 {
+		threshold = DefaultLoopThreshold
+	}
+	if evm.loopCounts[target] != threshold {
+		return nil
+	}
+	loop := &RunawayLoop{Address: evm.contract.Address, Depth: evm.depth, PC: pc, Target: target, Iterations: threshold}
+	evm.logger.Warn("runaway loop", "address", loop.Address.Hex(), "pc", pc, "target", target, "iterations", threshold)
+	if evm.loops.OnLoop != nil {
+		evm.loops.OnLoop(loop)
+	}
+	if evm.loops.Abort {
+		return fmt.Errorf("%w: %s", ErrRunawayLoop, loop)
+	}
+	return nil
+}
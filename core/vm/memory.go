@@ -0,0 +1,203 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/nutcas3/evm-golang/common/word"
+)
+
+// Memory gas: growing memory to w words charges the difference between
+// MemoryGas*w + w*w/QuadCoeffDiv and the same for its previous size
+const (
+	MemoryGas    = 3
+	QuadCoeffDiv = 512
+)
+
+// Memory represents the EVM memory. Its size is always a multiple of 32
+// bytes: accesses past the end, reads included, extend it with zeros to the
+// end of the word they reach. Accesses of no bytes leave it unchanged.
+type Memory struct {
+	data []byte
+}
+
+// Memory methods
+func (m *Memory) store(offset uint64, value []byte) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if err := m.resize(offset, uint64(len(value))); err != nil {
+		return err
+	}
+	copy(m.data[offset:], value)
+	return nil
+}
+
+func (m *Memory) load(offset uint64, size uint64) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	if err := m.resize(offset, size); err != nil {
+		return nil, err
+	}
+	return m.data[offset : offset+size], nil
+}
+
+// resize extends the memory with zeros to hold size bytes from offset,
+// rounded up to a whole word
+func (m *Memory) resize(offset, size uint64) error {
+	// offsets taken from words too large for a uint64 saturate, so the
+	// sum is checked for wrapping around
+	end, carry := bits.Add64(offset, size, 0)
+	if carry != 0 || end > MaxMemorySize {
+		return fmt.Errorf("memory size exceeded")
+	}
+	if uint64(len(m.data)) >= end {
+		return nil
+	}
+	newSize := toWordSize(end) * word.Size
+	if uint64(cap(m.data)) >= newSize {
+		// reuse the buffer kept by EVM.Reset, zeroing what it held
+		old := len(m.data)
+		m.data = m.data[:newSize]
+		clear(m.data[old:])
+	} else {
+		newData := make([]byte, newSize)
+		copy(newData, m.data)
+		m.data = newData
+	}
+	return nil
+}
+
+// memoryGasCost returns the gas paid for memory of the given number of
+// words
+func memoryGasCost(words uint64) uint64 {
+	return MemoryGas*words + words*words/QuadCoeffDiv
+}
+
+// expandMemory charges for growing memory to hold size bytes from offset
+// and grows it. Accesses of no bytes are free and leave memory unchanged.
+func (evm *EVM) expandMemory(offset, size uint64) error {
+	if size == 0 {
+		return nil
+	}
+	end, carry := bits.Add64(offset, size, 0)
+	if carry != 0 || end > MaxMemorySize {
+		return fmt.Errorf("memory size exceeded")
+	}
+	if words, old := toWordSize(end), uint64(len(evm.memory.data))/word.Size; words > old {
+		if err := evm.useGas(memoryGasCost(words) - memoryGasCost(old)); err != nil {
+			return err
+		}
+	}
+	return evm.memory.resize(offset, size)
+}
+
+// loadMemory returns size bytes of memory from offset, charging for any
+// expansion
+func (evm *EVM) loadMemory(offset, size uint64) ([]byte, error) {
+	if err := evm.expandMemory(offset, size); err != nil {
+		return nil, err
+	}
+	return evm.memory.load(offset, size)
+}
+
+// storeMemory writes value to memory at offset, charging for any
+// expansion
+func (evm *EVM) storeMemory(offset uint64, value []byte) error {
+	if err := evm.expandMemory(offset, uint64(len(value))); err != nil {
+		return err
+	}
+	return evm.memory.store(offset, value)
+}
+
+// memoryOperands pops n words, the top one last, as offsets or sizes
+func (evm *EVM) memoryOperands(n int) ([]*big.Int, error) {
+	if len(evm.stack.data) < n {
+		return nil, ErrStackUnderflow
+	}
+	operands := make([]*big.Int, n)
+	for i := range operands {
+		value, ok := evm.stack.data[len(evm.stack.data)-n+i].Value.(*big.Int)
+		if !ok {
+			return nil, errors.New("memoryOperands assertion failed")
+		}
+		operands[i] = value
+	}
+	evm.stack.data = evm.stack.data[:len(evm.stack.data)-n]
+	return operands, nil
+}
+
+// mload replaces the offset on top of the stack with the word of memory
+// starting there
+func (evm *EVM) mload(gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	operands, err := evm.memoryOperands(1)
+	if err != nil {
+		return err
+	}
+	data, err := evm.loadMemory(word.Uint64(operands[0]), word.Size)
+	if err != nil {
+		return err
+	}
+	return evm.stack.push(Value{Type: Uint256, Value: operands[0].SetBytes(data)})
+}
+
+// mstore writes a word to memory. As SSTORE does with its key, it takes
+// the offset from the top of the stack and the value below it. MSTORE8
+// writes only the low byte of the value.
+func (evm *EVM) mstore(size uint64, gasCost uint64) error {
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	operands, err := evm.memoryOperands(2)
+	if err != nil {
+		return err
+	}
+	defer evm.intPool.put(operands...)
+	value := word.Bytes32(operands[0])
+	return evm.storeMemory(word.Uint64(operands[1]), value[word.Size-size:])
+}
+
+// mcopy copies memory to memory (EIP-5656), overlapping ranges included.
+// Like CALLDATACOPY it takes the destination offset from the top of the
+// stack, the source offset below it and the size below that, and charges
+// CopyWordGas for every word copied along with the memory expansion.
+func (evm *EVM) mcopy(gasCost uint64) error {
+	if len(evm.stack.data) < 3 {
+		return ErrStackUnderflow
+	}
+	size, ok := evm.stack.data[len(evm.stack.data)-3].Value.(*big.Int)
+	if !ok {
+		return errors.New("mcopy assertion failed")
+	}
+	words := toWordSize(word.Uint64(size))
+	if words > (math.MaxUint64-gasCost)/CopyWordGas {
+		return ErrOutOfGas
+	}
+	if err := evm.useGas(gasCost + CopyWordGas*words); err != nil {
+		return err
+	}
+	operands, err := evm.memoryOperands(3)
+	if err != nil {
+		return err
+	}
+	defer evm.intPool.put(operands...)
+	n, src, dest := word.Uint64(operands[0]), word.Uint64(operands[1]), word.Uint64(operands[2])
+	if n == 0 {
+		return nil
+	}
+	if err := evm.expandMemory(src, n); err != nil {
+		return err
+	}
+	if err := evm.expandMemory(dest, n); err != nil {
+		return err
+	}
+	copy(evm.memory.data[dest:dest+n], evm.memory.data[src:src+n])
+	return nil
+}
@@ -0,0 +1,44 @@
+package vm
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Span names emitted by the EVM
+const (
+	SpanTransaction = "evm.transaction"
+	SpanCall        = "evm.call"
+)
+
+// spanTracer returns the configured OpenTelemetry tracer, or a no-op tracer
+// when spans are disabled
+func spanTracer(config Config) trace.Tracer {
+	if config.Tracer == nil {
+		return noop.NewTracerProvider().Tracer("")
+	}
+	return config.Tracer
+}
+
+func (evm *EVM) frameAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("evm.address", evm.contract.Address.Hex()),
+		attribute.Int("evm.depth", evm.depth),
+		attribute.Int64("evm.gas_limit", int64(evm.gas)),
+		attribute.Int("evm.code_size", len(evm.contract.Code)),
+	}
+}
+
+// endSpan records the gas used and the halting status of a frame
+func endSpan(span trace.Span, gasUsed uint64, err error) {
+	span.SetAttributes(attribute.Int64("evm.gas_used", int64(gasUsed)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
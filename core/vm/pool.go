@@ -0,0 +1,38 @@
+package vm
+
+import "math/big"
+
+// maxPooledInts bounds the number of idle big.Int instances kept per frame
+const maxPooledInts = 256
+
+// intPool is a per-frame free list recycling the big.Int instances of stack
+// words that have been consumed. Ints handed to put must no longer be
+// referenced anywhere else, which is why DUP and SLOAD push copies instead of
+// sharing values.
+type intPool struct {
+	ints []*big.Int
+}
+
+func newIntPool() *intPool {
+	return &intPool{ints: make([]*big.Int, 0, maxPooledInts)}
+}
+
+// get returns a zeroed big.Int, reusing a recycled one when available
+func (p *intPool) get() *big.Int {
+	if n := len(p.ints); n > 0 {
+		x := p.ints[n-1]
+		p.ints = p.ints[:n-1]
+		return x.SetUint64(0)
+	}
+	return new(big.Int)
+}
+
+// put hands big.Ints back to the pool for later reuse
+func (p *intPool) put(ints ...*big.Int) {
+	for _, x := range ints {
+		if len(p.ints) == maxPooledInts {
+			return
+		}
+		p.ints = append(p.ints, x)
+	}
+}
@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// PrecompiledContract is a contract implemented in Go rather than
+// bytecode, such as the system contracts of an L2
+type PrecompiledContract interface {
+	RequiredGas(input []byte) uint64
+	Run(input []byte) ([]byte, error)
+}
+
+// StubPrecompile is a precompile that charges a constant amount of gas and
+// returns a fixed output, whatever its input
+type StubPrecompile struct {
+	Gas    uint64
+	Output []byte
+}
+
+func (p *StubPrecompile) RequiredGas([]byte) uint64 { return p.Gas }
+
+func (p *StubPrecompile) Run([]byte) ([]byte, error) {
+	return append([]byte(nil), p.Output...), nil
+}
+
+// ChainPrecompiles returns stubs for the precompiles declared by a chain
+// config together with the given implementations, which take precedence
+func ChainPrecompiles(config *params.ChainConfig, precompiles map[common.Address]PrecompiledContract) map[common.Address]PrecompiledContract {
+	if config == nil || len(config.Precompiles) == 0 {
+		return precompiles
+	}
+	merged := make(map[common.Address]PrecompiledContract, len(config.Precompiles)+len(precompiles))
+	for _, p := range config.Precompiles {
+		merged[p.Address] = &StubPrecompile{Gas: p.Gas, Output: p.Output}
+	}
+	for addr, p := range precompiles {
+		merged[addr] = p
+	}
+	return merged
+}
+
+// callPrecompile runs a precompile in a call frame of its own, with the
+// gas the call forwards, and returns its output and the gas left over. A
+// precompile requiring more gas than is forwarded fails with ErrOutOfGas;
+// failed precompiles consume all the gas forwarded.
+func (evm *EVM) callPrecompile(p PrecompiledContract, addr common.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	depth := evm.depth + 1
+	if evm.hooks.OnEnter != nil {
+		evm.hooks.OnEnter(depth, evm.contract.Address, addr, input, gas)
+	}
+	cost := p.RequiredGas(input)
+	var output []byte
+	err := ErrOutOfGas
+	if cost <= gas {
+		output, err = p.Run(input)
+	}
+	if err != nil {
+		output, cost = nil, gas
+	}
+	if evm.hooks.OnExit != nil {
+		evm.hooks.OnExit(depth, output, cost, err)
+	}
+	return output, gas - cost, err
+}
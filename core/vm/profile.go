@@ -0,0 +1,46 @@
+//go:build !tinygo
+
+package vm
+
+import (
+	"context"
+	"io"
+	"runtime"
+	rpprof "runtime/pprof"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// Profiles selects the profiles captured around a single Run call. Nil
+// writers are skipped.
+type Profiles struct {
+	CPU  io.Writer // CPU profile covering the whole run
+	Heap io.Writer // heap profile taken once the run has finished
+}
+
+// RunWithProfiles executes Run while capturing the requested profiles. Only
+// one CPU profile can be active per process, so concurrent profiled runs fail.
+func (evm *EVM) RunWithProfiles(ctx context.Context, address common.Address, profiles Profiles) (*ExecutionResult, error) {
+	return Profile(profiles, func() *ExecutionResult { return evm.Run(ctx, address) })
+}
+
+// Profile captures the requested profiles around a single execution, such as
+// a Run or Resume call
+func Profile(profiles Profiles, execute func() *ExecutionResult) (*ExecutionResult, error) {
+	if profiles.CPU != nil {
+		if err := rpprof.StartCPUProfile(profiles.CPU); err != nil {
+			return nil, err
+		}
+	}
+	result := execute()
+	if profiles.CPU != nil {
+		rpprof.StopCPUProfile()
+	}
+	if profiles.Heap != nil {
+		runtime.GC() // materialize up-to-date allocation statistics
+		if err := rpprof.WriteHeapProfile(profiles.Heap); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,35 @@
+//go:build tinygo
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// errNoProfiling is returned when profiles are requested from a TinyGo
+// build, which has no runtime/pprof
+var errNoProfiling = errors.New("profiling is not supported in TinyGo builds")
+
+// Profiles selects the profiles captured around a single Run call. Nil
+// writers are skipped.
+type Profiles struct {
+	CPU  io.Writer // CPU profile covering the whole run
+	Heap io.Writer // heap profile taken once the run has finished
+}
+
+// RunWithProfiles executes Run. Requesting a profile fails in TinyGo builds.
+func (evm *EVM) RunWithProfiles(ctx context.Context, address common.Address, profiles Profiles) (*ExecutionResult, error) {
+	return Profile(profiles, func() *ExecutionResult { return evm.Run(ctx, address) })
+}
+
+// Profile runs execute. Requesting a profile fails in TinyGo builds.
+func Profile(profiles Profiles, execute func() *ExecutionResult) (*ExecutionResult, error) {
+	if profiles.CPU != nil || profiles.Heap != nil {
+		return nil, errNoProfiling
+	}
+	return execute(), nil
+}
@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"context"
+	"math/big"
+	"sync"
+)
+
+// Reset prepares the EVM for a new execution against statedb in blockCtx,
+// as if NewEVM had been called with the same Config. The stack, memory,
+// integer pool and per-execution maps are kept and cleared rather than
+// reallocated, so services running many short executions can reuse
+// machines instead of building one per call. The result of the previous
+// Run must not be used after Reset: its return data may point into the
+// memory the next execution overwrites.
+func (evm *EVM) Reset(blockCtx *Context, statedb StateDB) {
+	clear(evm.stack.data)
+	evm.stack.data = evm.stack.data[:0]
+	evm.memory.data = evm.memory.data[:0]
+
+	evm.contract = nil
+	evm.caller = blockCtx.Sender
+	evm.input = nil
+	evm.value = new(big.Int)
+	evm.pc = 0
+	evm.gas = blockCtx.gas()
+	evm.context = blockCtx
+	evm.statedb = statedb
+	evm.returnData = nil
+	evm.output = nil
+	evm.logs = nil
+	evm.depth = 0
+	evm.readOnly = false
+	evm.ctx = context.Background()
+	evm.scope = nil
+
+	clear(evm.accessed)
+	clear(evm.created)
+	clear(evm.destructed)
+	*evm.refund = 0
+	clear(evm.originals)
+	clear(evm.warmSlots)
+	evm.loopCounts = nil
+
+	evm.initialGas = 0
+	evm.suspended.Store(false)
+	evm.stepLimit = 0
+	evm.steps = 0
+}
+
+// Pool hands out EVMs built with one Config and resets them for each
+// execution. It is safe for concurrent use.
+type Pool struct {
+	config Config
+	pool   sync.Pool
+}
+
+// NewPool returns a pool of EVMs built with config
+func NewPool(config Config) *Pool {
+	return &Pool{config: config}
+}
+
+// Get returns an EVM executing against statedb in blockCtx, reused from
+// the pool if one is available
+func (p *Pool) Get(blockCtx *Context, statedb StateDB) *EVM {
+	if evm, ok := p.pool.Get().(*EVM); ok {
+		evm.Reset(blockCtx, statedb)
+		return evm
+	}
+	return NewEVM(blockCtx, statedb, p.config)
+}
+
+// Put returns an EVM to the pool once its result is no longer used
+func (p *Pool) Put(evm *EVM) {
+	p.pool.Put(evm)
+}
@@ -0,0 +1,60 @@
+package vm
+
+import "math/big"
+
+// The fork rules of the executing block, taken from Config.ChainConfig.
+// Without a chain config every fork is active.
+
+// isMerge reports whether the block follows the merge, when DIFFICULTY
+// became PREVRANDAO
+func (evm *EVM) isMerge() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsMerge(evm.blockNumber())
+}
+
+// isEIP150 reports whether Tangerine Whistle rules apply to the executing
+// block
+func (evm *EVM) isEIP150() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsEIP150(evm.blockNumber())
+}
+
+// isSpuriousDragon reports whether Spurious Dragon rules apply to the
+// executing block
+func (evm *EVM) isSpuriousDragon() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsEIP155(evm.blockNumber())
+}
+
+// isIstanbul reports whether Istanbul rules apply to the executing block
+func (evm *EVM) isIstanbul() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsIstanbul(evm.blockNumber())
+}
+
+// isLondon reports whether London rules apply to the executing block
+func (evm *EVM) isLondon() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsLondon(evm.blockNumber())
+}
+
+// isShanghai reports whether Shanghai rules apply to the executing block
+func (evm *EVM) isShanghai() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsShanghai(evm.blockNumber(), evm.blockTime())
+}
+
+// isCancun reports whether Cancun rules apply to the executing block
+func (evm *EVM) isCancun() bool {
+	return evm.chainConfig == nil || evm.chainConfig.IsCancun(evm.blockNumber(), evm.blockTime())
+}
+
+// blockTime returns the timestamp of the executing block, zero if unset
+func (evm *EVM) blockTime() uint64 {
+	if evm.context.Timestamp == nil {
+		return 0
+	}
+	return evm.context.Timestamp.Uint64()
+}
+
+// blockNumber returns the number of the executing block, zero if unset
+func (evm *EVM) blockNumber() *big.Int {
+	if evm.context.BlockNumber == nil {
+		return new(big.Int)
+	}
+	return evm.context.BlockNumber
+}
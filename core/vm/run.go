@@ -0,0 +1,39 @@
+package vm
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/state"
+)
+
+// RunAddress is the address Run deploys its code at
+var RunAddress = common.Address{19: 0xc0}
+
+// RunGasLimit is the gas Run gives executions whose context sets none
+const RunGasLimit = 10_000_000
+
+// Run executes code with the given call data in a fresh in-memory state
+// and returns its return data, logs and gas used. A nil blockCtx runs in
+// block 1 with RunGasLimit gas; embedders needing a state of their own,
+// hooks or fork rules use NewEVM instead.
+func Run(code, input []byte, blockCtx *Context) *ExecutionResult {
+	if blockCtx == nil {
+		blockCtx = &Context{
+			BlockNumber: big.NewInt(1),
+			Timestamp:   big.NewInt(1),
+			GasPrice:    new(big.Int),
+		}
+	}
+	if blockCtx.GasLimit == 0 {
+		ctx := *blockCtx
+		ctx.GasLimit = RunGasLimit
+		blockCtx = &ctx
+	}
+	statedb := state.New()
+	statedb.SetCode(RunAddress, code)
+	evm := NewEVM(blockCtx, statedb, Config{})
+	evm.SetInput(input)
+	return evm.Run(context.Background(), RunAddress)
+}
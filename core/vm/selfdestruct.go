@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common/word"
+)
+
+const (
+	// SelfdestructGas is the constant cost of SELFDESTRUCT
+	SelfdestructGas = 5000
+	// SelfdestructNewAccountGas is charged on top when SELFDESTRUCT sends
+	// a balance to an account that does not exist
+	SelfdestructNewAccountGas = 25000
+	// SelfdestructRefundGas is refunded for every account destructed before
+	// London, which removed the refund (EIP-3529)
+	SelfdestructRefundGas = 24000
+)
+
+// selfdestruct sends the balance of the executing contract to the
+// beneficiary on the stack and halts the frame. The account is deleted
+// when the execution succeeds; from Cancun on (EIP-6780) only if it was
+// created by the same execution, other accounts just lose their balance.
+// A destructed account that is its own beneficiary burns its balance.
+// Before London the first destruction of each account earns a refund.
+func (evm *EVM) selfdestruct(gasCost uint64) error {
+	if err := evm.writable(OpCode(0xff)); err != nil {
+		return err
+	}
+	if err := evm.useGas(gasCost); err != nil {
+		return err
+	}
+	beneficiaryWord, err := evm.stack.pop()
+	if err != nil {
+		return err
+	}
+	beneficiaryValue, ok := beneficiaryWord.Value.(*big.Int)
+	if !ok {
+		return errors.New("compareOperation assertion failed")
+	}
+	beneficiary, self := word.ToAddress(beneficiaryValue), evm.contract.Address
+	destroy := !evm.isCancun() || evm.created[self]
+
+	if db, ok := evm.statedb.(BalanceStateDB); ok {
+		balance := db.GetBalance(self)
+		if balance.Sign() > 0 && !db.Exist(beneficiary) {
+			if err := evm.useGas(SelfdestructNewAccountGas); err != nil {
+				return err
+			}
+		}
+		if beneficiary != self || destroy {
+			db.SubBalance(self, balance)
+			if beneficiary != self {
+				db.AddBalance(beneficiary, balance)
+			}
+		}
+	}
+	if destroy {
+		if !evm.destructed[self] && !evm.isLondon() {
+			*evm.refund += SelfdestructRefundGas
+		}
+		evm.destructed[self] = true
+	}
+	evm.stateLogger.Debug("self-destruct", "address", self.Hex(), "beneficiary", beneficiary.Hex(), "deleted", destroy)
+	return ErrStop
+}
+
+// deleteDestructed deletes the accounts self-destructed by a successful
+// execution from states that support it
+func (evm *EVM) deleteDestructed() {
+	db, ok := evm.statedb.(BalanceStateDB)
+	if !ok {
+		return
+	}
+	for addr := range evm.destructed {
+		db.DeleteAccount(addr)
+	}
+}
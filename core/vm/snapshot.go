@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"maps"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// callSnapshot is what a failed call rolls back: the state, in states that
+// support it, and the records of the execution the callee may have added to
+type callSnapshot struct {
+	state      int
+	refund     uint64
+	accessed   map[common.Address]bool
+	created    map[common.Address]bool
+	destructed map[common.Address]bool
+	warmSlots  map[storageKey]bool
+}
+
+// snapshot records what a call may change before it runs
+func (evm *EVM) snapshot() *callSnapshot {
+	s := &callSnapshot{
+		state:      -1,
+		refund:     *evm.refund,
+		accessed:   maps.Clone(evm.accessed),
+		created:    maps.Clone(evm.created),
+		destructed: maps.Clone(evm.destructed),
+		warmSlots:  maps.Clone(evm.warmSlots),
+	}
+	if db, ok := evm.statedb.(SnapshotStateDB); ok {
+		s.state = db.Snapshot()
+	}
+	return s
+}
+
+// revertToSnapshot undoes the changes made since s was taken. The maps are
+// shared by every frame of the execution, so they are refilled in place.
+func (evm *EVM) revertToSnapshot(s *callSnapshot) {
+	if db, ok := evm.statedb.(SnapshotStateDB); ok && s.state >= 0 {
+		db.RevertToSnapshot(s.state)
+	}
+	*evm.refund = s.refund
+	restore(evm.accessed, s.accessed)
+	restore(evm.created, s.created)
+	restore(evm.destructed, s.destructed)
+	restore(evm.warmSlots, s.warmSlots)
+}
+
+// restore makes dst a copy of src
+func restore[K comparable](dst, src map[K]bool) {
+	clear(dst)
+	maps.Copy(dst, src)
+}
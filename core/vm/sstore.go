@@ -0,0 +1,111 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// SstoreRules prices SSTORE under the rules of a fork. The rules with net
+// metering price a store from the slot's original value, at the start of
+// the transaction, as well as its current and new values, so that only
+// the first change of a slot in a transaction pays the full price.
+type SstoreRules struct {
+	Name        string
+	NetMetering bool   // EIP-1283 and later
+	Sentry      bool   // SSTORE fails with SstoreSentryGas or less left (EIP-2200)
+	ReadGas     uint64 // charged for no-op stores and slots already changed, with net metering
+	SetGas      uint64 // charged for making a zero slot nonzero
+	ResetGas    uint64 // charged for other changes
+	ClearRefund uint64 // refunded for clearing a slot
+	ColdGas     uint64 // charged on top for a slot not yet accessed by the transaction (EIP-2929)
+}
+
+// The SSTORE rules of each fork
+var (
+	// SstoreFrontier prices every store from the current value alone
+	SstoreFrontier = &SstoreRules{Name: "frontier", SetGas: 20000, ResetGas: 5000, ClearRefund: 15000}
+	// SstoreEIP1283 is Constantinople's net metering, which Petersburg
+	// reverted
+	SstoreEIP1283 = &SstoreRules{Name: "eip1283", NetMetering: true, ReadGas: 200, SetGas: 20000, ResetGas: 5000, ClearRefund: 15000}
+	// SstoreEIP2200 is Istanbul's net metering
+	SstoreEIP2200 = &SstoreRules{Name: "eip2200", NetMetering: true, Sentry: true, ReadGas: 800, SetGas: 20000, ResetGas: 5000, ClearRefund: 15000}
+	// SstoreEIP2929 is Berlin's net metering with cold slot surcharges
+	SstoreEIP2929 = &SstoreRules{Name: "eip2929", NetMetering: true, Sentry: true, ReadGas: 100, SetGas: 20000, ResetGas: 2900, ClearRefund: 15000, ColdGas: 2100}
+	// SstoreEIP3529 is London's, with the clearing refund reduced
+	SstoreEIP3529 = &SstoreRules{Name: "eip3529", NetMetering: true, Sentry: true, ReadGas: 100, SetGas: 20000, ResetGas: 2900, ClearRefund: 4800, ColdGas: 2100}
+)
+
+// SstoreRulesFor returns the SSTORE rules of the given block, those of the
+// latest fork without a chain config
+func SstoreRulesFor(config *params.ChainConfig, number *big.Int) *SstoreRules {
+	switch {
+	case config == nil || config.IsLondon(number):
+		return SstoreEIP3529
+	case config.IsBerlin(number):
+		return SstoreEIP2929
+	case config.IsIstanbul(number):
+		return SstoreEIP2200
+	case config.IsPetersburg(number):
+		return SstoreFrontier
+	case config.IsConstantinople(number):
+		return SstoreEIP1283
+	}
+	return SstoreFrontier
+}
+
+// Price returns the gas charged for storing value in a slot holding
+// current, whose value at the start of the transaction was original, and
+// the change of the refund counter. warm reports whether the transaction
+// already accessed the slot.
+func (r *SstoreRules) Price(original, current, value common.Hash, warm bool) (gas uint64, refund int64) {
+	if !warm {
+		gas = r.ColdGas
+	}
+	if !r.NetMetering {
+		if current.IsZero() && !value.IsZero() {
+			gas += r.SetGas
+		} else {
+			gas += r.ResetGas
+		}
+		if !current.IsZero() && value.IsZero() {
+			refund = int64(r.ClearRefund)
+		}
+		return gas, refund
+	}
+	if current == value {
+		return gas + r.ReadGas, 0
+	}
+	if original == current {
+		if original.IsZero() {
+			return gas + r.SetGas, 0
+		}
+		if value.IsZero() {
+			refund = int64(r.ClearRefund)
+		}
+		return gas + r.ResetGas, refund
+	}
+	// the slot was already changed by the transaction
+	if !original.IsZero() {
+		if current.IsZero() {
+			refund -= int64(r.ClearRefund)
+		} else if value.IsZero() {
+			refund += int64(r.ClearRefund)
+		}
+	}
+	if original == value {
+		if original.IsZero() {
+			refund += int64(r.SetGas - r.ReadGas)
+		} else {
+			refund += int64(r.ResetGas - r.ReadGas)
+		}
+	}
+	return gas + r.ReadGas, refund
+}
+
+// storageKey identifies a storage slot of an account
+type storageKey struct {
+	addr common.Address
+	slot common.Hash
+}
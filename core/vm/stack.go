@@ -0,0 +1,44 @@
+package vm
+
+// DataType represents different Ethereum data types
+type DataType int
+
+const (
+	Uint256 DataType = iota
+	Address
+	Bytes32
+)
+
+// Value represents a typed value in the EVM
+type Value struct {
+	Type  DataType
+	Value interface{}
+}
+
+// Stack represents the EVM stack. Words are stored inline so that pushing
+// and popping never allocates once the backing array has grown.
+type Stack struct {
+	data []Value
+}
+
+func newStack() *Stack {
+	return &Stack{data: make([]Value, 0, 16)}
+}
+
+// Stack methods
+func (s *Stack) push(value Value) error {
+	if len(s.data) >= MaxStackDepth {
+		return ErrStackOverflow
+	}
+	s.data = append(s.data, value)
+	return nil
+}
+
+func (s *Stack) pop() (Value, error) {
+	if len(s.data) == 0 {
+		return Value{}, ErrStackUnderflow
+	}
+	value := s.data[len(s.data)-1]
+	s.data = s.data[:len(s.data)-1]
+	return value, nil
+}
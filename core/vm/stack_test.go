@@ -0,0 +1,60 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// stackBenchmarks are stack-balanced instruction sequences, so they can be
+// repeated on one frame, each preceded by the words it expects
+var stackBenchmarks = []struct {
+	name  string
+	words int // pushed before the code runs
+	code  []byte
+}{
+	{"PUSH1", 0, []byte{0x60, 0x01, 0x50}},                                       // PUSH1 1 POP
+	{"PUSH32", 0, append(append([]byte{0x7f}, make([]byte, 31)...), 0xff, 0x50)}, // PUSH32 0xff POP
+	{"DUP1", 1, []byte{0x80, 0x50}},                                              // DUP1 POP
+	{"SWAP1", 2, []byte{0x90}},                                                   // SWAP1
+	{"POP", 0, []byte{0x5f, 0x50}},                                               // PUSH0 POP
+}
+
+// newStackFrame returns a frame executing code with the given number of
+// words on its stack
+func newStackFrame(code []byte, words int) *EVM {
+	evm := NewEVM(&Context{GasLimit: math.MaxUint64}, nil, Config{})
+	evm.contract = &Contract{Code: code}
+	for range words {
+		evm.stack.push(Value{Type: Uint256, Value: big.NewInt(1)})
+	}
+	return evm
+}
+
+// execute runs the frame's code once from the start
+func execute(tb testing.TB, evm *EVM) {
+	for evm.pc = 0; evm.pc < uint64(len(evm.contract.Code)); evm.pc++ {
+		if err := evm.ExecuteOpcode(evm.contract.Code[evm.pc]); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStackOps measures PUSH, DUP, SWAP and POP, which must not
+// allocate once the frame's stack and integer pool are warm
+func BenchmarkStackOps(b *testing.B) {
+	for _, bm := range stackBenchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			evm := newStackFrame(bm.code, bm.words)
+			execute(b, evm) // warm the integer pool
+			if allocs := testing.AllocsPerRun(100, func() { execute(b, evm) }); allocs != 0 {
+				b.Fatalf("%s: %v allocations per run, want 0", bm.name, allocs)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for b.Loop() {
+				execute(b, evm)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWriteProtection is returned when a read-only frame, one entered by
+// STATICCALL or nested in one, tries to modify the state
+var ErrWriteProtection = errors.New("write protection")
+
+// SetReadOnly makes the top-level frame read-only, as if it had been
+// entered by STATICCALL, which eth_call style callers use to reject state
+// changes. Call it before Run.
+func (evm *EVM) SetReadOnly(readOnly bool) {
+	evm.readOnly = readOnly
+}
+
+// writable fails if op would modify the state of a read-only frame
+func (evm *EVM) writable(op OpCode) error {
+	if evm.readOnly {
+		return fmt.Errorf("%w: %s", ErrWriteProtection, op)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Halt errors. Exceptional halts wrap one of them, so callers can tell the
+// cause apart with errors.Is.
+var (
+	ErrOutOfGas          = errors.New("out of gas")
+	ErrInvalidOpcode     = errors.New("unknown opcode")
+	ErrExecutionReverted = errors.New("execution reverted")
+	ErrStackUnderflow    = errors.New("stack underflow")
+	ErrStackOverflow     = errors.New("stack overflow")
+	ErrSstoreSentry      = errors.New("not enough gas for SSTORE")
+	ErrInvalidJump       = errors.New("invalid jump destination")
+)
+
+// Status is how an execution halted
+type Status uint8
+
+const (
+	// StatusSuccess is a normal halt by STOP, RETURN or the end of the code
+	StatusSuccess Status = iota
+	// StatusReverted is a REVERT: state changes are rolled back, the unused
+	// gas is returned and the return data holds the revert reason
+	StatusReverted
+	// StatusOutOfGas is an exceptional halt for lack of gas
+	StatusOutOfGas
+	// StatusInvalidOpcode is an exceptional halt on an undefined or
+	// unsupported instruction
+	StatusInvalidOpcode
+	// StatusExceptionalHalt is any other exceptional halt, such as a stack
+	// underflow or an invalid jump
+	StatusExceptionalHalt
+	// StatusAborted is an execution stopped by the tooling rather than by
+	// the EVM rules: a suspension, a canceled or expired context, a runaway
+	// loop or a violated invariant
+	StatusAborted
+)
+
+var statusNames = [...]string{"success", "reverted", "out of gas", "invalid opcode", "exceptional halt", "aborted"}
+
+func (s Status) String() string {
+	if int(s) < len(statusNames) {
+		return statusNames[s]
+	}
+	return fmt.Sprintf("status(%d)", uint8(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Status) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// Exceptional reports whether s is an exceptional halt, which consumes all
+// the gas of the execution
+func (s Status) Exceptional() bool {
+	return s == StatusOutOfGas || s == StatusInvalidOpcode || s == StatusExceptionalHalt
+}
+
+// HaltStatus returns the status of an execution that ended with err
+func HaltStatus(err error) Status {
+	var invariant *InvariantError
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case errors.Is(err, ErrExecutionReverted):
+		return StatusReverted
+	case errors.Is(err, ErrOutOfGas):
+		return StatusOutOfGas
+	case errors.Is(err, ErrInvalidOpcode):
+		return StatusInvalidOpcode
+	case errors.Is(err, ErrSuspended), errors.Is(err, ErrRunawayLoop), errors.As(err, &invariant):
+		return StatusAborted
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return StatusAborted
+	}
+	return StatusExceptionalHalt
+}
@@ -0,0 +1,59 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// ErrInsufficientBalance is returned when a CALL transfers more value than
+// the calling contract holds
+var ErrInsufficientBalance = errors.New("insufficient balance for transfer")
+
+// callCodeless executes a call to an account without code, such as an
+// externally owned account or one that does not exist yet: the value is
+// transferred, creating the account if needed, the call returns no output
+// and 1 is pushed for its success. A transfer exceeding the caller's
+// balance fails the call, pushing 0, without failing the caller. Value
+// only moves in states that hold balances.
+func (evm *EVM) callCodeless(addr common.Address, value *big.Int, input []byte, gas uint64) error {
+	depth := evm.depth + 1
+	if evm.hooks.OnEnter != nil {
+		evm.hooks.OnEnter(depth, evm.contract.Address, addr, input, gas)
+	}
+	err := evm.transfer(evm.contract.Address, addr, value)
+	if evm.hooks.OnExit != nil {
+		evm.hooks.OnExit(depth, nil, 0, err)
+	}
+	evm.returnData = nil
+	if err != nil {
+		evm.stateLogger.Debug("call failed", "address", addr.Hex(), "err", err)
+		return evm.pushSuccess(false)
+	}
+	evm.stateLogger.Debug("call to account without code", "address", addr.Hex(), "value", value)
+	return evm.pushSuccess(true)
+}
+
+// canTransfer reports whether from holds at least value. States without
+// balances can always transfer, as nothing moves in them.
+func (evm *EVM) canTransfer(from common.Address, value *big.Int) bool {
+	db, ok := evm.statedb.(BalanceStateDB)
+	return !ok || value.Sign() == 0 || db.GetBalance(from).Cmp(value) >= 0
+}
+
+// transfer moves value from one account to another in states that hold
+// balances
+func (evm *EVM) transfer(from, to common.Address, value *big.Int) error {
+	if !evm.canTransfer(from, value) {
+		return fmt.Errorf("%w: %s has %s, transfer of %s", ErrInsufficientBalance, from.Hex(), evm.statedb.(BalanceStateDB).GetBalance(from), value)
+	}
+	db, ok := evm.statedb.(BalanceStateDB)
+	if !ok || value.Sign() == 0 {
+		return nil
+	}
+	db.SubBalance(from, value)
+	db.AddBalance(to, value)
+	return nil
+}
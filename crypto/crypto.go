@@ -0,0 +1,147 @@
+// Package crypto provides the hashing and secp256k1 signature primitives
+// used by Ethereum.
+package crypto
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// SignatureLength is the length of an [R || S || V] signature
+const SignatureLength = 65
+
+// PrivateKey is a secp256k1 private key
+type PrivateKey = secp256k1.PrivateKey
+
+// PublicKey is a secp256k1 public key
+type PublicKey = secp256k1.PublicKey
+
+// Signature errors
+var (
+	ErrInvalidSignatureLength = errors.New("invalid signature length")
+	ErrInvalidRecoveryID      = errors.New("invalid signature recovery id")
+	ErrInvalidPrivateKey      = errors.New("invalid private key")
+)
+
+// GenerateKey creates a new random private key
+func GenerateKey() (*PrivateKey, error) {
+	return secp256k1.GeneratePrivateKey()
+}
+
+// ToPrivateKey parses a 32 byte private key
+func ToPrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != 32 {
+		return nil, ErrInvalidPrivateKey
+	}
+	key := secp256k1.PrivKeyFromBytes(b)
+	if key.Key.IsZero() {
+		return nil, ErrInvalidPrivateKey
+	}
+	return key, nil
+}
+
+// PubkeyToAddress derives the Ethereum address of a public key
+func PubkeyToAddress(pub *PublicKey) common.Address {
+	uncompressed := pub.SerializeUncompressed()
+	var hash common.Hash
+	Keccak256Into(hash[:], uncompressed[1:])
+	return common.BytesToAddress(hash[12:])
+}
+
+// Sign creates a recoverable signature of a 32 byte hash in [R || S || V]
+// form, where V is the recovery id 0 or 1
+func Sign(hash []byte, key *PrivateKey) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, errors.New("hash must be 32 bytes")
+	}
+	compact := ecdsa.SignCompact(key, hash, false) // [27 + V || R || S]
+	sig := make([]byte, SignatureLength)
+	copy(sig, compact[1:])
+	sig[64] = compact[0] - 27
+	return sig, nil
+}
+
+// SigToPub recovers the public key that produced an [R || S || V] signature.
+// V may be given as 0/1 or 27/28.
+func SigToPub(hash, sig []byte) (*PublicKey, error) {
+	if len(sig) != SignatureLength {
+		return nil, ErrInvalidSignatureLength
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, ErrInvalidRecoveryID
+	}
+	compact := make([]byte, SignatureLength)
+	compact[0] = v + 27
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	return pub, err
+}
+
+var (
+	secp256k1N     = secp256k1.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// ValidateSignatureValues reports whether r and s are valid signature
+// values. From Homestead on (EIP-2) s must be in the lower half of the
+// curve order.
+func ValidateSignatureValues(r, s *big.Int, homestead bool) bool {
+	if r.Sign() <= 0 || s.Sign() <= 0 {
+		return false
+	}
+	if homestead && s.Cmp(secp256k1HalfN) > 0 {
+		return false
+	}
+	return r.Cmp(secp256k1N) < 0 && s.Cmp(secp256k1N) < 0
+}
+
+// Ecrecover returns the address that produced an [R || S || V] signature
+func Ecrecover(hash, sig []byte) (common.Address, error) {
+	pub, err := SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return PubkeyToAddress(pub), nil
+}
+
+// CreateAddress returns the address of a contract created by b with the
+// given nonce: keccak256(rlp([b, nonce]))[12:]
+func CreateAddress(b common.Address, nonce uint64) common.Address {
+	// RLP list of the address and the nonce, built in the hasher's scratch
+	// space
+	var nonceBuf [9]byte
+	nonceEnc := nonceBuf[:1]
+	switch {
+	case nonce == 0:
+		nonceBuf[0] = 0x80
+	case nonce < 0x80:
+		nonceBuf[0] = byte(nonce)
+	default:
+		n := 0
+		for v := nonce; v > 0; v >>= 8 {
+			n++
+		}
+		nonceBuf[0] = 0x80 + byte(n)
+		for i := n; i > 0; i-- {
+			nonceBuf[i] = byte(nonce)
+			nonce >>= 8
+		}
+		nonceEnc = nonceBuf[:1+n]
+	}
+	payloadLen := 1 + common.AddressLength + len(nonceEnc)
+	kh := getKeccak()
+	enc := append(kh.scratch[:0], 0xc0+byte(payloadLen), 0x80+common.AddressLength)
+	enc = append(enc, b[:]...)
+	enc = append(enc, nonceEnc...)
+	kh.Write(enc)
+	hash := kh.sum()
+	return common.BytesToAddress(hash[12:])
+}
@@ -0,0 +1,202 @@
+// Package hd derives secp256k1 keys from BIP-39 mnemonics along BIP-32
+// derivation paths, as hardware and software wallets do, so the keys of a
+// wallet, or of the dev accounts of a test chain, can be recreated from
+// their mnemonic.
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// HardenedOffset is added to the indices of hardened path components,
+// written with a trailing ' (44' is HardenedOffset + 44)
+const HardenedOffset = 1 << 31
+
+// DefaultBasePath is the path of Ethereum accounts under which account i
+// is at index i, m/44'/60'/0'/0/i (BIP-44 with coin type 60)
+const DefaultBasePath = "m/44'/60'/0'/0"
+
+// ErrInvalidKey is returned in the unlikely case a derivation yields an
+// invalid key; BIP-32 then skips to the next index
+var ErrInvalidKey = errors.New("hd: derived key is invalid")
+
+// ErrInvalidMnemonic is returned for a mnemonic that is not valid BIP-39
+var ErrInvalidMnemonic = errors.New("hd: invalid mnemonic")
+
+// Path is a derivation path, the child indices from the master key
+type Path []uint32
+
+// ParsePath parses a path of the form m/44'/60'/0'/0/0. Components ending
+// in ' (or h) are hardened.
+func ParsePath(s string) (Path, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("hd: path %q does not start with m", s)
+	}
+	path := make(Path, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		offset := uint32(0)
+		if trimmed := strings.TrimRight(part, "'h"); len(trimmed) == len(part)-1 {
+			part, offset = trimmed, HardenedOffset
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("hd: path %q: invalid component %q", s, part)
+		}
+		path = append(path, uint32(index)+offset)
+	}
+	return path, nil
+}
+
+// String renders the path as ParsePath reads it
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		if index >= HardenedOffset {
+			fmt.Fprintf(&b, "/%d'", index-HardenedOffset)
+		} else {
+			fmt.Fprintf(&b, "/%d", index)
+		}
+	}
+	return b.String()
+}
+
+// Seed returns the BIP-39 seed of a mnemonic and optional passphrase. The
+// mnemonic must pass CheckMnemonic.
+func Seed(mnemonic, passphrase string) ([]byte, error) {
+	if err := CheckMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := pbkdf2.Key(sha512.New, mnemonic, []byte("mnemonic"+passphrase), 2048, 64)
+	if err != nil {
+		panic(err) // only for parameters FIPS mode rejects
+	}
+	return seed, nil
+}
+
+// CheckMnemonic checks that a mnemonic is a valid BIP-39 English mnemonic:
+// 12 to 24 words, a multiple of 3, from the word list, whose last bits are
+// the checksum of the entropy the others encode
+func CheckMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return fmt.Errorf("%w: %d words, want 12, 15, 18, 21 or 24", ErrInvalidMnemonic, len(words))
+	}
+	// each word encodes 11 bits: the entropy, then a checksum of one bit
+	// per 32 bits of entropy
+	bits := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return fmt.Errorf("%w: %q is not in the word list", ErrInvalidMnemonic, word)
+		}
+		bits.Lsh(bits, 11).Or(bits, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) * 11 / 33)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1)).Uint64()
+	entropy := bits.Rsh(bits, checksumBits).FillBytes(make([]byte, len(words)*4/3))
+	hash := sha256.Sum256(entropy)
+	if uint64(hash[0]>>(8-checksumBits)) != checksum {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return nil
+}
+
+// Key is an extended private key: a private key with its chain code
+type Key struct {
+	key       *crypto.PrivateKey
+	chainCode [32]byte
+}
+
+// NewMaster returns the master key of a seed
+func NewMaster(seed []byte) (*Key, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	return newKey(mac.Sum(nil))
+}
+
+// newKey builds a key from the 64-byte output of HMAC-SHA512: the key,
+// then the chain code
+func newKey(sum []byte) (*Key, error) {
+	var k secp256k1.ModNScalar
+	if overflow := k.SetByteSlice(sum[:32]); overflow || k.IsZero() {
+		return nil, ErrInvalidKey
+	}
+	key := &Key{key: secp256k1.NewPrivateKey(&k)}
+	copy(key.chainCode[:], sum[32:])
+	return key, nil
+}
+
+// Child derives the child key at index, hardened from HardenedOffset on
+func (k *Key) Child(index uint32) (*Key, error) {
+	mac := hmac.New(sha512.New, k.chainCode[:])
+	if index >= HardenedOffset {
+		priv := k.key.Key.Bytes()
+		mac.Write([]byte{0})
+		mac.Write(priv[:])
+	} else {
+		mac.Write(k.key.PubKey().SerializeCompressed())
+	}
+	var i [4]byte
+	binary.BigEndian.PutUint32(i[:], index)
+	mac.Write(i[:])
+	sum := mac.Sum(nil)
+
+	var tweak secp256k1.ModNScalar
+	if overflow := tweak.SetByteSlice(sum[:32]); overflow {
+		return nil, ErrInvalidKey
+	}
+	tweak.Add(&k.key.Key)
+	if tweak.IsZero() {
+		return nil, ErrInvalidKey
+	}
+	child := &Key{key: secp256k1.NewPrivateKey(&tweak)}
+	copy(child.chainCode[:], sum[32:])
+	return child, nil
+}
+
+// Derive derives the key at path below k
+func (k *Key) Derive(path Path) (*Key, error) {
+	for _, index := range path {
+		var err error
+		if k, err = k.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// PrivateKey returns the private key of k
+func (k *Key) PrivateKey() *crypto.PrivateKey { return k.key }
+
+// DeriveKey returns the private key at path of the wallet of a mnemonic
+// without passphrase
+func DeriveKey(mnemonic string, path Path) (*crypto.PrivateKey, error) {
+	seed, err := Seed(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	master, err := NewMaster(seed)
+	if err != nil {
+		return nil, err
+	}
+	key, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey(), nil
+}
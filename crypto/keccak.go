@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"hash"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// KeccakState is a Keccak-256 hasher that can also be read from. Read
+// returns the hash without copying the state, as Sum does, but changes the
+// state, so the hasher must be reset before it is used again.
+type KeccakState interface {
+	hash.Hash
+	Read([]byte) (int, error)
+}
+
+// NewKeccakState creates a Keccak-256 hasher
+func NewKeccakState() KeccakState {
+	return sha3.NewLegacyKeccak256().(KeccakState)
+}
+
+// pooledKeccak is a hasher of keccakPool. Hashes are read into out and
+// short preimages built in scratch, as buffers passed to the hasher's
+// methods escape to the heap.
+type pooledKeccak struct {
+	KeccakState
+	out     common.Hash
+	scratch [64]byte
+}
+
+// keccakPool recycles the hashers of the package level hash functions, so
+// hashing allocates no hasher state
+var keccakPool = sync.Pool{New: func() any { return &pooledKeccak{KeccakState: NewKeccakState()} }}
+
+// getKeccak returns a reset hasher of keccakPool
+func getKeccak() *pooledKeccak {
+	kh := keccakPool.Get().(*pooledKeccak)
+	kh.Reset()
+	return kh
+}
+
+// sum reads the hash into kh.out and returns kh to the pool. kh must not
+// be used afterwards.
+func (kh *pooledKeccak) sum() common.Hash {
+	kh.Read(kh.out[:])
+	out := kh.out
+	keccakPool.Put(kh)
+	return out
+}
+
+// Keccak256 returns the Keccak-256 hash of the concatenated data
+func Keccak256(data ...[]byte) []byte {
+	hash := make([]byte, common.HashLength)
+	Keccak256Into(hash, data...)
+	return hash
+}
+
+// Keccak256Hash returns the Keccak-256 hash of the concatenated data as a Hash
+func Keccak256Hash(data ...[]byte) (hash common.Hash) {
+	Keccak256Into(hash[:], data...)
+	return hash
+}
+
+// Keccak256Into writes the Keccak-256 hash of the concatenated data into
+// the first 32 bytes of dst, which must be at least that long, without
+// allocating
+func Keccak256Into(dst []byte, data ...[]byte) {
+	_ = dst[common.HashLength-1] // bounds check
+	kh := getKeccak()
+	for _, d := range data {
+		kh.Write(d)
+	}
+	hash := kh.sum()
+	copy(dst, hash[:])
+}
+
+// HashData returns the Keccak-256 hash of data computed with kh, which is
+// reset first, for callers hashing many values with a hasher of their own
+func HashData(kh KeccakState, data []byte) (hash common.Hash) {
+	kh.Reset()
+	kh.Write(data)
+	kh.Read(hash[:])
+	return hash
+}
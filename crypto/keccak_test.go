@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// keccakSizes are the preimage sizes benchmarked: a word, an ABI-encoded
+// call and a contract's code
+var keccakSizes = []int{32, 256, 4096}
+
+// noAllocs fails b unless fn runs without allocating
+func noAllocs(b *testing.B, fn func()) {
+	fn() // warm the hasher pool
+	if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+		b.Fatalf("%v allocations per run, want 0", allocs)
+	}
+}
+
+func BenchmarkKeccak256Hash(b *testing.B) {
+	for _, size := range keccakSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			data := make([]byte, size)
+			noAllocs(b, func() { Keccak256Hash(data) })
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				Keccak256Hash(data)
+			}
+		})
+	}
+}
+
+func BenchmarkKeccak256Into(b *testing.B) {
+	for _, size := range keccakSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			data, dst := make([]byte, size), make([]byte, common.HashLength)
+			noAllocs(b, func() { Keccak256Into(dst, data) })
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				Keccak256Into(dst, data)
+			}
+		})
+	}
+}
+
+// BenchmarkHashData hashes with a hasher kept by the caller
+func BenchmarkHashData(b *testing.B) {
+	for _, size := range keccakSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			data, kh := make([]byte, size), NewKeccakState()
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				HashData(kh, data)
+			}
+		})
+	}
+}
+
+// BenchmarkNewKeccakState allocates a hasher per hash, the cost the pool
+// saves
+func BenchmarkNewKeccakState(b *testing.B) {
+	for _, size := range keccakSizes {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			data, out := make([]byte, size), make([]byte, common.HashLength)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				kh := NewKeccakState()
+				kh.Write(data)
+				kh.Read(out)
+			}
+		})
+	}
+}
+
+func BenchmarkCreateAddress(b *testing.B) {
+	creator := common.Address{19: 1}
+	noAllocs(b, func() { CreateAddress(creator, 1) })
+	b.ReportAllocs()
+	for i := range uint64(b.N) {
+		CreateAddress(creator, i)
+	}
+}
@@ -0,0 +1,129 @@
+// Package kzg4844 verifies the blobs of EIP-4844 transactions: KZG
+// commitments and proofs over blobs, and the versioned hashes that commit
+// to them in a transaction. The KZG operations use go-kzg-4844 with the
+// Ethereum trusted setup, loaded on first use.
+package kzg4844
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// VersionKZG is the version byte of versioned hashes of KZG commitments
+const VersionKZG = 0x01
+
+// Blob is 4096 field elements of 32 bytes each
+type Blob = gokzg4844.Blob
+
+// Commitment is a KZG commitment to a blob
+type Commitment = gokzg4844.KZGCommitment
+
+// Proof is a KZG proof that a blob matches its commitment
+type Proof = gokzg4844.KZGProof
+
+// Errors reported for inconsistent sidecars
+var (
+	ErrSidecarLength = errors.New("blob sidecar lists differ in length")
+	ErrBlobHash      = errors.New("versioned hash does not match blob commitment")
+)
+
+var (
+	contextOnce sync.Once
+	context     *gokzg4844.Context
+	contextErr  error
+)
+
+// kzg returns the verification context, built from the trusted setup once
+func kzg() (*gokzg4844.Context, error) {
+	contextOnce.Do(func() {
+		context, contextErr = gokzg4844.NewContext4096Secure()
+	})
+	return context, contextErr
+}
+
+// BlobToCommitment computes the KZG commitment of a blob
+func BlobToCommitment(blob *Blob) (Commitment, error) {
+	ctx, err := kzg()
+	if err != nil {
+		return Commitment{}, err
+	}
+	return ctx.BlobToKZGCommitment(blob, 0)
+}
+
+// ComputeBlobProof computes the proof that a blob matches its commitment
+func ComputeBlobProof(blob *Blob, commitment Commitment) (Proof, error) {
+	ctx, err := kzg()
+	if err != nil {
+		return Proof{}, err
+	}
+	return ctx.ComputeBlobKZGProof(blob, commitment, 0)
+}
+
+// VerifyBlobProof checks that a blob matches its commitment
+func VerifyBlobProof(blob *Blob, commitment Commitment, proof Proof) error {
+	ctx, err := kzg()
+	if err != nil {
+		return err
+	}
+	return ctx.VerifyBlobKZGProof(blob, commitment, proof)
+}
+
+// CalcBlobHashV1 returns the versioned hash of a commitment: its SHA-256
+// hash with the first byte replaced by VersionKZG
+func CalcBlobHashV1(commitment Commitment) common.Hash {
+	hash := common.Hash(sha256.Sum256(commitment[:]))
+	hash[0] = VersionKZG
+	return hash
+}
+
+// IsValidVersionedHash reports whether a versioned hash carries the KZG
+// version, the only one transactions may use
+func IsValidVersionedHash(hash common.Hash) bool {
+	return hash[0] == VersionKZG
+}
+
+// Sidecar holds the blobs of a blob transaction with their commitments and
+// proofs, which travel beside the transaction rather than in it
+type Sidecar struct {
+	Blobs       []Blob
+	Commitments []Commitment
+	Proofs      []Proof
+}
+
+// BlobHashes returns the versioned hashes of the sidecar's commitments
+func (s *Sidecar) BlobHashes() []common.Hash {
+	hashes := make([]common.Hash, len(s.Commitments))
+	for i, c := range s.Commitments {
+		hashes[i] = CalcBlobHashV1(c)
+	}
+	return hashes
+}
+
+// Verify checks that the sidecar matches the versioned hashes of its
+// transaction and that every blob matches its commitment
+func (s *Sidecar) Verify(hashes []common.Hash) error {
+	if len(s.Blobs) != len(hashes) || len(s.Commitments) != len(hashes) || len(s.Proofs) != len(hashes) {
+		return fmt.Errorf("%w: %d hashes, %d blobs, %d commitments, %d proofs", ErrSidecarLength, len(hashes), len(s.Blobs), len(s.Commitments), len(s.Proofs))
+	}
+	for i, hash := range hashes {
+		if !IsValidVersionedHash(hash) {
+			return fmt.Errorf("blob %d: unsupported versioned hash version %#x", i, hash[0])
+		}
+		if CalcBlobHashV1(s.Commitments[i]) != hash {
+			return fmt.Errorf("blob %d: %w", i, ErrBlobHash)
+		}
+	}
+	ctx, err := kzg()
+	if err != nil {
+		return err
+	}
+	if err := ctx.VerifyBlobKZGProofBatch(s.Blobs, s.Commitments, s.Proofs); err != nil {
+		return fmt.Errorf("blob proofs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/simulator"
+)
+
+// maxBatchCalls bounds the calls of a BatchRequest
+const maxBatchCalls = 10_000
+
+// BatchRequest is the body of a POST to the batch handler: independent
+// read-only calls, as made by eth_call, all run against the same state
+// changed by State
+type BatchRequest struct {
+	State map[common.Address]AccountOverride `json:"state,omitempty"`
+	Block *simulator.BlockOverrides          `json:"block,omitempty"` // changes block 1 at timestamp 0
+	Calls []BatchCall                        `json:"calls"`
+	// ShareAccessSet starts each call with the accounts and slots warmed
+	// by the calls before it, as in a multicall transaction
+	ShareAccessSet bool `json:"shareAccessSet,omitempty"`
+}
+
+// BatchCall is a call of a BatchRequest
+type BatchCall struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Input hexutil.Bytes  `json:"input,omitempty"`
+	Value *hexutil.Big   `json:"value,omitempty"`
+	Gas   uint64         `json:"gas,omitempty"` // simulator.DefaultGasLimit if zero
+}
+
+// BatchResponse is the body returned for a BatchRequest, with a result
+// per call in the order of the calls
+type BatchResponse struct {
+	Results []*vm.ExecutionResult `json:"results"`
+}
+
+// NewBatchHandler returns an HTTP handler executing a BatchRequest POSTed
+// to it and replying with a BatchResponse. The gas of each call is capped
+// by limits.MaxGas. Malformed requests get status 400 and a JSON body with
+// an error field.
+func NewBatchHandler(config vm.Config, limits config.Limits) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req BatchRequest
+		if !decodePost(w, r, &req) {
+			return
+		}
+		for i := range req.Calls {
+			req.Calls[i].Gas = capGas(req.Calls[i].Gas, limits)
+		}
+		resp, err := ExecuteBatch(r.Context(), &req, config)
+		if errors.Is(err, ErrInvalidRequest) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// ExecuteBatch runs the calls of a BatchRequest with config. The state is
+// built once and every call runs against it with simulator.CallBatch,
+// rather than each in a state of its own.
+func ExecuteBatch(ctx context.Context, req *BatchRequest, config vm.Config) (*BatchResponse, error) {
+	if len(req.Calls) > maxBatchCalls {
+		return nil, fmt.Errorf("%w: %d calls, at most %d allowed", ErrInvalidRequest, len(req.Calls), maxBatchCalls)
+	}
+	sim := simulator.New(nil, config)
+	applyOverrides(sim.State(), req.State)
+	msgs := make([]simulator.CallMsg, len(req.Calls))
+	for i, call := range req.Calls {
+		msgs[i] = simulator.CallMsg{From: call.From, To: call.To, Gas: call.Gas, Data: call.Input}
+		if call.Value != nil {
+			msgs[i].Value = call.Value.ToInt()
+		}
+	}
+	results, err := sim.CallBatch(ctx, msgs, &simulator.BatchOptions{Block: req.Block, ShareAccessSet: req.ShareAccessSet})
+	if err != nil {
+		return nil, err
+	}
+	return &BatchResponse{Results: results}, nil
+}
@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/stats"
+)
+
+// ResultCache caches the responses of identical executions, so floods of
+// the same read-only call, such as a token's balanceOf, are answered
+// without executing again. Requests carry the whole pre-state, so the key,
+// a hash of everything the result depends on, changes whenever the state,
+// the call or the block does; Purge drops every entry for embedders whose
+// state can change otherwise, such as through hooks with side effects.
+//
+// Executions answered from the cache are not seen by the hooks of the
+// service's vm.Config. Requests for traces or tracer results are never
+// cached. The cache is safe for concurrent use.
+type ResultCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[common.Hash]*list.Element
+
+	counters *stats.Counters
+}
+
+type cacheEntry struct {
+	key   common.Hash
+	value any // *Response or *executionpb.ExecuteResponse
+}
+
+// NewResultCache creates a cache holding up to size responses, evicting
+// the least recently used first. Hits and misses are counted in counters
+// unless nil.
+func NewResultCache(size int, counters *stats.Counters) *ResultCache {
+	return &ResultCache{
+		size:     size,
+		order:    list.New(),
+		entries:  make(map[common.Hash]*list.Element),
+		counters: counters,
+	}
+}
+
+// Len returns the number of cached responses
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Purge drops every cached response
+func (c *ResultCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// get returns the response cached under key, nil if there is none
+func (c *ResultCache) get(key common.Hash) any {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.order.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+	if c.counters != nil {
+		if ok {
+			c.counters.CacheHit()
+		} else {
+			c.counters.CacheMiss()
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return elem.Value.(*cacheEntry).value
+}
+
+// add caches a response under key
+func (c *ResultCache) add(key common.Hash, value any) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey hashes the encoding of a request, prefixed by the kind of
+// request so the responses of different endpoints never collide
+func cacheKey(kind string, encoded []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte(kind), encoded)
+}
@@ -0,0 +1,5 @@
+// Package executionpb holds the protobuf schema and generated gRPC bindings
+// of the ExecutionService.
+package executionpb
+
+//go:generate protoc -I . -I ../../tracers/tracepb --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative execution.proto
@@ -0,0 +1,1205 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: execution.proto
+
+// Execution of messages against a state supplied with each request.
+
+package executionpb
+
+import (
+	tracepb "github.com/nutcas3/evm-golang/tracers/tracepb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Status is how a message halted. Exceptional halts consume all its gas.
+type Status int32
+
+const (
+	Status_STATUS_SUCCESS          Status = 0
+	Status_STATUS_REVERTED         Status = 1
+	Status_STATUS_OUT_OF_GAS       Status = 2
+	Status_STATUS_INVALID_OPCODE   Status = 3
+	Status_STATUS_EXCEPTIONAL_HALT Status = 4
+	Status_STATUS_ABORTED          Status = 5
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_SUCCESS",
+		1: "STATUS_REVERTED",
+		2: "STATUS_OUT_OF_GAS",
+		3: "STATUS_INVALID_OPCODE",
+		4: "STATUS_EXCEPTIONAL_HALT",
+		5: "STATUS_ABORTED",
+	}
+	Status_value = map[string]int32{
+		"STATUS_SUCCESS":          0,
+		"STATUS_REVERTED":         1,
+		"STATUS_OUT_OF_GAS":       2,
+		"STATUS_INVALID_OPCODE":   3,
+		"STATUS_EXCEPTIONAL_HALT": 4,
+		"STATUS_ABORTED":          5,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_execution_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_execution_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{0}
+}
+
+type Account struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"` // 20-byte address
+	Nonce         uint64                 `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Balance       []byte                 `protobuf:"bytes,3,opt,name=balance,proto3" json:"balance,omitempty"` // big-endian
+	Code          []byte                 `protobuf:"bytes,4,opt,name=code,proto3" json:"code,omitempty"`
+	Storage       []*StorageSlot         `protobuf:"bytes,5,rep,name=storage,proto3" json:"storage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_execution_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Account) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *Account) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Account) GetBalance() []byte {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *Account) GetCode() []byte {
+	if x != nil {
+		return x.Code
+	}
+	return nil
+}
+
+func (x *Account) GetStorage() []*StorageSlot {
+	if x != nil {
+		return x.Storage
+	}
+	return nil
+}
+
+type StorageSlot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`     // 32 bytes
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"` // 32 bytes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageSlot) Reset() {
+	*x = StorageSlot{}
+	mi := &file_execution_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageSlot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageSlot) ProtoMessage() {}
+
+func (x *StorageSlot) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageSlot.ProtoReflect.Descriptor instead.
+func (*StorageSlot) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StorageSlot) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *StorageSlot) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// Block is the block the messages execute in.
+type Block struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        uint64                 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Timestamp     uint64                 `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Coinbase      []byte                 `protobuf:"bytes,3,opt,name=coinbase,proto3" json:"coinbase,omitempty"`                                                                                                     // 20-byte fee recipient, zero address if empty
+	BaseFee       []byte                 `protobuf:"bytes,4,opt,name=base_fee,json=baseFee,proto3" json:"base_fee,omitempty"`                                                                                        // big-endian, used by SimulateBundle
+	PrevRandao    []byte                 `protobuf:"bytes,5,opt,name=prev_randao,json=prevRandao,proto3" json:"prev_randao,omitempty"`                                                                               // 32 bytes, zero if empty
+	BlockHashes   map[uint64][]byte      `protobuf:"bytes,6,rep,name=block_hashes,json=blockHashes,proto3" json:"block_hashes,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 32-byte BLOCKHASH results by block number
+	Difficulty    []byte                 `protobuf:"bytes,7,opt,name=difficulty,proto3" json:"difficulty,omitempty"`                                                                                                 // big-endian, returned by DIFFICULTY before the merge
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_execution_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Block) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Block) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetCoinbase() []byte {
+	if x != nil {
+		return x.Coinbase
+	}
+	return nil
+}
+
+func (x *Block) GetBaseFee() []byte {
+	if x != nil {
+		return x.BaseFee
+	}
+	return nil
+}
+
+func (x *Block) GetPrevRandao() []byte {
+	if x != nil {
+		return x.PrevRandao
+	}
+	return nil
+}
+
+func (x *Block) GetBlockHashes() map[uint64][]byte {
+	if x != nil {
+		return x.BlockHashes
+	}
+	return nil
+}
+
+func (x *Block) GetDifficulty() []byte {
+	if x != nil {
+		return x.Difficulty
+	}
+	return nil
+}
+
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  []byte                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`   // 20-byte address
+	To    []byte                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`       // 20-byte address
+	Value []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"` // big-endian, transferred before the call
+	Gas   uint64                 `protobuf:"varint,4,opt,name=gas,proto3" json:"gas,omitempty"`    // gas limit, 30M if zero
+	Code  []byte                 `protobuf:"bytes,5,opt,name=code,proto3" json:"code,omitempty"`   // installed at to before the call, or before the whole bundle, if not empty
+	// Fees and revert policy, used by SimulateBundle. Legacy messages set
+	// gas_price, dynamic fee messages max_fee_per_gas and
+	// max_priority_fee_per_gas. All are big-endian.
+	GasPrice             []byte `protobuf:"bytes,6,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
+	MaxFeePerGas         []byte `protobuf:"bytes,7,opt,name=max_fee_per_gas,json=maxFeePerGas,proto3" json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas []byte `protobuf:"bytes,8,opt,name=max_priority_fee_per_gas,json=maxPriorityFeePerGas,proto3" json:"max_priority_fee_per_gas,omitempty"`
+	RevertAllowed        bool   `protobuf:"varint,9,opt,name=revert_allowed,json=revertAllowed,proto3" json:"revert_allowed,omitempty"` // the bundle stays valid if the message fails
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_execution_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Message) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *Message) GetTo() []byte {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *Message) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Message) GetGas() uint64 {
+	if x != nil {
+		return x.Gas
+	}
+	return 0
+}
+
+func (x *Message) GetCode() []byte {
+	if x != nil {
+		return x.Code
+	}
+	return nil
+}
+
+func (x *Message) GetGasPrice() []byte {
+	if x != nil {
+		return x.GasPrice
+	}
+	return nil
+}
+
+func (x *Message) GetMaxFeePerGas() []byte {
+	if x != nil {
+		return x.MaxFeePerGas
+	}
+	return nil
+}
+
+func (x *Message) GetMaxPriorityFeePerGas() []byte {
+	if x != nil {
+		return x.MaxPriorityFeePerGas
+	}
+	return nil
+}
+
+func (x *Message) GetRevertAllowed() bool {
+	if x != nil {
+		return x.RevertAllowed
+	}
+	return false
+}
+
+type Log struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Topics        [][]byte               `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Log) Reset() {
+	*x = Log{}
+	mi := &file_execution_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Log) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Log) ProtoMessage() {}
+
+func (x *Log) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Log.ProtoReflect.Descriptor instead.
+func (*Log) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Log) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *Log) GetTopics() [][]byte {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+func (x *Log) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Result struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ReturnData []byte                 `protobuf:"bytes,1,opt,name=return_data,json=returnData,proto3" json:"return_data,omitempty"` // RETURN data, or REVERT data on revert
+	GasUsed    uint64                 `protobuf:"varint,2,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	Error      string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // empty if the message halted normally
+	Logs       []*Log                 `protobuf:"bytes,4,rep,name=logs,proto3" json:"logs,omitempty"`
+	// Set by SimulateBundle. All are big-endian.
+	EffectiveGasPrice []byte `protobuf:"bytes,5,opt,name=effective_gas_price,json=effectiveGasPrice,proto3" json:"effective_gas_price,omitempty"`
+	GasFees           []byte `protobuf:"bytes,6,opt,name=gas_fees,json=gasFees,proto3" json:"gas_fees,omitempty"`                                   // priority fees paid to the coinbase
+	EthSentToCoinbase []byte `protobuf:"bytes,7,opt,name=eth_sent_to_coinbase,json=ethSentToCoinbase,proto3" json:"eth_sent_to_coinbase,omitempty"` // value transferred to the coinbase directly
+	CoinbaseDiff      []byte `protobuf:"bytes,8,opt,name=coinbase_diff,json=coinbaseDiff,proto3" json:"coinbase_diff,omitempty"`                    // gas_fees plus eth_sent_to_coinbase
+	Status            Status `protobuf:"varint,9,opt,name=status,proto3,enum=evm.execution.v1.Status" json:"status,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_execution_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Result) GetReturnData() []byte {
+	if x != nil {
+		return x.ReturnData
+	}
+	return nil
+}
+
+func (x *Result) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *Result) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Result) GetLogs() []*Log {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *Result) GetEffectiveGasPrice() []byte {
+	if x != nil {
+		return x.EffectiveGasPrice
+	}
+	return nil
+}
+
+func (x *Result) GetGasFees() []byte {
+	if x != nil {
+		return x.GasFees
+	}
+	return nil
+}
+
+func (x *Result) GetEthSentToCoinbase() []byte {
+	if x != nil {
+		return x.EthSentToCoinbase
+	}
+	return nil
+}
+
+func (x *Result) GetCoinbaseDiff() []byte {
+	if x != nil {
+		return x.CoinbaseDiff
+	}
+	return nil
+}
+
+func (x *Result) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_SUCCESS
+}
+
+type ExecuteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         []*Account             `protobuf:"bytes,1,rep,name=state,proto3" json:"state,omitempty"`
+	Block         *Block                 `protobuf:"bytes,2,opt,name=block,proto3" json:"block,omitempty"`
+	Message       *Message               `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	ReturnState   bool                   `protobuf:"varint,4,opt,name=return_state,json=returnState,proto3" json:"return_state,omitempty"` // include the post-state in the response
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteRequest) Reset() {
+	*x = ExecuteRequest{}
+	mi := &file_execution_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteRequest) ProtoMessage() {}
+
+func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteRequest) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ExecuteRequest) GetState() []*Account {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetReturnState() bool {
+	if x != nil {
+		return x.ReturnState
+	}
+	return false
+}
+
+type ExecuteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        *Result                `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	State         []*Account             `protobuf:"bytes,2,rep,name=state,proto3" json:"state,omitempty"` // sorted by address, if requested
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteResponse) Reset() {
+	*x = ExecuteResponse{}
+	mi := &file_execution_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteResponse) ProtoMessage() {}
+
+func (x *ExecuteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteResponse) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExecuteResponse) GetResult() *Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ExecuteResponse) GetState() []*Account {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type TraceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Execute       *ExecuteRequest        `protobuf:"bytes,1,opt,name=execute,proto3" json:"execute,omitempty"`
+	DisableStack  bool                   `protobuf:"varint,2,opt,name=disable_stack,json=disableStack,proto3" json:"disable_stack,omitempty"`    // omit stack words from opcode events
+	DisableMemory bool                   `protobuf:"varint,3,opt,name=disable_memory,json=disableMemory,proto3" json:"disable_memory,omitempty"` // omit memory contents from opcode events
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceRequest) Reset() {
+	*x = TraceRequest{}
+	mi := &file_execution_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceRequest) ProtoMessage() {}
+
+func (x *TraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceRequest.ProtoReflect.Descriptor instead.
+func (*TraceRequest) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TraceRequest) GetExecute() *ExecuteRequest {
+	if x != nil {
+		return x.Execute
+	}
+	return nil
+}
+
+func (x *TraceRequest) GetDisableStack() bool {
+	if x != nil {
+		return x.DisableStack
+	}
+	return false
+}
+
+func (x *TraceRequest) GetDisableMemory() bool {
+	if x != nil {
+		return x.DisableMemory
+	}
+	return false
+}
+
+type TraceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Response:
+	//
+	//	*TraceResponse_Event
+	//	*TraceResponse_Result
+	Response      isTraceResponse_Response `protobuf_oneof:"response"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraceResponse) Reset() {
+	*x = TraceResponse{}
+	mi := &file_execution_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceResponse) ProtoMessage() {}
+
+func (x *TraceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceResponse.ProtoReflect.Descriptor instead.
+func (*TraceResponse) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TraceResponse) GetResponse() isTraceResponse_Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *TraceResponse) GetEvent() *tracepb.Event {
+	if x != nil {
+		if x, ok := x.Response.(*TraceResponse_Event); ok {
+			return x.Event
+		}
+	}
+	return nil
+}
+
+func (x *TraceResponse) GetResult() *ExecuteResponse {
+	if x != nil {
+		if x, ok := x.Response.(*TraceResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isTraceResponse_Response interface {
+	isTraceResponse_Response()
+}
+
+type TraceResponse_Event struct {
+	Event *tracepb.Event `protobuf:"bytes,1,opt,name=event,proto3,oneof"`
+}
+
+type TraceResponse_Result struct {
+	Result *ExecuteResponse `protobuf:"bytes,2,opt,name=result,proto3,oneof"` // last message of the stream
+}
+
+func (*TraceResponse_Event) isTraceResponse_Response() {}
+
+func (*TraceResponse_Result) isTraceResponse_Response() {}
+
+type SimulateBundleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         []*Account             `protobuf:"bytes,1,rep,name=state,proto3" json:"state,omitempty"`
+	Block         *Block                 `protobuf:"bytes,2,opt,name=block,proto3" json:"block,omitempty"`
+	Messages      []*Message             `protobuf:"bytes,3,rep,name=messages,proto3" json:"messages,omitempty"`
+	ReturnState   bool                   `protobuf:"varint,4,opt,name=return_state,json=returnState,proto3" json:"return_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulateBundleRequest) Reset() {
+	*x = SimulateBundleRequest{}
+	mi := &file_execution_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateBundleRequest) ProtoMessage() {}
+
+func (x *SimulateBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateBundleRequest.ProtoReflect.Descriptor instead.
+func (*SimulateBundleRequest) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SimulateBundleRequest) GetState() []*Account {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *SimulateBundleRequest) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *SimulateBundleRequest) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *SimulateBundleRequest) GetReturnState() bool {
+	if x != nil {
+		return x.ReturnState
+	}
+	return false
+}
+
+type SimulateBundleResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Results []*Result              `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`                 // one per message, in order
+	GasUsed uint64                 `protobuf:"varint,2,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"` // total over the bundle
+	State   []*Account             `protobuf:"bytes,3,rep,name=state,proto3" json:"state,omitempty"`                     // sorted by address, if requested
+	// Totals over the bundle, big-endian
+	GasFees           []byte `protobuf:"bytes,4,opt,name=gas_fees,json=gasFees,proto3" json:"gas_fees,omitempty"`
+	EthSentToCoinbase []byte `protobuf:"bytes,5,opt,name=eth_sent_to_coinbase,json=ethSentToCoinbase,proto3" json:"eth_sent_to_coinbase,omitempty"`
+	CoinbaseDiff      []byte `protobuf:"bytes,6,opt,name=coinbase_diff,json=coinbaseDiff,proto3" json:"coinbase_diff,omitempty"`
+	BundleGasPrice    []byte `protobuf:"bytes,7,opt,name=bundle_gas_price,json=bundleGasPrice,proto3" json:"bundle_gas_price,omitempty"` // coinbase_diff per unit of gas used
+	// Indices of failed messages without revert_allowed; the bundle is valid
+	// if there are none
+	Reverted      []uint32 `protobuf:"varint,8,rep,packed,name=reverted,proto3" json:"reverted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulateBundleResponse) Reset() {
+	*x = SimulateBundleResponse{}
+	mi := &file_execution_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateBundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateBundleResponse) ProtoMessage() {}
+
+func (x *SimulateBundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_execution_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateBundleResponse.ProtoReflect.Descriptor instead.
+func (*SimulateBundleResponse) Descriptor() ([]byte, []int) {
+	return file_execution_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SimulateBundleResponse) GetResults() []*Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SimulateBundleResponse) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *SimulateBundleResponse) GetState() []*Account {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *SimulateBundleResponse) GetGasFees() []byte {
+	if x != nil {
+		return x.GasFees
+	}
+	return nil
+}
+
+func (x *SimulateBundleResponse) GetEthSentToCoinbase() []byte {
+	if x != nil {
+		return x.EthSentToCoinbase
+	}
+	return nil
+}
+
+func (x *SimulateBundleResponse) GetCoinbaseDiff() []byte {
+	if x != nil {
+		return x.CoinbaseDiff
+	}
+	return nil
+}
+
+func (x *SimulateBundleResponse) GetBundleGasPrice() []byte {
+	if x != nil {
+		return x.BundleGasPrice
+	}
+	return nil
+}
+
+func (x *SimulateBundleResponse) GetReverted() []uint32 {
+	if x != nil {
+		return x.Reverted
+	}
+	return nil
+}
+
+var File_execution_proto protoreflect.FileDescriptor
+
+const file_execution_proto_rawDesc = "" +
+	"\n" +
+	"\x0fexecution.proto\x12\x10evm.execution.v1\x1a\vtrace.proto\"\xa0\x01\n" +
+	"\aAccount\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\x04R\x05nonce\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\fR\abalance\x12\x12\n" +
+	"\x04code\x18\x04 \x01(\fR\x04code\x127\n" +
+	"\astorage\x18\x05 \x03(\v2\x1d.evm.execution.v1.StorageSlotR\astorage\"5\n" +
+	"\vStorageSlot\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"\xc2\x02\n" +
+	"\x05Block\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x04R\x06number\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x04R\ttimestamp\x12\x1a\n" +
+	"\bcoinbase\x18\x03 \x01(\fR\bcoinbase\x12\x19\n" +
+	"\bbase_fee\x18\x04 \x01(\fR\abaseFee\x12\x1f\n" +
+	"\vprev_randao\x18\x05 \x01(\fR\n" +
+	"prevRandao\x12K\n" +
+	"\fblock_hashes\x18\x06 \x03(\v2(.evm.execution.v1.Block.BlockHashesEntryR\vblockHashes\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\a \x01(\fR\n" +
+	"difficulty\x1a>\n" +
+	"\x10BlockHashesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x04R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\x8c\x02\n" +
+	"\aMessage\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\fR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\fR\x02to\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x10\n" +
+	"\x03gas\x18\x04 \x01(\x04R\x03gas\x12\x12\n" +
+	"\x04code\x18\x05 \x01(\fR\x04code\x12\x1b\n" +
+	"\tgas_price\x18\x06 \x01(\fR\bgasPrice\x12%\n" +
+	"\x0fmax_fee_per_gas\x18\a \x01(\fR\fmaxFeePerGas\x126\n" +
+	"\x18max_priority_fee_per_gas\x18\b \x01(\fR\x14maxPriorityFeePerGas\x12%\n" +
+	"\x0erevert_allowed\x18\t \x01(\bR\rrevertAllowed\"K\n" +
+	"\x03Log\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\x12\x16\n" +
+	"\x06topics\x18\x02 \x03(\fR\x06topics\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\xd8\x02\n" +
+	"\x06Result\x12\x1f\n" +
+	"\vreturn_data\x18\x01 \x01(\fR\n" +
+	"returnData\x12\x19\n" +
+	"\bgas_used\x18\x02 \x01(\x04R\agasUsed\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12)\n" +
+	"\x04logs\x18\x04 \x03(\v2\x15.evm.execution.v1.LogR\x04logs\x12.\n" +
+	"\x13effective_gas_price\x18\x05 \x01(\fR\x11effectiveGasPrice\x12\x19\n" +
+	"\bgas_fees\x18\x06 \x01(\fR\agasFees\x12/\n" +
+	"\x14eth_sent_to_coinbase\x18\a \x01(\fR\x11ethSentToCoinbase\x12#\n" +
+	"\rcoinbase_diff\x18\b \x01(\fR\fcoinbaseDiff\x120\n" +
+	"\x06status\x18\t \x01(\x0e2\x18.evm.execution.v1.StatusR\x06status\"\xc8\x01\n" +
+	"\x0eExecuteRequest\x12/\n" +
+	"\x05state\x18\x01 \x03(\v2\x19.evm.execution.v1.AccountR\x05state\x12-\n" +
+	"\x05block\x18\x02 \x01(\v2\x17.evm.execution.v1.BlockR\x05block\x123\n" +
+	"\amessage\x18\x03 \x01(\v2\x19.evm.execution.v1.MessageR\amessage\x12!\n" +
+	"\freturn_state\x18\x04 \x01(\bR\vreturnState\"t\n" +
+	"\x0fExecuteResponse\x120\n" +
+	"\x06result\x18\x01 \x01(\v2\x18.evm.execution.v1.ResultR\x06result\x12/\n" +
+	"\x05state\x18\x02 \x03(\v2\x19.evm.execution.v1.AccountR\x05state\"\x96\x01\n" +
+	"\fTraceRequest\x12:\n" +
+	"\aexecute\x18\x01 \x01(\v2 .evm.execution.v1.ExecuteRequestR\aexecute\x12#\n" +
+	"\rdisable_stack\x18\x02 \x01(\bR\fdisableStack\x12%\n" +
+	"\x0edisable_memory\x18\x03 \x01(\bR\rdisableMemory\"\x85\x01\n" +
+	"\rTraceResponse\x12+\n" +
+	"\x05event\x18\x01 \x01(\v2\x13.evm.trace.v1.EventH\x00R\x05event\x12;\n" +
+	"\x06result\x18\x02 \x01(\v2!.evm.execution.v1.ExecuteResponseH\x00R\x06resultB\n" +
+	"\n" +
+	"\bresponse\"\xd1\x01\n" +
+	"\x15SimulateBundleRequest\x12/\n" +
+	"\x05state\x18\x01 \x03(\v2\x19.evm.execution.v1.AccountR\x05state\x12-\n" +
+	"\x05block\x18\x02 \x01(\v2\x17.evm.execution.v1.BlockR\x05block\x125\n" +
+	"\bmessages\x18\x03 \x03(\v2\x19.evm.execution.v1.MessageR\bmessages\x12!\n" +
+	"\freturn_state\x18\x04 \x01(\bR\vreturnState\"\xcf\x02\n" +
+	"\x16SimulateBundleResponse\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.evm.execution.v1.ResultR\aresults\x12\x19\n" +
+	"\bgas_used\x18\x02 \x01(\x04R\agasUsed\x12/\n" +
+	"\x05state\x18\x03 \x03(\v2\x19.evm.execution.v1.AccountR\x05state\x12\x19\n" +
+	"\bgas_fees\x18\x04 \x01(\fR\agasFees\x12/\n" +
+	"\x14eth_sent_to_coinbase\x18\x05 \x01(\fR\x11ethSentToCoinbase\x12#\n" +
+	"\rcoinbase_diff\x18\x06 \x01(\fR\fcoinbaseDiff\x12(\n" +
+	"\x10bundle_gas_price\x18\a \x01(\fR\x0ebundleGasPrice\x12\x1a\n" +
+	"\breverted\x18\b \x03(\rR\breverted*\x94\x01\n" +
+	"\x06Status\x12\x12\n" +
+	"\x0eSTATUS_SUCCESS\x10\x00\x12\x13\n" +
+	"\x0fSTATUS_REVERTED\x10\x01\x12\x15\n" +
+	"\x11STATUS_OUT_OF_GAS\x10\x02\x12\x19\n" +
+	"\x15STATUS_INVALID_OPCODE\x10\x03\x12\x1b\n" +
+	"\x17STATUS_EXCEPTIONAL_HALT\x10\x04\x12\x12\n" +
+	"\x0eSTATUS_ABORTED\x10\x052\x93\x02\n" +
+	"\x10ExecutionService\x12N\n" +
+	"\aExecute\x12 .evm.execution.v1.ExecuteRequest\x1a!.evm.execution.v1.ExecuteResponse\x12J\n" +
+	"\x05Trace\x12\x1e.evm.execution.v1.TraceRequest\x1a\x1f.evm.execution.v1.TraceResponse0\x01\x12c\n" +
+	"\x0eSimulateBundle\x12'.evm.execution.v1.SimulateBundleRequest\x1a(.evm.execution.v1.SimulateBundleResponseB5Z3github.com/nutcas3/evm-golang/execution/executionpbb\x06proto3"
+
+var (
+	file_execution_proto_rawDescOnce sync.Once
+	file_execution_proto_rawDescData []byte
+)
+
+func file_execution_proto_rawDescGZIP() []byte {
+	file_execution_proto_rawDescOnce.Do(func() {
+		file_execution_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_execution_proto_rawDesc), len(file_execution_proto_rawDesc)))
+	})
+	return file_execution_proto_rawDescData
+}
+
+var file_execution_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_execution_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_execution_proto_goTypes = []any{
+	(Status)(0),                    // 0: evm.execution.v1.Status
+	(*Account)(nil),                // 1: evm.execution.v1.Account
+	(*StorageSlot)(nil),            // 2: evm.execution.v1.StorageSlot
+	(*Block)(nil),                  // 3: evm.execution.v1.Block
+	(*Message)(nil),                // 4: evm.execution.v1.Message
+	(*Log)(nil),                    // 5: evm.execution.v1.Log
+	(*Result)(nil),                 // 6: evm.execution.v1.Result
+	(*ExecuteRequest)(nil),         // 7: evm.execution.v1.ExecuteRequest
+	(*ExecuteResponse)(nil),        // 8: evm.execution.v1.ExecuteResponse
+	(*TraceRequest)(nil),           // 9: evm.execution.v1.TraceRequest
+	(*TraceResponse)(nil),          // 10: evm.execution.v1.TraceResponse
+	(*SimulateBundleRequest)(nil),  // 11: evm.execution.v1.SimulateBundleRequest
+	(*SimulateBundleResponse)(nil), // 12: evm.execution.v1.SimulateBundleResponse
+	nil,                            // 13: evm.execution.v1.Block.BlockHashesEntry
+	(*tracepb.Event)(nil),          // 14: evm.trace.v1.Event
+}
+var file_execution_proto_depIdxs = []int32{
+	2,  // 0: evm.execution.v1.Account.storage:type_name -> evm.execution.v1.StorageSlot
+	13, // 1: evm.execution.v1.Block.block_hashes:type_name -> evm.execution.v1.Block.BlockHashesEntry
+	5,  // 2: evm.execution.v1.Result.logs:type_name -> evm.execution.v1.Log
+	0,  // 3: evm.execution.v1.Result.status:type_name -> evm.execution.v1.Status
+	1,  // 4: evm.execution.v1.ExecuteRequest.state:type_name -> evm.execution.v1.Account
+	3,  // 5: evm.execution.v1.ExecuteRequest.block:type_name -> evm.execution.v1.Block
+	4,  // 6: evm.execution.v1.ExecuteRequest.message:type_name -> evm.execution.v1.Message
+	6,  // 7: evm.execution.v1.ExecuteResponse.result:type_name -> evm.execution.v1.Result
+	1,  // 8: evm.execution.v1.ExecuteResponse.state:type_name -> evm.execution.v1.Account
+	7,  // 9: evm.execution.v1.TraceRequest.execute:type_name -> evm.execution.v1.ExecuteRequest
+	14, // 10: evm.execution.v1.TraceResponse.event:type_name -> evm.trace.v1.Event
+	8,  // 11: evm.execution.v1.TraceResponse.result:type_name -> evm.execution.v1.ExecuteResponse
+	1,  // 12: evm.execution.v1.SimulateBundleRequest.state:type_name -> evm.execution.v1.Account
+	3,  // 13: evm.execution.v1.SimulateBundleRequest.block:type_name -> evm.execution.v1.Block
+	4,  // 14: evm.execution.v1.SimulateBundleRequest.messages:type_name -> evm.execution.v1.Message
+	6,  // 15: evm.execution.v1.SimulateBundleResponse.results:type_name -> evm.execution.v1.Result
+	1,  // 16: evm.execution.v1.SimulateBundleResponse.state:type_name -> evm.execution.v1.Account
+	7,  // 17: evm.execution.v1.ExecutionService.Execute:input_type -> evm.execution.v1.ExecuteRequest
+	9,  // 18: evm.execution.v1.ExecutionService.Trace:input_type -> evm.execution.v1.TraceRequest
+	11, // 19: evm.execution.v1.ExecutionService.SimulateBundle:input_type -> evm.execution.v1.SimulateBundleRequest
+	8,  // 20: evm.execution.v1.ExecutionService.Execute:output_type -> evm.execution.v1.ExecuteResponse
+	10, // 21: evm.execution.v1.ExecutionService.Trace:output_type -> evm.execution.v1.TraceResponse
+	12, // 22: evm.execution.v1.ExecutionService.SimulateBundle:output_type -> evm.execution.v1.SimulateBundleResponse
+	20, // [20:23] is the sub-list for method output_type
+	17, // [17:20] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_execution_proto_init() }
+func file_execution_proto_init() {
+	if File_execution_proto != nil {
+		return
+	}
+	file_execution_proto_msgTypes[9].OneofWrappers = []any{
+		(*TraceResponse_Event)(nil),
+		(*TraceResponse_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_execution_proto_rawDesc), len(file_execution_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_execution_proto_goTypes,
+		DependencyIndexes: file_execution_proto_depIdxs,
+		EnumInfos:         file_execution_proto_enumTypes,
+		MessageInfos:      file_execution_proto_msgTypes,
+	}.Build()
+	File_execution_proto = out.File
+	file_execution_proto_goTypes = nil
+	file_execution_proto_depIdxs = nil
+}
@@ -0,0 +1,241 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/filters"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// maxRequestSize bounds the body of HTTP execution requests
+const maxRequestSize = 10 << 20
+
+// Request is the body of a POST to the HTTP handler: code run once at
+// Address against an empty state changed by State
+type Request struct {
+	Code    hexutil.Bytes                      `json:"code"`
+	Input   hexutil.Bytes                      `json:"input,omitempty"` // call data of the run
+	Gas     uint64                             `json:"gas,omitempty"`   // simulator.DefaultGasLimit if zero
+	Address common.Address                     `json:"address"`
+	Sender  common.Address                     `json:"sender"`
+	State   map[common.Address]AccountOverride `json:"state,omitempty"`
+	Block   *simulator.BlockOverrides          `json:"block,omitempty"` // changes block 1 at timestamp 0
+	Trace   bool                               `json:"trace,omitempty"` // include the struct logs of the run
+	Logs    *filters.Criteria                  `json:"logs,omitempty"`  // keep only the result logs passing this filter
+
+	// Tracer names a registered tracer whose result is returned, configured
+	// by TracerConfig
+	Tracer       string          `json:"tracer,omitempty"`
+	TracerConfig json.RawMessage `json:"tracerConfig,omitempty"`
+}
+
+// AccountOverride sets fields of an account before the run. Nil fields are
+// left unset.
+type AccountOverride struct {
+	Nonce   *hexutil.Uint64             `json:"nonce,omitempty"`
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// Response is the body returned for a Request
+type Response struct {
+	Result *vm.ExecutionResult `json:"result"`
+	Trace  []json.RawMessage   `json:"trace,omitempty"` // struct logs as written by evm --trace
+
+	TracerResult json.RawMessage `json:"tracerResult,omitempty"` // result of the requested tracer
+}
+
+// ErrInvalidRequest is wrapped by the errors Execute returns for requests
+// that cannot be run, such as those naming an unknown tracer
+var ErrInvalidRequest = errors.New("invalid request")
+
+// NewHandler returns an HTTP handler executing a Request POSTed to it and
+// replying with a Response. The gas of requests is capped by
+// limits.MaxGas, and executions stop when the client goes away. Malformed
+// requests get status 400 and a JSON body with an error field; failed
+// executions are reported in the result.
+func NewHandler(config vm.Config, limits config.Limits) http.Handler {
+	return NewCachedHandler(config, limits, nil)
+}
+
+// NewCachedHandler returns the handler of NewHandler, answering repeated
+// requests from cache unless it is nil
+func NewCachedHandler(config vm.Config, limits config.Limits, cache *ResultCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if !decodePost(w, r, &req) {
+			return
+		}
+		req.Gas = capGas(req.Gas, limits)
+		resp, err := cache.Execute(r.Context(), &req, config)
+		if errors.Is(err, ErrInvalidRequest) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// Execute runs a Request with config
+func Execute(ctx context.Context, req *Request, config vm.Config) (*Response, error) {
+	statedb := state.New()
+	applyOverrides(statedb, req.State)
+	if len(req.Code) > 0 {
+		statedb.SetCode(req.Address, req.Code)
+	}
+
+	gas := req.Gas
+	if gas == 0 {
+		gas = simulator.DefaultGasLimit
+	}
+	blockCtx := &vm.Context{
+		BlockNumber: big.NewInt(1),
+		Timestamp:   big.NewInt(0),
+		Sender:      req.Sender,
+		GasLimit:    simulator.DefaultGasLimit,
+		Gas:         gas,
+		GasPrice:    new(big.Int),
+	}
+	req.Block.Apply(blockCtx)
+	var (
+		trace  bytes.Buffer
+		logger *tracers.JSONLogger
+	)
+	if req.Trace {
+		logger = tracers.NewJSONLogger(&trace, nil)
+		config.Hooks = tracers.Combine(config.Hooks, logger.Hooks())
+	}
+	var tracer tracers.Tracer
+	if req.Tracer != "" {
+		var err error
+		if tracer, err = tracers.New(req.Tracer, &tracers.Env{StateDB: statedb}, req.TracerConfig); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		config.Hooks = tracers.Combine(config.Hooks, tracer.Hooks())
+	}
+	evm := vm.NewEVM(blockCtx, statedb, config)
+	evm.SetInput(req.Input)
+	resp := &Response{Result: evm.Run(ctx, req.Address)}
+	if req.Logs != nil {
+		var logs []*types.Log
+		for _, l := range resp.Result.Logs {
+			if req.Logs.Matches(l) {
+				logs = append(logs, l)
+			}
+		}
+		resp.Result.Logs = logs
+	}
+	if tracer != nil {
+		result, err := tracer.Result()
+		if err != nil {
+			return nil, fmt.Errorf("tracer: %w", err)
+		}
+		resp.TracerResult = result
+	}
+	if logger == nil {
+		return resp, nil
+	}
+	if err := logger.Flush(); err != nil {
+		return nil, fmt.Errorf("trace: %w", err)
+	}
+	resp.Trace = []json.RawMessage{}
+	for _, line := range bytes.Split(bytes.TrimSpace(trace.Bytes()), []byte("\n")) {
+		if len(line) > 0 {
+			resp.Trace = append(resp.Trace, json.RawMessage(line))
+		}
+	}
+	return resp, nil
+}
+
+// Execute runs a Request with config like the Execute function, returning
+// the cached response of an identical request if there is one. Cached
+// responses are shared and must not be modified. A nil cache executes
+// every request.
+func (c *ResultCache) Execute(ctx context.Context, req *Request, config vm.Config) (*Response, error) {
+	if c == nil || req.Trace || req.Tracer != "" {
+		return Execute(ctx, req, config)
+	}
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return Execute(ctx, req, config)
+	}
+	key := cacheKey("http", encoded)
+	if resp, ok := c.get(key).(*Response); ok {
+		return resp, nil
+	}
+	resp, err := Execute(ctx, req, config)
+	if err == nil && ctx.Err() == nil {
+		c.add(key, resp)
+	}
+	return resp, err
+}
+
+// capGas returns the gas of a request asking for gas:
+// simulator.DefaultGasLimit if zero, and at most limits.MaxGas
+func capGas(gas uint64, limits config.Limits) uint64 {
+	if gas == 0 {
+		gas = simulator.DefaultGasLimit
+	}
+	return limits.CapGas(gas)
+}
+
+// applyOverrides sets the accounts of a request's state
+func applyOverrides(statedb *state.StateDB, overrides map[common.Address]AccountOverride) {
+	for addr, acc := range overrides {
+		statedb.CreateAccount(addr)
+		if acc.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*acc.Nonce))
+		}
+		if acc.Balance != nil {
+			statedb.AddBalance(addr, acc.Balance.ToInt())
+		}
+		if acc.Code != nil {
+			statedb.SetCode(addr, acc.Code)
+		}
+		for key, value := range acc.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+}
+
+// decodePost decodes the JSON body of a POST request into v, rejecting
+// unknown fields. It replies with an error and returns false if the
+// request is not a POST or its body cannot be decoded.
+func decodePost(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "use POST"})
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,339 @@
+// Package execution serves the ExecutionService of execution/executionpb:
+// one-shot execution, streamed tracing and bundle simulation over gRPC, so
+// the VM can run as a sidecar next to services that do not speak Ethereum
+// JSON-RPC. NewHandler offers one-shot execution over plain HTTP.
+package execution
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/config"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/execution/executionpb"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/tracers"
+	"github.com/nutcas3/evm-golang/tracers/stream"
+	"github.com/nutcas3/evm-golang/tracers/tracepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Server implements executionpb.ExecutionService. Each request runs in a
+// fresh simulator loaded with the request's state, so requests are
+// independent and may be served concurrently.
+type Server struct {
+	executionpb.UnimplementedExecutionServiceServer
+
+	config vm.Config
+	limits config.Limits
+	cache  *ResultCache
+}
+
+// NewServer creates a server running messages with config. Hooks set in
+// config see every execution of the server. Messages get at most
+// simulator.DefaultGasLimit gas unless SetLimits changes it.
+func NewServer(config vm.Config) *Server {
+	s := &Server{config: config}
+	s.limits.MaxGas = simulator.DefaultGasLimit
+	return s
+}
+
+// SetLimits caps the gas of every message at limits.MaxGas, or lifts the
+// cap if it is zero
+func (s *Server) SetLimits(limits config.Limits) {
+	s.limits = limits
+}
+
+// SetCache answers repeated Execute requests from cache, or from none if
+// cache is nil. Trace and SimulateBundle always execute.
+func (s *Server) SetCache(cache *ResultCache) {
+	s.cache = cache
+}
+
+// Execute runs the message of the request
+func (s *Server) Execute(ctx context.Context, req *executionpb.ExecuteRequest) (*executionpb.ExecuteResponse, error) {
+	if s.cache == nil {
+		return s.execute(ctx, req)
+	}
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return s.execute(ctx, req)
+	}
+	key := cacheKey("grpc", encoded)
+	if resp, ok := s.cache.get(key).(*executionpb.ExecuteResponse); ok {
+		return resp, nil
+	}
+	resp, err := s.execute(ctx, req)
+	if err == nil {
+		s.cache.add(key, resp)
+	}
+	return resp, err
+}
+
+func (s *Server) execute(ctx context.Context, req *executionpb.ExecuteRequest) (*executionpb.ExecuteResponse, error) {
+	sim, err := s.simulator(req.GetState(), req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.call(ctx, sim, req.GetMessage())
+	if err != nil {
+		return nil, err
+	}
+	resp := &executionpb.ExecuteResponse{Result: result}
+	if req.GetReturnState() {
+		resp.State = accounts(sim.State())
+	}
+	return resp, nil
+}
+
+// Trace runs the message of the request, streaming its execution events
+// and then the response Execute would return
+func (s *Server) Trace(req *executionpb.TraceRequest, srv grpc.ServerStreamingServer[executionpb.TraceResponse]) error {
+	exec := req.GetExecute()
+	sim, err := s.simulator(exec.GetState(), exec.GetBlock())
+	if err != nil {
+		return err
+	}
+	var sendErr error
+	filter := &tracepb.SubscribeRequest{DisableStack: req.GetDisableStack(), DisableMemory: req.GetDisableMemory()}
+	events := stream.Hooks(filter, func(ev *tracepb.Event) {
+		if sendErr == nil {
+			sendErr = srv.Send(&executionpb.TraceResponse{Response: &executionpb.TraceResponse_Event{Event: ev}})
+		}
+	})
+	sim.SetHooks(tracers.Combine(s.config.Hooks, events))
+
+	result, err := s.call(srv.Context(), sim, exec.GetMessage())
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	resp := &executionpb.ExecuteResponse{Result: result}
+	if exec.GetReturnState() {
+		resp.State = accounts(sim.State())
+	}
+	return srv.Send(&executionpb.TraceResponse{Response: &executionpb.TraceResponse_Result{Result: resp}})
+}
+
+// SimulateBundle runs the messages of the request in order on one state,
+// with the fee accounting of simulator.SimulateBundle
+func (s *Server) SimulateBundle(ctx context.Context, req *executionpb.SimulateBundleRequest) (*executionpb.SimulateBundleResponse, error) {
+	sim, err := s.simulator(req.GetState(), req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	bundle := &simulator.Bundle{}
+	for i, msg := range req.GetMessages() {
+		callMsg, err := s.prepare(sim, msg)
+		if err != nil {
+			return nil, status.Errorf(status.Code(err), "message %d: %s", i, status.Convert(err).Message())
+		}
+		tx := simulator.BundleTx{CallMsg: callMsg, RevertAllowed: msg.GetRevertAllowed()}
+		if price := msg.GetGasPrice(); len(price) > 0 {
+			tx.GasPrice = new(big.Int).SetBytes(price)
+		}
+		if feeCap := msg.GetMaxFeePerGas(); len(feeCap) > 0 {
+			tx.GasFeeCap = new(big.Int).SetBytes(feeCap)
+			tx.GasTipCap = new(big.Int).SetBytes(msg.GetMaxPriorityFeePerGas())
+		}
+		bundle.Txs = append(bundle.Txs, tx)
+	}
+	out, err := sim.SimulateBundle(ctx, bundle)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	resp := &executionpb.SimulateBundleResponse{
+		GasUsed:           out.GasUsed,
+		GasFees:           out.GasFees.Bytes(),
+		EthSentToCoinbase: out.EthSentToCoinbase.Bytes(),
+		CoinbaseDiff:      out.CoinbaseDiff.Bytes(),
+		BundleGasPrice:    out.BundleGasPrice.Bytes(),
+	}
+	for _, r := range out.Results {
+		result := executionResult(r.ExecutionResult)
+		result.EffectiveGasPrice = r.EffectiveGasPrice.Bytes()
+		result.GasFees = r.GasFees.Bytes()
+		result.EthSentToCoinbase = r.EthSentToCoinbase.Bytes()
+		result.CoinbaseDiff = r.CoinbaseDiff.Bytes()
+		resp.Results = append(resp.Results, result)
+	}
+	for _, i := range out.Reverted {
+		resp.Reverted = append(resp.Reverted, uint32(i))
+	}
+	if req.GetReturnState() {
+		resp.State = accounts(sim.State())
+	}
+	return resp, nil
+}
+
+// simulator creates a simulator holding the given accounts at the given
+// block
+func (s *Server) simulator(alloc []*executionpb.Account, block *executionpb.Block) (*simulator.Simulator, error) {
+	sim := simulator.New(nil, s.config)
+	if block != nil {
+		overrides, err := blockOverrides(block)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "block: %v", err)
+		}
+		sim.SetBlock(block.GetNumber(), block.GetTimestamp())
+		sim.OverrideBlock(overrides)
+	}
+	statedb := sim.State()
+	for _, acc := range alloc {
+		addr, err := address(acc.GetAddress())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "account: %v", err)
+		}
+		statedb.CreateAccount(addr)
+		statedb.SetNonce(addr, acc.GetNonce())
+		statedb.AddBalance(addr, new(big.Int).SetBytes(acc.GetBalance()))
+		if len(acc.GetCode()) > 0 {
+			statedb.SetCode(addr, acc.GetCode())
+		}
+		for _, slot := range acc.GetStorage() {
+			if len(slot.GetKey()) > common.HashLength || len(slot.GetValue()) > common.HashLength {
+				return nil, status.Errorf(codes.InvalidArgument, "account %s: storage words are at most 32 bytes", addr.Hex())
+			}
+			statedb.SetState(addr, common.BytesToHash(slot.GetKey()), common.BytesToHash(slot.GetValue()))
+		}
+	}
+	return sim, nil
+}
+
+// blockOverrides returns the block fields beyond number and timestamp
+func blockOverrides(block *executionpb.Block) (*simulator.BlockOverrides, error) {
+	o := &simulator.BlockOverrides{}
+	if baseFee := block.GetBaseFee(); len(baseFee) > 0 {
+		o.BaseFee = (*hexutil.Big)(new(big.Int).SetBytes(baseFee))
+	}
+	if coinbase := block.GetCoinbase(); len(coinbase) > 0 {
+		addr, err := address(coinbase)
+		if err != nil {
+			return nil, fmt.Errorf("coinbase: %v", err)
+		}
+		o.FeeRecipient = &addr
+	}
+	if difficulty := block.GetDifficulty(); len(difficulty) > 0 {
+		o.Difficulty = (*hexutil.Big)(new(big.Int).SetBytes(difficulty))
+	}
+	if random := block.GetPrevRandao(); len(random) > 0 {
+		if len(random) != common.HashLength {
+			return nil, fmt.Errorf("prev_randao must be %d bytes, got %d", common.HashLength, len(random))
+		}
+		hash := common.BytesToHash(random)
+		o.PrevRandao = &hash
+	}
+	for number, hash := range block.GetBlockHashes() {
+		if len(hash) != common.HashLength {
+			return nil, fmt.Errorf("hash of block %d must be %d bytes, got %d", number, common.HashLength, len(hash))
+		}
+		if o.BlockHash == nil {
+			o.BlockHash = make(map[uint64]common.Hash)
+		}
+		o.BlockHash[number] = common.BytesToHash(hash)
+	}
+	return o, nil
+}
+
+// call runs a message on the simulator
+func (s *Server) call(ctx context.Context, sim *simulator.Simulator, msg *executionpb.Message) (*executionpb.Result, error) {
+	callMsg, err := s.prepare(sim, msg)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sim.Call(ctx, callMsg)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return executionResult(result), nil
+}
+
+// prepare decodes a message, installing its code on the simulator and
+// capping its gas
+func (s *Server) prepare(sim *simulator.Simulator, msg *executionpb.Message) (simulator.CallMsg, error) {
+	from, err := address(msg.GetFrom())
+	if err != nil {
+		return simulator.CallMsg{}, status.Errorf(codes.InvalidArgument, "from: %v", err)
+	}
+	to, err := address(msg.GetTo())
+	if err != nil {
+		return simulator.CallMsg{}, status.Errorf(codes.InvalidArgument, "to: %v", err)
+	}
+	if len(msg.GetCode()) > 0 {
+		sim.State().SetCode(to, msg.GetCode())
+	}
+	return simulator.CallMsg{
+		From:  from,
+		To:    to,
+		Value: new(big.Int).SetBytes(msg.GetValue()),
+		Gas:   capGas(msg.GetGas(), s.limits),
+	}, nil
+}
+
+// executionResult converts the result of a message
+func executionResult(result *vm.ExecutionResult) *executionpb.Result {
+	out := &executionpb.Result{ReturnData: result.ReturnData, GasUsed: result.GasUsed, Status: executionpb.Status(result.Status())}
+	if result.Failed() {
+		out.Error = result.Err.Error()
+	}
+	for _, l := range result.Logs {
+		log := &executionpb.Log{Address: l.Address.Bytes(), Data: l.Data}
+		for _, topic := range l.Topics {
+			log.Topics = append(log.Topics, topic.Bytes())
+		}
+		out.Logs = append(out.Logs, log)
+	}
+	return out
+}
+
+// accounts returns the accounts of statedb sorted by address
+func accounts(statedb *state.StateDB) []*executionpb.Account {
+	var out []*executionpb.Account
+	for addr, acc := range statedb.Dump() {
+		account := &executionpb.Account{
+			Address: addr.Bytes(),
+			Nonce:   uint64(acc.Nonce),
+			Code:    acc.Code,
+		}
+		if acc.Balance != nil {
+			account.Balance = acc.Balance.ToInt().Bytes()
+		}
+		keys := make([]common.Hash, 0, len(acc.Storage))
+		for key := range acc.Storage {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+		for _, key := range keys {
+			value := acc.Storage[key]
+			account.Storage = append(account.Storage, &executionpb.StorageSlot{Key: key.Bytes(), Value: value.Bytes()})
+		}
+		out = append(out, account)
+	}
+	sort.Slice(out, func(i, j int) bool { return bytes.Compare(out[i].Address, out[j].Address) < 0 })
+	return out
+}
+
+// address decodes a 20-byte address
+func address(b []byte) (common.Address, error) {
+	if len(b) != common.AddressLength {
+		return common.Address{}, fmt.Errorf("address must be %d bytes, got %d", common.AddressLength, len(b))
+	}
+	return common.BytesToAddress(b), nil
+}
@@ -0,0 +1,29 @@
+// Package debug contains the profiling helpers shared by the commands.
+package debug
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// StartPprofServer serves the net/http/pprof endpoints under /debug/pprof/
+// on the given address, from a mux of its own. Importing net/http/pprof also
+// registers them on http.DefaultServeMux, so an embedding application that
+// serves the default mux exposes them too.
+func StartPprofServer(addr string) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
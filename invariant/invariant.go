@@ -0,0 +1,335 @@
+// Package invariant parses predicates over the world state into
+// vm.Invariants, so properties such as "balances never exceed the total
+// supply" can be checked while an execution runs.
+//
+// A predicate is an expression over 256-bit values, computed without
+// wrapping so that differences can go negative:
+//
+//	storage(0x5FbDB2315678afecb367f032d93F642f64180aa3, 0) >= sum(
+//	    storage(0x5FbDB2315678afecb367f032d93F642f64180aa3, mapping(1, 0x70997970C51812dc3A010C7d01b50e0d17dc79C8)),
+//	    storage(0x5FbDB2315678afecb367f032d93F642f64180aa3, mapping(1, 0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC)))
+//
+// Numbers are decimal or 0x-prefixed hex; addresses are written as hex
+// numbers. The functions are
+//
+//	storage(address, slot)  a storage slot
+//	balance(address)        the balance of an account, if the state tracks balances
+//	nonce(address)          the nonce of an account
+//	mapping(slot, key)      the slot of key in the Solidity mapping at slot
+//	sum(a, b, ...)          the sum of its arguments
+//
+// and the operators, by increasing precedence, ||, &&, the comparisons
+// == != < <= > >=, and + -. Comparisons and logical operators give 1 or 0;
+// the predicate holds when it evaluates to a nonzero value.
+package invariant
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/word"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// balanceReader is implemented by states that track balances
+type balanceReader interface {
+	GetBalance(addr common.Address) *big.Int
+}
+
+// Parse compiles a predicate into an invariant named name
+func Parse(name, src string) (vm.Invariant, error) {
+	p := &parser{tokens: tokenize(src)}
+	expr, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return vm.Invariant{}, fmt.Errorf("invariant %s: %w", name, err)
+	}
+	return vm.Invariant{Name: name, Check: func(statedb vm.StateDB) error {
+		return check(expr, statedb)
+	}}, nil
+}
+
+// check evaluates the predicate, describing the values of a failed
+// comparison
+func check(expr node, statedb vm.StateDB) error {
+	v, err := expr.eval(statedb)
+	if err != nil {
+		return err
+	}
+	if v.Sign() != 0 {
+		return nil
+	}
+	if b, ok := expr.(*binary); ok && b.comparison() {
+		x, _ := b.x.eval(statedb)
+		y, _ := b.y.eval(statedb)
+		return fmt.Errorf("%s is false: %s %s %s", expr, x, b.op, y)
+	}
+	return fmt.Errorf("%s is false", expr)
+}
+
+type node interface {
+	eval(statedb vm.StateDB) (*big.Int, error)
+	String() string
+}
+
+type number struct {
+	v   *big.Int
+	src string
+}
+
+func (n *number) eval(vm.StateDB) (*big.Int, error) { return n.v, nil }
+func (n *number) String() string                    { return n.src }
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (b *binary) comparison() bool {
+	switch b.op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func (b *binary) String() string { return b.x.String() + " " + b.op + " " + b.y.String() }
+
+func (b *binary) eval(statedb vm.StateDB) (*big.Int, error) {
+	x, err := b.x.eval(statedb)
+	if err != nil {
+		return nil, err
+	}
+	// short-circuit the logical operators
+	switch {
+	case b.op == "&&" && x.Sign() == 0:
+		return new(big.Int), nil
+	case b.op == "||" && x.Sign() != 0:
+		return big.NewInt(1), nil
+	}
+	y, err := b.y.eval(statedb)
+	if err != nil {
+		return nil, err
+	}
+	cmp := x.Cmp(y)
+	var holds bool
+	switch b.op {
+	case "+":
+		return new(big.Int).Add(x, y), nil
+	case "-":
+		return new(big.Int).Sub(x, y), nil
+	case "&&", "||":
+		holds = y.Sign() != 0
+	case "==":
+		holds = cmp == 0
+	case "!=":
+		holds = cmp != 0
+	case "<":
+		holds = cmp < 0
+	case "<=":
+		holds = cmp <= 0
+	case ">":
+		holds = cmp > 0
+	case ">=":
+		holds = cmp >= 0
+	}
+	if holds {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int), nil
+}
+
+type paren struct{ x node }
+
+func (p *paren) eval(statedb vm.StateDB) (*big.Int, error) { return p.x.eval(statedb) }
+func (p *paren) String() string                            { return "(" + p.x.String() + ")" }
+
+type call struct {
+	name string
+	args []node
+}
+
+func (c *call) String() string {
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = arg.String()
+	}
+	return c.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// arity is the number of arguments of each function, -1 for any
+var arity = map[string]int{"storage": 2, "balance": 1, "nonce": 1, "mapping": 2, "sum": -1}
+
+func (c *call) eval(statedb vm.StateDB) (*big.Int, error) {
+	args := make([]*big.Int, len(c.args))
+	for i, arg := range c.args {
+		v, err := arg.eval(statedb)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch c.name {
+	case "storage":
+		return statedb.GetState(word.ToAddress(args[0]), word.ToHash(args[1])).Big(), nil
+	case "balance":
+		db, ok := statedb.(balanceReader)
+		if !ok {
+			return nil, fmt.Errorf("%s: the state does not track balances", c)
+		}
+		return new(big.Int).Set(db.GetBalance(word.ToAddress(args[0]))), nil
+	case "nonce":
+		return new(big.Int).SetUint64(statedb.GetNonce(word.ToAddress(args[0]))), nil
+	case "mapping":
+		key, slot := word.ToHash(args[1]), word.ToHash(args[0])
+		return crypto.Keccak256Hash(key[:], slot[:]).Big(), nil
+	default: // sum
+		total := new(big.Int)
+		for _, arg := range args {
+			total.Add(total, arg)
+		}
+		return total, nil
+	}
+}
+
+// tokenize splits src into numbers, identifiers, punctuation and operators
+func tokenize(src string) []string {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isWord(c):
+			j := i
+			for j < len(src) && isWord(src[j]) {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case i+1 < len(src) && strings.Contains("== != <= >= && ||", src[i:i+2]):
+			tokens = append(tokens, src[i:i+2])
+			i += 2
+		default:
+			tokens = append(tokens, src[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) expect(tok string) error {
+	if p.peek() != tok {
+		if p.pos == len(p.tokens) {
+			return fmt.Errorf("expected %q at the end", tok)
+		}
+		return fmt.Errorf("expected %q, found %q", tok, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// parseLevel parses a left-associative chain of the operators ops
+func (p *parser) parseLevel(next func() (node, error), ops ...string) (node, error) {
+	x, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, o := range ops {
+			found = found || op == o
+		}
+		if !found || op == "" {
+			return x, nil
+		}
+		p.pos++
+		y, err := next()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, x: x, y: y}
+	}
+}
+
+func (p *parser) parseOr() (node, error) { return p.parseLevel(p.parseAnd, "||") }
+
+func (p *parser) parseAnd() (node, error) { return p.parseLevel(p.parseCompare, "&&") }
+
+func (p *parser) parseCompare() (node, error) {
+	return p.parseLevel(p.parseSum, "==", "!=", "<", "<=", ">", ">=")
+}
+
+func (p *parser) parseSum() (node, error) { return p.parseLevel(p.parseTerm, "+", "-") }
+
+func (p *parser) parseTerm() (node, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of predicate")
+	case tok == "(":
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &paren{x: x}, p.expect(")")
+	case tok[0] >= '0' && tok[0] <= '9':
+		p.pos++
+		v, ok := new(big.Int).SetString(tok, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", tok)
+		}
+		return &number{v: v, src: tok}, nil
+	case isWord(tok[0]):
+		p.pos++
+		n, ok := arity[tok]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", tok)
+		}
+		c := &call{name: tok}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		for p.peek() != ")" {
+			if len(c.args) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+		}
+		p.pos++
+		if n >= 0 && len(c.args) != n {
+			return nil, fmt.Errorf("%s takes %d arguments, got %d", tok, n, len(c.args))
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
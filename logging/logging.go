@@ -0,0 +1,61 @@
+// Package logging builds the per-component slog loggers used by the EVM.
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Component names
+const (
+	ComponentInterpreter = "interpreter"
+	ComponentState       = "state"
+)
+
+// Config configures the structured loggers used by the EVM components.
+// A nil config, or one without a handler, discards all log records so the
+// EVM stays silent when embedded in a larger application.
+type Config struct {
+	Handler slog.Handler
+	Level   slog.Leveler            // default minimum level for all components
+	Levels  map[string]slog.Leveler // per-component overrides of Level
+}
+
+// Logger returns the logger for the given component
+func (c *Config) Logger(component string) *slog.Logger {
+	if c == nil || c.Handler == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	level := c.Level
+	if l, ok := c.Levels[component]; ok {
+		level = l
+	}
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	handler := &levelHandler{level: level, handler: c.Handler}
+	return slog.New(handler).With("component", component)
+}
+
+// levelHandler filters records below a component's level before passing
+// them on to the user-provided handler
+type levelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/filters"
+	"github.com/nutcas3/evm-golang/core/types"
+)
+
+// LogFilter selects logs by block range, emitting address and topics, as
+// the filter object of eth_getLogs and eth_subscribe("logs") does.
+// Subscriptions ignore the block range.
+type LogFilter = filters.Criteria
+
+// Logs returns the logs of the chain passing filter, in chain order, as
+// eth_getLogs does. Blocks whose logs bloom rules out a match are skipped.
+func (d *Dev) Logs(filter *LogFilter) ([]*Log, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	matches, err := filters.Filter(devChain{d}, filter)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]*Log, len(matches))
+	for i, m := range matches {
+		logs[i] = d.blockLogs(m.BlockNumber)[m.Index]
+	}
+	return logs, nil
+}
+
+// blockLogs returns the logs of a block. d.mu must be held.
+func (d *Dev) blockLogs(number uint64) []*Log {
+	header := d.headers[number]
+	if receipt := d.receipts[header.TxHash]; receipt != nil && number > 0 {
+		return receipt.Logs
+	}
+	return nil
+}
+
+// devChain is the filters.Backend of a node whose d.mu is held
+type devChain struct{ d *Dev }
+
+func (c devChain) HeadNumber() uint64 { return uint64(len(c.d.headers) - 1) }
+
+func (c devChain) BlockNumber(hash common.Hash) (uint64, bool) {
+	for _, h := range c.d.headers {
+		if h.Hash == hash {
+			return h.Number, true
+		}
+	}
+	return 0, false
+}
+
+func (c devChain) Bloom(number uint64) types.Bloom { return c.d.headers[number].Bloom }
+
+func (c devChain) Logs(number uint64) []*types.Log {
+	var logs []*types.Log
+	for _, l := range c.d.blockLogs(number) {
+		logs = append(logs, &l.Log)
+	}
+	return logs
+}
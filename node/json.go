@@ -0,0 +1,136 @@
+package node
+
+import (
+	"encoding/json"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+type headerJSON struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	GasLimit   hexutil.Uint64 `json:"gasLimit"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Miner      common.Address `json:"miner"`
+	LogsBloom  types.Bloom    `json:"logsBloom"`
+	MixHash    common.Hash    `json:"mixHash"`
+	Difficulty hexutil.Uint64 `json:"difficulty"`
+
+	BlobGasUsed   *hexutil.Uint64 `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas *hexutil.Uint64 `json:"excessBlobGas,omitempty"`
+}
+
+// MarshalJSON encodes the header with the field names of Ethereum JSON-RPC
+func (h *Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(headerJSON{
+		Number:     hexutil.Uint64(h.Number),
+		Hash:       h.Hash,
+		ParentHash: h.ParentHash,
+		Timestamp:  hexutil.Uint64(h.Timestamp),
+		GasLimit:   hexutil.Uint64(h.GasLimit),
+		GasUsed:    hexutil.Uint64(h.GasUsed),
+		LogsBloom:  h.Bloom,
+		MixHash:    h.MixHash,
+		Difficulty: hexutil.Uint64(h.Difficulty),
+
+		BlobGasUsed:   (*hexutil.Uint64)(h.BlobGasUsed),
+		ExcessBlobGas: (*hexutil.Uint64)(h.ExcessBlobGas),
+	})
+}
+
+// devAccountJSON is a dev account as returned by dev_accounts
+type devAccountJSON struct {
+	Address    common.Address `json:"address"`
+	PrivateKey string         `json:"privateKey"`
+	Path       string         `json:"path"`
+}
+
+type logJSON struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+	Index       hexutil.Uint64 `json:"logIndex"`
+	Removed     bool           `json:"removed"`
+}
+
+// MarshalJSON encodes the log with the field names of Ethereum JSON-RPC
+func (l *Log) MarshalJSON() ([]byte, error) {
+	topics := l.Topics
+	if topics == nil {
+		topics = []common.Hash{}
+	}
+	return json.Marshal(logJSON{
+		Address:     l.Address,
+		Topics:      topics,
+		Data:        l.Data,
+		BlockNumber: hexutil.Uint64(l.BlockNumber),
+		BlockHash:   l.BlockHash,
+		TxHash:      l.TxHash,
+		TxIndex:     hexutil.Uint64(l.TxIndex),
+		Index:       hexutil.Uint64(l.Index),
+	})
+}
+
+type receiptJSON struct {
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Logs        []*Log         `json:"logs"`
+	ReturnData  hexutil.Bytes  `json:"returnData"`
+	Error       string         `json:"error,omitempty"`
+	HaltReason  *vm.Status     `json:"haltReason,omitempty"`
+	L1Fee       *hexutil.Big   `json:"l1Fee,omitempty"`
+
+	BlobGasUsed  hexutil.Uint64 `json:"blobGasUsed,omitempty"`
+	BlobGasPrice *hexutil.Big   `json:"blobGasPrice,omitempty"`
+
+	ContractAddress   *common.Address `json:"contractAddress"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice,omitempty"`
+}
+
+// MarshalJSON encodes the receipt with the field names of Ethereum
+// JSON-RPC, adding the return data and error of the execution
+func (r *Receipt) MarshalJSON() ([]byte, error) {
+	enc := receiptJSON{
+		TxHash:      r.TxHash,
+		BlockNumber: hexutil.Uint64(r.BlockNumber),
+		BlockHash:   r.BlockHash,
+		From:        r.From,
+		To:          r.To,
+		GasUsed:     hexutil.Uint64(r.GasUsed),
+		Status:      1,
+		Logs:        r.Logs,
+		ReturnData:  r.ReturnData,
+		L1Fee:       (*hexutil.Big)(r.L1Fee),
+
+		BlobGasUsed:  hexutil.Uint64(r.BlobGasUsed),
+		BlobGasPrice: (*hexutil.Big)(r.BlobGasPrice),
+
+		ContractAddress:   r.ContractAddress,
+		EffectiveGasPrice: (*hexutil.Big)(r.EffectiveGasPrice),
+	}
+	if enc.Logs == nil {
+		enc.Logs = []*Log{}
+	}
+	if r.Err != nil {
+		status := r.HaltStatus()
+		enc.Status = 0
+		enc.Error = r.Err.Error()
+		enc.HaltReason = &status
+	}
+	return json.Marshal(enc)
+}
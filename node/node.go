@@ -0,0 +1,509 @@
+// Package node runs a development node: an in-memory chain that mines a
+// block for every transaction it receives and serves Ethereum JSON-RPC,
+// including eth_subscribe over WebSocket, to frontend tooling and indexers.
+package node
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nutcas3/evm-golang/accounts"
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/eip4844"
+	"github.com/nutcas3/evm-golang/core/system"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/crypto/kzg4844"
+	"github.com/nutcas3/evm-golang/params"
+	"github.com/nutcas3/evm-golang/simulator"
+)
+
+// Header is the header of a mined block. Dev-node block hashes are the
+// Keccak-256 hash of the header fields and the transaction hash, not of an
+// RLP-encoded Ethereum header.
+type Header struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+	Timestamp  uint64
+	GasLimit   uint64
+	GasUsed    uint64
+	TxHash     common.Hash // hash of the block's transaction, zero for genesis
+	Bloom      types.Bloom // bloom of the addresses and topics of the block's logs
+	MixHash    common.Hash // randao mix returned by PREVRANDAO, zero before the merge
+	Difficulty uint64      // returned by DIFFICULTY before the merge, zero after it
+
+	BlobGasUsed   *uint64 // nil before Cancun
+	ExcessBlobGas *uint64 // nil before Cancun
+}
+
+// Log is a log emitted by a mined transaction, with its position in the
+// chain
+type Log struct {
+	types.Log
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	TxIndex     uint
+	Index       uint // position of the log in the block
+}
+
+// Receipt is the outcome of a mined transaction
+type Receipt struct {
+	TxHash      common.Hash
+	BlockNumber uint64
+	BlockHash   common.Hash
+	From        common.Address
+	To          common.Address
+	GasUsed     uint64
+	ReturnData  []byte
+	Err         error    // execution error, nil if the transaction succeeded
+	L1Fee       *big.Int // L1 data fee paid on OP Stack chains, nil elsewhere
+	Logs        []*Log
+
+	BlobGasUsed  uint64   // zero unless the transaction carries blobs
+	BlobGasPrice *big.Int // blob base fee paid, nil unless the transaction carries blobs
+
+	ContractAddress   *common.Address // contract deployed by a creation transaction, nil otherwise
+	EffectiveGasPrice *big.Int        // gas price paid, nil for deposits
+}
+
+// HaltStatus reports how the transaction's execution halted, telling a
+// revert apart from exceptional halts
+func (r *Receipt) HaltStatus() vm.Status { return vm.HaltStatus(r.Err) }
+
+// Dev is a development node. It mines one block per transaction, so every
+// transaction is final as soon as SendTransaction returns. It is safe for
+// concurrent use.
+type Dev struct {
+	mu       sync.Mutex
+	pending  atomic.Int64 // transactions waiting for mu to be mined
+	sim      *simulator.Simulator
+	system   *system.Processor
+	headers  []*Header
+	receipts map[common.Hash]*Receipt
+	accounts []*accounts.Account // dev accounts, in derivation order
+	origins  []string            // origins browsers may send requests from, localhost ones if nil
+
+	subMu sync.Mutex
+	heads map[*func(*Header)]struct{}
+	logs  map[*logSubscription]struct{}
+}
+
+type logSubscription struct {
+	filter LogFilter
+	fn     func(*Log)
+}
+
+// NewDev creates a node whose chain holds only a genesis block. A nil chain
+// config selects params.DevChainConfig. Transactions and the system calls
+// of each block execute with config.
+func NewDev(chainConfig *params.ChainConfig, config vm.Config) *Dev {
+	sim := simulator.New(chainConfig, config)
+	config.Precompiles = vm.ChainPrecompiles(sim.ChainConfig(), config.Precompiles)
+	if config.ChainConfig == nil {
+		config.ChainConfig = sim.ChainConfig()
+	}
+	d := &Dev{
+		sim:      sim,
+		system:   system.NewProcessor(sim.ChainConfig(), config, nil),
+		receipts: make(map[common.Hash]*Receipt),
+		heads:    make(map[*func(*Header)]struct{}),
+		logs:     make(map[*logSubscription]struct{}),
+	}
+	genesis := &Header{Timestamp: uint64(time.Now().Unix()), GasLimit: simulator.DefaultGasLimit}
+	if d.blobConfig(genesis) != nil {
+		genesis.BlobGasUsed, genesis.ExcessBlobGas = new(uint64), new(uint64)
+	}
+	genesis.Hash = headerHash(genesis)
+	d.headers = append(d.headers, genesis)
+	return d
+}
+
+// Update calls fn with the simulator holding the node's state, for funding
+// accounts or deploying contracts outside of transactions. No block is
+// mined.
+func (d *Dev) Update(fn func(sim *simulator.Simulator)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn(d.sim)
+}
+
+// AddDevAccounts funds the dev accounts with balance without mining a
+// block, as part of the genesis state when called before the first
+// transaction, and lists them in eth_accounts and dev_accounts
+func (d *Dev) AddDevAccounts(accs []*accounts.Account, balance *big.Int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	accounts.Fund(d.sim.State(), accs, balance)
+	d.accounts = append(d.accounts, accs...)
+}
+
+// DevAccounts returns the dev accounts added to the node
+func (d *Dev) DevAccounts() []*accounts.Account {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*accounts.Account(nil), d.accounts...)
+}
+
+// ChainID returns the chain id of the node
+func (d *Dev) ChainID() uint64 {
+	return d.sim.ChainConfig().ChainID.Uint64()
+}
+
+// BlockNumber returns the number of the latest block
+func (d *Dev) BlockNumber() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return uint64(len(d.headers) - 1)
+}
+
+// Header returns the header of a block, nil if it has not been mined
+func (d *Dev) Header(number uint64) *Header {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if number >= uint64(len(d.headers)) {
+		return nil
+	}
+	return d.headers[number]
+}
+
+// Receipt returns the receipt of a transaction, nil if it is unknown
+func (d *Dev) Receipt(txHash common.Hash) *Receipt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.receipts[txHash]
+}
+
+// SendTransaction executes msg in a new block and returns its receipt. The
+// sender's nonce is incremented. Messages the simulator rejects, such as
+// transfers the sender cannot afford, are not mined. Subscribers are
+// notified of the block and its logs before SendTransaction returns.
+func (d *Dev) SendTransaction(ctx context.Context, msg simulator.CallMsg) (*Receipt, error) {
+	return d.sendTransaction(ctx, &simulator.Transaction{CallMsg: msg}, true, 0)
+}
+
+// ApplyTransaction mines tx in a new block like SendTransaction, with the
+// nonce given by tx rather than the sender's next one. Transactions whose
+// nonce is not the sender's next one are rejected with
+// simulator.ErrNonceTooLow or simulator.ErrNonceTooHigh and not mined.
+func (d *Dev) ApplyTransaction(ctx context.Context, tx *simulator.Transaction) (*Receipt, error) {
+	return d.sendTransaction(ctx, tx, false, 0)
+}
+
+// SendRawTransaction mines a signed legacy transaction in a new block
+// like ApplyTransaction. The sender is recovered from the signature, which
+// must be bound to the chain id of the node once EIP-155 is active;
+// signatures for other chains fail with types.ErrInvalidChainID.
+func (d *Dev) SendRawTransaction(ctx context.Context, raw []byte) (*Receipt, error) {
+	signed, err := types.DecodeLegacyTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(d.sim.ChainConfig(), new(big.Int).SetUint64(d.BlockNumber()+1), signed)
+	if err != nil {
+		return nil, err
+	}
+	tx := &simulator.Transaction{
+		CallMsg:  simulator.CallMsg{From: from, Value: signed.Value, Gas: signed.Gas, Data: signed.Data},
+		Nonce:    signed.Nonce,
+		GasPrice: signed.GasPrice,
+	}
+	if signed.To != nil {
+		tx.To = *signed.To
+	} else {
+		tx.Create = true
+	}
+	return d.ApplyTransaction(ctx, tx)
+}
+
+// SendBlobTransaction executes msg as a blob transaction carrying the
+// given versioned hashes. The transaction is only accepted if the sidecar
+// holds a blob for every hash, with matching KZG commitments and proofs,
+// and if the sender can pay the blob fee: the blob gas of the blobs at the
+// block's blob base fee, which is burned.
+func (d *Dev) SendBlobTransaction(ctx context.Context, msg simulator.CallMsg, hashes []common.Hash, sidecar *kzg4844.Sidecar) (*Receipt, error) {
+	if len(hashes) == 0 {
+		return nil, errors.New("blob transaction without blobs")
+	}
+	if sidecar == nil {
+		return nil, errors.New("blob transaction without sidecar")
+	}
+	if err := sidecar.Verify(hashes); err != nil {
+		return nil, fmt.Errorf("invalid blob sidecar: %w", err)
+	}
+	return d.sendTransaction(ctx, &simulator.Transaction{CallMsg: msg}, true, len(hashes))
+}
+
+// sendTransaction mines tx, with the given number of blobs, in a new
+// block. With nextNonce, tx is given the sender's next nonce.
+func (d *Dev) sendTransaction(ctx context.Context, tx *simulator.Transaction, nextNonce bool, blobs int) (*Receipt, error) {
+	msg := tx.CallMsg
+	d.lockPending()
+	snapshot := d.sim.Snapshot()
+	header := d.nextHeader()
+	if err := d.systemCalls(ctx, system.PreBlock, header); err != nil {
+		d.sim.Revert(snapshot)
+		d.mu.Unlock()
+		return nil, err
+	}
+	var blobGas uint64
+	var blobFee *big.Int
+	if blobs > 0 {
+		config := d.blobConfig(header)
+		if config == nil {
+			d.sim.Revert(snapshot)
+			d.mu.Unlock()
+			return nil, errors.New("blob transactions require Cancun")
+		}
+		blobGas = uint64(blobs) * eip4844.GasPerBlob
+		if blobGas > eip4844.MaxBlobGasPerBlock(config) {
+			d.sim.Revert(snapshot)
+			d.mu.Unlock()
+			return nil, fmt.Errorf("%d blobs exceed the maximum of %d per block", blobs, config.Max)
+		}
+		blobFee = eip4844.CalcBlobFee(config, *header.ExcessBlobGas)
+		cost := new(big.Int).Mul(blobFee, new(big.Int).SetUint64(blobGas))
+		if d.sim.State().GetBalance(msg.From).Cmp(cost) < 0 {
+			d.sim.Revert(snapshot)
+			d.mu.Unlock()
+			return nil, fmt.Errorf("%w: blob fee %s", simulator.ErrInsufficientBalance, cost)
+		}
+		d.sim.State().SubBalance(msg.From, cost)
+	}
+	if nextNonce {
+		tx.Nonce = d.sim.State().GetNonce(msg.From)
+	}
+	l1Fee := d.sim.L1Fee(msg)
+	result, err := d.sim.ApplyTransaction(ctx, tx)
+	if err != nil {
+		d.sim.Revert(snapshot)
+		d.mu.Unlock()
+		return nil, err
+	}
+	if err := d.systemCalls(ctx, system.PostBlock, header); err != nil {
+		d.sim.Revert(snapshot)
+		d.mu.Unlock()
+		return nil, err
+	}
+
+	if blobs > 0 {
+		*header.BlobGasUsed = blobGas
+	}
+	to := msg.To
+	if tx.Create {
+		to = common.Address{}
+	}
+	receipt := d.mine(header, txHash(msg.From, tx.Nonce), msg.From, to, result.ExecutionResult)
+	receipt.EffectiveGasPrice = result.EffectiveGasPrice
+	if tx.Create {
+		addr := tx.ContractAddress()
+		receipt.ContractAddress = &addr
+	}
+	if d.sim.ChainConfig().IsOptimism() {
+		receipt.L1Fee = l1Fee
+	}
+	receipt.BlobGasUsed, receipt.BlobGasPrice = blobGas, blobFee
+	d.mu.Unlock()
+
+	d.notify(header, receipt.Logs)
+	return receipt, nil
+}
+
+// blobConfig returns the blob parameters of the fork a block belongs to,
+// nil before Cancun
+func (d *Dev) blobConfig(header *Header) *params.BlobConfig {
+	chain := d.sim.ChainConfig()
+	return chain.BlobConfig(chain.Fork(new(big.Int).SetUint64(header.Number), header.Timestamp))
+}
+
+// SendDeposit applies an OP Stack deposit transaction in a new block, as
+// simulator.ApplyDeposit does, and returns its receipt. It fails with
+// simulator.ErrDepositsDisabled unless the chain config has an Optimism
+// section.
+func (d *Dev) SendDeposit(ctx context.Context, tx *types.DepositTx) (*Receipt, error) {
+	d.lockPending()
+	snapshot := d.sim.Snapshot()
+	header := d.nextHeader()
+	if err := d.systemCalls(ctx, system.PreBlock, header); err != nil {
+		d.sim.Revert(snapshot)
+		d.mu.Unlock()
+		return nil, err
+	}
+	result, err := d.sim.ApplyDeposit(ctx, tx)
+	if err == nil {
+		err = d.systemCalls(ctx, system.PostBlock, header)
+	}
+	if err != nil {
+		d.sim.Revert(snapshot)
+		d.mu.Unlock()
+		return nil, err
+	}
+	receipt := d.mine(header, tx.Hash(), tx.From, *tx.To, result)
+	d.mu.Unlock()
+
+	d.notify(header, receipt.Logs)
+	return receipt, nil
+}
+
+// lockPending locks d.mu for mining a transaction, counting the
+// transaction as pending while it waits
+func (d *Dev) lockPending() {
+	d.pending.Add(1)
+	d.mu.Lock()
+	d.pending.Add(-1)
+}
+
+// PendingTransactions returns the number of transactions waiting for the
+// transactions before them to be mined
+func (d *Dev) PendingTransactions() int { return int(d.pending.Load()) }
+
+// nextHeader starts the block following the head and sets the simulator's
+// block context to it. d.mu must be held.
+func (d *Dev) nextHeader() *Header {
+	parent := d.headers[len(d.headers)-1]
+	header := &Header{
+		Number:     parent.Number + 1,
+		ParentHash: parent.Hash,
+		Timestamp:  max(uint64(time.Now().Unix()), parent.Timestamp+1),
+		GasLimit:   simulator.DefaultGasLimit,
+	}
+	if config := d.blobConfig(header); config != nil {
+		var parentExcess, parentUsed uint64
+		if parent.ExcessBlobGas != nil {
+			parentExcess, parentUsed = *parent.ExcessBlobGas, *parent.BlobGasUsed
+		}
+		excess := eip4844.CalcExcessBlobGas(config, parentExcess, parentUsed)
+		header.ExcessBlobGas, header.BlobGasUsed = &excess, new(uint64)
+	}
+	d.sim.SetBlock(header.Number, header.Timestamp)
+	// there is no beacon chain to provide randomness: each mix is derived
+	// from the parent's
+	if d.sim.ChainConfig().IsMerge(new(big.Int).SetUint64(header.Number)) {
+		header.MixHash = crypto.Keccak256Hash(parent.MixHash.Bytes(), parent.Hash.Bytes())
+		d.sim.OverrideBlock(&simulator.BlockOverrides{PrevRandao: &header.MixHash})
+	} else {
+		header.Difficulty = 1
+		d.sim.OverrideBlock(&simulator.BlockOverrides{Difficulty: (*hexutil.Big)(big.NewInt(1))})
+	}
+	return header
+}
+
+// systemCalls makes the system calls of a phase of the block being mined.
+// The dev node has no consensus layer, so the parent beacon root is zero.
+// d.mu must be held.
+func (d *Dev) systemCalls(ctx context.Context, phase system.Phase, header *Header) error {
+	_, err := d.system.Run(ctx, phase, d.sim.State(), &system.Block{
+		Number:     new(big.Int).SetUint64(header.Number),
+		Time:       header.Timestamp,
+		ParentHash: header.ParentHash,
+	})
+	return err
+}
+
+// mine appends the block holding one executed transaction to the chain
+// and records its receipt. d.mu must be held.
+func (d *Dev) mine(header *Header, hash common.Hash, from, to common.Address, result *vm.ExecutionResult) *Receipt {
+	header.TxHash = hash
+	header.GasUsed = result.GasUsed
+	header.Bloom = types.LogsBloom(result.Logs)
+	header.Hash = headerHash(header)
+	receipt := &Receipt{
+		TxHash:      header.TxHash,
+		BlockNumber: header.Number,
+		BlockHash:   header.Hash,
+		From:        from,
+		To:          to,
+		GasUsed:     result.GasUsed,
+		ReturnData:  result.ReturnData,
+		Err:         result.Err,
+	}
+	for i, l := range result.Logs {
+		receipt.Logs = append(receipt.Logs, &Log{
+			Log:         *l,
+			BlockNumber: header.Number,
+			BlockHash:   header.Hash,
+			TxHash:      header.TxHash,
+			Index:       uint(i),
+		})
+	}
+	d.headers = append(d.headers, header)
+	d.receipts[receipt.TxHash] = receipt
+	return receipt
+}
+
+// SubscribeNewHeads calls fn with the header of every block mined until
+// the returned function is called. fn runs on the mining goroutine, so it
+// should not block.
+func (d *Dev) SubscribeNewHeads(fn func(*Header)) (unsubscribe func()) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	key := &fn
+	d.heads[key] = struct{}{}
+	return func() {
+		d.subMu.Lock()
+		defer d.subMu.Unlock()
+		delete(d.heads, key)
+	}
+}
+
+// SubscribeLogs calls fn with every mined log that matches filter until
+// the returned function is called. fn runs on the mining goroutine, so it
+// should not block.
+func (d *Dev) SubscribeLogs(filter LogFilter, fn func(*Log)) (unsubscribe func()) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	sub := &logSubscription{filter: filter, fn: fn}
+	d.logs[sub] = struct{}{}
+	return func() {
+		d.subMu.Lock()
+		defer d.subMu.Unlock()
+		delete(d.logs, sub)
+	}
+}
+
+// notify delivers a mined block and its logs to the subscribers
+func (d *Dev) notify(header *Header, logs []*Log) {
+	d.subMu.Lock()
+	heads := make([]func(*Header), 0, len(d.heads))
+	for fn := range d.heads {
+		heads = append(heads, *fn)
+	}
+	subs := make([]*logSubscription, 0, len(d.logs))
+	for sub := range d.logs {
+		subs = append(subs, sub)
+	}
+	d.subMu.Unlock()
+
+	for _, fn := range heads {
+		fn(header)
+	}
+	for _, l := range logs {
+		for _, sub := range subs {
+			if sub.filter.Matches(&l.Log) {
+				sub.fn(l)
+			}
+		}
+	}
+}
+
+// txHash identifies a dev-node transaction by its sender and nonce
+func txHash(from common.Address, nonce uint64) common.Hash {
+	return crypto.Keccak256Hash(from.Bytes(), binary.BigEndian.AppendUint64(nil, nonce))
+}
+
+func headerHash(h *Header) common.Hash {
+	var fields []byte
+	for _, v := range []uint64{h.Number, h.Timestamp, h.GasLimit, h.GasUsed} {
+		fields = binary.BigEndian.AppendUint64(fields, v)
+	}
+	return crypto.Keccak256Hash(h.ParentHash.Bytes(), fields, h.TxHash.Bytes())
+}
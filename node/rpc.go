@@ -0,0 +1,423 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/simulator"
+	"golang.org/x/net/websocket"
+)
+
+// JSON-RPC error codes
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeServer         = -32000
+)
+
+// maxRequestSize bounds the body of a JSON-RPC request
+const maxRequestSize = 5 << 20
+
+// notificationBuffer is the number of notifications queued per WebSocket
+// connection; a client falling further behind is disconnected
+const notificationBuffer = 256
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Subscription string `json:"subscription"`
+		Result       any    `json:"result"`
+	} `json:"params"`
+}
+
+// SetOrigins sets the origins browsers may send requests from, "*" allowing
+// any. By default only pages served from localhost may, so other web pages
+// cannot send transactions from the dev accounts.
+func (d *Dev) SetOrigins(origins []string) {
+	d.origins = origins
+}
+
+// allowOrigin reports whether a request with the given Origin header may be
+// served. Clients other than browsers send none and are always served.
+func (d *Dev) allowOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	if d.origins == nil {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return true
+		}
+		return false
+	}
+	for _, allowed := range d.origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns an HTTP handler serving JSON-RPC. Requests upgrading to
+// WebSocket may also use eth_subscribe for newHeads and logs; plain HTTP
+// requests are POSTed one at a time. Requests from origins not allowed by
+// SetOrigins are refused.
+func (d *Dev) Handler() http.Handler {
+	ws := websocket.Server{
+		Handshake: func(*websocket.Config, *http.Request) error { return nil }, // the origin is checked below
+		Handler:   d.serveWebSocket,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.allowOrigin(r.Header.Get("Origin")) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(d.handle(r.Context(), body, nil))
+	})
+}
+
+// connection is a WebSocket client with its subscriptions
+type connection struct {
+	out    chan []byte
+	closed chan struct{}
+	once   sync.Once
+
+	mu   sync.Mutex
+	subs map[string]func() // unsubscribe functions by subscription id
+}
+
+// send queues a message, disconnecting a client that does not keep up
+func (c *connection) send(msg []byte) {
+	select {
+	case c.out <- msg:
+	case <-c.closed:
+	default:
+		c.close()
+	}
+}
+
+func (c *connection) close() {
+	c.once.Do(func() { close(c.closed) })
+}
+
+func (d *Dev) serveWebSocket(ws *websocket.Conn) {
+	conn := &connection{
+		out:    make(chan []byte, notificationBuffer),
+		closed: make(chan struct{}),
+		subs:   make(map[string]func()),
+	}
+	defer func() {
+		conn.close()
+		conn.mu.Lock()
+		for _, unsubscribe := range conn.subs {
+			unsubscribe()
+		}
+		conn.mu.Unlock()
+		ws.Close()
+	}()
+	go func() {
+		for {
+			select {
+			case msg := <-conn.out:
+				if err := websocket.Message.Send(ws, string(msg)); err != nil {
+					conn.close()
+					return
+				}
+			case <-conn.closed:
+				ws.Close() // unblocks Receive
+				return
+			}
+		}
+	}()
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return
+		}
+		conn.send(d.handle(ws.Request().Context(), msg, conn))
+	}
+}
+
+// handle answers a JSON-RPC request; conn is nil for plain HTTP
+func (d *Dev) handle(ctx context.Context, body []byte, conn *connection) []byte {
+	var req rpcRequest
+	resp := rpcResponse{JSONRPC: "2.0"}
+	if err := json.Unmarshal(body, &req); err != nil {
+		resp.Error = &rpcError{Code: errCodeParse, Message: err.Error()}
+	} else if req.Method == "" {
+		resp.ID = req.ID
+		resp.Error = &rpcError{Code: errCodeInvalidRequest, Message: "missing method"}
+	} else {
+		resp.ID = req.ID
+		result, err := d.call(ctx, req.Method, req.Params, conn)
+		if err != nil {
+			var rerr *rpcError
+			if !errors.As(err, &rerr) {
+				rerr = &rpcError{Code: errCodeServer, Message: err.Error()}
+			}
+			resp.Error = rerr
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Error = &rpcError{Code: errCodeServer, Message: err.Error()}
+		}
+	}
+	out, _ := json.Marshal(resp)
+	return out
+}
+
+// call runs a JSON-RPC method
+func (d *Dev) call(ctx context.Context, method string, params json.RawMessage, conn *connection) (any, error) {
+	switch method {
+	case "web3_clientVersion":
+		return "evm-golang/dev", nil
+	case "net_version":
+		return fmt.Sprint(d.ChainID()), nil
+	case "eth_chainId":
+		return hexutil.Uint64(d.ChainID()), nil
+	case "eth_accounts":
+		addrs := []common.Address{}
+		for _, acc := range d.DevAccounts() {
+			addrs = append(addrs, acc.Address)
+		}
+		return addrs, nil
+	case "dev_accounts":
+		accs := []devAccountJSON{}
+		for _, acc := range d.DevAccounts() {
+			accs = append(accs, devAccountJSON{Address: acc.Address, PrivateKey: acc.PrivateKeyHex(), Path: acc.Path.String()})
+		}
+		return accs, nil
+	case "eth_blockNumber":
+		return hexutil.Uint64(d.BlockNumber()), nil
+	case "eth_getBlockByNumber":
+		var args []json.RawMessage
+		if err := decodeParams(params, &args, 1); err != nil {
+			return nil, err
+		}
+		number, err := d.blockNumberArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if h := d.Header(number); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	case "eth_getTransactionReceipt":
+		var args []common.Hash
+		if err := decodeParams(params, &args, 1); err != nil {
+			return nil, err
+		}
+		if r := d.Receipt(args[0]); r != nil {
+			return r, nil
+		}
+		return nil, nil
+	case "eth_getLogs":
+		var args []LogFilter
+		if err := decodeParams(params, &args, 1); err != nil {
+			return nil, err
+		}
+		logs, err := d.Logs(&args[0])
+		if err != nil {
+			return nil, &rpcError{Code: errCodeInvalidParams, Message: err.Error()}
+		}
+		if logs == nil {
+			logs = []*Log{}
+		}
+		return logs, nil
+	case "eth_sendTransaction":
+		var args []struct {
+			From  common.Address  `json:"from"`
+			To    *common.Address `json:"to"`
+			Value *hexutil.Big    `json:"value"`
+			Gas   *hexutil.Uint64 `json:"gas"`
+			Data  hexutil.Bytes   `json:"data"`
+			Nonce *hexutil.Uint64 `json:"nonce"`
+		}
+		if err := decodeParams(params, &args, 1); err != nil {
+			return nil, err
+		}
+		tx := &simulator.Transaction{CallMsg: simulator.CallMsg{From: args[0].From, Value: args[0].Value.ToInt(), Data: args[0].Data}}
+		if args[0].To != nil {
+			tx.To = *args[0].To
+		} else {
+			// creation transactions install the data as runtime code
+			tx.Create = true
+		}
+		if args[0].Gas != nil {
+			tx.Gas = uint64(*args[0].Gas)
+		}
+		var receipt *Receipt
+		var err error
+		if args[0].Nonce != nil {
+			tx.Nonce = uint64(*args[0].Nonce)
+			receipt, err = d.ApplyTransaction(ctx, tx)
+		} else {
+			receipt, err = d.sendTransaction(ctx, tx, true, 0)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return receipt.TxHash, nil
+	case "eth_sendRawTransaction":
+		var args []hexutil.Bytes
+		if err := decodeParams(params, &args, 1); err != nil {
+			return nil, err
+		}
+		receipt, err := d.SendRawTransaction(ctx, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return receipt.TxHash, nil
+	case "eth_subscribe":
+		if conn == nil {
+			return nil, &rpcError{Code: errCodeMethodNotFound, Message: "notifications not supported over HTTP"}
+		}
+		return d.subscribe(params, conn)
+	case "eth_unsubscribe":
+		if conn == nil {
+			return nil, &rpcError{Code: errCodeMethodNotFound, Message: "notifications not supported over HTTP"}
+		}
+		var args []string
+		if err := decodeParams(params, &args, 1); err != nil {
+			return nil, err
+		}
+		conn.mu.Lock()
+		unsubscribe, ok := conn.subs[args[0]]
+		delete(conn.subs, args[0])
+		conn.mu.Unlock()
+		if ok {
+			unsubscribe()
+		}
+		return ok, nil
+	}
+	return nil, &rpcError{Code: errCodeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist", method)}
+}
+
+// subscribe starts a newHeads or logs subscription on a WebSocket
+// connection
+func (d *Dev) subscribe(params json.RawMessage, conn *connection) (any, error) {
+	var args []json.RawMessage
+	if err := decodeParams(params, &args, 1); err != nil {
+		return nil, err
+	}
+	var kind string
+	if err := json.Unmarshal(args[0], &kind); err != nil {
+		return nil, &rpcError{Code: errCodeInvalidParams, Message: "subscription type must be a string"}
+	}
+	id := subscriptionID()
+	notify := func(result any) {
+		n := rpcNotification{JSONRPC: "2.0", Method: "eth_subscription"}
+		n.Params.Subscription = id
+		n.Params.Result = result
+		msg, _ := json.Marshal(n)
+		conn.send(msg)
+	}
+	var unsubscribe func()
+	switch kind {
+	case "newHeads":
+		unsubscribe = d.SubscribeNewHeads(func(h *Header) { notify(h) })
+	case "logs":
+		var filter LogFilter
+		if len(args) > 1 {
+			if err := json.Unmarshal(args[1], &filter); err != nil {
+				return nil, &rpcError{Code: errCodeInvalidParams, Message: fmt.Sprintf("invalid log filter: %v", err)}
+			}
+		}
+		unsubscribe = d.SubscribeLogs(filter, func(l *Log) { notify(l) })
+	default:
+		return nil, &rpcError{Code: errCodeInvalidParams, Message: fmt.Sprintf("unsupported subscription %q", kind)}
+	}
+	conn.mu.Lock()
+	conn.subs[id] = unsubscribe
+	conn.mu.Unlock()
+	return id, nil
+}
+
+// blockNumberArg decodes a block number or the tags latest, pending,
+// safe, finalized and earliest
+func (d *Dev) blockNumberArg(raw json.RawMessage) (uint64, error) {
+	var tag string
+	if err := json.Unmarshal(raw, &tag); err != nil {
+		return 0, &rpcError{Code: errCodeInvalidParams, Message: "block number must be a string"}
+	}
+	switch tag {
+	case "latest", "pending", "safe", "finalized":
+		return d.BlockNumber(), nil
+	case "earliest":
+		return 0, nil
+	}
+	n, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, &rpcError{Code: errCodeInvalidParams, Message: fmt.Sprintf("invalid block number: %v", err)}
+	}
+	return n, nil
+}
+
+// decodeParams decodes positional parameters, requiring at least min
+func decodeParams[T any](params json.RawMessage, args *[]T, min int) error {
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, args); err != nil {
+			return &rpcError{Code: errCodeInvalidParams, Message: err.Error()}
+		}
+	}
+	if len(*args) < min {
+		return &rpcError{Code: errCodeInvalidParams, Message: fmt.Sprintf("missing value for required argument %d", len(*args))}
+	}
+	return nil
+}
+
+func subscriptionID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hexutil.Encode(id[:])
+}
@@ -0,0 +1,42 @@
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+func TestHandlerChecksTheOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    int
+	}{
+		{name: "no origin", origin: "", want: http.StatusOK},
+		{name: "localhost", origin: "http://localhost:3000", want: http.StatusOK},
+		{name: "loopback address", origin: "http://127.0.0.1:5173", want: http.StatusOK},
+		{name: "other site", origin: "https://example.com", want: http.StatusForbidden},
+		{name: "allowed site", origins: []string{"https://example.com"}, origin: "https://example.com", want: http.StatusOK},
+		{name: "localhost not allowed", origins: []string{"https://example.com"}, origin: "http://localhost:3000", want: http.StatusForbidden},
+		{name: "any site", origins: []string{"*"}, origin: "https://example.org", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := NewDev(nil, vm.Config{})
+			dev.SetOrigins(tt.origins)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`))
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			dev.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
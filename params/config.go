@@ -0,0 +1,230 @@
+// Package params holds chain configuration parameters.
+package params
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+// ChainConfig describes the chain the EVM executes on. Forks up to Paris
+// activate at a block number and later ones at a block timestamp; a nil
+// field leaves the fork unscheduled.
+type ChainConfig struct {
+	ChainID *big.Int `json:"chainId"`
+
+	HomesteadBlock        *big.Int `json:"homesteadBlock,omitempty"`
+	TangerineWhistleBlock *big.Int `json:"tangerineWhistleBlock,omitempty"` // EIP-150
+	SpuriousDragonBlock   *big.Int `json:"spuriousDragonBlock,omitempty"`   // EIP-155 and EIP-158
+	ByzantiumBlock        *big.Int `json:"byzantiumBlock,omitempty"`
+	ConstantinopleBlock   *big.Int `json:"constantinopleBlock,omitempty"`
+	PetersburgBlock       *big.Int `json:"petersburgBlock,omitempty"`
+	IstanbulBlock         *big.Int `json:"istanbulBlock,omitempty"`
+	BerlinBlock           *big.Int `json:"berlinBlock,omitempty"`
+	LondonBlock           *big.Int `json:"londonBlock,omitempty"`
+	ParisBlock            *big.Int `json:"parisBlock,omitempty"` // the merge
+
+	ShanghaiTime *uint64 `json:"shanghaiTime,omitempty"`
+	CancunTime   *uint64 `json:"cancunTime,omitempty"`
+	PragueTime   *uint64 `json:"pragueTime,omitempty"`
+
+	// BlobSchedule holds the EIP-4844 blob parameters of each fork from
+	// Cancun on, by lower-case fork name
+	BlobSchedule map[string]*BlobConfig `json:"blobSchedule,omitempty"`
+
+	// Optimism is set on OP Stack chains and enables their L2 rules, such
+	// as deposit transactions
+	Optimism *OptimismConfig `json:"optimism,omitempty"`
+
+	// AllowUnprotectedTxs accepts legacy transactions signed without a
+	// chain id once EIP-155 is active, as mainnet consensus does. Without
+	// it only replay-protected signatures are accepted from SpuriousDragon
+	// on.
+	AllowUnprotectedTxs bool `json:"allowUnprotectedTxs,omitempty"`
+
+	// Precompiles declares contracts the chain implements natively at
+	// addresses of its own, such as L2 system contracts. Calls to them
+	// are stubbed: they charge Gas and return Output.
+	Precompiles []*PrecompileConfig `json:"precompiles,omitempty"`
+}
+
+// PrecompileConfig declares a stubbed precompile
+type PrecompileConfig struct {
+	Address common.Address `json:"address"`
+	Name    string         `json:"name,omitempty"`
+	Gas     uint64         `json:"gas"`
+	Output  hexutil.Bytes  `json:"output,omitempty"` // returned for every input
+}
+
+// OptimismConfig holds the parameters of an OP Stack chain
+type OptimismConfig struct {
+	EIP1559Elasticity  uint64 `json:"eip1559Elasticity"`
+	EIP1559Denominator uint64 `json:"eip1559Denominator"`
+}
+
+// IsOptimism reports whether the chain follows the OP Stack rules
+func (c *ChainConfig) IsOptimism() bool { return c.Optimism != nil }
+
+// BlobConfig holds the blob parameters of a fork
+type BlobConfig struct {
+	Target                uint64 `json:"target"` // blobs per block
+	Max                   uint64 `json:"max"`    // blobs per block
+	BaseFeeUpdateFraction uint64 `json:"baseFeeUpdateFraction"`
+}
+
+// Blob parameters of the Cancun and Prague forks
+var (
+	DefaultCancunBlobConfig = &BlobConfig{Target: 3, Max: 6, BaseFeeUpdateFraction: 3338477}
+	DefaultPragueBlobConfig = &BlobConfig{Target: 6, Max: 9, BaseFeeUpdateFraction: 5007716}
+)
+
+// DefaultChainID is the chain id used by local development chains
+var DefaultChainID = big.NewInt(1337)
+
+// DevChainConfig returns the configuration used for local simulations, with
+// every fork up to Cancun active from genesis
+func DevChainConfig() *ChainConfig {
+	zero := uint64(0)
+	cancun := *DefaultCancunBlobConfig
+	return &ChainConfig{
+		ChainID:               new(big.Int).Set(DefaultChainID),
+		HomesteadBlock:        new(big.Int),
+		TangerineWhistleBlock: new(big.Int),
+		SpuriousDragonBlock:   new(big.Int),
+		ByzantiumBlock:        new(big.Int),
+		ConstantinopleBlock:   new(big.Int),
+		PetersburgBlock:       new(big.Int),
+		IstanbulBlock:         new(big.Int),
+		BerlinBlock:           new(big.Int),
+		LondonBlock:           new(big.Int),
+		ParisBlock:            new(big.Int),
+		ShanghaiTime:          &zero,
+		CancunTime:            &zero,
+		BlobSchedule:          map[string]*BlobConfig{"cancun": &cancun},
+	}
+}
+
+// BlobConfig returns the blob parameters of the given fork, nil if the
+// fork has none
+func (c *ChainConfig) BlobConfig(fork string) *BlobConfig {
+	return c.BlobSchedule[strings.ToLower(fork)]
+}
+
+// IsEIP155 reports whether transaction signatures bind the chain id
+// (EIP-155, SpuriousDragon) at the given block
+func (c *ChainConfig) IsEIP155(number *big.Int) bool {
+	return isBlockForked(c.SpuriousDragonBlock, number)
+}
+
+// IsEIP150 reports whether calls forward at most all but a 64th of the
+// remaining gas (EIP-150, Tangerine Whistle) at the given block
+func (c *ChainConfig) IsEIP150(number *big.Int) bool {
+	return isBlockForked(c.TangerineWhistleBlock, number)
+}
+
+// IsHomestead reports whether Homestead is active at the given block
+func (c *ChainConfig) IsHomestead(number *big.Int) bool {
+	return isBlockForked(c.HomesteadBlock, number)
+}
+
+// IsConstantinople reports whether Constantinople is active at the given
+// block
+func (c *ChainConfig) IsConstantinople(number *big.Int) bool {
+	return isBlockForked(c.ConstantinopleBlock, number)
+}
+
+// IsPetersburg reports whether Petersburg is active at the given block
+func (c *ChainConfig) IsPetersburg(number *big.Int) bool {
+	return isBlockForked(c.PetersburgBlock, number)
+}
+
+// IsIstanbul reports whether Istanbul is active at the given block
+func (c *ChainConfig) IsIstanbul(number *big.Int) bool {
+	return isBlockForked(c.IstanbulBlock, number)
+}
+
+// IsBerlin reports whether Berlin is active at the given block
+func (c *ChainConfig) IsBerlin(number *big.Int) bool {
+	return isBlockForked(c.BerlinBlock, number)
+}
+
+// IsLondon reports whether London is active at the given block
+func (c *ChainConfig) IsLondon(number *big.Int) bool {
+	return isBlockForked(c.LondonBlock, number)
+}
+
+// IsMerge reports whether the given block follows the merge (Paris)
+func (c *ChainConfig) IsMerge(number *big.Int) bool {
+	return isBlockForked(c.ParisBlock, number)
+}
+
+// isBlockForked reports whether a fork scheduled at block fork is active
+// at the given block
+func isBlockForked(fork, number *big.Int) bool {
+	return fork != nil && number != nil && fork.Cmp(number) <= 0
+}
+
+// RefundQuotient returns the divisor of a transaction's gas used that caps
+// its gas refund: 2 before London and 5 from London on (EIP-3529)
+func (c *ChainConfig) RefundQuotient(number *big.Int) uint64 {
+	if c.IsLondon(number) {
+		return 5
+	}
+	return 2
+}
+
+// IsShanghai reports whether Shanghai is active at the given block
+func (c *ChainConfig) IsShanghai(number *big.Int, time uint64) bool {
+	return c.isTimeForked(c.ShanghaiTime, number, time)
+}
+
+// IsCancun reports whether Cancun is active at the given block
+func (c *ChainConfig) IsCancun(number *big.Int, time uint64) bool {
+	return c.isTimeForked(c.CancunTime, number, time)
+}
+
+// IsPrague reports whether Prague is active at the given block
+func (c *ChainConfig) IsPrague(number *big.Int, time uint64) bool {
+	return c.isTimeForked(c.PragueTime, number, time)
+}
+
+// isTimeForked reports whether a fork scheduled at fork, a timestamp, is
+// active. Time-based forks follow the merge.
+func (c *ChainConfig) isTimeForked(fork *uint64, number *big.Int, time uint64) bool {
+	return fork != nil && *fork <= time && c.ParisBlock != nil && c.ParisBlock.Cmp(number) <= 0
+}
+
+// Fork returns the name of the latest fork active at the given block
+// number and timestamp, "Frontier" if none is
+func (c *ChainConfig) Fork(number *big.Int, time uint64) string {
+	for _, f := range []struct {
+		name string
+		time *uint64
+	}{{"Prague", c.PragueTime}, {"Cancun", c.CancunTime}, {"Shanghai", c.ShanghaiTime}} {
+		if c.isTimeForked(f.time, number, time) {
+			return f.name
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		block *big.Int
+	}{
+		{"Paris", c.ParisBlock},
+		{"London", c.LondonBlock},
+		{"Berlin", c.BerlinBlock},
+		{"Istanbul", c.IstanbulBlock},
+		{"Petersburg", c.PetersburgBlock},
+		{"Constantinople", c.ConstantinopleBlock},
+		{"Byzantium", c.ByzantiumBlock},
+		{"SpuriousDragon", c.SpuriousDragonBlock},
+		{"TangerineWhistle", c.TangerineWhistleBlock},
+		{"Homestead", c.HomesteadBlock},
+	} {
+		if f.block != nil && f.block.Cmp(number) <= 0 {
+			return f.name
+		}
+	}
+	return "Frontier"
+}
@@ -0,0 +1,167 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+// presets are the built-in network configurations by name
+var presets = map[string]func() *ChainConfig{
+	"dev": DevChainConfig,
+
+	"mainnet": func() *ChainConfig {
+		return &ChainConfig{
+			ChainID:               big.NewInt(1),
+			HomesteadBlock:        big.NewInt(1_150_000),
+			TangerineWhistleBlock: big.NewInt(2_463_000),
+			SpuriousDragonBlock:   big.NewInt(2_675_000),
+			ByzantiumBlock:        big.NewInt(4_370_000),
+			ConstantinopleBlock:   big.NewInt(7_280_000),
+			PetersburgBlock:       big.NewInt(7_280_000),
+			IstanbulBlock:         big.NewInt(9_069_000),
+			BerlinBlock:           big.NewInt(12_244_000),
+			LondonBlock:           big.NewInt(12_965_000),
+			ParisBlock:            big.NewInt(15_537_394),
+			ShanghaiTime:          newUint64(1681338455),
+			CancunTime:            newUint64(1710338135),
+			PragueTime:            newUint64(1746612311),
+			BlobSchedule:          l1BlobSchedule(),
+			AllowUnprotectedTxs:   true,
+		}
+	},
+
+	"sepolia": func() *ChainConfig {
+		c := genesisForks(big.NewInt(11155111))
+		c.ParisBlock = big.NewInt(1_735_371) // the merge netsplit block, as in geth
+		c.ShanghaiTime = newUint64(1677557088)
+		c.CancunTime = newUint64(1706655072)
+		c.PragueTime = newUint64(1741159776)
+		c.BlobSchedule = l1BlobSchedule()
+		return c
+	},
+
+	"holesky": func() *ChainConfig {
+		c := genesisForks(big.NewInt(17000))
+		c.ParisBlock = new(big.Int)
+		c.ShanghaiTime = newUint64(1696000704)
+		c.CancunTime = newUint64(1707305664)
+		c.PragueTime = newUint64(1740434112)
+		c.BlobSchedule = l1BlobSchedule()
+		return c
+	},
+
+	// OP Stack chains map Canyon, Ecotone and Isthmus to the L1 forks they
+	// include. Blobs are not used on L2.
+	"optimism": func() *ChainConfig {
+		c := genesisForks(big.NewInt(10))
+		c.BerlinBlock = big.NewInt(3_950_000)
+		c.LondonBlock = big.NewInt(105_235_063) // Bedrock
+		c.ParisBlock = big.NewInt(105_235_063)
+		c.ShanghaiTime = newUint64(1704992401)
+		c.CancunTime = newUint64(1710374401)
+		c.PragueTime = newUint64(1746806401)
+		c.Optimism = opMainnetConfig()
+		return c
+	},
+
+	// Arbitrum activates the Shanghai and Cancun rules with ArbOS upgrades
+	// rather than at fixed times, so they are left unscheduled
+	"arbitrum": func() *ChainConfig {
+		c := genesisForks(big.NewInt(42161))
+		c.Precompiles = ArbitrumPrecompiles()
+		return c
+	},
+
+	"base": func() *ChainConfig {
+		c := genesisForks(big.NewInt(8453))
+		c.ParisBlock = new(big.Int)
+		c.ShanghaiTime = newUint64(1704992401)
+		c.CancunTime = newUint64(1710374401)
+		c.PragueTime = newUint64(1746806401)
+		c.Optimism = opMainnetConfig()
+		return c
+	},
+}
+
+// ArbitrumPrecompiles returns stubs for the ArbOS precompiles, from ArbSys
+// at 0x64 to NodeInterface at 0xc8, each returning a zero word
+func ArbitrumPrecompiles() []*PrecompileConfig {
+	var precompiles []*PrecompileConfig
+	for _, p := range []struct {
+		addr byte
+		name string
+	}{
+		{0x64, "ArbSys"},
+		{0x65, "ArbInfo"},
+		{0x66, "ArbAddressTable"},
+		{0x6b, "ArbOwnerPublic"},
+		{0x6c, "ArbGasInfo"},
+		{0x6d, "ArbAggregator"},
+		{0x6e, "ArbRetryableTx"},
+		{0x6f, "ArbStatistics"},
+		{0x70, "ArbOwner"},
+		{0xc8, "NodeInterface"},
+	} {
+		precompiles = append(precompiles, &PrecompileConfig{
+			Address: common.Address{19: p.addr},
+			Name:    p.name,
+			Output:  make(hexutil.Bytes, 32),
+		})
+	}
+	return precompiles
+}
+
+// Preset returns a copy of the built-in configuration of a network: dev,
+// mainnet, sepolia, holesky, optimism, base or arbitrum
+func Preset(name string) (*ChainConfig, error) {
+	preset, ok := presets[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown chain %q, want one of %s", name, strings.Join(PresetNames(), ", "))
+	}
+	return preset(), nil
+}
+
+// PresetNames returns the names accepted by Preset, sorted
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// genesisForks returns a config with every fork up to London active from
+// genesis
+func genesisForks(chainID *big.Int) *ChainConfig {
+	return &ChainConfig{
+		ChainID:               chainID,
+		HomesteadBlock:        new(big.Int),
+		TangerineWhistleBlock: new(big.Int),
+		SpuriousDragonBlock:   new(big.Int),
+		ByzantiumBlock:        new(big.Int),
+		ConstantinopleBlock:   new(big.Int),
+		PetersburgBlock:       new(big.Int),
+		IstanbulBlock:         new(big.Int),
+		BerlinBlock:           new(big.Int),
+		LondonBlock:           new(big.Int),
+	}
+}
+
+func l1BlobSchedule() map[string]*BlobConfig {
+	cancun, prague := *DefaultCancunBlobConfig, *DefaultPragueBlobConfig
+	return map[string]*BlobConfig{"cancun": &cancun, "prague": &prague}
+}
+
+// opMainnetConfig returns the EIP-1559 parameters OP Mainnet and Base use
+// since Canyon
+func opMainnetConfig() *OptimismConfig {
+	return &OptimismConfig{EIP1559Elasticity: 6, EIP1559Denominator: 250}
+}
+
+func newUint64(v uint64) *uint64 { return &v }
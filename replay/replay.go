@@ -0,0 +1,184 @@
+// Package replay records the external inputs of an execution so that it can
+// be reproduced offline, independently of the state backend it first ran on.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// Recording holds everything an execution read from outside the interpreter:
+// the block context, the executed address and the state it observed before
+// modifying it
+type Recording struct {
+	Context  *vm.Context                          `json:"context"`
+	Address  common.Address                       `json:"address"`
+	Prestate map[common.Address]state.DumpAccount `json:"prestate"`
+}
+
+// recordedAccount tracks the pre-execution values read from one account.
+// Fields written before being read are never recorded, since replay
+// reproduces them itself.
+type recordedAccount struct {
+	exists bool
+
+	nonce, nonceSet bool
+	nonceValue      uint64
+	code, codeSet   bool
+	codeValue       []byte
+
+	storage map[common.Hash]common.Hash
+	written map[common.Hash]bool
+}
+
+// Recorder is a StateDB that forwards to another StateDB and records the
+// first value of every piece of state read through it
+type Recorder struct {
+	inner    vm.StateDB
+	accounts map[common.Address]*recordedAccount
+}
+
+// NewRecorder wraps a state so that reads through it are recorded
+func NewRecorder(inner vm.StateDB) *Recorder {
+	return &Recorder{inner: inner, accounts: make(map[common.Address]*recordedAccount)}
+}
+
+func (r *Recorder) account(addr common.Address) *recordedAccount {
+	acc, ok := r.accounts[addr]
+	if !ok {
+		acc = &recordedAccount{
+			exists:  r.inner.Exist(addr),
+			storage: make(map[common.Hash]common.Hash),
+			written: make(map[common.Hash]bool),
+		}
+		r.accounts[addr] = acc
+	}
+	return acc
+}
+
+// Exist implements vm.StateDB
+func (r *Recorder) Exist(addr common.Address) bool {
+	r.account(addr)
+	return r.inner.Exist(addr)
+}
+
+// GetNonce implements vm.StateDB
+func (r *Recorder) GetNonce(addr common.Address) uint64 {
+	acc := r.account(addr)
+	nonce := r.inner.GetNonce(addr)
+	if !acc.nonce && !acc.nonceSet {
+		acc.nonce, acc.nonceValue = true, nonce
+	}
+	return nonce
+}
+
+// SetNonce implements vm.StateDB
+func (r *Recorder) SetNonce(addr common.Address, nonce uint64) {
+	r.account(addr).nonceSet = true
+	r.inner.SetNonce(addr, nonce)
+}
+
+// GetCode implements vm.StateDB
+func (r *Recorder) GetCode(addr common.Address) []byte {
+	acc := r.account(addr)
+	code := r.inner.GetCode(addr)
+	if !acc.code && !acc.codeSet {
+		acc.code, acc.codeValue = true, code
+	}
+	return code
+}
+
+// GetCodeHash implements vm.StateDB. The hash is derived from the code on
+// replay, so recording the code is enough.
+func (r *Recorder) GetCodeHash(addr common.Address) common.Hash {
+	r.GetCode(addr)
+	return r.inner.GetCodeHash(addr)
+}
+
+// SetCode implements vm.StateDB
+func (r *Recorder) SetCode(addr common.Address, code []byte) {
+	r.account(addr).codeSet = true
+	r.inner.SetCode(addr, code)
+}
+
+// GetState implements vm.StateDB
+func (r *Recorder) GetState(addr common.Address, key common.Hash) common.Hash {
+	acc := r.account(addr)
+	value := r.inner.GetState(addr, key)
+	if _, ok := acc.storage[key]; !ok && !acc.written[key] {
+		acc.storage[key] = value
+	}
+	return value
+}
+
+// SetState implements vm.StateDB
+func (r *Recorder) SetState(addr common.Address, key, value common.Hash) {
+	r.account(addr).written[key] = true
+	r.inner.SetState(addr, key, value)
+}
+
+// Prestate returns the recorded pre-execution state of every account that
+// existed when it was first accessed
+func (r *Recorder) Prestate() map[common.Address]state.DumpAccount {
+	prestate := make(map[common.Address]state.DumpAccount)
+	for addr, acc := range r.accounts {
+		if !acc.exists {
+			continue
+		}
+		dump := state.DumpAccount{
+			Nonce:    hexutil.Uint64(acc.nonceValue),
+			CodeHash: crypto.Keccak256Hash(acc.codeValue),
+			Code:     acc.codeValue,
+			Storage:  make(map[common.Hash]common.Hash),
+		}
+		for key, value := range acc.storage {
+			if !value.IsZero() {
+				dump.Storage[key] = value
+			}
+		}
+		prestate[addr] = dump
+	}
+	return prestate
+}
+
+// Recording returns the recording of an execution of address in blockCtx
+// that read its state through r
+func (r *Recorder) Recording(blockCtx *vm.Context, address common.Address) *Recording {
+	return &Recording{Context: blockCtx, Address: address, Prestate: r.Prestate()}
+}
+
+// Replay re-executes a recording against a fresh state built from its
+// prestate
+func Replay(ctx context.Context, rec *Recording, config vm.Config) *vm.ExecutionResult {
+	statedb := state.NewFromDump(rec.Prestate)
+	return vm.NewEVM(rec.Context, statedb, config).Run(ctx, rec.Address)
+}
+
+// Load reads a recording from a JSON file
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Save writes a recording to a JSON file
+func Save(path string, rec *Recording) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,142 @@
+// Package rlp implements the Recursive Length Prefix encoding used for
+// Ethereum consensus data structures.
+//
+// Strings are encoded with EncodeBytes and friends; lists are built with
+// EncodeList from items that are already encoded. Split and SplitList read
+// encoded values back one layer at a time.
+package rlp
+
+import (
+	"errors"
+	"math/big"
+)
+
+// EmptyString and EmptyList are the encodings of "" and []
+var (
+	EmptyString = []byte{0x80}
+	EmptyList   = []byte{0xc0}
+)
+
+// EncodeBytes encodes b as a string
+func EncodeBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(header(0x80, len(b)), b...)
+}
+
+// EncodeUint encodes i as a big-endian integer without leading zeros
+func EncodeUint(i uint64) []byte {
+	return EncodeBig(new(big.Int).SetUint64(i))
+}
+
+// EncodeBig encodes a non-negative integer, nil encoding as zero
+func EncodeBig(i *big.Int) []byte {
+	if i == nil {
+		return EmptyString
+	}
+	return EncodeBytes(i.Bytes())
+}
+
+// EncodeList encodes a list of already encoded items
+func EncodeList(items ...[]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	out := header(0xc0, size)
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+// header returns the prefix of a string (offset 0x80) or list (offset
+// 0xc0) of the given payload size
+func header(offset byte, size int) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+	length := new(big.Int).SetUint64(uint64(size)).Bytes()
+	return append([]byte{offset + 55 + byte(len(length))}, length...)
+}
+
+// ErrValueTooLarge is returned for a value whose header claims more bytes
+// than the input holds
+var ErrValueTooLarge = errors.New("rlp: value size exceeds available input length")
+
+// Split reads the first value of b, returning whether it is a list, its
+// payload and the bytes that follow it
+func Split(b []byte) (isList bool, content, rest []byte, err error) {
+	if len(b) == 0 {
+		return false, nil, nil, errors.New("rlp: empty input")
+	}
+	prefix := b[0]
+	var offset, size int
+	switch {
+	case prefix < 0x80:
+		return false, b[:1], b[1:], nil
+	case prefix < 0xb8:
+		offset, size = 1, int(prefix-0x80)
+		if size == 1 && len(b) > 1 && b[1] < 0x80 {
+			return false, nil, nil, errors.New("rlp: non-canonical size for single byte")
+		}
+	case prefix < 0xc0:
+		offset, size, err = longSize(b, int(prefix-0xb7))
+	case prefix < 0xf8:
+		isList, offset, size = true, 1, int(prefix-0xc0)
+	default:
+		isList = true
+		offset, size, err = longSize(b, int(prefix-0xf7))
+	}
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if size > len(b)-offset {
+		return false, nil, nil, ErrValueTooLarge
+	}
+	return isList, b[offset : offset+size], b[offset+size:], nil
+}
+
+// SplitList returns the encoded items of the list b, which must hold
+// nothing but the list
+func SplitList(b []byte) ([][]byte, error) {
+	isList, content, rest, err := Split(b)
+	if err != nil {
+		return nil, err
+	}
+	if !isList {
+		return nil, errors.New("rlp: expected list")
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("rlp: input contains more than one value")
+	}
+	var items [][]byte
+	for len(content) > 0 {
+		_, _, after, err := Split(content)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, content[:len(content)-len(after)])
+		content = after
+	}
+	return items, nil
+}
+
+// longSize reads a size of n bytes following the prefix of b
+func longSize(b []byte, n int) (offset, size int, err error) {
+	if len(b) < 1+n {
+		return 0, 0, ErrValueTooLarge
+	}
+	if b[1] == 0 {
+		return 0, 0, errors.New("rlp: non-canonical size information")
+	}
+	s := new(big.Int).SetBytes(b[1 : 1+n])
+	if !s.IsInt64() || s.Int64() > int64(len(b)) {
+		return 0, 0, ErrValueTooLarge
+	}
+	if s.Int64() < 56 {
+		return 0, 0, errors.New("rlp: non-canonical size information")
+	}
+	return 1 + n, int(s.Int64()), nil
+}
@@ -0,0 +1,329 @@
+// Package signer implements EIP-712 typed structured data hashing and
+// signing.
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// DomainType is the name of the type describing the signing domain
+const DomainType = "EIP712Domain"
+
+// Type is a single member of a struct type
+type Type struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Types maps struct type names to their members
+type Types map[string][]Type
+
+// TypedData is an EIP-712 typed-data payload as accepted by
+// eth_signTypedData_v4
+type TypedData struct {
+	Types       Types                  `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      map[string]interface{} `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// ParseTypedData decodes a typed-data JSON document. Numbers are kept as
+// json.Number so that 256-bit values survive decoding.
+func ParseTypedData(input []byte) (*TypedData, error) {
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var td TypedData
+	if err := dec.Decode(&td); err != nil {
+		return nil, err
+	}
+	if _, ok := td.Types[DomainType]; !ok {
+		return nil, fmt.Errorf("missing %s type", DomainType)
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return nil, fmt.Errorf("unknown primary type %q", td.PrimaryType)
+	}
+	return &td, nil
+}
+
+// baseType strips any array suffixes from a type name
+func baseType(typ string) string {
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// dependencies collects the struct types referenced by typ, including itself
+func (td *TypedData) dependencies(typ string, found map[string]bool) {
+	typ = baseType(typ)
+	if found[typ] {
+		return
+	}
+	members, ok := td.Types[typ]
+	if !ok {
+		return
+	}
+	found[typ] = true
+	for _, member := range members {
+		td.dependencies(member.Type, found)
+	}
+}
+
+// EncodeType returns the type encoding of primaryType: the type itself
+// followed by its referenced struct types in alphabetical order
+func (td *TypedData) EncodeType(primaryType string) (string, error) {
+	if _, ok := td.Types[primaryType]; !ok {
+		return "", fmt.Errorf("unknown type %q", primaryType)
+	}
+	found := make(map[string]bool)
+	td.dependencies(primaryType, found)
+	delete(found, primaryType)
+	deps := make([]string, 0, len(found))
+	for dep := range found {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+
+	var buf strings.Builder
+	for _, typ := range append([]string{primaryType}, deps...) {
+		buf.WriteString(typ)
+		buf.WriteByte('(')
+		for i, member := range td.Types[typ] {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(member.Type + " " + member.Name)
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String(), nil
+}
+
+// TypeHash returns the Keccak-256 hash of the type encoding of primaryType
+func (td *TypedData) TypeHash(primaryType string) (common.Hash, error) {
+	enc, err := td.EncodeType(primaryType)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte(enc)), nil
+}
+
+// HashStruct returns hashStruct(s) = keccak256(typeHash || encodeData(s))
+func (td *TypedData) HashStruct(primaryType string, data map[string]interface{}) (common.Hash, error) {
+	typeHash, err := td.TypeHash(primaryType)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	enc := [][]byte{typeHash[:]}
+	for _, member := range td.Types[primaryType] {
+		value, ok := data[member.Name]
+		if !ok {
+			return common.Hash{}, fmt.Errorf("%s: missing field %q", primaryType, member.Name)
+		}
+		word, err := td.encodeValue(member.Type, value)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("%s.%s: %w", primaryType, member.Name, err)
+		}
+		enc = append(enc, word)
+	}
+	return crypto.Keccak256Hash(enc...), nil
+}
+
+// DomainSeparator returns the hash of the signing domain
+func (td *TypedData) DomainSeparator() (common.Hash, error) {
+	return td.HashStruct(DomainType, td.Domain)
+}
+
+// Hash returns the EIP-712 signing digest
+// keccak256("\x19\x01" || domainSeparator || hashStruct(message))
+func (td *TypedData) Hash() (common.Hash, error) {
+	domain, err := td.DomainSeparator()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	message, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain[:], message[:]), nil
+}
+
+// encodeValue encodes a single member value into its 32 byte form
+func (td *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if strings.HasSuffix(typ, "]") {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array for %s", typ)
+		}
+		elemType := typ[:strings.LastIndexByte(typ, '[')]
+		enc := make([][]byte, 0, len(items))
+		for _, item := range items {
+			word, err := td.encodeValue(elemType, item)
+			if err != nil {
+				return nil, err
+			}
+			enc = append(enc, word)
+		}
+		return crypto.Keccak256(enc...), nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object for %s", typ)
+		}
+		hash, err := td.HashStruct(typ, fields)
+		return hash[:], err
+	}
+
+	switch {
+	case typ == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("expected string")
+		}
+		return crypto.Keccak256([]byte(s)), nil
+	case typ == "bytes":
+		b, err := decodeBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+	case typ == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("expected bool")
+		}
+		word := make([]byte, 32)
+		if b {
+			word[31] = 1
+		}
+		return word, nil
+	case typ == "address":
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("expected address string")
+		}
+		addr, err := common.HexToAddress(s)
+		if err != nil {
+			return nil, err
+		}
+		return common.BytesToHash(addr[:]).Bytes(), nil
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil || size < 1 || size > 32 {
+			return nil, fmt.Errorf("invalid type %q", typ)
+		}
+		b, err := decodeBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > size {
+			return nil, fmt.Errorf("%d bytes do not fit %s", len(b), typ)
+		}
+		word := make([]byte, 32)
+		copy(word, b) // fixed-size bytes are right-padded
+		return word, nil
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		return encodeInteger(typ, value)
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+func decodeBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, errors.New("expected hex string")
+	}
+	if !common.Has0xPrefix(s) {
+		return nil, common.ErrMissingPrefix
+	}
+	return common.FromHex(s)
+}
+
+// encodeInteger encodes a uintN/intN value given as a JSON number or as a
+// decimal or 0x-prefixed hex string, sign-extending negative values
+func encodeInteger(typ string, value interface{}) ([]byte, error) {
+	signed := strings.HasPrefix(typ, "int")
+	bits := 256
+	if suffix := strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"); suffix != "" {
+		var err error
+		if bits, err = strconv.Atoi(suffix); err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("invalid type %q", typ)
+		}
+	}
+
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("expected number for %s", typ)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+
+	min, max := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	if signed {
+		max.Rsh(max, 1)
+		min.Neg(max)
+	}
+	if n.Cmp(min) < 0 || n.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("%s out of range for %s", n, typ)
+	}
+	if n.Sign() < 0 {
+		n.Add(n, new(big.Int).Lsh(big.NewInt(1), 256)) // two's complement
+	}
+	return common.BigToHash(n).Bytes(), nil
+}
+
+// SignTypedData signs the typed-data digest, returning an [R || S || V]
+// signature with V set to 27 or 28 as wallets produce it
+func SignTypedData(td *TypedData, key *crypto.PrivateKey) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverTypedData returns the address that signed the typed data
+func RecoverTypedData(td *TypedData, sig []byte) (common.Address, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.Ecrecover(hash[:], sig)
+}
+
+// VerifyTypedData checks that sig is a signature of the typed data by signer
+func VerifyTypedData(td *TypedData, sig []byte, signer common.Address) error {
+	recovered, err := RecoverTypedData(td, sig)
+	if err != nil {
+		return err
+	}
+	if recovered != signer {
+		return fmt.Errorf("signature by %s, want %s", recovered.Hex(), signer.Hex())
+	}
+	return nil
+}
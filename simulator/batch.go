@@ -0,0 +1,54 @@
+package simulator
+
+import (
+	"context"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// BatchOptions configures CallBatch
+type BatchOptions struct {
+	// Block is the block the calls execute in, the simulator's if nil
+	Block *BlockOverrides
+	// ShareAccessSet starts each call with the accounts and slots warmed
+	// by the calls before it, as if the batch were one multicall
+	// transaction. Gas used is then lower than that of separate calls.
+	ShareAccessSet bool
+}
+
+// CallBatch executes independent read-only calls, as eth_call does, each
+// against the state the simulator holds when CallBatch is called: the
+// state changes of a call are rolled back before the next one runs. The
+// calls share the simulator's state and its caches, which makes a batch
+// cheaper than as many separate simulations of the same state. No L1
+// data fee is charged.
+//
+// Results are returned in the order of msgs. A call the simulator rejects,
+// such as a value transfer the sender cannot afford, gets a result whose
+// Err is the reason; CallBatch itself only fails if ctx is done.
+func (s *Simulator) CallBatch(ctx context.Context, msgs []CallMsg, opts *BatchOptions) ([]*vm.ExecutionResult, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	saved := s.block
+	defer func() { s.block = saved }()
+	opts.Block.Apply(&s.block)
+	var access *vm.AccessSet
+	if opts.ShareAccessSet {
+		access = vm.NewAccessSet()
+	}
+	results := make([]*vm.ExecutionResult, 0, len(msgs))
+	for _, msg := range msgs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		snapshot := s.state.Snapshot()
+		result, err := s.callWithAccess(ctx, msg, access)
+		s.state.RevertToSnapshot(snapshot)
+		if err != nil {
+			result = &vm.ExecutionResult{Err: err}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,179 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// ErrFeeCapTooLow is returned for a bundle transaction whose maximum fee
+// per gas is below the base fee of the block
+var ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
+
+// BundleTx is a message of a bundle with the gas price it pays. Legacy
+// transactions set GasPrice; dynamic fee transactions set GasFeeCap and
+// GasTipCap instead.
+type BundleTx struct {
+	CallMsg
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+
+	RevertAllowed bool // the bundle stays valid if this transaction fails
+}
+
+// effectiveGasPrice returns the gas price tx pays at the given base fee
+func (tx *BundleTx) effectiveGasPrice(baseFee *big.Int) (*big.Int, error) {
+	return effectiveGasPrice(tx.GasPrice, tx.GasFeeCap, tx.GasTipCap, baseFee)
+}
+
+// effectiveGasPrice returns the gas price paid at the given base fee by a
+// legacy transaction, which sets gasPrice, or a dynamic fee transaction,
+// which sets feeCap and tipCap. A transaction setting neither pays the base
+// fee without a tip. The price is never below the base fee, so the tip is
+// never negative.
+func effectiveGasPrice(gasPrice, feeCap, tipCap, baseFee *big.Int) (*big.Int, error) {
+	if feeCap == nil {
+		if gasPrice == nil {
+			return new(big.Int).Set(baseFee), nil
+		}
+		if gasPrice.Cmp(baseFee) < 0 {
+			return nil, fmt.Errorf("%w: gas price %s, base fee %s", ErrFeeCapTooLow, gasPrice, baseFee)
+		}
+		return new(big.Int).Set(gasPrice), nil
+	}
+	if feeCap.Cmp(baseFee) < 0 {
+		return nil, fmt.Errorf("%w: max fee %s, base fee %s", ErrFeeCapTooLow, feeCap, baseFee)
+	}
+	price := new(big.Int).Add(baseFee, bigOrZero(tipCap))
+	if price.Cmp(feeCap) > 0 {
+		price.Set(feeCap)
+	}
+	return price, nil
+}
+
+// Bundle is an ordered list of transactions simulated on top of each
+// other, as eth_callBundle does
+type Bundle struct {
+	Txs   []BundleTx
+	Block *BlockOverrides // block the bundle executes in, the simulator's if nil
+}
+
+// BundleTxResult is the outcome of a bundle transaction
+type BundleTxResult struct {
+	*vm.ExecutionResult
+	EffectiveGasPrice *big.Int // gas price paid per unit of gas
+	GasFees           *big.Int // priority fee paid to the coinbase: gas used times the price above the base fee
+	EthSentToCoinbase *big.Int // value the transaction transferred to the coinbase directly
+	CoinbaseDiff      *big.Int // change of the coinbase balance, GasFees plus EthSentToCoinbase
+}
+
+// BundleResult is the outcome of a bundle
+type BundleResult struct {
+	Results           []*BundleTxResult
+	GasUsed           uint64
+	GasFees           *big.Int
+	EthSentToCoinbase *big.Int
+	CoinbaseDiff      *big.Int
+	BundleGasPrice    *big.Int // CoinbaseDiff per unit of gas used, what builders rank bundles by
+	Reverted          []int    // indices of failed transactions that were not allowed to revert
+}
+
+// Valid reports whether every failed transaction of the bundle was allowed
+// to revert
+func (r *BundleResult) Valid() bool { return len(r.Reverted) == 0 }
+
+// SimulateBundle executes the transactions of a bundle in order, each
+// seeing the state left by the ones before it, and reports what the
+// coinbase earns from them. Each sender is charged its gas used at its
+// effective gas price: the part above the base fee goes to the coinbase
+// of the block and the base fee is burned. A failed transaction is rolled
+// back on its own and, unless it is allowed to revert, listed in the
+// result's Reverted. SimulateBundle fails without changing the state if a
+// transaction's fee cap is below the base fee or its sender cannot afford
+// its value and gas limit.
+func (s *Simulator) SimulateBundle(ctx context.Context, bundle *Bundle) (*BundleResult, error) {
+	saved := s.block
+	defer func() { s.block = saved }()
+	bundle.Block.Apply(&s.block)
+	coinbase, baseFee := s.block.Coinbase, bigOrZero(s.block.BaseFee)
+	snapshot := s.state.Snapshot()
+
+	out := &BundleResult{
+		GasFees:           new(big.Int),
+		EthSentToCoinbase: new(big.Int),
+		CoinbaseDiff:      new(big.Int),
+		BundleGasPrice:    new(big.Int),
+	}
+	for i := range bundle.Txs {
+		tx := &bundle.Txs[i]
+		price, err := tx.effectiveGasPrice(baseFee)
+		if err == nil {
+			err = s.checkBundleFunds(tx, price)
+		}
+		if err != nil {
+			s.state.RevertToSnapshot(snapshot)
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+
+		before := s.state.GetBalance(coinbase)
+		s.block.GasPrice = price
+		result, err := s.Call(ctx, tx.CallMsg)
+		if err != nil {
+			s.state.RevertToSnapshot(snapshot)
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		sent := new(big.Int).Sub(s.state.GetBalance(coinbase), before)
+
+		gas := new(big.Int).SetUint64(result.GasUsed)
+		s.state.SubBalance(tx.From, new(big.Int).Mul(gas, price))
+		fees := new(big.Int).Mul(gas, new(big.Int).Sub(price, baseFee))
+		s.state.AddBalance(coinbase, fees)
+
+		txResult := &BundleTxResult{
+			ExecutionResult:   result,
+			EffectiveGasPrice: price,
+			GasFees:           fees,
+			EthSentToCoinbase: sent,
+			CoinbaseDiff:      new(big.Int).Add(fees, sent),
+		}
+		out.Results = append(out.Results, txResult)
+		out.GasUsed += result.GasUsed
+		out.GasFees.Add(out.GasFees, fees)
+		out.EthSentToCoinbase.Add(out.EthSentToCoinbase, sent)
+		out.CoinbaseDiff.Add(out.CoinbaseDiff, txResult.CoinbaseDiff)
+		if result.Failed() && !tx.RevertAllowed {
+			out.Reverted = append(out.Reverted, i)
+		}
+	}
+	if out.GasUsed > 0 {
+		out.BundleGasPrice.Div(out.CoinbaseDiff, new(big.Int).SetUint64(out.GasUsed))
+	}
+	return out, nil
+}
+
+// checkBundleFunds fails unless the sender of tx can afford its value and
+// gas limit at the given price
+func (s *Simulator) checkBundleFunds(tx *BundleTx, price *big.Int) error {
+	gas := tx.Gas
+	if gas == 0 {
+		gas = DefaultGasLimit
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(gas), price)
+	cost.Add(cost, bigOrZero(tx.Value))
+	if s.state.GetBalance(tx.From).Cmp(cost) < 0 {
+		return fmt.Errorf("%w: %s needs %s", ErrInsufficientBalance, tx.From.Hex(), cost)
+	}
+	return nil
+}
+
+// bigOrZero returns b, or zero if b is nil
+func bigOrZero(b *big.Int) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return b
+}
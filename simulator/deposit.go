@@ -0,0 +1,44 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// ErrDepositsDisabled is returned when a deposit transaction is applied on
+// a chain without an Optimism config
+var ErrDepositsDisabled = errors.New("deposit transactions require an OP Stack chain config")
+
+// ApplyDeposit applies an OP Stack deposit transaction. The deposit is not
+// signed and buys neither gas nor an L1 data fee: Mint is credited to the
+// sender, its nonce is incremented and the value is transferred before the
+// recipient's code runs with tx.Gas, DefaultGasLimit if zero. The mint and
+// the nonce are kept even if the transfer or the execution fails. System deposits report no gas used, since they do
+// not count against the block gas limit.
+func (s *Simulator) ApplyDeposit(ctx context.Context, tx *types.DepositTx) (*vm.ExecutionResult, error) {
+	if !s.chainConfig.IsOptimism() {
+		return nil, ErrDepositsDisabled
+	}
+	if tx.To == nil {
+		return nil, fmt.Errorf("%w: contract creation deposits", errors.ErrUnsupported)
+	}
+	if tx.Mint != nil && tx.Mint.Sign() > 0 {
+		s.state.AddBalance(tx.From, tx.Mint)
+	}
+	s.state.SetNonce(tx.From, s.state.GetNonce(tx.From)+1)
+
+	result, err := s.call(ctx, CallMsg{From: tx.From, To: *tx.To, Value: tx.Value, Gas: tx.Gas, Data: tx.Data})
+	if errors.Is(err, ErrInsufficientBalance) {
+		result = &vm.ExecutionResult{GasUsed: tx.Gas, Err: err}
+	} else if err != nil {
+		return nil, err
+	}
+	if tx.IsSystemTransaction {
+		result.GasUsed = 0
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package simulator
+
+import (
+	"github.com/nutcas3/evm-golang/abi"
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/filters"
+	"github.com/nutcas3/evm-golang/core/types"
+)
+
+// logSubscription is a callback registered with SubscribeLogs
+type logSubscription struct {
+	filter filters.Criteria
+	fn     func(*types.Log)
+}
+
+// SubscribeLogs calls fn with every log passing the address and topic
+// criteria of filter until the returned function is called. Logs are
+// delivered in order when the call emitting them completes, before Call
+// returns, and only if the call succeeded, so logs of reverted frames are
+// never seen. Calls made by SimulateBundle and ApplyDeposit deliver their
+// logs too, even if the bundle is later rejected. fn runs on the goroutine
+// of the call and must not call the simulator.
+func (s *Simulator) SubscribeLogs(filter filters.Criteria, fn func(*types.Log)) (unsubscribe func()) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if s.subs == nil {
+		s.subs = make(map[*logSubscription]struct{})
+	}
+	sub := &logSubscription{filter: filter, fn: fn}
+	s.subs[sub] = struct{}{}
+	return func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.subs, sub)
+	}
+}
+
+// WatchLogs sends every log passing filter on ch until the returned
+// function is called, with the delivery rules of SubscribeLogs. Calls
+// block until ch receives the log, so ch should be buffered or drained by
+// another goroutine.
+func (s *Simulator) WatchLogs(filter filters.Criteria, ch chan<- *types.Log) (unsubscribe func()) {
+	return s.SubscribeLogs(filter, func(l *types.Log) { ch <- l })
+}
+
+// SubscribeEvent calls fn with every log of event emitted by one of
+// addresses, or by any contract if there are none, and its arguments
+// decoded with event.Decode. Logs that do not decode as the event are
+// skipped. Anonymous events have no topic to select them by, so every log
+// of the addresses that decodes is delivered.
+func (s *Simulator) SubscribeEvent(event *abi.Event, addresses []common.Address, fn func(l *types.Log, args []interface{})) (unsubscribe func()) {
+	filter := filters.Criteria{Addresses: addresses}
+	if !event.Anonymous {
+		filter.Topics = [][]common.Hash{{event.ID}}
+	}
+	return s.SubscribeLogs(filter, func(l *types.Log) {
+		if args, err := event.Decode(l.Topics, l.Data); err == nil {
+			fn(l, args)
+		}
+	})
+}
+
+// deliverLogs passes the logs of a successful call to the subscribers
+func (s *Simulator) deliverLogs(logs []*types.Log) {
+	if len(logs) == 0 {
+		return
+	}
+	s.subMu.Lock()
+	subs := make([]*logSubscription, 0, len(s.subs))
+	for sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.subMu.Unlock()
+
+	for _, l := range logs {
+		for _, sub := range subs {
+			if sub.filter.Matches(l) {
+				sub.fn(l)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package simulator
+
+import (
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/params"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// OP Stack predeploys: the L1Block contract holding the L1 fee parameters
+// and the vault L1 data fees are paid to
+var (
+	L1BlockAddress    = common.Address{0x42, 19: 0x15}
+	L1FeeVaultAddress = common.Address{0x42, 19: 0x1a}
+)
+
+// Storage slots of the L1Block contract
+var (
+	l1BaseFeeSlot     = common.Hash{31: 1}
+	l1FeeScalarsSlot  = common.Hash{31: 3} // Ecotone base fee and blob base fee scalars
+	l1OverheadSlot    = common.Hash{31: 5}
+	l1ScalarSlot      = common.Hash{31: 6}
+	l1BlobBaseFeeSlot = common.Hash{31: 7}
+)
+
+var (
+	feeScalarDivisor = big.NewInt(1_000_000)
+	ecotoneDivisor   = big.NewInt(16_000_000)
+)
+
+// L1CostFunc returns the L1 data fee of a transaction with the encoding tx
+// at the given block time, reading the fee parameters from statedb
+type L1CostFunc func(statedb vm.StateDB, tx []byte, time uint64) *big.Int
+
+// OptimismL1Cost returns the L1 data fee function of an OP Stack chain,
+// nil if config has no Optimism section. The fee follows the Bedrock
+// formula before Ecotone, which OP Stack chains activate with Cancun, and
+// the Ecotone formula after it. Fjord's compression estimate is not
+// implemented.
+func OptimismL1Cost(config *params.ChainConfig) L1CostFunc {
+	if !config.IsOptimism() {
+		return nil
+	}
+	return func(statedb vm.StateDB, tx []byte, time uint64) *big.Int {
+		dataGas := new(big.Int).SetUint64(rollupDataGas(tx))
+		word := func(slot common.Hash) *big.Int {
+			v := statedb.GetState(L1BlockAddress, slot)
+			return new(big.Int).SetBytes(v[:])
+		}
+		l1BaseFee := word(l1BaseFeeSlot)
+		if config.CancunTime == nil || time < *config.CancunTime {
+			fee := dataGas.Add(dataGas, word(l1OverheadSlot))
+			fee.Mul(fee, l1BaseFee)
+			fee.Mul(fee, word(l1ScalarSlot))
+			return fee.Div(fee, feeScalarDivisor)
+		}
+		scalars := statedb.GetState(L1BlockAddress, l1FeeScalarsSlot)
+		baseFeeScalar := new(big.Int).SetBytes(scalars[16:20])
+		blobBaseFeeScalar := new(big.Int).SetBytes(scalars[20:24])
+
+		fee := new(big.Int).Mul(l1BaseFee, big.NewInt(16))
+		fee.Mul(fee, baseFeeScalar)
+		fee.Add(fee, new(big.Int).Mul(word(l1BlobBaseFeeSlot), blobBaseFeeScalar))
+		fee.Mul(fee, dataGas)
+		return fee.Div(fee, ecotoneDivisor)
+	}
+}
+
+// rollupDataGas is the L1 calldata gas of a transaction encoding
+func rollupDataGas(tx []byte) uint64 {
+	var gas uint64
+	for _, b := range tx {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	return gas
+}
+
+// SetL1CostFunc replaces the L1 data fee function of subsequent calls,
+// which defaults to OptimismL1Cost of the chain config; nil disables L1
+// fees
+func (s *Simulator) SetL1CostFunc(fn L1CostFunc) {
+	s.l1Cost = fn
+}
+
+// L1Fee returns the L1 data fee Call charges for msg, zero on chains
+// without one. Messages are not signed, so the fee is that of msg encoded
+// as a signed legacy transaction with a signature of non-zero bytes.
+func (s *Simulator) L1Fee(msg CallMsg) *big.Int {
+	if s.l1Cost == nil {
+		return new(big.Int)
+	}
+	return s.l1Cost(s.state, s.encodeMessage(msg), s.block.Timestamp.Uint64())
+}
+
+// encodeMessage approximates the signed encoding of msg
+func (s *Simulator) encodeMessage(msg CallMsg) []byte {
+	gas := msg.Gas
+	if gas == 0 {
+		gas = DefaultGasLimit
+	}
+	signature := make([]byte, 32)
+	for i := range signature {
+		signature[i] = 0xff
+	}
+	v := new(big.Int).Add(new(big.Int).Lsh(s.chainConfig.ChainID, 1), big.NewInt(35))
+	return rlp.EncodeList(
+		rlp.EncodeUint(s.state.GetNonce(msg.From)),
+		rlp.EncodeBig(s.block.GasPrice),
+		rlp.EncodeUint(gas),
+		rlp.EncodeBytes(msg.To[:]),
+		rlp.EncodeBig(msg.Value),
+		rlp.EncodeBytes(msg.Data),
+		rlp.EncodeBig(v),
+		rlp.EncodeBytes(signature),
+		rlp.EncodeBytes(signature),
+	)
+}
@@ -0,0 +1,78 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// BlockOverrides replaces fields of the block context for a call or
+// bundle, like the block overrides of eth_call. Nil fields keep the
+// simulator's block.
+type BlockOverrides struct {
+	Number       *hexutil.Big           `json:"number,omitempty"`
+	Time         *hexutil.Uint64        `json:"time,omitempty"`
+	FeeRecipient *common.Address        `json:"feeRecipient,omitempty"`
+	BaseFee      *hexutil.Big           `json:"baseFeePerGas,omitempty"`
+	PrevRandao   *common.Hash           `json:"prevRandao,omitempty"`
+	Difficulty   *hexutil.Big           `json:"difficulty,omitempty"` // returned by DIFFICULTY before the merge
+	BlockHash    map[uint64]common.Hash `json:"blockHash,omitempty"`  // BLOCKHASH results by block number
+}
+
+// Apply changes ctx as described by o. Block numbers missing from
+// BlockHash are still answered by the previous GetHash of ctx.
+func (o *BlockOverrides) Apply(ctx *vm.Context) {
+	if o == nil {
+		return
+	}
+	if o.Number != nil {
+		ctx.BlockNumber = new(big.Int).Set(o.Number.ToInt())
+	}
+	if o.Time != nil {
+		ctx.Timestamp = new(big.Int).SetUint64(uint64(*o.Time))
+	}
+	if o.FeeRecipient != nil {
+		ctx.Coinbase = *o.FeeRecipient
+	}
+	if o.BaseFee != nil {
+		ctx.BaseFee = new(big.Int).Set(o.BaseFee.ToInt())
+	}
+	if o.PrevRandao != nil {
+		random := *o.PrevRandao
+		ctx.Random = &random
+	}
+	if o.Difficulty != nil {
+		ctx.Difficulty = new(big.Int).Set(o.Difficulty.ToInt())
+	}
+	if len(o.BlockHash) > 0 {
+		hashes, parent := o.BlockHash, ctx.GetHash
+		ctx.GetHash = func(number uint64) common.Hash {
+			if hash, ok := hashes[number]; ok {
+				return hash
+			}
+			if parent != nil {
+				return parent(number)
+			}
+			return common.Hash{}
+		}
+	}
+}
+
+// OverrideBlock changes the block subsequent calls execute in as
+// described by o
+func (s *Simulator) OverrideBlock(o *BlockOverrides) {
+	o.Apply(&s.block)
+}
+
+// CallWithOverrides executes msg like Call in the simulator's block
+// changed by block, which may be nil. The simulator's block is unchanged
+// afterwards.
+func (s *Simulator) CallWithOverrides(ctx context.Context, msg CallMsg, block *BlockOverrides) (*vm.ExecutionResult, error) {
+	saved := s.block
+	defer func() { s.block = saved }()
+	block.Apply(&s.block)
+	return s.Call(ctx, msg)
+}
@@ -0,0 +1,197 @@
+// Package simtest provides assertions for Go tests run against a
+// Simulator: storage and balance checks, and golden-file snapshots of the
+// state changes a test makes, all failing with a readable diff.
+package simtest
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/tests"
+)
+
+// UpdateEnv is the environment variable that, when set to a non-empty
+// value, makes golden-file assertions write the files instead of comparing
+const UpdateEnv = "SIMTEST_UPDATE"
+
+// AssertSlot fails the test unless the storage slot of addr holds want
+func AssertSlot(t testing.TB, sim *simulator.Simulator, addr common.Address, slot, want common.Hash) {
+	t.Helper()
+	if got := sim.State().GetState(addr, slot); got != want {
+		t.Errorf("storage %s of %s: got %s, want %s", hexutil.EncodeBig(slot.Big()), addr.Hex(), hexutil.EncodeBig(got.Big()), hexutil.EncodeBig(want.Big()))
+	}
+}
+
+// AssertBalance fails the test unless addr holds want wei
+func AssertBalance(t testing.TB, sim *simulator.Simulator, addr common.Address, want *big.Int) {
+	t.Helper()
+	if got := sim.State().GetBalance(addr); got.Cmp(want) != 0 {
+		t.Errorf("balance of %s: got %s, want %s (off by %s)", addr.Hex(), got, want, new(big.Int).Sub(got, want))
+	}
+}
+
+// AssertBalanceDelta runs fn and fails the test unless the balance of addr
+// changed by want wei, negative for a decrease
+func AssertBalanceDelta(t testing.TB, sim *simulator.Simulator, addr common.Address, want *big.Int, fn func()) {
+	t.Helper()
+	before := new(big.Int).Set(sim.State().GetBalance(addr))
+	fn()
+	if got := new(big.Int).Sub(sim.State().GetBalance(addr), before); got.Cmp(want) != 0 {
+		t.Errorf("balance change of %s: got %s, want %s", addr.Hex(), got, want)
+	}
+}
+
+// StateDiff lists the changes between two states, one line per changed
+// nonce, balance, code or storage slot, sorted by address and field
+type StateDiff []string
+
+func (d StateDiff) String() string {
+	if len(d) == 0 {
+		return ""
+	}
+	return strings.Join(d, "\n") + "\n"
+}
+
+// Diff returns the changes from before to after. Missing accounts and
+// slots count as empty.
+func Diff(before, after map[common.Address]state.DumpAccount) StateDiff {
+	addrs := make([]common.Address, 0, len(after))
+	for addr := range after {
+		addrs = append(addrs, addr)
+	}
+	for addr := range before {
+		if _, ok := after[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Cmp(addrs[j]) < 0 })
+
+	var diff StateDiff
+	for _, addr := range addrs {
+		a, b := before[addr], after[addr]
+		change := func(field, from, to string) {
+			if from != to {
+				diff = append(diff, fmt.Sprintf("%s %s: %s -> %s", addr.Hex(), field, from, to))
+			}
+		}
+		change("nonce", fmt.Sprint(uint64(a.Nonce)), fmt.Sprint(uint64(b.Nonce)))
+		change("balance", balance(a).String(), balance(b).String())
+		if !bytes.Equal(a.Code, b.Code) {
+			change("code", hexutil.Encode(a.Code), hexutil.Encode(b.Code))
+		}
+		keys := make([]common.Hash, 0, len(b.Storage))
+		for key := range b.Storage {
+			keys = append(keys, key)
+		}
+		for key := range a.Storage {
+			if _, ok := b.Storage[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+		for _, key := range keys {
+			change("storage "+hexutil.EncodeBig(key.Big()), hexutil.EncodeBig(a.Storage[key].Big()), hexutil.EncodeBig(b.Storage[key].Big()))
+		}
+	}
+	return diff
+}
+
+func balance(acc state.DumpAccount) *big.Int {
+	if acc.Balance == nil {
+		return new(big.Int)
+	}
+	return acc.Balance.ToInt()
+}
+
+// RecordDiff runs fn and returns the state changes it made
+func RecordDiff(sim *simulator.Simulator, fn func()) StateDiff {
+	before := sim.State().Dump()
+	fn()
+	return Diff(before, sim.State().Dump())
+}
+
+// AssertStateDiff runs fn and compares the state changes it made with the
+// golden file testdata/NAME.golden. When the environment variable named by
+// UpdateEnv is set, the file is written instead.
+func AssertStateDiff(t testing.TB, sim *simulator.Simulator, name string, fn func()) {
+	t.Helper()
+	AssertGolden(t, name, []byte(RecordDiff(sim, fn).String()))
+}
+
+// AssertGolden compares got with the golden file testdata/NAME.golden,
+// failing the test with the lines that differ. When the environment
+// variable named by UpdateEnv is set, the file is written instead.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (set %s=1 to create it)", err, UpdateEnv)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (set %s=1 to update it):\n%s", name, UpdateEnv, lineDiff(string(want), string(got)))
+	}
+}
+
+// lineDiff lists the lines only in want with a leading "-" and those only
+// in got with a leading "+", in order
+func lineDiff(want, got string) string {
+	count := func(s string) map[string]int {
+		m := make(map[string]int)
+		for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+			m[line]++
+		}
+		return m
+	}
+	inWant, inGot := count(want), count(got)
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(want, "\n"), "\n") {
+		if inGot[line] > 0 {
+			inGot[line]--
+		} else {
+			fmt.Fprintf(&b, "-%s\n", line)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		if inWant[line] > 0 {
+			inWant[line]--
+		} else {
+			fmt.Fprintf(&b, "+%s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// AssertGas records the gas used by a scenario in snap and fails the test
+// if it changed beyond the snapshot's tolerance. When the environment
+// variable named by UpdateEnv is set, changes are accepted; save the
+// snapshot after the tests run, e.g. in TestMain, to check them in.
+func AssertGas(t testing.TB, snap *tests.GasSnapshot, name string, gas uint64) {
+	t.Helper()
+	if os.Getenv(UpdateEnv) != "" {
+		snap.Record(name, gas)
+		return
+	}
+	if c := snap.Check(name, gas); c != nil {
+		t.Errorf("gas of %s changed beyond %.2f%%: %d -> %d (set %s=1 to update the snapshot)", name, 100*snap.Tolerance, c.Old, c.New, UpdateEnv)
+	}
+}
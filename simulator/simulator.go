@@ -0,0 +1,194 @@
+// Package simulator offers a high-level facade over the EVM for tests and
+// scripts: accounts, deployments, calls and state snapshots without wiring
+// the state and interpreter by hand.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// DefaultGasLimit is the gas made available to calls that do not set one
+const DefaultGasLimit = 30_000_000
+
+// ErrInsufficientBalance is returned when a sender cannot cover a transfer
+var ErrInsufficientBalance = errors.New("insufficient balance for transfer")
+
+// CallMsg describes a message call executed by the simulator
+type CallMsg struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int // amount transferred before the call, may be nil
+	Gas   uint64   // gas limit, DefaultGasLimit if zero
+	Data  []byte   // call data
+}
+
+// Simulator bundles a chain configuration, an in-memory world state and the
+// current block context
+type Simulator struct {
+	chainConfig *params.ChainConfig
+	vmConfig    vm.Config
+	state       *state.StateDB
+	block       vm.Context
+	l1Cost      L1CostFunc
+
+	subMu sync.Mutex
+	subs  map[*logSubscription]struct{}
+}
+
+// New creates a simulator with an empty state at block 1. A nil chain config
+// selects params.DevChainConfig. Precompiles declared by the chain config
+// are stubbed unless vmConfig implements them.
+func New(chainConfig *params.ChainConfig, vmConfig vm.Config) *Simulator {
+	if chainConfig == nil {
+		chainConfig = params.DevChainConfig()
+	}
+	vmConfig.Precompiles = vm.ChainPrecompiles(chainConfig, vmConfig.Precompiles)
+	if vmConfig.ChainConfig == nil {
+		vmConfig.ChainConfig = chainConfig
+	}
+	return &Simulator{
+		chainConfig: chainConfig,
+		l1Cost:      OptimismL1Cost(chainConfig),
+		vmConfig:    vmConfig,
+		state:       state.New(),
+		block: vm.Context{
+			BlockNumber: big.NewInt(1),
+			Timestamp:   big.NewInt(0),
+			GasLimit:    DefaultGasLimit,
+			GasPrice:    new(big.Int),
+		},
+	}
+}
+
+// ChainConfig returns the chain configuration of the simulator
+func (s *Simulator) ChainConfig() *params.ChainConfig { return s.chainConfig }
+
+// State gives access to the underlying world state
+func (s *Simulator) State() *state.StateDB { return s.state }
+
+// CreateAccount creates an empty account holding balance
+func (s *Simulator) CreateAccount(addr common.Address, balance *big.Int) {
+	s.state.CreateAccount(addr)
+	if balance != nil {
+		s.state.AddBalance(addr, balance)
+	}
+}
+
+// Deploy installs code as the runtime code of a new contract created by from,
+// at the address derived from the sender and its nonce. The code is not run
+// as an initializer.
+func (s *Simulator) Deploy(from common.Address, code []byte) (common.Address, error) {
+	nonce := s.state.GetNonce(from)
+	addr := crypto.CreateAddress(from, nonce)
+	if s.state.Exist(addr) {
+		return common.Address{}, fmt.Errorf("contract address collision at %s", addr.Hex())
+	}
+	s.state.SetNonce(from, nonce+1)
+	s.state.SetCode(addr, code)
+	return addr, nil
+}
+
+// Transfer moves amount wei from one account to another
+func (s *Simulator) Transfer(from, to common.Address, amount *big.Int) error {
+	if s.state.GetBalance(from).Cmp(amount) < 0 {
+		return ErrInsufficientBalance
+	}
+	s.state.SubBalance(from, amount)
+	s.state.AddBalance(to, amount)
+	return nil
+}
+
+// Call transfers the message value and executes the code at msg.To. State
+// changes are rolled back if the execution fails. On chains with an L1 data
+// fee, the fee returned by L1Fee is first paid from msg.From to
+// L1FeeVaultAddress and is kept even if the execution fails.
+func (s *Simulator) Call(ctx context.Context, msg CallMsg) (*vm.ExecutionResult, error) {
+	fee := s.L1Fee(msg)
+	if fee.Sign() == 0 {
+		return s.call(ctx, msg)
+	}
+	snapshot := s.state.Snapshot()
+	if err := s.Transfer(msg.From, L1FeeVaultAddress, fee); err != nil {
+		return nil, err
+	}
+	result, err := s.call(ctx, msg)
+	if err != nil {
+		s.state.RevertToSnapshot(snapshot)
+	}
+	return result, err
+}
+
+// call executes msg without charging an L1 data fee
+func (s *Simulator) call(ctx context.Context, msg CallMsg) (*vm.ExecutionResult, error) {
+	result, err := s.callWithAccess(ctx, msg, nil)
+	if err == nil && !result.Failed() {
+		s.deliverLogs(result.Logs)
+	}
+	return result, err
+}
+
+// callWithAccess executes msg starting with the warm accounts and slots
+// of access, or with none if it is nil. Logs are not delivered to
+// subscribers.
+func (s *Simulator) callWithAccess(ctx context.Context, msg CallMsg, access *vm.AccessSet) (*vm.ExecutionResult, error) {
+	snapshot := s.state.Snapshot()
+	if msg.Value != nil && msg.Value.Sign() > 0 {
+		if err := s.Transfer(msg.From, msg.To, msg.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	blockCtx := s.block
+	blockCtx.Sender = msg.From
+	blockCtx.Gas = msg.Gas
+	if blockCtx.Gas == 0 {
+		blockCtx.Gas = DefaultGasLimit
+	}
+	evm := vm.NewEVM(&blockCtx, s.state, s.vmConfig)
+	evm.SetInput(msg.Data)
+	evm.SetValue(msg.Value)
+	if access != nil {
+		evm.SetAccessSet(access)
+	}
+	result := evm.Run(ctx, msg.To)
+	if result.Failed() {
+		s.state.RevertToSnapshot(snapshot)
+	}
+	return result, nil
+}
+
+// Hooks returns the execution hooks of calls, nil if there are none
+func (s *Simulator) Hooks() *vm.Hooks { return s.vmConfig.Hooks }
+
+// SetHooks replaces the execution hooks of subsequent calls; nil removes
+// them
+func (s *Simulator) SetHooks(hooks *vm.Hooks) {
+	s.vmConfig.Hooks = hooks
+}
+
+// SetBlock sets the number and timestamp of the block subsequent calls
+// execute in
+func (s *Simulator) SetBlock(number, timestamp uint64) {
+	s.block.BlockNumber = new(big.Int).SetUint64(number)
+	s.block.Timestamp = new(big.Int).SetUint64(timestamp)
+}
+
+// Snapshot records the current state, returning an id for Revert
+func (s *Simulator) Snapshot() int {
+	return s.state.Snapshot()
+}
+
+// Revert restores the state recorded by Snapshot
+func (s *Simulator) Revert(id int) {
+	s.state.RevertToSnapshot(id)
+}
@@ -0,0 +1,28 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+func TestCallReadsTheBlockGasLimit(t *testing.T) {
+	sim := New(nil, vm.Config{})
+	// GASLIMIT PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	sim.State().SetCode(testContract, []byte{0x45, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3})
+	result, err := sim.Call(context.Background(), CallMsg{From: testSender, To: testContract, Gas: 50000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed() {
+		t.Fatal(result.Err)
+	}
+	if got := new(big.Int).SetBytes(result.ReturnData); got.Uint64() != DefaultGasLimit {
+		t.Errorf("GASLIMIT pushed %s, want the block gas limit %d", got, uint64(DefaultGasLimit))
+	}
+	if result.GasUsed > 50000 {
+		t.Errorf("used %d gas of the 50000 given", result.GasUsed)
+	}
+}
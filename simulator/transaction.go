@@ -0,0 +1,203 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// Nonce errors of ApplyTransaction. A transaction failing with one of them
+// is invalid and changes nothing, unlike one whose execution fails.
+var (
+	ErrNonceTooLow  = errors.New("nonce too low")
+	ErrNonceTooHigh = errors.New("nonce too high")
+	ErrNonceMax     = errors.New("nonce has max value")
+)
+
+// ErrSenderNoEOA is returned by ApplyTransaction for a sender with deployed
+// code, which cannot sign transactions (EIP-3607)
+var ErrSenderNoEOA = errors.New("sender not an eoa")
+
+// ErrContractAddressCollision is the execution error of a creation
+// transaction whose contract address already holds code or a nonce
+var ErrContractAddressCollision = errors.New("contract address collision")
+
+// ErrIntrinsicGas is returned by ApplyTransaction for a transaction whose
+// gas limit does not cover its intrinsic gas
+var ErrIntrinsicGas = errors.New("intrinsic gas too low")
+
+// Intrinsic gas of a transaction, charged before any code runs
+const (
+	txGas                    = 21000
+	txCreationGas            = 32000 // added for contract creation from Homestead
+	txDataZeroGas            = 4
+	txDataNonZeroGas         = 16 // from Istanbul (EIP-2028)
+	txDataNonZeroGasFrontier = 68
+	initCodeWordGas          = 2 // per word of creation data from Shanghai (EIP-3860)
+)
+
+// Transaction is a message applied as a transaction of the sender: its
+// nonce must be the sender's next one, and the sender must not be a
+// contract. Legacy transactions set GasPrice; dynamic fee transactions set
+// GasFeeCap and GasTipCap instead. A transaction setting neither pays the
+// base fee without a tip.
+type Transaction struct {
+	CallMsg
+	Nonce     uint64
+	Create    bool // install Data as the code of a new contract instead of calling To
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+// ContractAddress returns the address of the contract a creation
+// transaction deploys, which is derived from the sender and the nonce
+func (tx *Transaction) ContractAddress() common.Address {
+	return crypto.CreateAddress(tx.From, tx.Nonce)
+}
+
+// TransactionResult is the outcome of a transaction. GasUsed is the gas
+// paid for, after the refund.
+type TransactionResult struct {
+	*vm.ExecutionResult
+	EffectiveGasPrice *big.Int // gas price paid per unit of gas
+	GasRefunded       uint64   // refund earned by the execution, capped and deducted from GasUsed
+	Tip               *big.Int // priority fee credited to the coinbase
+	Burned            *big.Int // base fee burned: GasUsed times the block's base fee
+}
+
+// ApplyTransaction checks the nonce of tx against the sender's account,
+// increments it and executes the message like Call. The nonce is
+// incremented exactly once, and kept even if the execution fails; a
+// transaction whose nonce is not the sender's, whose fee cap is below the
+// base fee, whose gas limit is below its intrinsic gas or whose gas and
+// value the sender cannot afford fails without changing the state. A
+// creation transaction installs Data, which is not run as an initializer,
+// at ContractAddress, that is at the address of the nonce before the
+// increment.
+//
+// The sender pays its gas limit at the effective gas price up front and
+// gets back the gas left over and the refund earned, capped at a fifth of
+// the gas used from London on and a half before. The gas used includes the
+// intrinsic gas, which the execution does not get. The coinbase is credited
+// the price above the base fee for every gas used, and the base fee is
+// burned, so the total balance of all accounts drops by Burned.
+func (s *Simulator) ApplyTransaction(ctx context.Context, tx *Transaction) (*TransactionResult, error) {
+	switch nonce := s.state.GetNonce(tx.From); {
+	case tx.Nonce < nonce:
+		return nil, fmt.Errorf("%w: address %s, tx nonce %d, state nonce %d", ErrNonceTooLow, tx.From.Hex(), tx.Nonce, nonce)
+	case tx.Nonce > nonce:
+		return nil, fmt.Errorf("%w: address %s, tx nonce %d, state nonce %d", ErrNonceTooHigh, tx.From.Hex(), tx.Nonce, nonce)
+	case nonce == math.MaxUint64:
+		return nil, fmt.Errorf("%w: address %s", ErrNonceMax, tx.From.Hex())
+	}
+	// accounts delegating to a contract (EIP-7702) remain EOAs
+	if code := s.state.GetCode(tx.From); len(code) > 0 {
+		if _, ok := types.ParseDelegation(code); !ok {
+			return nil, fmt.Errorf("%w: address %s, code size %d", ErrSenderNoEOA, tx.From.Hex(), len(code))
+		}
+	}
+	baseFee := bigOrZero(s.block.BaseFee)
+	price, err := effectiveGasPrice(tx.GasPrice, tx.GasFeeCap, tx.GasTipCap, baseFee)
+	if err != nil {
+		return nil, err
+	}
+	gas := tx.Gas
+	if gas == 0 {
+		gas = DefaultGasLimit
+	}
+	intrinsic := s.intrinsicGas(tx)
+	if gas < intrinsic {
+		return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, gas, intrinsic)
+	}
+	prepaid := new(big.Int).Mul(new(big.Int).SetUint64(gas), price)
+	if cost := new(big.Int).Add(prepaid, bigOrZero(tx.Value)); s.state.GetBalance(tx.From).Cmp(cost) < 0 {
+		return nil, fmt.Errorf("%w: %s needs %s", ErrInsufficientBalance, tx.From.Hex(), cost)
+	}
+
+	snapshot := s.state.Snapshot()
+	s.state.SubBalance(tx.From, prepaid)
+	s.state.SetNonce(tx.From, tx.Nonce+1)
+	var result *vm.ExecutionResult
+	switch msg := tx.CallMsg; {
+	case tx.Create:
+		result, err = s.create(tx, gas-intrinsic)
+	case gas == intrinsic && len(s.state.GetCode(tx.To)) > 0:
+		// Call reads a gas limit of zero as DefaultGasLimit
+		result = &vm.ExecutionResult{Err: vm.ErrOutOfGas}
+	default:
+		saved := s.block.GasPrice
+		s.block.GasPrice = price
+		msg.Gas = gas - intrinsic
+		result, err = s.Call(ctx, msg)
+		s.block.GasPrice = saved
+	}
+	if err != nil {
+		s.state.RevertToSnapshot(snapshot)
+		return nil, err
+	}
+	result.GasUsed += intrinsic
+
+	out := &TransactionResult{ExecutionResult: result, EffectiveGasPrice: price}
+	out.GasRefunded = min(result.Refund, result.GasUsed/s.chainConfig.RefundQuotient(s.block.BlockNumber))
+	result.GasUsed -= out.GasRefunded
+	used := new(big.Int).SetUint64(result.GasUsed)
+	s.state.AddBalance(tx.From, new(big.Int).Mul(new(big.Int).SetUint64(gas-result.GasUsed), price))
+	out.Tip = new(big.Int).Mul(used, new(big.Int).Sub(price, baseFee))
+	s.state.AddBalance(s.block.Coinbase, out.Tip)
+	out.Burned = new(big.Int).Mul(used, baseFee)
+	return out, nil
+}
+
+// intrinsicGas returns the gas tx pays before any code runs: the base
+// cost of a transaction, its data and, for a creation, the creation cost
+// and, from Shanghai, the cost of the initcode words
+func (s *Simulator) intrinsicGas(tx *Transaction) uint64 {
+	number := s.block.BlockNumber
+	gas := uint64(txGas)
+	nonZeroGas := uint64(txDataNonZeroGasFrontier)
+	if s.chainConfig.IsIstanbul(number) {
+		nonZeroGas = txDataNonZeroGas
+	}
+	for _, b := range tx.Data {
+		if b == 0 {
+			gas += txDataZeroGas
+		} else {
+			gas += nonZeroGas
+		}
+	}
+	if tx.Create {
+		if s.chainConfig.IsHomestead(number) {
+			gas += txCreationGas
+		}
+		if s.chainConfig.IsShanghai(number, s.block.Timestamp.Uint64()) {
+			gas += initCodeWordGas * ((uint64(len(tx.Data)) + 31) / 32)
+		}
+	}
+	return gas
+}
+
+// create deploys the code of a creation transaction whose sender nonce is
+// already incremented, with gas left after the intrinsic gas. The new
+// contract starts with nonce 1 (EIP-161).
+func (s *Simulator) create(tx *Transaction, gas uint64) (*vm.ExecutionResult, error) {
+	addr := tx.ContractAddress()
+	if s.state.GetNonce(addr) != 0 || len(s.state.GetCode(addr)) > 0 {
+		return &vm.ExecutionResult{GasUsed: gas, Err: fmt.Errorf("%w at %s", ErrContractAddressCollision, addr.Hex())}, nil
+	}
+	if tx.Value != nil && tx.Value.Sign() > 0 {
+		if err := s.Transfer(tx.From, addr, tx.Value); err != nil {
+			return nil, err
+		}
+	}
+	s.state.SetNonce(addr, 1)
+	s.state.SetCode(addr, tx.Data)
+	return &vm.ExecutionResult{}, nil
+}
@@ -0,0 +1,156 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+var (
+	testSender   = common.Address{19: 0x01}
+	testCoinbase = common.Address{19: 0xcb}
+	testContract = common.Address{19: 0xc0}
+	testBaseFee  = big.NewInt(10)
+)
+
+// newFeeSimulator returns a simulator at a base fee of 10 wei with a
+// funded sender and a contract storing 1 in slot 0
+func newFeeSimulator() *Simulator {
+	sim := New(nil, vm.Config{})
+	sim.OverrideBlock(&BlockOverrides{FeeRecipient: &testCoinbase, BaseFee: (*hexutil.Big)(testBaseFee)})
+	sim.CreateAccount(testSender, big.NewInt(1e18))
+	sim.State().SetCode(testContract, []byte{0x60, 0x01, 0x60, 0x00, 0x55}) // PUSH1 1 PUSH1 0 SSTORE
+	return sim
+}
+
+func TestApplyTransactionConservesEther(t *testing.T) {
+	tests := []struct {
+		name    string
+		tx      Transaction
+		price   int64
+		gasUsed uint64 // checked if set
+	}{
+		{
+			name:    "transfer without a price",
+			tx:      Transaction{CallMsg: CallMsg{From: testSender, To: common.Address{19: 0xee}, Value: big.NewInt(1000), Gas: 21000}},
+			price:   10,
+			gasUsed: 21000,
+		},
+		{
+			name:    "transfer with data",
+			tx:      Transaction{CallMsg: CallMsg{From: testSender, To: common.Address{19: 0xee}, Data: []byte{0, 1}, Gas: 50000}, GasPrice: big.NewInt(12)},
+			price:   12,
+			gasUsed: 21000 + 4 + 16,
+		},
+		{
+			name:  "call with a tip",
+			tx:    Transaction{CallMsg: CallMsg{From: testSender, To: testContract, Gas: 100000}, GasFeeCap: big.NewInt(20), GasTipCap: big.NewInt(3)},
+			price: 13,
+		},
+		{
+			name:  "tip capped by the fee cap",
+			tx:    Transaction{CallMsg: CallMsg{From: testSender, To: testContract, Gas: 100000}, GasFeeCap: big.NewInt(11), GasTipCap: big.NewInt(3)},
+			price: 11,
+		},
+		{
+			name:  "call out of gas",
+			tx:    Transaction{CallMsg: CallMsg{From: testSender, To: testContract, Value: big.NewInt(5), Gas: 21000}, GasPrice: big.NewInt(10)},
+			price: 10,
+		},
+		{
+			name:    "creation",
+			tx:      Transaction{CallMsg: CallMsg{From: testSender, Data: []byte{0x00}, Gas: 60000}, Create: true, GasPrice: big.NewInt(15)},
+			price:   15,
+			gasUsed: 21000 + 4 + 32000 + 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newFeeSimulator()
+			statedb := sim.State()
+			senderBefore := statedb.GetBalance(testSender)
+			coinbaseBefore := statedb.GetBalance(testCoinbase)
+			totalBefore := statedb.TotalBalance()
+
+			result, err := sim.ApplyTransaction(context.Background(), &tt.tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.EffectiveGasPrice.Int64() != tt.price {
+				t.Errorf("effective gas price %s, want %d", result.EffectiveGasPrice, tt.price)
+			}
+			if tt.gasUsed != 0 && result.GasUsed != tt.gasUsed {
+				t.Errorf("gas used %d, want %d", result.GasUsed, tt.gasUsed)
+			}
+			used := new(big.Int).SetUint64(result.GasUsed)
+			if result.Tip.Sign() < 0 {
+				t.Errorf("negative tip %s", result.Tip)
+			}
+
+			value := new(big.Int)
+			if tt.tx.Value != nil && !result.Failed() {
+				value.Set(tt.tx.Value)
+			}
+			debit := new(big.Int).Sub(senderBefore, statedb.GetBalance(testSender))
+			if want := new(big.Int).Add(value, new(big.Int).Mul(used, result.EffectiveGasPrice)); debit.Cmp(want) != 0 {
+				t.Errorf("sender debited %s, want %s", debit, want)
+			}
+			if want := new(big.Int).Mul(used, testBaseFee); result.Burned.Cmp(want) != 0 {
+				t.Errorf("burned %s, want %s", result.Burned, want)
+			}
+			credit := new(big.Int).Sub(statedb.GetBalance(testCoinbase), coinbaseBefore)
+			if want := new(big.Int).Mul(used, new(big.Int).Sub(result.EffectiveGasPrice, testBaseFee)); credit.Cmp(want) != 0 || result.Tip.Cmp(want) != 0 {
+				t.Errorf("coinbase credited %s, tip %s, want %s", credit, result.Tip, want)
+			}
+			if drop := new(big.Int).Sub(totalBefore, statedb.TotalBalance()); drop.Cmp(result.Burned) != 0 {
+				t.Errorf("total balance dropped by %s, want the burned %s", drop, result.Burned)
+			}
+		})
+	}
+}
+
+func TestApplyTransactionRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   Transaction
+		want error
+	}{
+		{
+			name: "gas price below the base fee",
+			tx:   Transaction{CallMsg: CallMsg{From: testSender, To: testContract, Gas: 100000}, GasPrice: big.NewInt(9)},
+			want: ErrFeeCapTooLow,
+		},
+		{
+			name: "fee cap below the base fee",
+			tx:   Transaction{CallMsg: CallMsg{From: testSender, To: testContract, Gas: 100000}, GasFeeCap: big.NewInt(9), GasTipCap: big.NewInt(9)},
+			want: ErrFeeCapTooLow,
+		},
+		{
+			name: "gas below the intrinsic gas",
+			tx:   Transaction{CallMsg: CallMsg{From: testSender, To: testContract, Data: []byte{1}, Gas: 21000}},
+			want: ErrIntrinsicGas,
+		},
+		{
+			name: "creation gas below the intrinsic gas",
+			tx:   Transaction{CallMsg: CallMsg{From: testSender, Gas: 50000}, Create: true},
+			want: ErrIntrinsicGas,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newFeeSimulator()
+			before := sim.State().GetBalance(testSender)
+			if _, err := sim.ApplyTransaction(context.Background(), &tt.tx); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+			if got := sim.State().GetBalance(testSender); got.Cmp(before) != 0 || sim.State().GetNonce(testSender) != 0 {
+				t.Errorf("state changed: balance %s, nonce %d", got, sim.State().GetNonce(testSender))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Dashboard redraws the snapshots returned by fetch on w every interval
+// until ctx is done, clearing the terminal with ANSI escapes. Throughput
+// is computed between consecutive snapshots, so it reflects the current
+// load rather than the average since start. Fetch errors are displayed
+// and retried.
+func Dashboard(ctx context.Context, w io.Writer, interval time.Duration, fetch func(context.Context) (*Snapshot, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var prev *Snapshot
+	for {
+		s, err := fetch(ctx)
+		if err != nil {
+			if _, err := fmt.Fprintf(w, "\x1b[H\x1b[2J%s\nerror: %v\n", time.Now().Format(time.TimeOnly), err); err != nil {
+				return err
+			}
+		} else {
+			if err := draw(w, prev, s); err != nil {
+				return err
+			}
+			prev = s
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// draw writes one frame of the dashboard
+func draw(w io.Writer, prev, s *Snapshot) error {
+	gasRate, execRate := s.GasPerSecond, 0.0
+	if elapsed := s.Uptime; elapsed > 0 {
+		execRate = float64(s.Executions) / elapsed
+	}
+	if prev != nil {
+		if elapsed := s.Time.Sub(prev.Time).Seconds(); elapsed > 0 {
+			gasRate = float64(s.GasUsed-prev.GasUsed) / elapsed
+			execRate = float64(s.Executions-prev.Executions) / elapsed
+		}
+	}
+	frame := fmt.Sprintf("\x1b[H\x1b[2J%s  up %s\n\n", s.Time.Format(time.TimeOnly), (time.Duration(s.Uptime) * time.Second).String())
+	if s.Blocks != nil {
+		frame += fmt.Sprintf("blocks mined     %d\n", *s.Blocks)
+		frame += fmt.Sprintf("txpool           %d pending\n", *s.Pending)
+	}
+	frame += fmt.Sprintf("in flight        %d\n", s.InFlight)
+	frame += fmt.Sprintf("executions       %d (%d failed), %.1f/s\n", s.Executions, s.Failed, execRate)
+	frame += fmt.Sprintf("gas              %d, %.3f Mgas/s (%.3f average)\n", s.GasUsed, gasRate/1e6, s.GasPerSecond/1e6)
+	if lookups := s.CacheHits + s.CacheMisses; lookups > 0 {
+		frame += fmt.Sprintf("cache            %.1f%% of %d lookups hit\n", 100*s.CacheHitRate, lookups)
+	} else {
+		frame += "cache            no lookups\n"
+	}
+	_, err := io.WriteString(w, frame)
+	return err
+}
+
+// Fetch returns a function fetching snapshots from the stats endpoint at
+// url, for watching a remote instance with Dashboard
+func Fetch(client *http.Client, url string) func(context.Context) (*Snapshot, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) (*Snapshot, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", url, resp.Status)
+		}
+		var s Snapshot
+		if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+		return &s, nil
+	}
+}
@@ -0,0 +1,146 @@
+// Package stats counts the work done by the long-running modes, the dev
+// node and the execution service, and reports it over HTTP and as a
+// terminal dashboard so operators can watch a running instance.
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Chain is the chain of a dev node, whose blocks and pending transactions
+// are reported along with the executions
+type Chain interface {
+	// BlockNumber returns the number of the latest block
+	BlockNumber() uint64
+	// PendingTransactions returns the number of transactions waiting to be
+	// mined
+	PendingTransactions() int
+}
+
+// Counters counts executions as they happen. It is safe for concurrent use.
+type Counters struct {
+	start time.Time
+
+	inFlight    atomic.Int64
+	executions  atomic.Uint64
+	failed      atomic.Uint64
+	gasUsed     atomic.Uint64
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
+
+	mu    sync.Mutex
+	chain Chain
+}
+
+// New creates counters starting at zero, with the uptime measured from now
+func New() *Counters {
+	return &Counters{start: time.Now()}
+}
+
+// Hooks returns the hooks counting the top-level frames of the executions
+// they are installed in, to be combined with the hooks of the vm.Config
+// of the node or service
+func (c *Counters) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter: func(depth int, _, _ common.Address, _ []byte, _ uint64) {
+			if depth == 0 {
+				c.inFlight.Add(1)
+			}
+		},
+		OnExit: func(depth int, _ []byte, gasUsed uint64, err error) {
+			if depth != 0 {
+				return
+			}
+			c.inFlight.Add(-1)
+			c.executions.Add(1)
+			c.gasUsed.Add(gasUsed)
+			if err != nil {
+				c.failed.Add(1)
+			}
+		},
+	}
+}
+
+// CacheHit counts a lookup answered by a cache
+func (c *Counters) CacheHit() { c.cacheHits.Add(1) }
+
+// CacheMiss counts a lookup a cache could not answer
+func (c *Counters) CacheMiss() { c.cacheMisses.Add(1) }
+
+// SetChain reports the blocks and pending transactions of chain in the
+// snapshots
+func (c *Counters) SetChain(chain Chain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chain = chain
+}
+
+// Snapshot is the state of the counters at a point in time
+type Snapshot struct {
+	Time       time.Time `json:"time"`
+	Uptime     float64   `json:"uptime"` // seconds
+	InFlight   int64     `json:"inFlight"`
+	Executions uint64    `json:"executions"` // finished
+	Failed     uint64    `json:"failed"`
+	GasUsed    uint64    `json:"gasUsed"`
+	// GasPerSecond is the gas used per second of uptime
+	GasPerSecond float64 `json:"gasPerSecond"`
+
+	CacheHits    uint64  `json:"cacheHits"`
+	CacheMisses  uint64  `json:"cacheMisses"`
+	CacheHitRate float64 `json:"cacheHitRate"` // zero before the first lookup
+
+	// set when the counters belong to a dev node
+	Blocks  *uint64 `json:"blocks,omitempty"`
+	Pending *int    `json:"pending,omitempty"` // transactions waiting to be mined
+}
+
+// Snapshot returns the current state of the counters
+func (c *Counters) Snapshot() *Snapshot {
+	now := time.Now()
+	s := &Snapshot{
+		Time:        now,
+		Uptime:      now.Sub(c.start).Seconds(),
+		InFlight:    c.inFlight.Load(),
+		Executions:  c.executions.Load(),
+		Failed:      c.failed.Load(),
+		GasUsed:     c.gasUsed.Load(),
+		CacheHits:   c.cacheHits.Load(),
+		CacheMisses: c.cacheMisses.Load(),
+	}
+	if s.Uptime > 0 {
+		s.GasPerSecond = float64(s.GasUsed) / s.Uptime
+	}
+	if lookups := s.CacheHits + s.CacheMisses; lookups > 0 {
+		s.CacheHitRate = float64(s.CacheHits) / float64(lookups)
+	}
+	c.mu.Lock()
+	chain := c.chain
+	c.mu.Unlock()
+	if chain != nil {
+		blocks, pending := chain.BlockNumber(), chain.PendingTransactions()
+		s.Blocks, s.Pending = &blocks, &pending
+	}
+	return s
+}
+
+// Handler returns an HTTP handler replying to GET requests with the
+// current snapshot as JSON
+func (c *Counters) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Snapshot())
+	})
+}
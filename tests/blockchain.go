@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	systemcalls "github.com/nutcas3/evm-golang/core/system"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/witness"
+)
+
+// BlockchainTest is a blockchain_test fixture: blocks imported on top of a
+// genesis state
+type BlockchainTest struct {
+	Network       string       `json:"network"`
+	Genesis       *BlockHeader `json:"genesisBlockHeader"`
+	Pre           Alloc        `json:"pre"`
+	Blocks        []TestBlock  `json:"blocks"`
+	PostState     Alloc        `json:"postState"`
+	PostStateHash *common.Hash `json:"postStateHash"`
+	LastBlockHash common.Hash  `json:"lastblockhash"`
+}
+
+// TestBlock is a block of a blockchain test, given as RLP and, for valid
+// blocks, decoded
+type TestBlock struct {
+	RLP             hexutil.Bytes      `json:"rlp"`
+	Header          *BlockHeader       `json:"blockHeader"`
+	Transactions    []BlockTransaction `json:"transactions"`
+	Withdrawals     []Withdrawal       `json:"withdrawals"`
+	ExpectException string             `json:"expectException"`
+}
+
+// BlockHeader holds the header fields transactions and system calls depend
+// on
+type BlockHeader struct {
+	ParentHash       common.Hash    `json:"parentHash"`
+	StateRoot        common.Hash    `json:"stateRoot"`
+	ParentBeaconRoot common.Hash    `json:"parentBeaconBlockRoot"`
+	Coinbase         common.Address `json:"coinbase"`
+	Number           Number         `json:"number"`
+	GasLimit         Number         `json:"gasLimit"`
+	Timestamp        Number         `json:"timestamp"`
+	BaseFee          *Number        `json:"baseFeePerGas"`
+	Difficulty       *Number        `json:"difficulty"`
+	MixHash          common.Hash    `json:"mixHash"` // PREVRANDAO after the merge
+
+	ExcessBlobGas *Number `json:"excessBlobGas"`
+}
+
+// BlockTransaction is a decoded transaction of a test block
+type BlockTransaction struct {
+	Nonce                Number         `json:"nonce"`
+	To                   string         `json:"to"`
+	GasLimit             Number         `json:"gasLimit"`
+	GasPrice             *Number        `json:"gasPrice"`
+	MaxFeePerGas         *Number        `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *Number        `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     *Number        `json:"maxFeePerBlobGas"`
+	Value                Number         `json:"value"`
+	Data                 hexutil.Bytes  `json:"data"`
+	BlobVersionedHashes  []common.Hash  `json:"blobVersionedHashes"`
+	Sender               common.Address `json:"sender"`
+}
+
+// Withdrawal credits a validator withdrawal, in gwei, after the block's
+// transactions
+type Withdrawal struct {
+	Address common.Address `json:"address"`
+	Amount  Number         `json:"amount"`
+}
+
+var gwei = big.NewInt(1_000_000_000)
+
+// Run imports the blocks in order and compares the final state with the
+// expected post-state, or its root when only the root is given. Blocks are
+// trusted as given: headers and transactions are not validated. The system
+// calls of the network's fork are made around each block's transactions;
+// transition networks get none.
+func (t *BlockchainTest) Run(config vm.Config) error {
+	statedb := t.Pre.State()
+	config, system := t.systemCalls(config)
+	for i := range t.Blocks {
+		if err := t.applyBlock(statedb, i, system, config); err != nil {
+			return err
+		}
+	}
+	return t.verifyPost(statedb)
+}
+
+// Witnesses imports the blocks like Run, collecting the witness of each,
+// and compares the final state with the expected post-state
+func (t *BlockchainTest) Witnesses(config vm.Config) ([]*witness.Witness, error) {
+	statedb := t.Pre.State()
+	config, system := t.systemCalls(config)
+	witnesses := make([]*witness.Witness, len(t.Blocks))
+	for i := range t.Blocks {
+		w, err := witness.Collect(statedb, func(statedb *state.StateDB) error {
+			return t.applyBlock(statedb, i, system, config)
+		})
+		if err != nil {
+			return nil, err
+		}
+		witnesses[i] = w
+	}
+	return witnesses, t.verifyPost(statedb)
+}
+
+// VerifyWitness re-executes block i statelessly from a witness of it and
+// checks that the witness proves the state root of the block's parent and
+// yields the state root of the block
+func (t *BlockchainTest) VerifyWitness(config vm.Config, i int, w *witness.Witness) error {
+	if i < 0 || i >= len(t.Blocks) {
+		return fmt.Errorf("block %d out of range, the test has %d", i, len(t.Blocks))
+	}
+	parent := t.Genesis
+	if i > 0 {
+		parent = t.Blocks[i-1].Header
+	}
+	if parent != nil && w.Root != parent.StateRoot {
+		return fmt.Errorf("block %d: witness of state root %s, parent state root is %s", i, w.Root.Hex(), parent.StateRoot.Hex())
+	}
+	config, system := t.systemCalls(config)
+	root, err := w.Execute(func(statedb *state.StateDB) error {
+		return t.applyBlock(statedb, i, system, config)
+	})
+	if err != nil {
+		return err
+	}
+	if want := t.Blocks[i].Header.StateRoot; root != want {
+		return fmt.Errorf("block %d: state root %s, want %s", i, root.Hex(), want.Hex())
+	}
+	return nil
+}
+
+// RunStateless imports the blocks like Witnesses, then re-executes each
+// block statelessly from its witness, testing the witness collector
+func (t *BlockchainTest) RunStateless(config vm.Config) error {
+	witnesses, err := t.Witnesses(config)
+	if err != nil {
+		return err
+	}
+	for i, w := range witnesses {
+		if err := t.VerifyWitness(config, i, w); err != nil {
+			return fmt.Errorf("stateless: %w", err)
+		}
+	}
+	return nil
+}
+
+// systemCalls returns config with the chain config of the network, unless
+// it has one, and the processor of the network's system calls, nil for
+// transition networks
+func (t *BlockchainTest) systemCalls(config vm.Config) (vm.Config, *systemcalls.Processor) {
+	chain := chainConfig(t.Network)
+	if chain == nil {
+		return config, nil
+	}
+	if config.ChainConfig == nil {
+		config.ChainConfig = chain
+	}
+	return config, systemcalls.NewProcessor(chain, config, nil)
+}
+
+// applyBlock applies block i to statedb: its system calls, transactions
+// and withdrawals
+func (t *BlockchainTest) applyBlock(statedb *state.StateDB, i int, system *systemcalls.Processor, config vm.Config) error {
+	tb := t.Blocks[i]
+	if tb.ExpectException != "" {
+		return fmt.Errorf("%w: block %d expected to be invalid (%s)", ErrUnsupported, i, tb.ExpectException)
+	}
+	if tb.Header == nil {
+		return fmt.Errorf("%w: block %d given only as RLP", ErrUnsupported, i)
+	}
+	b := &block{
+		coinbase:   tb.Header.Coinbase,
+		number:     tb.Header.Number.Int(),
+		gasLimit:   tb.Header.GasLimit.Uint64(),
+		timestamp:  tb.Header.Timestamp.Int(),
+		difficulty: nilInt(tb.Header.Difficulty),
+		random:     &tb.Header.MixHash,
+	}
+	if tb.Header.BaseFee != nil {
+		b.baseFee = tb.Header.BaseFee.Int()
+	}
+	b.setBlobBaseFee(t.Network, tb.Header.ExcessBlobGas)
+	sb := &systemcalls.Block{
+		Number:           b.number,
+		Time:             b.timestamp.Uint64(),
+		ParentHash:       tb.Header.ParentHash,
+		ParentBeaconRoot: tb.Header.ParentBeaconRoot,
+	}
+	if system != nil {
+		if _, err := system.Run(context.Background(), systemcalls.PreBlock, statedb, sb); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+	for j, tx := range tb.Transactions {
+		if err := checkBlobHashes(tx.BlobVersionedHashes); err != nil {
+			return fmt.Errorf("block %d, transaction %d: %w", i, j, err)
+		}
+		msg := &message{
+			from:     tx.Sender,
+			nonce:    tx.Nonce.Uint64(),
+			gas:      tx.GasLimit.Uint64(),
+			gasPrice: effectiveGasPrice(tx.GasPrice.Int(), nilInt(tx.MaxFeePerGas), tx.MaxPriorityFeePerGas.Int(), b.baseFee),
+			value:    tx.Value.Int(),
+			data:     tx.Data,
+
+			blobHashes:       tx.BlobVersionedHashes,
+			maxFeePerBlobGas: nilInt(tx.MaxFeePerBlobGas),
+		}
+		if tx.To != "" {
+			to, err := common.HexToAddressUnchecked(tx.To)
+			if err != nil {
+				return fmt.Errorf("block %d, transaction %d: recipient: %w", i, j, err)
+			}
+			msg.to = &to
+		}
+		if _, err := applyMessage(statedb, b, msg, config); err != nil {
+			return fmt.Errorf("block %d, transaction %d: %w", i, j, err)
+		}
+	}
+	for _, w := range tb.Withdrawals {
+		statedb.AddBalance(w.Address, new(big.Int).Mul(w.Amount.Int(), gwei))
+	}
+	if system != nil {
+		if _, err := system.Run(context.Background(), systemcalls.PostBlock, statedb, sb); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// verifyPost compares the state after the blocks with the expected
+// post-state, or its root when only the root is given
+func (t *BlockchainTest) verifyPost(statedb *state.StateDB) error {
+	if t.PostState == nil && t.PostStateHash != nil {
+		if root := statedb.Root(); root != *t.PostStateHash {
+			return fmt.Errorf("state root %s, want %s", root.Hex(), t.PostStateHash.Hex())
+		}
+		return nil
+	}
+	return t.PostState.Verify(statedb)
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// DefaultFork is the fork filled post-states are recorded under
+const DefaultFork = "Cancun"
+
+// Definition is a state test written in Go: a pre-state, one transaction
+// and, optionally, what the post-state must look like
+type Definition struct {
+	Fork    string   // fork the post-state is recorded under, DefaultFork if empty
+	ChainID *big.Int // chain id the transaction is signed for, 1 if nil
+	Env     StateEnv
+	Pre     Alloc
+	Tx      TxDefinition
+	Expect  Expectation // checked against the filled post-state, may be nil
+}
+
+// TxDefinition is the transaction of a Definition
+type TxDefinition struct {
+	SecretKey []byte         // signs the transaction; the sender is derived from it
+	From      common.Address // sender when no key is given, in which case txbytes is omitted
+	To        *common.Address
+	Nonce     uint64
+	Gas       uint64
+	GasPrice  *big.Int
+	Value     *big.Int
+	Data      []byte
+}
+
+// Expectation lists accounts of the post-state that must have the given
+// fields. Nil fields and accounts that are not listed are not checked.
+type Expectation map[common.Address]ExpectedAccount
+
+// ExpectedAccount is an account of an Expectation
+type ExpectedAccount struct {
+	Nonce   *uint64
+	Balance *big.Int
+	Code    []byte
+	Storage map[common.Hash]common.Hash // listed slots only
+}
+
+// Fill runs the definition and returns it as a state test fixture whose
+// post-state, state root and logs hash are those computed by this VM. It
+// fails if the post-state does not meet the expectation.
+func Fill(def *Definition, config vm.Config) (*StateTest, error) {
+	fork := def.Fork
+	if fork == "" {
+		fork = DefaultFork
+	}
+	chainID := def.chainID()
+	tx := def.Tx
+	gasPrice, value := bigOrZero(tx.GasPrice), bigOrZero(tx.Value)
+	from, key, err := def.sender()
+	if err != nil {
+		return nil, err
+	}
+	var txBytes []byte
+	if key != nil {
+		if txBytes, err = signLegacy(&tx, gasPrice, value, chainID, key); err != nil {
+			return nil, err
+		}
+	}
+	if config.ChainConfig == nil {
+		config.ChainConfig = chainConfig(fork)
+	}
+	statedb, r, err := def.execute(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := def.Expect.check(statedb); err != nil {
+		return nil, err
+	}
+
+	test := &StateTest{
+		Info:   &Info{FillingTool: "evm-golang", Format: "state_test"},
+		Env:    def.Env,
+		Pre:    allocOf(def.Pre.State()),
+		Config: &Config{ChainID: Number(*chainID)},
+		Transaction: StateTransaction{
+			Nonce:     Number(*new(big.Int).SetUint64(tx.Nonce)),
+			GasPrice:  (*Number)(gasPrice),
+			Data:      []hexutil.Bytes{tx.Data},
+			GasLimit:  []Number{Number(*new(big.Int).SetUint64(tx.Gas))},
+			Value:     []Number{Number(*value)},
+			Sender:    from,
+			SecretKey: tx.SecretKey,
+		},
+		Post: map[string][]StatePost{fork: {{
+			Hash:    statedb.Root(),
+			Logs:    logsHash(r.logs),
+			TxBytes: txBytes,
+			State:   allocOf(statedb),
+		}}},
+	}
+	if tx.To != nil {
+		test.Transaction.To = hexutil.Encode(tx.To[:])
+	}
+	return test, nil
+}
+
+func (def *Definition) chainID() *big.Int {
+	if def.ChainID == nil {
+		return big.NewInt(1)
+	}
+	return def.ChainID
+}
+
+// sender returns the sender of the transaction and its key, nil if the
+// definition gives no key
+func (def *Definition) sender() (common.Address, *crypto.PrivateKey, error) {
+	if def.Tx.SecretKey == nil {
+		return def.Tx.From, nil, nil
+	}
+	key, err := crypto.ToPrivateKey(def.Tx.SecretKey)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("secret key: %w", err)
+	}
+	return crypto.PubkeyToAddress(key.PubKey()), key, nil
+}
+
+// execute applies the transaction to the pre-state
+func (def *Definition) execute(config vm.Config) (*state.StateDB, *receipt, error) {
+	from, _, err := def.sender()
+	if err != nil {
+		return nil, nil, err
+	}
+	b := &block{
+		coinbase:   def.Env.Coinbase,
+		number:     def.Env.Number.Int(),
+		gasLimit:   def.Env.GasLimit.Uint64(),
+		timestamp:  def.Env.Timestamp.Int(),
+		difficulty: nilInt(def.Env.Difficulty),
+		random:     def.Env.Random,
+	}
+	if def.Env.BaseFee != nil {
+		b.baseFee = def.Env.BaseFee.Int()
+	}
+	tx := def.Tx
+	if b.gasLimit == 0 {
+		// as given to the reference client
+		b.gasLimit = tx.Gas
+	}
+	msg := &message{from: from, to: tx.To, nonce: tx.Nonce, gas: tx.Gas, gasPrice: bigOrZero(tx.GasPrice), value: bigOrZero(tx.Value), data: tx.Data}
+	statedb := def.Pre.State()
+	r, err := applyMessage(statedb, b, msg, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, r, nil
+}
+
+// WriteFixtures writes state tests by name as a JSON fixture file
+func WriteFixtures(w io.Writer, fixtures map[string]*StateTest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fixtures)
+}
+
+func bigOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
+// allocOf returns the accounts of statedb as an allocation
+func allocOf(statedb *state.StateDB) Alloc {
+	alloc := make(Alloc)
+	for addr, acc := range statedb.Dump() {
+		storage := make(map[Word]Word, len(acc.Storage))
+		for key, value := range acc.Storage {
+			storage[Word(key)] = Word(value)
+		}
+		alloc[addr] = Account{
+			Nonce:   Number(*new(big.Int).SetUint64(uint64(acc.Nonce))),
+			Balance: Number(*acc.Balance.ToInt()),
+			Code:    acc.Code,
+			Storage: storage,
+		}
+	}
+	return alloc
+}
+
+// check compares statedb with the expectation
+func (e Expectation) check(statedb *state.StateDB) error {
+	for addr, want := range e {
+		if want.Nonce != nil && statedb.GetNonce(addr) != *want.Nonce {
+			return fmt.Errorf("account %s: nonce %d, want %d", addr.Hex(), statedb.GetNonce(addr), *want.Nonce)
+		}
+		if want.Balance != nil && statedb.GetBalance(addr).Cmp(want.Balance) != 0 {
+			return fmt.Errorf("account %s: balance %s, want %s", addr.Hex(), statedb.GetBalance(addr), want.Balance)
+		}
+		if want.Code != nil && string(statedb.GetCode(addr)) != string(want.Code) {
+			return fmt.Errorf("account %s: code %#x, want %#x", addr.Hex(), statedb.GetCode(addr), want.Code)
+		}
+		for key, value := range want.Storage {
+			if have := statedb.GetState(addr, key); have != value {
+				return fmt.Errorf("account %s: slot %s is %s, want %s", addr.Hex(), key.Hex(), have.Hex(), value.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+// signLegacy returns the RLP encoding of the transaction signed with
+// EIP-155 replay protection
+func signLegacy(tx *TxDefinition, gasPrice, value, chainID *big.Int, key *crypto.PrivateKey) ([]byte, error) {
+	signed := &types.LegacyTx{
+		Nonce:    tx.Nonce,
+		GasPrice: gasPrice,
+		Gas:      tx.Gas,
+		To:       tx.To,
+		Value:    value,
+		Data:     tx.Data,
+	}
+	if err := types.SignLegacyTx(signed, chainID, key); err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
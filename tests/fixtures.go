@@ -0,0 +1,244 @@
+// Package tests runs the JSON fixtures of the Ethereum consensus test
+// suites against the interpreter: the state_test and blockchain_test
+// formats filled by execution-spec-tests (EEST), which the legacy
+// GeneralStateTests and BlockchainTests of ethereum/tests share.
+//
+// Post states are compared account by account, or by state root when the
+// accounts are not listed. Fixtures relying on RLP-encoded blocks or
+// transaction validity rules report ErrUnsupported instead of failing.
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+)
+
+// ErrUnsupported is returned for fixtures relying on features the runner
+// does not implement
+var ErrUnsupported = errors.New("unsupported")
+
+// Number is a fixture quantity: hex, possibly with leading zeros, or
+// decimal
+type Number big.Int
+
+func (n *Number) UnmarshalText(input []byte) error {
+	s := string(input)
+	v := new(big.Int)
+	ok := true
+	switch {
+	case s == "0x" || s == "":
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0X"):
+		_, ok = v.SetString(s[2:], 16)
+	default:
+		_, ok = v.SetString(s, 10)
+	}
+	if !ok {
+		return fmt.Errorf("invalid number %q", s)
+	}
+	*n = Number(*v)
+	return nil
+}
+
+func (n Number) MarshalText() ([]byte, error) {
+	v := big.Int(n)
+	return []byte(hexutil.EncodeBig(&v)), nil
+}
+
+// Int returns the number as a big.Int, zero for nil
+func (n *Number) Int() *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return (*big.Int)(n)
+}
+
+// Uint64 returns the number truncated to 64 bits
+func (n *Number) Uint64() uint64 { return n.Int().Uint64() }
+
+// Word is a storage key or value, written as a number
+type Word common.Hash
+
+func (w *Word) UnmarshalText(input []byte) error {
+	var n Number
+	if err := n.UnmarshalText(input); err != nil {
+		return err
+	}
+	if n.Int().BitLen() > 256 {
+		return fmt.Errorf("word %q exceeds 256 bits", input)
+	}
+	*w = Word(common.BigToHash(n.Int()))
+	return nil
+}
+
+func (w Word) MarshalText() ([]byte, error) {
+	return []byte(hexutil.EncodeBig(common.Hash(w).Big())), nil
+}
+
+// Account is an entry of a pre or post state allocation
+type Account struct {
+	Nonce   Number        `json:"nonce"`
+	Balance Number        `json:"balance"`
+	Code    hexutil.Bytes `json:"code"`
+	Storage map[Word]Word `json:"storage"`
+}
+
+// Alloc is a state allocation by address
+type Alloc map[common.Address]Account
+
+// State builds an in-memory state holding the allocation
+func (a Alloc) State() *state.StateDB {
+	statedb := state.New()
+	for addr, acc := range a {
+		statedb.CreateAccount(addr)
+		statedb.SetNonce(addr, acc.Nonce.Uint64())
+		statedb.AddBalance(addr, acc.Balance.Int())
+		if len(acc.Code) > 0 {
+			statedb.SetCode(addr, acc.Code)
+		}
+		for key, value := range acc.Storage {
+			statedb.SetState(addr, common.Hash(key), common.Hash(value))
+		}
+	}
+	return statedb
+}
+
+// Verify compares the accounts of statedb with the allocation, which must
+// list every non-empty account
+func (a Alloc) Verify(statedb *state.StateDB) error {
+	dump := statedb.Dump()
+	for addr, want := range a {
+		got, ok := dump[addr]
+		if !ok {
+			return fmt.Errorf("account %s: missing", addr.Hex())
+		}
+		if err := verifyAccount(addr, got, want); err != nil {
+			return err
+		}
+	}
+	for addr, got := range dump {
+		if _, ok := a[addr]; ok {
+			continue
+		}
+		if got.Nonce != 0 || got.Balance.ToInt().Sign() != 0 || len(got.Code) > 0 {
+			return fmt.Errorf("account %s: unexpected", addr.Hex())
+		}
+	}
+	return nil
+}
+
+// verifyAccount compares an account of a state dump with want
+func verifyAccount(addr common.Address, got state.DumpAccount, want Account) error {
+	if uint64(got.Nonce) != want.Nonce.Uint64() {
+		return fmt.Errorf("account %s: nonce %d, want %d", addr.Hex(), uint64(got.Nonce), want.Nonce.Uint64())
+	}
+	if balance := got.Balance.ToInt(); balance.Cmp(want.Balance.Int()) != 0 {
+		return fmt.Errorf("account %s: balance %s, want %s", addr.Hex(), balance, want.Balance.Int())
+	}
+	if string(got.Code) != string(want.Code) {
+		return fmt.Errorf("account %s: code %s, want %s", addr.Hex(), got.Code, want.Code)
+	}
+	for key, value := range want.Storage {
+		if have := got.Storage[common.Hash(key)]; have != common.Hash(value) {
+			return fmt.Errorf("account %s: slot %s is %s, want %s", addr.Hex(), common.Hash(key).Hex(), have.Hex(), common.Hash(value).Hex())
+		}
+	}
+	for key, value := range got.Storage {
+		if _, ok := want.Storage[Word(key)]; !ok && !value.IsZero() {
+			return fmt.Errorf("account %s: unexpected slot %s = %s", addr.Hex(), key.Hex(), value.Hex())
+		}
+	}
+	return nil
+}
+
+// Fixtures are the tests of a fixture file by name
+type Fixtures struct {
+	State      map[string]*StateTest
+	Blockchain map[string]*BlockchainTest
+	Script     map[string]*ScriptTest
+	Skipped    map[string]string // tests in formats that are not supported, with their format
+}
+
+// Info describes how a fixture was produced
+type Info struct {
+	Comment     string `json:"comment,omitempty"`
+	FillingTool string `json:"filling-transition-tool,omitempty"`
+	Format      string `json:"fixture_format,omitempty"`
+}
+
+// Config holds the chain parameters of a fixture
+type Config struct {
+	ChainID Number `json:"chainid"`
+}
+
+// fixtureInfo is the part of a fixture used to detect its format
+type fixtureInfo struct {
+	Info        Info            `json:"_info"`
+	Transaction json.RawMessage `json:"transaction"`
+	Blocks      json.RawMessage `json:"blocks"`
+	Code        json.RawMessage `json:"code"`
+}
+
+// Load reads a fixture file, sorting its tests by format. YAML files may
+// only hold scripts.
+func Load(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isScriptFile(path) {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f := &Fixtures{
+		State:      make(map[string]*StateTest),
+		Blockchain: make(map[string]*BlockchainTest),
+		Script:     make(map[string]*ScriptTest),
+		Skipped:    make(map[string]string),
+	}
+	for name, msg := range raw {
+		var info fixtureInfo
+		if err := json.Unmarshal(msg, &info); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+		switch {
+		case info.Transaction != nil:
+			t := new(StateTest)
+			if err := json.Unmarshal(msg, t); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+			f.State[name] = t
+		case info.Blocks != nil:
+			t := new(BlockchainTest)
+			if err := json.Unmarshal(msg, t); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+			f.Blockchain[name] = t
+		case info.Code != nil:
+			t := new(ScriptTest)
+			if err := json.Unmarshal(msg, t); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+			f.Script[name] = t
+		default:
+			format := info.Info.Format
+			if format == "" {
+				format = "unknown"
+			}
+			f.Skipped[name] = format
+		}
+	}
+	return f, nil
+}
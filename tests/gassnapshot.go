@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// gasSnapshotLine matches a line of a gas snapshot file, in the format of
+// forge snapshot: "name (gas: 21000)"
+var gasSnapshotLine = regexp.MustCompile(`^(.+) \(gas: (\d+)\)$`)
+
+// GasSnapshot compares the gas used by named scenarios with the values
+// recorded in a checked-in snapshot file, so changes to gas accounting or
+// to contracts are noticed and accepted deliberately. It is safe for
+// concurrent use.
+type GasSnapshot struct {
+	Tolerance float64 // relative change allowed without failing, e.g. 0.01 for 1%
+
+	mu   sync.Mutex
+	want map[string]uint64
+	got  map[string]uint64
+}
+
+// GasChange is a scenario whose gas differs from the snapshot
+type GasChange struct {
+	Name     string
+	Old, New uint64
+	Added    bool // the scenario is not in the snapshot
+	Removed  bool // the scenario was not recorded
+}
+
+func (c GasChange) String() string {
+	switch {
+	case c.Added:
+		return fmt.Sprintf("%s: new, %d", c.Name, c.New)
+	case c.Removed:
+		return fmt.Sprintf("%s: removed, was %d", c.Name, c.Old)
+	}
+	return fmt.Sprintf("%s: %d -> %d (%+.2f%%)", c.Name, c.Old, c.New, 100*c.relative())
+}
+
+// relative returns the change relative to the snapshot value
+func (c GasChange) relative() float64 {
+	if c.Old == 0 {
+		return 1
+	}
+	return (float64(c.New) - float64(c.Old)) / float64(c.Old)
+}
+
+// NewGasSnapshot creates a snapshot comparing with the gas values of want
+func NewGasSnapshot(want map[string]uint64, tolerance float64) *GasSnapshot {
+	if want == nil {
+		want = make(map[string]uint64)
+	}
+	return &GasSnapshot{Tolerance: tolerance, want: want, got: make(map[string]uint64)}
+}
+
+// LoadGasSnapshot reads the snapshot file at path. A missing file is an
+// empty snapshot, to which every scenario is new.
+func LoadGasSnapshot(path string, tolerance float64) (*GasSnapshot, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return NewGasSnapshot(nil, tolerance), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	want, err := ReadGasSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewGasSnapshot(want, tolerance), nil
+}
+
+// ReadGasSnapshot parses a snapshot file, one "name (gas: N)" line per
+// scenario
+func ReadGasSnapshot(r io.Reader) (map[string]uint64, error) {
+	want := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		m := gasSnapshotLine.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected \"name (gas: N)\"", line)
+		}
+		gas, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		want[m[1]] = gas
+	}
+	return want, scanner.Err()
+}
+
+// Record sets the gas used by a scenario, replacing an earlier value
+func (s *GasSnapshot) Record(name string, gas uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.got[name] = gas
+}
+
+// Check records the gas used by a scenario and returns its change if it
+// exceeds the tolerance. New scenarios are not reported.
+func (s *GasSnapshot) Check(name string, gas uint64) *GasChange {
+	s.Record(name, gas)
+	s.mu.Lock()
+	old, ok := s.want[name]
+	s.mu.Unlock()
+	if c := (GasChange{Name: name, Old: old, New: gas}); ok && s.exceeds(c) {
+		return &c
+	}
+	return nil
+}
+
+// exceeds reports whether a change of a recorded scenario is beyond the
+// tolerance
+func (s *GasSnapshot) exceeds(c GasChange) bool {
+	r := c.relative()
+	return c.Old != c.New && (r > s.Tolerance || -r > s.Tolerance)
+}
+
+// Changes returns every scenario whose gas differs from the snapshot,
+// including those within the tolerance and those added or not recorded,
+// sorted by name
+func (s *GasSnapshot) Changes() []GasChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var changes []GasChange
+	for name, gas := range s.got {
+		old, ok := s.want[name]
+		if !ok {
+			changes = append(changes, GasChange{Name: name, New: gas, Added: true})
+		} else if old != gas {
+			changes = append(changes, GasChange{Name: name, Old: old, New: gas})
+		}
+	}
+	for name, old := range s.want {
+		if _, ok := s.got[name]; !ok {
+			changes = append(changes, GasChange{Name: name, Old: old, Removed: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// Regressions returns the changes of recorded scenarios beyond the
+// tolerance, in either direction
+func (s *GasSnapshot) Regressions() []GasChange {
+	var out []GasChange
+	for _, c := range s.Changes() {
+		if !c.Added && !c.Removed && s.exceeds(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Write writes the recorded scenarios in snapshot file format, sorted by
+// name
+func (s *GasSnapshot) Write(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.got))
+	for name := range s.got {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "%s (gas: %d)\n", name, s.got[name])
+	}
+	return bw.Flush()
+}
+
+// Save writes the recorded scenarios to the snapshot file at path
+func (s *GasSnapshot) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
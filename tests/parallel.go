@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"runtime"
+	"sync"
+)
+
+// RunParallel calls run for each job received from jobs on the given
+// number of goroutines, GOMAXPROCS if workers is zero or less, until jobs
+// is closed. report is called on the calling goroutine with each job and
+// the error run returned, in the order the jobs were received, so output
+// stays deterministic. Fixture tests build their own state from their
+// pre-allocation, so their Run methods may run concurrently as long as the
+// hooks of their vm.Config are safe for concurrent use.
+func RunParallel[J any](jobs <-chan J, workers int, run func(J) error, report func(J, error)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type indexed struct {
+		seq int
+		job J
+		err error
+	}
+	queue := make(chan indexed, workers)
+	done := make(chan indexed, workers)
+	go func() {
+		seq := 0
+		for job := range jobs {
+			queue <- indexed{seq: seq, job: job}
+			seq++
+		}
+		close(queue)
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				j.err = run(j.job)
+				done <- j
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// results arriving out of order wait until those before them are in
+	pending := make(map[int]indexed)
+	next := 0
+	for j := range done {
+		pending[j.seq] = j
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			report(p.job, p.err)
+			next++
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// DefaultT8n is the transition tool of the Python execution specs (EELS)
+var DefaultT8n = []string{"ethereum-spec-evm", "t8n"}
+
+// Reference runs transactions through the transition tool ("t8n") of
+// another implementation, the execution specs by default, to check this
+// VM against it. Any tool implementing the t8n command line interface can
+// be used, geth's `evm t8n` included.
+type Reference struct {
+	Command []string // t8n command and its leading arguments, DefaultT8n if empty
+}
+
+// Difference is a value that differs between this VM and the reference
+type Difference struct {
+	Field     string // e.g. "gasUsed" or "0x...: storage 0x01"
+	Local     string
+	Reference string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s, reference %s", d.Field, d.Local, d.Reference)
+}
+
+// t8nResult is the part of the t8n result file that is compared
+type t8nResult struct {
+	GasUsed  Number `json:"gasUsed"`
+	Rejected []struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	} `json:"rejected"`
+}
+
+// Compare applies the definition's transaction with this VM and with the
+// reference and returns the differences in gas used and in the nonce,
+// balance, code and storage of every account. The transaction must have a
+// secret key, which the reference signs it with.
+func (r *Reference) Compare(def *Definition, config vm.Config) ([]Difference, error) {
+	if def.Tx.SecretKey == nil {
+		return nil, fmt.Errorf("the reference needs the secret key of the sender")
+	}
+	statedb, receipt, err := def.execute(config)
+	if err != nil {
+		return nil, err
+	}
+	result, alloc, err := r.run(def)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rejected) > 0 {
+		return []Difference{{Field: "transaction", Local: "accepted", Reference: "rejected: " + result.Rejected[0].Error}}, nil
+	}
+	var diffs []Difference
+	if gas := result.GasUsed.Uint64(); gas != receipt.gasUsed {
+		diffs = append(diffs, Difference{Field: "gasUsed", Local: fmt.Sprint(receipt.gasUsed), Reference: fmt.Sprint(gas)})
+	}
+	return append(diffs, diffAllocs(allocOf(statedb), alloc)...), nil
+}
+
+// run invokes the t8n tool in a temporary directory
+func (r *Reference) run(def *Definition) (*t8nResult, Alloc, error) {
+	dir, err := os.MkdirTemp("", "t8n")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tx := def.Tx
+	txs := []map[string]interface{}{{
+		"type":      "0x0",
+		"nonce":     hexutil.EncodeUint64(tx.Nonce),
+		"gasPrice":  hexutil.EncodeBig(bigOrZero(tx.GasPrice)),
+		"gas":       hexutil.EncodeUint64(tx.Gas),
+		"value":     hexutil.EncodeBig(bigOrZero(tx.Value)),
+		"input":     hexutil.Encode(tx.Data),
+		"v":         "0x0",
+		"r":         "0x0",
+		"s":         "0x0",
+		"secretKey": hexutil.Encode(tx.SecretKey),
+		"protected": true,
+	}}
+	if tx.To != nil {
+		txs[0]["to"] = hexutil.Encode(tx.To[:])
+	}
+	env := map[string]interface{}{
+		"currentCoinbase":       def.Env.Coinbase,
+		"currentGasLimit":       def.Env.GasLimit,
+		"currentNumber":         def.Env.Number,
+		"currentTimestamp":      def.Env.Timestamp,
+		"currentDifficulty":     "0x0",
+		"currentRandom":         common.Hash{},
+		"currentExcessBlobGas":  "0x0",
+		"parentBeaconBlockRoot": common.Hash{},
+		"withdrawals":           []interface{}{},
+		"blockHashes":           map[string]string{},
+	}
+	if def.Env.GasLimit.Int().Sign() == 0 {
+		env["currentGasLimit"] = hexutil.EncodeUint64(tx.Gas)
+	}
+	if def.Env.BaseFee != nil {
+		env["currentBaseFee"] = def.Env.BaseFee
+	}
+	if def.Env.Difficulty != nil {
+		env["currentDifficulty"] = def.Env.Difficulty
+	}
+	if def.Env.Random != nil {
+		env["currentRandom"] = def.Env.Random
+	}
+	inputs := map[string]interface{}{"alloc.json": def.Pre, "env.json": env, "txs.json": txs}
+	for name, v := range inputs {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fork := def.Fork
+	if fork == "" {
+		fork = DefaultFork
+	}
+	command := r.Command
+	if len(command) == 0 {
+		command = DefaultT8n
+	}
+	args := append(append([]string(nil), command[1:]...),
+		"--input.alloc", filepath.Join(dir, "alloc.json"),
+		"--input.env", filepath.Join(dir, "env.json"),
+		"--input.txs", filepath.Join(dir, "txs.json"),
+		"--output.basedir", dir,
+		"--output.result", "result.json",
+		"--output.alloc", "post.json",
+		"--state.fork", fork,
+		"--state.chainid", def.chainID().String(),
+		"--state.reward", "-1",
+	)
+	cmd := exec.Command(command[0], args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return nil, nil, fmt.Errorf("%s: %w: %s", command[0], err, msg)
+		}
+		return nil, nil, fmt.Errorf("%s: %w", command[0], err)
+	}
+
+	result := new(t8nResult)
+	if err := readJSON(filepath.Join(dir, "result.json"), result); err != nil {
+		return nil, nil, err
+	}
+	var alloc Alloc
+	if err := readJSON(filepath.Join(dir, "post.json"), &alloc); err != nil {
+		return nil, nil, err
+	}
+	return result, alloc, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// diffAllocs compares two allocations, treating missing accounts as empty
+func diffAllocs(local, ref Alloc) []Difference {
+	addrs := make(map[common.Address]bool)
+	for addr := range local {
+		addrs[addr] = true
+	}
+	for addr := range ref {
+		addrs[addr] = true
+	}
+	sorted := make([]common.Address, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+
+	var diffs []Difference
+	for _, addr := range sorted {
+		l, r := local[addr], ref[addr]
+		field := func(name string) string { return addr.Hex() + ": " + name }
+		if l.Nonce.Uint64() != r.Nonce.Uint64() {
+			diffs = append(diffs, Difference{field("nonce"), fmt.Sprint(l.Nonce.Uint64()), fmt.Sprint(r.Nonce.Uint64())})
+		}
+		if l.Balance.Int().Cmp(r.Balance.Int()) != 0 {
+			diffs = append(diffs, Difference{field("balance"), l.Balance.Int().String(), r.Balance.Int().String()})
+		}
+		if !bytes.Equal(l.Code, r.Code) {
+			diffs = append(diffs, Difference{field("code"), l.Code.String(), r.Code.String()})
+		}
+		keys := make(map[Word]bool)
+		for key := range l.Storage {
+			keys[key] = true
+		}
+		for key := range r.Storage {
+			keys[key] = true
+		}
+		sortedKeys := make([]Word, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Slice(sortedKeys, func(i, j int) bool { return common.Hash(sortedKeys[i]).Cmp(common.Hash(sortedKeys[j])) < 0 })
+		for _, key := range sortedKeys {
+			lv, rv := common.Hash(l.Storage[key]).Big(), common.Hash(r.Storage[key]).Big()
+			if lv.Cmp(rv) != 0 {
+				diffs = append(diffs, Difference{field("storage " + hexutil.EncodeBig(common.Hash(key).Big())), hexutil.EncodeBig(lv), hexutil.EncodeBig(rv)})
+			}
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultScriptAddress is the address script code runs at unless the
+// script names another
+var DefaultScriptAddress = common.Address{18: 0x10}
+
+// DefaultScriptGas is the gas a script runs with unless it sets its own,
+// and the block gas limit GASLIMIT reads unless that gas is larger
+const DefaultScriptGas = 10_000_000
+
+// ScriptTest is an interpreter regression test written as data rather
+// than Go: code run once against a pre-state, and what the execution must
+// leave behind. Script files hold tests by name, in JSON or YAML.
+//
+//	add:
+//	  code: PUSH1 10 PUSH1 20 ADD
+//	  expect:
+//	    stack: [30]
+//	    gas: 9
+type ScriptTest struct {
+	Code     ScriptCode      `json:"code"`
+	Calldata hexutil.Bytes   `json:"calldata"`
+	Address  *common.Address `json:"address"` // DefaultScriptAddress if nil
+	Sender   common.Address  `json:"sender"`
+	Value    *Number         `json:"value"` // read by CALLVALUE, not moved between accounts
+	Gas      *Number         `json:"gas"`   // DefaultScriptGas if nil
+	Fork     string          `json:"fork"`  // DefaultFork if empty
+	Pre      Alloc           `json:"pre"`
+	Expect   ScriptExpect    `json:"expect"`
+}
+
+// ScriptCode is the code of a script: hex starting with 0x, or mnemonics
+// parsed by asm.Parse
+type ScriptCode []byte
+
+func (c *ScriptCode) UnmarshalText(input []byte) error {
+	s := strings.TrimSpace(string(input))
+	if strings.HasPrefix(s, "0x") && !strings.ContainsAny(s, " \t\n") {
+		code, err := hexutil.Decode(s)
+		if err != nil {
+			return fmt.Errorf("code: %w", err)
+		}
+		*c = code
+		return nil
+	}
+	code, err := asm.Parse(s)
+	if err != nil {
+		return fmt.Errorf("code: %w", err)
+	}
+	*c = code
+	return nil
+}
+
+// ScriptExpect is what a script must leave behind. Nil fields are not
+// checked.
+type ScriptExpect struct {
+	Status     string        `json:"status"`     // halt status, such as "success" or "out of gas"
+	Stack      []Word        `json:"stack"`      // final stack of the outermost frame, bottom first
+	Storage    map[Word]Word `json:"storage"`    // listed slots of the executing contract
+	Gas        *Number       `json:"gas"`        // gas used
+	ReturnData hexutil.Bytes `json:"returnData"` // checked when set
+	Logs       []ScriptLog   `json:"logs"`
+	Post       Alloc         `json:"post"` // accounts that must match exactly, as in a state test
+}
+
+// ScriptLog is an expected log. A nil address is that of the executing
+// contract.
+type ScriptLog struct {
+	Address *common.Address `json:"address"`
+	Topics  []Word          `json:"topics"`
+	Data    hexutil.Bytes   `json:"data"`
+}
+
+// Run executes the script and compares the outcome with its expectation
+func (t *ScriptTest) Run(config vm.Config) error {
+	fork := t.Fork
+	if fork == "" {
+		fork = DefaultFork
+	}
+	chain := chainConfig(fork)
+	if chain == nil {
+		return fmt.Errorf("%w: fork %s", ErrUnsupported, fork)
+	}
+	address := DefaultScriptAddress
+	if t.Address != nil {
+		address = *t.Address
+	}
+	gas := uint64(DefaultScriptGas)
+	if t.Gas != nil {
+		gas = t.Gas.Uint64()
+	}
+
+	statedb := t.Pre.State()
+	statedb.SetCode(address, t.Code)
+	config.ChainConfig = chain
+	blockCtx := &vm.Context{
+		BlockNumber: big.NewInt(1),
+		Timestamp:   big.NewInt(1),
+		Sender:      t.Sender,
+		GasLimit:    max(gas, DefaultScriptGas),
+		Gas:         gas,
+		GasPrice:    new(big.Int),
+		BaseFee:     new(big.Int),
+		Random:      &common.Hash{},
+	}
+	evm := vm.NewEVM(blockCtx, statedb, config)
+	evm.SetInput(t.Calldata)
+	evm.SetValue(nilInt(t.Value))
+	result := evm.Run(context.Background(), address)
+
+	want := &t.Expect
+	if want.Status != "" && result.Status().String() != want.Status {
+		return fmt.Errorf("status %q, want %q (%v)", result.Status(), want.Status, result.Err)
+	}
+	if want.Status == "" && result.Failed() {
+		return fmt.Errorf("execution failed: %w", result.Err)
+	}
+	if want.Gas != nil && result.GasUsed != want.Gas.Uint64() {
+		return fmt.Errorf("gas used %d, want %d", result.GasUsed, want.Gas.Uint64())
+	}
+	if want.Stack != nil {
+		stack := evm.Checkpoint().Stack
+		if len(stack) != len(want.Stack) {
+			return fmt.Errorf("stack has %d words, want %d", len(stack), len(want.Stack))
+		}
+		for i, v := range stack {
+			word, _ := v.Value.(*big.Int)
+			if word == nil || common.BigToHash(word) != common.Hash(want.Stack[i]) {
+				return fmt.Errorf("stack word %d is %v, want %s", i, v.Value, common.Hash(want.Stack[i]).Big())
+			}
+		}
+	}
+	for key, value := range want.Storage {
+		if have := statedb.GetState(address, common.Hash(key)); have != common.Hash(value) {
+			return fmt.Errorf("slot %s is %s, want %s", common.Hash(key).Hex(), have.Hex(), common.Hash(value).Hex())
+		}
+	}
+	if want.ReturnData != nil && !bytes.Equal(result.ReturnData, want.ReturnData) {
+		return fmt.Errorf("return data %s, want %s", hexutil.Bytes(result.ReturnData), want.ReturnData)
+	}
+	if want.Logs != nil {
+		if len(result.Logs) != len(want.Logs) {
+			return fmt.Errorf("%d logs, want %d", len(result.Logs), len(want.Logs))
+		}
+		for i, l := range result.Logs {
+			if err := want.Logs[i].check(l.Address, l.Topics, l.Data, address); err != nil {
+				return fmt.Errorf("log %d: %w", i, err)
+			}
+		}
+	}
+	dump := statedb.Dump()
+	for addr, acc := range want.Post {
+		got, ok := dump[addr]
+		if !ok {
+			return fmt.Errorf("account %s: missing", addr.Hex())
+		}
+		if err := verifyAccount(addr, got, acc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *ScriptLog) check(address common.Address, topics []common.Hash, data []byte, self common.Address) error {
+	want := self
+	if l.Address != nil {
+		want = *l.Address
+	}
+	if address != want {
+		return fmt.Errorf("address %s, want %s", address.Hex(), want.Hex())
+	}
+	if len(topics) != len(l.Topics) {
+		return fmt.Errorf("%d topics, want %d", len(topics), len(l.Topics))
+	}
+	for i, topic := range topics {
+		if topic != common.Hash(l.Topics[i]) {
+			return fmt.Errorf("topic %d is %s, want %s", i, topic.Hex(), common.Hash(l.Topics[i]).Hex())
+		}
+	}
+	if !bytes.Equal(data, l.Data) {
+		return fmt.Errorf("data %s, want %s", hexutil.Bytes(data), l.Data)
+	}
+	return nil
+}
+
+// isScriptFile reports whether path holds scripts in YAML
+func isScriptFile(path string) bool {
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
+// yamlToJSON converts a YAML document to JSON so it decodes like a JSON
+// fixture. Scalars become strings, which the fixture types parse as
+// numbers, words or hex, except for nulls.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return []byte("{}"), nil
+	}
+	v, err := yamlValue(doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func yamlValue(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil, nil
+		}
+		return n.Value, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := yamlValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
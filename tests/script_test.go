@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// TestScripts runs the scripts of tests/scripts, which the evm fixtures
+// command runs too
+func TestScripts(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("scripts", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no scripts in tests/scripts")
+	}
+	for _, file := range files {
+		fixtures, err := Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, test := range fixtures.Script {
+			t.Run(filepath.Base(file)+"/"+name, func(t *testing.T) {
+				if err := test.Run(vm.Config{}); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/params"
+)
+
+// StateTest is a state_test fixture: a single transaction, parameterized
+// by data, gas and value variants, applied to a pre-state
+type StateTest struct {
+	Info        *Info                  `json:"_info,omitempty"`
+	Env         StateEnv               `json:"env"`
+	Pre         Alloc                  `json:"pre"`
+	Transaction StateTransaction       `json:"transaction"`
+	Post        map[string][]StatePost `json:"post"` // by fork name
+	Config      *Config                `json:"config,omitempty"`
+}
+
+// StateEnv is the block environment of a state test
+type StateEnv struct {
+	Coinbase  common.Address `json:"currentCoinbase"`
+	GasLimit  Number         `json:"currentGasLimit"`
+	Number    Number         `json:"currentNumber"`
+	Timestamp Number         `json:"currentTimestamp"`
+	BaseFee   *Number        `json:"currentBaseFee,omitempty"`
+
+	Difficulty *Number      `json:"currentDifficulty,omitempty"` // DIFFICULTY before the merge
+	Random     *common.Hash `json:"currentRandom,omitempty"`     // PREVRANDAO after the merge
+
+	ExcessBlobGas *Number `json:"currentExcessBlobGas,omitempty"`
+}
+
+// StateTransaction lists the variants of the tested transaction
+type StateTransaction struct {
+	Nonce                Number          `json:"nonce"`
+	GasPrice             *Number         `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *Number         `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *Number         `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     *Number         `json:"maxFeePerBlobGas,omitempty"`
+	To                   string          `json:"to"` // empty for contract creation
+	Data                 []hexutil.Bytes `json:"data"`
+	GasLimit             []Number        `json:"gasLimit"`
+	Value                []Number        `json:"value"`
+	BlobVersionedHashes  []common.Hash   `json:"blobVersionedHashes,omitempty"`
+	Sender               common.Address  `json:"sender"`              // filled by EEST
+	SecretKey            hexutil.Bytes   `json:"secretKey,omitempty"` // sender key, used when sender is absent
+}
+
+// StatePost is the expected outcome of one transaction variant
+type StatePost struct {
+	Hash    common.Hash   `json:"hash"`
+	Logs    common.Hash   `json:"logs"`
+	TxBytes hexutil.Bytes `json:"txbytes,omitempty"`
+	Indexes struct {
+		Data  int `json:"data"`
+		Gas   int `json:"gas"`
+		Value int `json:"value"`
+	} `json:"indexes"`
+	State           Alloc  `json:"state"`
+	ExpectException string `json:"expectException"`
+}
+
+// Subtest identifies one post-state entry of a state test
+type Subtest struct {
+	Fork  string
+	Index int
+}
+
+func (s Subtest) String() string { return fmt.Sprintf("%s/%d", s.Fork, s.Index) }
+
+// Subtests returns the post-state entries of the test, sorted by fork
+func (t *StateTest) Subtests() []Subtest {
+	var subtests []Subtest
+	for fork, posts := range t.Post {
+		for i := range posts {
+			subtests = append(subtests, Subtest{Fork: fork, Index: i})
+		}
+	}
+	sort.Slice(subtests, func(i, j int) bool {
+		if subtests[i].Fork != subtests[j].Fork {
+			return subtests[i].Fork < subtests[j].Fork
+		}
+		return subtests[i].Index < subtests[j].Index
+	})
+	return subtests
+}
+
+// Run applies the transaction variant of a subtest to the pre-state and
+// compares the logs and the resulting accounts with the expected
+// post-state. Without a post-state, the state root is compared instead.
+func (t *StateTest) Run(subtest Subtest, config vm.Config) error {
+	posts, ok := t.Post[subtest.Fork]
+	if !ok || subtest.Index >= len(posts) {
+		return fmt.Errorf("no post-state %s", subtest)
+	}
+	post := posts[subtest.Index]
+	if post.ExpectException != "" {
+		return fmt.Errorf("%w: transaction expected to be invalid (%s)", ErrUnsupported, post.ExpectException)
+	}
+	msg, err := t.message(post)
+	if err != nil {
+		return err
+	}
+	b := &block{
+		coinbase:   t.Env.Coinbase,
+		number:     t.Env.Number.Int(),
+		gasLimit:   t.Env.GasLimit.Uint64(),
+		timestamp:  t.Env.Timestamp.Int(),
+		difficulty: nilInt(t.Env.Difficulty),
+		random:     t.Env.Random,
+	}
+	if t.Env.BaseFee != nil {
+		b.baseFee = t.Env.BaseFee.Int()
+	}
+	b.setBlobBaseFee(subtest.Fork, t.Env.ExcessBlobGas)
+	msg.gasPrice = effectiveGasPrice(t.Transaction.GasPrice.Int(), nilInt(t.Transaction.MaxFeePerGas), t.Transaction.MaxPriorityFeePerGas.Int(), b.baseFee)
+
+	if config.ChainConfig == nil {
+		config.ChainConfig = chainConfig(subtest.Fork)
+	}
+	if err := t.checkSender(post.TxBytes, msg.from, config.ChainConfig, b.number); err != nil {
+		return err
+	}
+	statedb := t.Pre.State()
+	r, err := applyMessage(statedb, b, msg, config)
+	if err != nil {
+		return err
+	}
+	if !post.Logs.IsZero() {
+		if hash := logsHash(r.logs); hash != post.Logs {
+			return fmt.Errorf("logs hash %s, want %s", hash.Hex(), post.Logs.Hex())
+		}
+	}
+	if post.State == nil {
+		if root := statedb.Root(); root != post.Hash {
+			return fmt.Errorf("state root %s, want %s", root.Hex(), post.Hash.Hex())
+		}
+		return nil
+	}
+	return post.State.Verify(statedb)
+}
+
+// Definition returns the transaction variant of a subtest as a Definition.
+// An EIP-1559 transaction becomes a legacy one paying its effective gas
+// price.
+func (t *StateTest) Definition(subtest Subtest) (*Definition, error) {
+	posts, ok := t.Post[subtest.Fork]
+	if !ok || subtest.Index >= len(posts) {
+		return nil, fmt.Errorf("no post-state %s", subtest)
+	}
+	msg, err := t.message(posts[subtest.Index])
+	if err != nil {
+		return nil, err
+	}
+	tx := &t.Transaction
+	def := &Definition{
+		Fork: subtest.Fork,
+		Env:  t.Env,
+		Pre:  t.Pre,
+		Tx: TxDefinition{
+			SecretKey: tx.SecretKey,
+			From:      msg.from,
+			To:        msg.to,
+			Nonce:     msg.nonce,
+			Gas:       msg.gas,
+			GasPrice:  effectiveGasPrice(tx.GasPrice.Int(), nilInt(tx.MaxFeePerGas), tx.MaxPriorityFeePerGas.Int(), nilInt(t.Env.BaseFee)),
+			Value:     msg.value,
+			Data:      msg.data,
+		},
+	}
+	if t.Config != nil {
+		def.ChainID = t.Config.ChainID.Int()
+	}
+	return def, nil
+}
+
+// message selects the transaction variant of a post-state entry
+func (t *StateTest) message(post StatePost) (*message, error) {
+	tx := &t.Transaction
+	idx := post.Indexes
+	if idx.Data >= len(tx.Data) || idx.Gas >= len(tx.GasLimit) || idx.Value >= len(tx.Value) {
+		return nil, fmt.Errorf("post-state indexes out of range")
+	}
+	if err := checkBlobHashes(tx.BlobVersionedHashes); err != nil {
+		return nil, err
+	}
+	from := tx.Sender
+	if from.IsZero() && len(tx.SecretKey) > 0 {
+		key, err := crypto.ToPrivateKey(tx.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("transaction secret key: %w", err)
+		}
+		from = crypto.PubkeyToAddress(key.PubKey())
+	}
+	msg := &message{
+		from:  from,
+		nonce: tx.Nonce.Uint64(),
+		gas:   tx.GasLimit[idx.Gas].Uint64(),
+		value: tx.Value[idx.Value].Int(),
+		data:  tx.Data[idx.Data],
+
+		blobHashes:       tx.BlobVersionedHashes,
+		maxFeePerBlobGas: nilInt(tx.MaxFeePerBlobGas),
+	}
+	if tx.To != "" {
+		to, err := common.HexToAddressUnchecked(tx.To)
+		if err != nil {
+			return nil, fmt.Errorf("transaction recipient: %w", err)
+		}
+		msg.to = &to
+	}
+	return msg, nil
+}
+
+// checkSender recovers the sender of a signed legacy transaction and
+// checks it is the one the fixture names, under the chain id of the
+// fixture. Typed transactions are not checked.
+func (t *StateTest) checkSender(txBytes []byte, from common.Address, config *params.ChainConfig, number *big.Int) error {
+	if len(txBytes) == 0 || txBytes[0] < 0xc0 || config == nil {
+		return nil
+	}
+	tx, err := types.DecodeLegacyTx(txBytes)
+	if err != nil {
+		return err
+	}
+	chain := *config
+	if t.Config != nil {
+		chain.ChainID = t.Config.ChainID.Int()
+	}
+	sender, err := types.Sender(&chain, number, tx)
+	if err != nil {
+		return fmt.Errorf("transaction sender: %w", err)
+	}
+	if sender != from {
+		return fmt.Errorf("transaction signed by %s, sender %s", sender.Hex(), from.Hex())
+	}
+	return nil
+}
+
+// nilInt returns the value of an optional number
+func nilInt(n *Number) *big.Int {
+	if n == nil {
+		return nil
+	}
+	return n.Int()
+}
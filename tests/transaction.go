@@ -0,0 +1,259 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/eip4844"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/crypto/kzg4844"
+	"github.com/nutcas3/evm-golang/params"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// Intrinsic gas of a transaction and its call data
+const (
+	txGas            = 21000
+	txDataZeroGas    = 4
+	txDataNonZeroGas = 16
+)
+
+// message is a transaction as applied to the state
+type message struct {
+	from     common.Address
+	to       *common.Address // nil for contract creation
+	nonce    uint64
+	gas      uint64
+	gasPrice *big.Int // effective gas price
+	value    *big.Int
+	data     []byte
+
+	blobHashes       []common.Hash
+	maxFeePerBlobGas *big.Int
+}
+
+// block is the context transactions execute in
+type block struct {
+	coinbase    common.Address
+	number      *big.Int
+	gasLimit    uint64
+	timestamp   *big.Int
+	baseFee     *big.Int     // nil before London
+	blobBaseFee *big.Int     // nil before Cancun
+	difficulty  *big.Int     // DIFFICULTY before the merge
+	random      *common.Hash // PREVRANDAO after the merge
+}
+
+// setBlobBaseFee derives the blob base fee of a block from its excess blob
+// gas and the blob parameters of fork, if it has them
+func (b *block) setBlobBaseFee(fork string, excessBlobGas *Number) {
+	config := blobConfig(fork)
+	if config == nil || excessBlobGas == nil {
+		return
+	}
+	b.blobBaseFee = eip4844.CalcBlobFee(config, excessBlobGas.Uint64())
+}
+
+// blobConfig returns the blob parameters of a fixture fork, nil before
+// Cancun or for transition forks
+func blobConfig(fork string) *params.BlobConfig {
+	config := chainConfig(fork)
+	if config == nil {
+		return nil
+	}
+	return config.BlobConfig(fork)
+}
+
+// fixtureForks lists the forks fixtures are filled for, in order
+var fixtureForks = []string{
+	"Frontier", "Homestead", "TangerineWhistle", "SpuriousDragon", "Byzantium", "Constantinople",
+	"Petersburg", "Istanbul", "Berlin", "London", "Paris", "Shanghai", "Cancun", "Prague",
+}
+
+// chainConfig returns a chain config with every fork up to fork active from
+// genesis, nil for transition forks such as ShanghaiToCancunAtTime15k.
+// EEST calls Paris "Merge".
+func chainConfig(fork string) *params.ChainConfig {
+	if fork == "Merge" {
+		fork = "Paris"
+	}
+	index := -1
+	for i, f := range fixtureForks {
+		if f == fork {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+	zero := uint64(0)
+	// fixtures follow mainnet, which accepts legacy signatures without a
+	// chain id after EIP-155
+	c := &params.ChainConfig{ChainID: big.NewInt(1), AllowUnprotectedTxs: true}
+	for i, field := range []**big.Int{
+		nil, &c.HomesteadBlock, &c.TangerineWhistleBlock, &c.SpuriousDragonBlock, &c.ByzantiumBlock,
+		&c.ConstantinopleBlock, &c.PetersburgBlock, &c.IstanbulBlock, &c.BerlinBlock, &c.LondonBlock, &c.ParisBlock,
+	} {
+		if field != nil && i <= index {
+			*field = new(big.Int)
+		}
+	}
+	for i, field := range []**uint64{&c.ShanghaiTime, &c.CancunTime, &c.PragueTime} {
+		if i+11 <= index {
+			*field = &zero
+		}
+	}
+	cancun, prague := *params.DefaultCancunBlobConfig, *params.DefaultPragueBlobConfig
+	c.BlobSchedule = map[string]*params.BlobConfig{"cancun": &cancun, "prague": &prague}
+	return c
+}
+
+// effectiveGasPrice returns the price paid per gas by a legacy or EIP-1559
+// transaction
+func effectiveGasPrice(gasPrice, maxFee, maxPriority, baseFee *big.Int) *big.Int {
+	if maxFee == nil {
+		return gasPrice
+	}
+	price := new(big.Int).Add(baseFee, maxPriority)
+	if price.Cmp(maxFee) > 0 {
+		price.Set(maxFee)
+	}
+	return price
+}
+
+// refundQuotient returns the divisor of the gas used capping refunds,
+// that of the latest fork without a chain config
+func refundQuotient(config *params.ChainConfig, number *big.Int) uint64 {
+	if config == nil {
+		return 5
+	}
+	return config.RefundQuotient(number)
+}
+
+// intrinsicGas is the gas charged before any code runs
+func intrinsicGas(data []byte) uint64 {
+	gas := uint64(txGas)
+	for _, b := range data {
+		if b == 0 {
+			gas += txDataZeroGas
+		} else {
+			gas += txDataNonZeroGas
+		}
+	}
+	return gas
+}
+
+// checkBlobHashes checks the versioned hashes of a blob transaction.
+// Fixtures carry no sidecars, so only the hash versions can be verified.
+func checkBlobHashes(hashes []common.Hash) error {
+	for i, hash := range hashes {
+		if !kzg4844.IsValidVersionedHash(hash) {
+			return fmt.Errorf("blob versioned hash %d: unsupported version %#x", i, hash[0])
+		}
+	}
+	return nil
+}
+
+// receipt is the outcome of an applied message
+type receipt struct {
+	logs    []*types.Log // logs of a successful execution
+	gasUsed uint64       // gas paid for, including intrinsic gas
+	err     error        // execution error, nil on success
+}
+
+// applyMessage buys gas, increments the sender nonce, transfers the value,
+// runs the recipient's code and pays the fees. Contract creation is not
+// supported.
+func applyMessage(statedb *state.StateDB, b *block, msg *message, config vm.Config) (*receipt, error) {
+	if msg.to == nil {
+		return nil, fmt.Errorf("%w: contract creation transactions", ErrUnsupported)
+	}
+	if nonce := statedb.GetNonce(msg.from); nonce != msg.nonce {
+		return nil, fmt.Errorf("%w: nonce %d, sender nonce is %d", ErrUnsupported, msg.nonce, nonce)
+	}
+	if code := statedb.GetCode(msg.from); len(code) > 0 {
+		if _, ok := types.ParseDelegation(code); !ok {
+			return nil, fmt.Errorf("%w: sender %s has code (EIP-3607)", ErrUnsupported, msg.from.Hex())
+		}
+	}
+	intrinsic := intrinsicGas(msg.data)
+	if msg.gas < intrinsic {
+		return nil, fmt.Errorf("%w: intrinsic gas %d exceeds gas limit %d", ErrUnsupported, intrinsic, msg.gas)
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(msg.gas), msg.gasPrice)
+	if len(msg.blobHashes) > 0 {
+		if b.blobBaseFee == nil {
+			return nil, fmt.Errorf("%w: blob transaction without a blob base fee", ErrUnsupported)
+		}
+		if msg.maxFeePerBlobGas == nil || msg.maxFeePerBlobGas.Cmp(b.blobBaseFee) < 0 {
+			return nil, fmt.Errorf("%w: max fee per blob gas below the blob base fee %s", ErrUnsupported, b.blobBaseFee)
+		}
+		// the blob fee is burned along with the gas bought
+		blobGas := uint64(len(msg.blobHashes)) * eip4844.GasPerBlob
+		cost.Add(cost, new(big.Int).Mul(new(big.Int).SetUint64(blobGas), b.blobBaseFee))
+	}
+	if statedb.GetBalance(msg.from).Cmp(new(big.Int).Add(cost, msg.value)) < 0 {
+		return nil, fmt.Errorf("%w: sender cannot pay for gas and value", ErrUnsupported)
+	}
+	statedb.SubBalance(msg.from, cost)
+	statedb.SetNonce(msg.from, msg.nonce+1)
+
+	r := &receipt{gasUsed: intrinsic}
+	snapshot := statedb.Snapshot()
+	if msg.value.Sign() > 0 {
+		statedb.SubBalance(msg.from, msg.value)
+		statedb.AddBalance(*msg.to, msg.value)
+	}
+	if len(statedb.GetCode(*msg.to)) > 0 {
+		ctx := &vm.Context{
+			BlockNumber: b.number,
+			Timestamp:   b.timestamp,
+			Sender:      msg.from,
+			GasLimit:    b.gasLimit,
+			Gas:         msg.gas - intrinsic,
+			GasPrice:    msg.gasPrice,
+			Random:      b.random,
+			Difficulty:  b.difficulty,
+		}
+		evm := vm.NewEVM(ctx, statedb, config)
+		evm.SetInput(msg.data)
+		evm.SetValue(msg.value)
+		result := evm.Run(context.Background(), *msg.to)
+		r.gasUsed += result.GasUsed
+		r.gasUsed -= min(result.Refund, r.gasUsed/refundQuotient(config.ChainConfig, b.number))
+		r.err = result.Err
+		if result.Failed() {
+			statedb.RevertToSnapshot(snapshot)
+		} else {
+			r.logs = result.Logs
+		}
+	}
+
+	refund := new(big.Int).Mul(new(big.Int).SetUint64(msg.gas-r.gasUsed), msg.gasPrice)
+	statedb.AddBalance(msg.from, refund)
+	tip := new(big.Int).Set(msg.gasPrice)
+	if b.baseFee != nil {
+		tip.Sub(tip, b.baseFee)
+	}
+	statedb.AddBalance(b.coinbase, tip.Mul(tip, new(big.Int).SetUint64(r.gasUsed)))
+	return r, nil
+}
+
+// logsHash returns the hash of the RLP encoding of logs, as recorded in
+// state test post-states
+func logsHash(logs []*types.Log) common.Hash {
+	items := make([][]byte, len(logs))
+	for i, log := range logs {
+		topics := make([][]byte, len(log.Topics))
+		for j, topic := range log.Topics {
+			topics[j] = rlp.EncodeBytes(topic[:])
+		}
+		items[i] = rlp.EncodeList(rlp.EncodeBytes(log.Address[:]), rlp.EncodeList(topics...), rlp.EncodeBytes(log.Data))
+	}
+	return crypto.Keccak256Hash(rlp.EncodeList(items...))
+}
@@ -0,0 +1,111 @@
+package tracers
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// StorageChange is a storage slot written during a frame
+type StorageChange struct {
+	Address common.Address
+	Key     common.Hash
+	Prev    common.Hash
+	Value   common.Hash
+}
+
+// CallFrame is a node of the call tree recorded by CallTracer
+type CallFrame struct {
+	Depth          int
+	From           common.Address
+	To             common.Address
+	Input          []byte
+	Output         []byte
+	Gas            uint64 // gas available when the frame started
+	GasUsed        uint64 // gas used by the frame, including its calls
+	Err            error
+	Logs           []*types.Log
+	StorageChanges []StorageChange
+	Calls          []*CallFrame
+	Reentrancy     *Reentrancy // set by ReentrancyTracer.Annotate
+}
+
+// SelfGas returns the gas used by the frame itself, excluding its calls
+func (f *CallFrame) SelfGas() uint64 {
+	gas := f.GasUsed
+	for _, call := range f.Calls {
+		if call.GasUsed > gas {
+			return 0
+		}
+		gas -= call.GasUsed
+	}
+	return gas
+}
+
+// CallTracer records the tree of call frames of an execution along with
+// the logs and storage writes of each frame
+type CallTracer struct {
+	root   *CallFrame
+	frames []*CallFrame // frames currently executing, innermost last
+}
+
+// NewCallTracer creates an empty call tracer
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (t *CallTracer) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter:         t.onEnter,
+		OnExit:          t.onExit,
+		OnLog:           t.onLog,
+		OnStorageChange: t.onStorageChange,
+	}
+}
+
+// Root returns the outermost frame of the latest execution, nil if nothing
+// was traced
+func (t *CallTracer) Root() *CallFrame { return t.root }
+
+func (t *CallTracer) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	frame := &CallFrame{
+		Depth: depth,
+		From:  from,
+		To:    to,
+		Input: append([]byte(nil), input...),
+		Gas:   gas,
+	}
+	if len(t.frames) == 0 {
+		t.root = frame
+	} else {
+		parent := t.frames[len(t.frames)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	t.frames = append(t.frames, frame)
+}
+
+func (t *CallTracer) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	frame.Output = append([]byte(nil), output...)
+	frame.GasUsed = gasUsed
+	frame.Err = err
+}
+
+func (t *CallTracer) onLog(log *types.Log) {
+	if len(t.frames) > 0 {
+		frame := t.frames[len(t.frames)-1]
+		frame.Logs = append(frame.Logs, log)
+	}
+}
+
+func (t *CallTracer) onStorageChange(address common.Address, key, prev, value common.Hash) {
+	if len(t.frames) > 0 {
+		frame := t.frames[len(t.frames)-1]
+		frame.StorageChanges = append(frame.StorageChanges, StorageChange{Address: address, Key: key, Prev: prev, Value: value})
+	}
+}
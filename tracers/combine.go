@@ -0,0 +1,61 @@
+package tracers
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Combine returns hooks that invoke each of the given hooks in order, so
+// several tracers can observe the same execution. Nil hooks are skipped.
+func Combine(hooks ...*vm.Hooks) *vm.Hooks {
+	var list []*vm.Hooks
+	for _, h := range hooks {
+		if h != nil {
+			list = append(list, h)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	if len(list) == 1 {
+		return list[0]
+	}
+	return &vm.Hooks{
+		OnEnter: func(depth int, from, to common.Address, input []byte, gas uint64) {
+			for _, h := range list {
+				if h.OnEnter != nil {
+					h.OnEnter(depth, from, to, input, gas)
+				}
+			}
+		},
+		OnExit: func(depth int, output []byte, gasUsed uint64, err error) {
+			for _, h := range list {
+				if h.OnExit != nil {
+					h.OnExit(depth, output, gasUsed, err)
+				}
+			}
+		},
+		OnOpcode: func(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+			for _, h := range list {
+				if h.OnOpcode != nil {
+					h.OnOpcode(pc, op, gas, scope, depth)
+				}
+			}
+		},
+		OnLog: func(log *types.Log) {
+			for _, h := range list {
+				if h.OnLog != nil {
+					h.OnLog(log)
+				}
+			}
+		},
+		OnStorageChange: func(address common.Address, key, prev, value common.Hash) {
+			for _, h := range list {
+				if h.OnStorageChange != nil {
+					h.OnStorageChange(address, key, prev, value)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,268 @@
+package tracers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+)
+
+// ContractCoverage is the execution count of every instruction of a piece
+// of code
+type ContractCoverage struct {
+	Address  common.Address // first address the code executed at
+	CodeHash common.Hash
+	Code     []byte
+	Hits     map[uint64]uint64 // by pc, instructions never executed are absent
+}
+
+// Instructions returns the number of instructions of the code that were
+// executed and the number of instructions
+func (c *ContractCoverage) Instructions() (hit, total int) {
+	for _, in := range asm.Disassemble(c.Code) {
+		total++
+		if c.Hits[in.PC] > 0 {
+			hit++
+		}
+	}
+	return hit, total
+}
+
+// Blocks returns the number of basic blocks of the code that were entered
+// and the number of basic blocks
+func (c *ContractCoverage) Blocks() (hit, total int) {
+	for start := range asm.BuildCFG(c.Code).Blocks {
+		total++
+		if c.Hits[start] > 0 {
+			hit++
+		}
+	}
+	return hit, total
+}
+
+// Coverage records which instructions of each contract execute, across
+// every execution its hooks are installed in, to measure the bytecode
+// coverage of a test suite. Contracts are told apart by code hash, so the
+// same code deployed at several addresses is reported once.
+type Coverage struct {
+	dec       *Decoder
+	contracts map[common.Hash]*ContractCoverage
+	frames    []*ContractCoverage // per frame, nil until its first instruction
+}
+
+// NewCoverage creates a coverage tracer. dec, if not nil, labels the
+// contracts of the reports.
+func NewCoverage(dec *Decoder) *Coverage {
+	return &Coverage{dec: dec, contracts: make(map[common.Hash]*ContractCoverage)}
+}
+
+// Hooks returns the hooks to install in vm.Config. They may be installed
+// in any number of executions, one at a time.
+func (c *Coverage) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter: func(depth int, from, to common.Address, input []byte, gas uint64) {
+			c.frames = append(c.frames, nil)
+		},
+		OnExit: func(depth int, output []byte, gasUsed uint64, err error) {
+			if len(c.frames) > 0 {
+				c.frames = c.frames[:len(c.frames)-1]
+			}
+		},
+		OnOpcode: c.onOpcode,
+	}
+}
+
+func (c *Coverage) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	if len(c.frames) == 0 {
+		return
+	}
+	frame := &c.frames[len(c.frames)-1]
+	if *frame == nil {
+		code := scope.Code()
+		hash := crypto.Keccak256Hash(code)
+		if c.contracts[hash] == nil {
+			c.contracts[hash] = &ContractCoverage{
+				Address:  scope.Address(),
+				CodeHash: hash,
+				Code:     append([]byte(nil), code...),
+				Hits:     make(map[uint64]uint64),
+			}
+		}
+		*frame = c.contracts[hash]
+	}
+	(*frame).Hits[pc]++
+}
+
+// Contracts returns the coverage of every contract executed so far, sorted
+// by address
+func (c *Coverage) Contracts() []*ContractCoverage {
+	contracts := make([]*ContractCoverage, 0, len(c.contracts))
+	for _, cc := range c.contracts {
+		contracts = append(contracts, cc)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		if c := contracts[i].Address.Cmp(contracts[j].Address); c != 0 {
+			return c < 0
+		}
+		return contracts[i].CodeHash.Cmp(contracts[j].CodeHash) < 0
+	})
+	return contracts
+}
+
+// WriteLCOV writes the coverage in LCOV tracefile format, with one source
+// file per contract, named by its label or address, and its pcs as line
+// numbers. Each JUMPI is a branch with its taken and not taken outcomes
+// counted by how often their target block was entered from any jump or
+// fall-through.
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	var buf bytes.Buffer
+	for _, cc := range c.Contracts() {
+		fmt.Fprintf(&buf, "TN:\nSF:%s\n", c.dec.Address(cc.Address))
+		hit, total := 0, 0
+		for _, in := range asm.Disassemble(cc.Code) {
+			count := cc.Hits[in.PC]
+			fmt.Fprintf(&buf, "DA:%d,%d\n", in.PC, count)
+			total++
+			if count > 0 {
+				hit++
+			}
+		}
+		branchesHit, branches := 0, 0
+		cfg := asm.BuildCFG(cc.Code)
+		starts := make([]uint64, 0, len(cfg.Blocks))
+		for start := range cfg.Blocks {
+			starts = append(starts, start)
+		}
+		sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+		for _, start := range starts {
+			b := cfg.Blocks[start]
+			last := b.Instructions[len(b.Instructions)-1]
+			if last.Op != 0x57 { // JUMPI
+				continue
+			}
+			for i, edge := range b.Succs {
+				taken := "-"
+				if cc.Hits[last.PC] > 0 {
+					taken = fmt.Sprint(cc.Hits[edge.To])
+				}
+				fmt.Fprintf(&buf, "BRDA:%d,0,%d,%s\n", last.PC, i, taken)
+				branches++
+				if cc.Hits[edge.To] > 0 {
+					branchesHit++
+				}
+			}
+		}
+		fmt.Fprintf(&buf, "BRF:%d\nBRH:%d\nLF:%d\nLH:%d\nend_of_record\n", branches, branchesHit, total, hit)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteSummary writes a table of the instruction and basic block coverage
+// of each contract
+func (c *Coverage) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "contract\tinstructions\tblocks")
+	for _, cc := range c.Contracts() {
+		ih, it := cc.Instructions()
+		bh, bt := cc.Blocks()
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.dec.Address(cc.Address), percent(ih, it), percent(bh, bt))
+	}
+	return tw.Flush()
+}
+
+// percent formats hit out of total as e.g. "75.0% (3/4)"
+func percent(hit, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%% (%d/%d)", 100*float64(hit)/float64(total), hit, total)
+}
+
+// CompiledContract is the runtime code of a compiled contract and its solc
+// source map
+type CompiledContract struct {
+	Code      []byte
+	SourceMap string // srcmap-runtime
+}
+
+// SourceFile is a source file of a compilation, at the index solc gave it
+type SourceFile struct {
+	Path    string
+	Content []byte
+}
+
+// LineCoverage is the execution count of the lines of a source file that
+// instructions were compiled from
+type LineCoverage struct {
+	Path string
+	Hits map[int]uint64 // by 1-based line; lines of instructions never executed hold zero
+}
+
+// SourceLines maps the coverage of the given contracts through their
+// source maps onto the lines of sources, indexed as in the compilation.
+// A line counts as often as the most executed instruction starting on it.
+// Contracts are matched by code hash, so code whose immutables were set by
+// its constructor does not match its compiler output. Instructions
+// generated by the compiler, or mapped to sources not given, are left out.
+func (c *Coverage) SourceLines(contracts []CompiledContract, sources []SourceFile) ([]*LineCoverage, error) {
+	lines := make([]*LineCoverage, len(sources))
+	for i, src := range sources {
+		lines[i] = &LineCoverage{Path: src.Path, Hits: make(map[int]uint64)}
+	}
+	for _, compiled := range contracts {
+		ranges, err := asm.ParseSourceMap(compiled.SourceMap)
+		if err != nil {
+			return nil, err
+		}
+		var hits map[uint64]uint64
+		if cc := c.contracts[crypto.Keccak256Hash(compiled.Code)]; cc != nil {
+			hits = cc.Hits
+		}
+		for pc, r := range asm.SourceRangesByPC(compiled.Code, ranges) {
+			if r.File < 0 || r.File >= len(sources) || r.Start > len(sources[r.File].Content) {
+				continue
+			}
+			line := 1 + bytes.Count(sources[r.File].Content[:r.Start], []byte{'\n'})
+			if count := hits[pc]; count >= lines[r.File].Hits[line] {
+				lines[r.File].Hits[line] = count
+			}
+		}
+	}
+	var covered []*LineCoverage
+	for _, l := range lines {
+		if len(l.Hits) > 0 {
+			covered = append(covered, l)
+		}
+	}
+	return covered, nil
+}
+
+// WriteLineLCOV writes line coverage in LCOV tracefile format
+func WriteLineLCOV(w io.Writer, files []*LineCoverage) error {
+	var buf bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&buf, "TN:\nSF:%s\n", f.Path)
+		lines := make([]int, 0, len(f.Hits))
+		for line := range f.Hits {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		hit := 0
+		for _, line := range lines {
+			fmt.Fprintf(&buf, "DA:%d,%d\n", line, f.Hits[line])
+			if f.Hits[line] > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(&buf, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,110 @@
+package tracers
+
+import (
+	"strings"
+
+	"github.com/nutcas3/evm-golang/abi"
+	"github.com/nutcas3/evm-golang/abi/fourbyte"
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+// Decoder renders addresses, call data and logs in human-readable form for
+// tracer output. The contract ABI is preferred; the signature directory
+// names selectors and topics the ABI does not know. Any field may be nil,
+// as may the Decoder itself.
+type Decoder struct {
+	ABI        *abi.ABI
+	Signatures *fourbyte.Directory
+	Labels     Labels
+}
+
+// Address returns the label of address, or its checksummed hex form
+func (d *Decoder) Address(address common.Address) string {
+	if d != nil {
+		if name, ok := d.Labels[address]; ok {
+			return name
+		}
+	}
+	return address.Hex()
+}
+
+// FunctionName returns the name of the function called with input, or ""
+// if it is unknown
+func (d *Decoder) FunctionName(input []byte) string {
+	if m := d.method(input); m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// Call renders call data as name(arg=value, ...) when the function is
+// known, otherwise as hex
+func (d *Decoder) Call(input []byte) string {
+	if m := d.method(input); m != nil {
+		if values, err := m.DecodeInput(input); err == nil {
+			return formatArguments(m.Name, m.Inputs, values)
+		}
+		return m.Signature() + " " + hexutil.Encode(input[4:])
+	}
+	if len(input) == 0 {
+		return ""
+	}
+	return hexutil.Encode(input)
+}
+
+// Event renders a log as name(arg=value, ...), or returns "" if its event
+// is unknown. Events only known by their text signature are shown undecoded
+// since the signature does not tell which arguments are indexed.
+func (d *Decoder) Event(topics []common.Hash, data []byte) string {
+	if d == nil || len(topics) == 0 {
+		return ""
+	}
+	if d.ABI != nil {
+		if e, ok := d.ABI.EventByID(topics[0]); ok {
+			if values, err := e.Decode(topics, data); err == nil {
+				return formatArguments(e.Name, e.Inputs, values)
+			}
+		}
+	}
+	if d.Signatures != nil {
+		if sig, ok := d.Signatures.Event(topics[0]); ok {
+			return sig
+		}
+	}
+	return ""
+}
+
+// method returns the ABI method, or one built from the directory's text
+// signature, whose selector starts input
+func (d *Decoder) method(input []byte) *abi.Method {
+	if d == nil || len(input) < 4 {
+		return nil
+	}
+	if d.ABI != nil {
+		if m, ok := d.ABI.MethodBySelector(input); ok {
+			return m
+		}
+	}
+	if d.Signatures != nil {
+		var selector [4]byte
+		copy(selector[:], input)
+		if sig, ok := d.Signatures.Function(selector); ok {
+			if m, err := abi.NewMethod(sig); err == nil {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+func formatArguments(name string, args []abi.Argument, values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = abi.FormatValue(v)
+		if args[i].Name != "" {
+			parts[i] = args[i].Name + "=" + parts[i]
+		}
+	}
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
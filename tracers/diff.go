@@ -0,0 +1,137 @@
+package tracers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxTraceLine bounds the length of a single struct log line, large enough
+// for entries carrying the full memory of a frame
+const maxTraceLine = 64 << 20
+
+// Divergence describes the first step at which two traces differ
+type Divergence struct {
+	Step  int        // index of the step, counted from 0
+	Field string     // "pc", "op", "gas", "gasCost", "depth", "stack", or "length" if one trace ended
+	A, B  *StructLog // the diverging steps, one of them nil if its trace ended
+}
+
+func (d *Divergence) String() string {
+	if d.Field == "length" {
+		ended := "a"
+		if d.B == nil {
+			ended = "b"
+		}
+		return fmt.Sprintf("trace %s ends after %d steps", ended, d.Step)
+	}
+	return fmt.Sprintf("traces diverge at step %d: %s differs\n  a: %s\n  b: %s", d.Step, d.Field, d.A, d.B)
+}
+
+func (l *StructLog) String() string {
+	words := make([]string, len(l.Stack))
+	for i := range l.Stack {
+		words[i] = l.Stack[i].String()
+	}
+	s := fmt.Sprintf("pc=%d op=%s gas=%d gasCost=%d depth=%d stack=[%s]", l.Pc, l.Op, uint64(l.Gas), uint64(l.GasCost), l.Depth, strings.Join(words, " "))
+	if l.Err != "" {
+		s += " error=" + l.Err
+	}
+	return s
+}
+
+// StructLogReader reads the steps of a JSON-lines struct log, as written by
+// JSONLogger or geth's --json tracer, skipping summary lines
+type StructLogReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewStructLogReader creates a reader over r
+func NewStructLogReader(r io.Reader) *StructLogReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxTraceLine)
+	return &StructLogReader{scanner: scanner}
+}
+
+// Next returns the next step, or io.EOF at the end of the trace
+func (r *StructLogReader) Next() (*StructLog, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+		if _, ok := fields["pc"]; !ok {
+			continue
+		}
+		var step StructLog
+		if err := json.Unmarshal(line, &step); err != nil {
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+		return &step, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// DiffTraces walks two struct logs in lockstep and returns the first
+// divergence, or nil if they are identical. Stacks are only compared when
+// both traces recorded them.
+func DiffTraces(a, b io.Reader) (*Divergence, error) {
+	ra, rb := NewStructLogReader(a), NewStructLogReader(b)
+	for step := 0; ; step++ {
+		la, err := ra.Next()
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("trace a: %v", err)
+		}
+		lb, err := rb.Next()
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("trace b: %v", err)
+		}
+		if la == nil && lb == nil {
+			return nil, nil
+		}
+		if la == nil || lb == nil {
+			return &Divergence{Step: step, Field: "length", A: la, B: lb}, nil
+		}
+		if field := firstDifference(la, lb); field != "" {
+			return &Divergence{Step: step, Field: field, A: la, B: lb}, nil
+		}
+	}
+}
+
+func firstDifference(a, b *StructLog) string {
+	switch {
+	case a.Pc != b.Pc:
+		return "pc"
+	case a.Op != b.Op:
+		return "op"
+	case a.Depth != b.Depth:
+		return "depth"
+	case a.Gas != b.Gas:
+		return "gas"
+	case a.GasCost != b.GasCost:
+		return "gasCost"
+	}
+	if a.Stack == nil || b.Stack == nil {
+		return ""
+	}
+	if len(a.Stack) != len(b.Stack) {
+		return "stack"
+	}
+	for i := range a.Stack {
+		if a.Stack[i].ToInt().Cmp(b.Stack[i].ToInt()) != 0 {
+			return "stack"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,324 @@
+// Package erc4337 checks ERC-4337 user operation validation against the
+// ERC-7562 rules bundlers enforce: banned opcodes, storage access limited
+// to the sender and its associated slots, calls to contracts only and no
+// out-of-gas reverts. Its tracer turns the interpreter into a bundler
+// validation backend.
+package erc4337
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/word"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/simulator"
+	"github.com/nutcas3/evm-golang/tracers"
+)
+
+// ruleOutOfGas forbids validation frames from running out of gas
+const ruleOutOfGas = "OP-020"
+
+// maxAssociatedOffset is how far past keccak(address || x) a slot may be
+// and still be associated with the address, covering struct members
+const maxAssociatedOffset = 128
+
+// depositToSelector is the selector of EntryPoint.depositTo(address), the
+// only EntryPoint function validation may call
+var depositToSelector = []byte{0xb7, 0x60, 0xfa, 0xf9}
+
+// bannedOpcodes may not be executed during validation (OP-011)
+var bannedOpcodes = map[vm.OpCode]bool{
+	0x3a: true, // GASPRICE
+	0x45: true, // GASLIMIT
+	0x44: true, // PREVRANDAO
+	0x42: true, // TIMESTAMP
+	0x48: true, // BASEFEE
+	0x40: true, // BLOCKHASH
+	0x43: true, // NUMBER
+	0x47: true, // SELFBALANCE
+	0x31: true, // BALANCE
+	0x32: true, // ORIGIN
+	0x41: true, // COINBASE
+	0x49: true, // BLOBHASH
+	0x4a: true, // BLOBBASEFEE
+	0xfe: true, // INVALID
+	0xff: true, // SELFDESTRUCT
+}
+
+// callOpcodes are the instructions GAS may precede (OP-012)
+var callOpcodes = map[vm.OpCode]bool{0xf1: true, 0xf2: true, 0xf4: true, 0xfa: true}
+
+// Entities are the parties whose code runs during the validation of a user
+// operation. Factory and Paymaster are zero when the operation has none.
+type Entities struct {
+	EntryPoint common.Address
+	Sender     common.Address
+	Factory    common.Address
+	Paymaster  common.Address
+	Staked     map[common.Address]bool // entities with enough stake in the EntryPoint
+}
+
+func (e *Entities) isEntity(addr common.Address) bool {
+	return !addr.IsZero() && (addr == e.Sender || addr == e.Factory || addr == e.Paymaster)
+}
+
+// Violation is a breach of an ERC-7562 validation rule
+type Violation struct {
+	Rule    string         // rule id, such as OP-011 or STO-021
+	Entity  common.Address // entity whose validation broke the rule
+	Address common.Address // contract executing the offending instruction
+	Depth   int
+	PC      uint64
+	Op      vm.OpCode
+	Detail  string
+}
+
+func (v *Violation) String() string {
+	where := fmt.Sprintf("%s at pc %d of %s", v.Op, v.PC, v.Address.Hex())
+	if v.Rule == ruleOutOfGas {
+		where = "in " + v.Address.Hex() // raised on frame exit, not by an instruction
+	}
+	return fmt.Sprintf("%s: %s (validating %s): %s", v.Rule, where, v.Entity.Hex(), v.Detail)
+}
+
+// Tracer records the rule violations of a validation run. Install its
+// Hooks in vm.Config while running the EntryPoint's validation, or direct
+// calls to the entities' validation functions.
+type Tracer struct {
+	// OnViolation, if set, is called as soon as a violation is detected
+	OnViolation func(*Violation)
+
+	statedb    vm.StateDB
+	entities   Entities
+	frames     []common.Address
+	keccaks    map[common.Hash]common.Address // keccak(address || x) results by hash
+	created    bool                           // the factory already used CREATE or CREATE2
+	outOfGas   error                          // last out-of-gas error reported, seen again in the callers' exits
+	seen       map[string]bool
+	violations []*Violation
+}
+
+// NewTracer creates a validation tracer for a user operation. statedb is
+// read to tell contracts from accounts without code.
+func NewTracer(statedb vm.StateDB, entities Entities) *Tracer {
+	return &Tracer{
+		statedb:  statedb,
+		entities: entities,
+		keccaks:  make(map[common.Hash]common.Address),
+		seen:     make(map[string]bool),
+	}
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (t *Tracer) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter:  t.onEnter,
+		OnExit:   t.onExit,
+		OnOpcode: t.onOpcode,
+	}
+}
+
+// Violations returns the violations detected so far, each rule reported
+// once per instruction
+func (t *Tracer) Violations() []*Violation { return t.violations }
+
+// entity returns the entity whose validation is running: the outermost
+// entity among the executing frames, or the outermost frame
+func (t *Tracer) entity() common.Address {
+	for _, addr := range t.frames {
+		if t.entities.isEntity(addr) {
+			return addr
+		}
+	}
+	if len(t.frames) > 0 {
+		return t.frames[0]
+	}
+	return common.Address{}
+}
+
+func (t *Tracer) report(rule string, scope *vm.ScopeContext, pc uint64, op vm.OpCode, depth int, detail string) {
+	key := fmt.Sprintf("%s/%s/%d", rule, scope.Address().Hex(), pc)
+	if t.seen[key] {
+		return
+	}
+	t.seen[key] = true
+	v := &Violation{
+		Rule:    rule,
+		Entity:  t.entity(),
+		Address: scope.Address(),
+		Depth:   depth,
+		PC:      pc,
+		Op:      op,
+		Detail:  detail,
+	}
+	t.violations = append(t.violations, v)
+	if t.OnViolation != nil {
+		t.OnViolation(v)
+	}
+}
+
+func (t *Tracer) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	t.frames = append(t.frames, to)
+}
+
+func (t *Tracer) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	if err != nil && err != t.outOfGas && strings.Contains(err.Error(), "out of gas") && len(t.frames) > 0 {
+		// reported against the frame that ran out, which has no scope left
+		t.outOfGas = err
+		v := &Violation{Rule: ruleOutOfGas, Entity: t.entity(), Address: t.frames[len(t.frames)-1], Depth: depth, Detail: "out of gas"}
+		t.violations = append(t.violations, v)
+		if t.OnViolation != nil {
+			t.OnViolation(v)
+		}
+	}
+	if len(t.frames) > 0 {
+		t.frames = t.frames[:len(t.frames)-1]
+	}
+}
+
+func (t *Tracer) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	switch {
+	case bannedOpcodes[op]:
+		t.report("OP-011", scope, pc, op, depth, "opcode banned during validation")
+	case op == 0x5a: // GAS
+		code := scope.Code()
+		if pc+1 >= uint64(len(code)) || !callOpcodes[vm.OpCode(code[pc+1])] {
+			t.report("OP-012", scope, pc, op, depth, "GAS not followed by a call")
+		}
+	case op == 0xf0 || op == 0xf5: // CREATE, CREATE2
+		if t.entity() != t.entities.Factory || t.entities.Factory.IsZero() || t.created {
+			t.report("OP-031", scope, pc, op, depth, "only the factory may create a contract, once")
+		}
+		t.created = true
+	case op == 0x20: // KECCAK256
+		t.recordKeccak(scope)
+	case op == 0x54: // SLOAD
+		if n := scope.StackLen(); n >= 1 {
+			t.checkStorage(scope, pc, op, depth, scope.StackAt(n-1), false)
+		}
+	case op == 0x55: // SSTORE
+		if n := scope.StackLen(); n >= 2 {
+			t.checkStorage(scope, pc, op, depth, scope.StackAt(n-1), true)
+		}
+	case callOpcodes[op]:
+		t.checkCall(scope, pc, op, depth)
+	}
+}
+
+// recordKeccak remembers hashes of an address followed by more data, the
+// base slots of mappings keyed by that address
+func (t *Tracer) recordKeccak(scope *vm.ScopeContext) {
+	n := scope.StackLen()
+	if n < 2 {
+		return
+	}
+	offset, size := scope.StackAt(n-1), scope.StackAt(n-2)
+	memory := scope.Memory()
+	if !offset.IsUint64() || !size.IsUint64() || size.Uint64() < 32 || offset.Uint64()+size.Uint64() > uint64(len(memory)) {
+		return
+	}
+	data := memory[offset.Uint64() : offset.Uint64()+size.Uint64()]
+	if !bytes.Equal(data[:12], make([]byte, 12)) {
+		return
+	}
+	t.keccaks[crypto.Keccak256Hash(data)] = common.BytesToAddress(data[12:32])
+}
+
+// associated reports whether slot is associated with addr: the address
+// itself, or within maxAssociatedOffset of keccak(addr || x)
+func (t *Tracer) associated(slot *big.Int, addr common.Address) bool {
+	if addr.IsZero() {
+		return false
+	}
+	if slot.Cmp(addr.Big()) == 0 {
+		return true
+	}
+	for hash, owner := range t.keccaks {
+		if owner != addr {
+			continue
+		}
+		delta := new(big.Int).Sub(slot, hash.Big())
+		if delta.Sign() >= 0 && delta.Cmp(big.NewInt(maxAssociatedOffset)) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStorage applies the storage access rules (STO-0xx)
+func (t *Tracer) checkStorage(scope *vm.ScopeContext, pc uint64, op vm.OpCode, depth int, slot *big.Int, write bool) {
+	if slot == nil {
+		return
+	}
+	addr, entity := scope.Address(), t.entity()
+	staked := t.entities.Staked[entity]
+	switch {
+	case addr == t.entities.Sender, t.associated(slot, t.entities.Sender):
+		// STO-010, STO-021: the sender's storage and its associated slots
+	case addr == entity:
+		if !staked {
+			t.report("STO-031", scope, pc, op, depth, "unstaked entity accessed its own storage")
+		}
+	case t.associated(slot, entity):
+		if !staked {
+			t.report("STO-032", scope, pc, op, depth, "unstaked entity accessed storage associated with it")
+		}
+	case staked && !write:
+		// STO-033: staked entities may read any storage
+	default:
+		t.report("STO-021", scope, pc, op, depth, fmt.Sprintf("access to slot %#x of %s not associated with the sender", slot, addr.Hex()))
+	}
+}
+
+// checkCall applies the rules on call targets: they must have code
+// (OP-041) and the EntryPoint may only be called to deposit (OP-052)
+func (t *Tracer) checkCall(scope *vm.ScopeContext, pc uint64, op vm.OpCode, depth int) {
+	// CALL takes the gas, the target address, the value and then the
+	// argument range
+	n := scope.StackLen()
+	if op != 0xf1 || n < 7 {
+		return
+	}
+	to := word.ToAddress(scope.StackAt(n - 2))
+	if !t.entities.EntryPoint.IsZero() && to == t.entities.EntryPoint {
+		argsOffset, argsSize := scope.StackAt(n-4), scope.StackAt(n-5)
+		memory := scope.Memory()
+		var selector []byte
+		if argsSize.Uint64() >= 4 && argsOffset.IsUint64() && argsOffset.Uint64()+4 <= uint64(len(memory)) {
+			selector = memory[argsOffset.Uint64() : argsOffset.Uint64()+4]
+		}
+		if !bytes.Equal(selector, depositToSelector) {
+			t.report("OP-052", scope, pc, op, depth, "EntryPoint called with a function other than depositTo")
+		}
+		return
+	}
+	if to != t.entities.Sender && len(t.statedb.GetCode(to)) == 0 {
+		t.report("OP-041", scope, pc, op, depth, fmt.Sprintf("call to %s, which has no code", to.Hex()))
+	}
+}
+
+// Validate executes the validation calls of a user operation, such as
+// EntryPoint.simulateValidation or direct calls to validateUserOp and
+// validatePaymasterUserOp, and returns the rule violations. The calls run
+// on top of the simulator's hooks and their state changes are reverted.
+func Validate(ctx context.Context, sim *simulator.Simulator, entities Entities, calls ...simulator.CallMsg) ([]*Violation, error) {
+	tracer := NewTracer(sim.State(), entities)
+	hooks := sim.Hooks()
+	sim.SetHooks(tracers.Combine(hooks, tracer.Hooks()))
+	snapshot := sim.Snapshot()
+	defer func() {
+		sim.Revert(snapshot)
+		sim.SetHooks(hooks)
+	}()
+	for i, msg := range calls {
+		if _, err := sim.Call(ctx, msg); err != nil {
+			return nil, fmt.Errorf("validation call %d: %w", i, err)
+		}
+	}
+	return tracer.Violations(), nil
+}
@@ -0,0 +1,106 @@
+package tracers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// FlameGraph accumulates the gas spent by each opcode under its chain of
+// calls, keyed by folded stacks of the form contract;function;...;OPCODE.
+// The output is accepted by flamegraph.pl, speedscope and inferno.
+type FlameGraph struct {
+	dec    *Decoder
+	frames []string // folded stack prefix of each executing frame
+	gas    map[string]uint64
+
+	// the latest opcode is held back until its gas cost is known
+	pending      string
+	pendingGas   uint64
+	pendingScope *vm.ScopeContext
+}
+
+// NewFlameGraph creates a flame graph tracer. dec, if not nil, is used to
+// name functions by their selector.
+func NewFlameGraph(dec *Decoder) *FlameGraph {
+	return &FlameGraph{dec: dec, gas: make(map[string]uint64)}
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (f *FlameGraph) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter:  f.onEnter,
+		OnExit:   f.onExit,
+		OnOpcode: f.onOpcode,
+	}
+}
+
+// WriteFolded writes one "stack gas" line per folded stack, sorted by stack
+func (f *FlameGraph) WriteFolded(w io.Writer) error {
+	f.flushPending()
+	stacks := make([]string, 0, len(f.gas))
+	for stack := range f.gas {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, f.gas[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// functionName names the function called with input
+func (f *FlameGraph) functionName(input []byte) string {
+	if len(input) < 4 {
+		return "fallback"
+	}
+	if name := f.dec.FunctionName(input); name != "" {
+		return name
+	}
+	return hexutil.Encode(input[:4])
+}
+
+func (f *FlameGraph) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	f.flushPending()
+	frame := strings.ReplaceAll(f.dec.Address(to), ";", "_") + ";" + f.functionName(input)
+	if len(f.frames) > 0 {
+		frame = f.frames[len(f.frames)-1] + ";" + frame
+	}
+	f.frames = append(f.frames, frame)
+}
+
+func (f *FlameGraph) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	f.flushPending()
+	if len(f.frames) > 0 {
+		f.frames = f.frames[:len(f.frames)-1]
+	}
+}
+
+func (f *FlameGraph) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	f.flushPending()
+	if len(f.frames) == 0 {
+		return
+	}
+	f.pending = f.frames[len(f.frames)-1] + ";" + strings.ReplaceAll(scope.OpName(op), " ", "_")
+	f.pendingGas = gas
+	f.pendingScope = scope
+}
+
+// flushPending charges the held back opcode with the gas its frame lost
+// since it started executing
+func (f *FlameGraph) flushPending() {
+	if f.pendingScope == nil {
+		return
+	}
+	if left := f.pendingScope.Gas(); f.pendingGas > left {
+		f.gas[f.pending] += f.pendingGas - left
+	}
+	f.pendingScope = nil
+}
@@ -0,0 +1,135 @@
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// GasReportEntry is the gas used by the calls of one function of a
+// contract. Gas is that of the whole frame, including nested calls.
+type GasReportEntry struct {
+	Contract common.Address `json:"contract"`
+	Label    string         `json:"label,omitempty"`    // contract label of the decoder, if any
+	Selector hexutil.Bytes  `json:"selector,omitempty"` // empty for calls whose input is shorter than a selector
+	Function string         `json:"function"`           // function name, the selector in hex if unknown, or fallback
+	Calls    int            `json:"calls"`
+	Min      uint64         `json:"min"`
+	Max      uint64         `json:"max"`
+	Avg      uint64         `json:"avg"`
+	Total    uint64         `json:"total"`
+}
+
+type gasReportKey struct {
+	contract common.Address
+	selector string
+}
+
+// GasReport aggregates the gas used per contract and function selector
+// across every execution its hooks are installed in, the gas report of
+// test suites. Every call frame counts, so functions reached through
+// other contracts are reported too.
+type GasReport struct {
+	dec     *Decoder
+	frames  []gasReportKey
+	entries map[gasReportKey]*GasReportEntry
+}
+
+// NewGasReport creates a gas report tracer. dec, if not nil, labels
+// contracts and names functions by their selector.
+func NewGasReport(dec *Decoder) *GasReport {
+	return &GasReport{dec: dec, entries: make(map[gasReportKey]*GasReportEntry)}
+}
+
+// Hooks returns the hooks to install in vm.Config. They may be installed
+// in any number of executions, one at a time.
+func (r *GasReport) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter: r.onEnter,
+		OnExit:  r.onExit,
+	}
+}
+
+func (r *GasReport) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	key := gasReportKey{contract: to}
+	if len(input) >= 4 {
+		key.selector = string(input[:4])
+	}
+	if _, ok := r.entries[key]; !ok {
+		entry := &GasReportEntry{Contract: to, Function: "fallback"}
+		if label := r.dec.Address(to); label != to.Hex() {
+			entry.Label = label
+		}
+		if key.selector != "" {
+			entry.Selector = []byte(key.selector)
+			entry.Function = hexutil.Encode(entry.Selector)
+			if name := r.dec.FunctionName(input); name != "" {
+				entry.Function = name
+			}
+		}
+		r.entries[key] = entry
+	}
+	r.frames = append(r.frames, key)
+}
+
+func (r *GasReport) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	if len(r.frames) == 0 {
+		return
+	}
+	entry := r.entries[r.frames[len(r.frames)-1]]
+	r.frames = r.frames[:len(r.frames)-1]
+	if entry.Calls == 0 || gasUsed < entry.Min {
+		entry.Min = gasUsed
+	}
+	if gasUsed > entry.Max {
+		entry.Max = gasUsed
+	}
+	entry.Calls++
+	entry.Total += gasUsed
+	entry.Avg = entry.Total / uint64(entry.Calls)
+}
+
+// Entries returns the functions called so far, sorted by contract and
+// function name
+func (r *GasReport) Entries() []GasReportEntry {
+	entries := make([]GasReportEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if e.Calls > 0 {
+			entries = append(entries, *e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if c := entries[i].Contract.Cmp(entries[j].Contract); c != 0 {
+			return c < 0
+		}
+		return entries[i].Function < entries[j].Function
+	})
+	return entries
+}
+
+// WriteTable writes the report as an aligned text table
+func (r *GasReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "contract\tfunction\tcalls\tmin\tavg\tmax")
+	for _, e := range r.Entries() {
+		contract := e.Label
+		if contract == "" {
+			contract = e.Contract.Hex()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\n", contract, e.Function, e.Calls, e.Min, e.Avg, e.Max)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the entries of the report as a JSON array
+func (r *GasReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Entries())
+}
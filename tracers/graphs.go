@@ -0,0 +1,152 @@
+package tracers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/asm"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Jump is a jump taken during execution
+type Jump struct {
+	PC, Target uint64
+}
+
+// JumpTracer records the jumps taken by each contract, to complete control
+// flow graphs with targets that cannot be resolved statically
+type JumpTracer struct {
+	jumps   map[common.Address]map[Jump]struct{}
+	pending []*pendingJump // per frame, innermost last
+}
+
+type pendingJump struct {
+	address common.Address
+	pc      uint64
+	active  bool
+}
+
+// NewJumpTracer creates an empty jump tracer
+func NewJumpTracer() *JumpTracer {
+	return &JumpTracer{jumps: make(map[common.Address]map[Jump]struct{})}
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (t *JumpTracer) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter: func(depth int, from, to common.Address, input []byte, gas uint64) {
+			t.pending = append(t.pending, &pendingJump{address: to})
+		},
+		OnExit: func(depth int, output []byte, gasUsed uint64, err error) {
+			if len(t.pending) > 0 {
+				t.pending = t.pending[:len(t.pending)-1]
+			}
+		},
+		OnOpcode: t.onOpcode,
+	}
+}
+
+func (t *JumpTracer) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	if len(t.pending) == 0 {
+		return
+	}
+	frame := t.pending[len(t.pending)-1]
+	if frame.active {
+		// a jump is followed by its target unless it was a JUMPI not taken
+		if pc != frame.pc+1 {
+			if t.jumps[frame.address] == nil {
+				t.jumps[frame.address] = make(map[Jump]struct{})
+			}
+			t.jumps[frame.address][Jump{PC: frame.pc, Target: pc}] = struct{}{}
+		}
+		frame.active = false
+	}
+	if op == 0x56 || op == 0x57 { // JUMP, JUMPI
+		frame.pc, frame.active = pc, true
+	}
+}
+
+// Jumps returns the jumps taken in the code of address, sorted by pc
+func (t *JumpTracer) Jumps(address common.Address) []Jump {
+	jumps := make([]Jump, 0, len(t.jumps[address]))
+	for j := range t.jumps[address] {
+		jumps = append(jumps, j)
+	}
+	sort.Slice(jumps, func(i, k int) bool {
+		if jumps[i].PC != jumps[k].PC {
+			return jumps[i].PC < jumps[k].PC
+		}
+		return jumps[i].Target < jumps[k].Target
+	})
+	return jumps
+}
+
+// AddTo adds the jumps taken in the code of address to its control flow graph
+func (t *JumpTracer) AddTo(cfg *asm.CFG, address common.Address) {
+	for _, j := range t.Jumps(address) {
+		cfg.AddEdge(j.PC, j.Target)
+	}
+}
+
+// WriteCallGraphDOT writes the inter-contract call graph of a traced
+// execution in Graphviz DOT format. Each edge is labelled with the number
+// of calls between the two contracts and the gas they used; contracts are
+// named by the decoder's labels, which may be nil.
+func WriteCallGraphDOT(w io.Writer, root *CallFrame, dec *Decoder) error {
+	if root == nil {
+		return fmt.Errorf("no execution was traced")
+	}
+	type edge struct{ from, to common.Address }
+	type stats struct{ calls, gas uint64 }
+	nodes := map[common.Address]bool{root.To: true}
+	edges := make(map[edge]*stats)
+	var walk func(f *CallFrame)
+	walk = func(f *CallFrame) {
+		for _, call := range f.Calls {
+			nodes[call.To] = true
+			e := edge{f.To, call.To}
+			if edges[e] == nil {
+				edges[e] = &stats{}
+			}
+			edges[e].calls++
+			edges[e].gas += call.GasUsed
+			walk(call)
+		}
+	}
+	walk(root)
+
+	var b strings.Builder
+	b.WriteString("digraph calls {\n\tnode [shape=box fontname=\"monospace\"];\n")
+	addresses := make([]common.Address, 0, len(nodes))
+	for address := range nodes {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Cmp(addresses[j]) < 0 })
+	for _, address := range addresses {
+		label := address.Hex()
+		if name := dec.Address(address); name != label {
+			label = name + "\n" + label
+		}
+		fmt.Fprintf(&b, "\t%q [label=%q];\n", address.Hex(), label)
+	}
+	keys := make([]edge, 0, len(edges))
+	for e := range edges {
+		keys = append(keys, e)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := keys[i].from.Cmp(keys[j].from); c != 0 {
+			return c < 0
+		}
+		return keys[i].to.Cmp(keys[j].to) < 0
+	})
+	for _, e := range keys {
+		s := edges[e]
+		fmt.Fprintf(&b, "\t%q -> %q [label=\"%d calls, %d gas\"];\n", e.from.Hex(), e.to.Hex(), s.calls, s.gas)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
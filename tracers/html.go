@@ -0,0 +1,168 @@
+package tracers
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/nutcas3/evm-golang/common/hexutil"
+)
+
+type frameView struct {
+	From, To  string
+	Call      string // decoded call, or the raw call data
+	Output    string
+	Gas       uint64
+	GasUsed   uint64
+	SelfGas   uint64
+	GasShare  float64 // percentage of the root frame's gas used
+	Err       string
+	Reentry   string // description of the re-entry the frame makes, if any
+	Logs      []logView
+	Storage   []storageView
+	Calls     []frameView
+	FrameOpen bool
+}
+
+type logView struct {
+	Address string
+	Event   string // decoded event, empty if unknown
+	Topics  []string
+	Data    string
+}
+
+type storageView struct {
+	Address, Key, Prev, Value string
+}
+
+type reportView struct {
+	Root      frameView
+	Frames    int
+	Logs      int
+	Writes    int
+	Reentries int
+	Succeeded bool
+}
+
+// WriteHTMLReport writes a self-contained HTML page describing the call
+// tree rooted at root: gas used per frame, call data, logs and storage
+// writes. Call data and logs the decoder recognizes are shown decoded; dec
+// may be nil.
+func WriteHTMLReport(w io.Writer, root *CallFrame, dec *Decoder) error {
+	if root == nil {
+		return fmt.Errorf("no execution was traced")
+	}
+	report := &reportView{Succeeded: root.Err == nil}
+	report.Root = newFrameView(root, root.GasUsed, dec, report)
+	return reportTemplate.Execute(w, report)
+}
+
+func newFrameView(f *CallFrame, total uint64, dec *Decoder, report *reportView) frameView {
+	report.Frames++
+	report.Logs += len(f.Logs)
+	report.Writes += len(f.StorageChanges)
+
+	view := frameView{
+		From:      dec.Address(f.From),
+		To:        dec.Address(f.To),
+		Call:      dec.Call(f.Input),
+		Output:    hexutil.Encode(f.Output),
+		Gas:       f.Gas,
+		GasUsed:   f.GasUsed,
+		SelfGas:   f.SelfGas(),
+		FrameOpen: f.Depth < 2,
+	}
+	if total > 0 {
+		view.GasShare = float64(f.GasUsed) * 100 / float64(total)
+	}
+	if f.Err != nil {
+		view.Err = f.Err.Error()
+	}
+	if r := f.Reentrancy; r != nil {
+		report.Reentries++
+		if r.SameFunction {
+			view.Reentry = fmt.Sprintf("re-enters %s in the function running at depth %d", dec.Address(r.Address), r.AncestorDepth)
+		} else {
+			view.Reentry = fmt.Sprintf("re-enters %s running at depth %d", dec.Address(r.Address), r.AncestorDepth)
+		}
+	}
+	for _, log := range f.Logs {
+		lv := logView{Address: dec.Address(log.Address), Data: hexutil.Encode(log.Data)}
+		for _, topic := range log.Topics {
+			lv.Topics = append(lv.Topics, topic.Hex())
+		}
+		lv.Event = dec.Event(log.Topics, log.Data)
+		view.Logs = append(view.Logs, lv)
+	}
+	for _, change := range f.StorageChanges {
+		view.Storage = append(view.Storage, storageView{
+			Address: dec.Address(change.Address),
+			Key:     change.Key.Hex(),
+			Prev:    change.Prev.Hex(),
+			Value:   change.Value.Hex(),
+		})
+	}
+	for _, call := range f.Calls {
+		view.Calls = append(view.Calls, newFrameView(call, total, dec, report))
+	}
+	return view
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(f float64) string { return fmt.Sprintf("%.1f", f) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>EVM execution report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+code, .mono { font-family: monospace; word-break: break-all; }
+details { margin: 0.3em 0 0.3em 1.2em; border-left: 2px solid #ccc; padding-left: 0.6em; }
+summary { cursor: pointer; }
+.failed { color: #b00; }
+.warning { color: #c60; }
+.bar { display: inline-block; height: 0.7em; background: #e80; vertical-align: middle; margin-right: 0.4em; }
+table { border-collapse: collapse; margin: 0.4em 0; }
+td, th { border: 1px solid #ddd; padding: 0.2em 0.5em; text-align: left; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>EVM execution report</h1>
+<p>
+{{if .Succeeded}}Succeeded{{else}}<span class="failed">Failed: {{.Root.Err}}</span>{{end}} &middot;
+gas used {{.Root.GasUsed}} of {{.Root.Gas}} &middot;
+{{.Frames}} frames &middot; {{.Logs}} logs &middot; {{.Writes}} storage writes{{if .Reentries}} &middot;
+<span class="warning">{{.Reentries}} re-entries</span>{{end}}
+</p>
+{{template "frame" .Root}}
+</body>
+</html>
+{{define "frame"}}
+<details{{if .FrameOpen}} open{{end}}>
+<summary>
+<span class="bar" style="width: {{percent .GasShare}}px"></span>
+<code>{{.To}}</code>{{if .Call}} <code>{{.Call}}</code>{{end}}
+&mdash; gas {{.GasUsed}} ({{percent .GasShare}}%, self {{.SelfGas}})
+{{if .Err}}<span class="failed">&mdash; {{.Err}}</span>{{end}}
+{{if .Reentry}}<span class="warning">&mdash; {{.Reentry}}</span>{{end}}
+</summary>
+<p>from <code>{{.From}}</code>, gas available {{.Gas}}{{if ne .Output "0x"}}, output <code>{{.Output}}</code>{{end}}</p>
+{{if .Logs}}
+<table>
+<tr><th>log</th><th>address</th><th>topics</th><th>data</th></tr>
+{{range .Logs}}<tr><td>{{if .Event}}<code>{{.Event}}</code>{{end}}</td><td class="mono">{{.Address}}</td><td class="mono">{{range .Topics}}{{.}}<br>{{end}}</td><td class="mono">{{.Data}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{if .Storage}}
+<table>
+<tr><th>address</th><th>slot</th><th>before</th><th>after</th></tr>
+{{range .Storage}}<tr><td class="mono">{{.Address}}</td><td class="mono">{{.Key}}</td><td class="mono">{{.Prev}}</td><td class="mono">{{.Value}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{range .Calls}}{{template "frame" .}}{{end}}
+</details>
+{{end}}
+`))
@@ -0,0 +1,37 @@
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nutcas3/evm-golang/common"
+)
+
+// Labels maps addresses to human-readable names such as WETH
+type Labels map[common.Address]string
+
+// LoadLabels reads a JSON object mapping addresses to names:
+//
+//	{"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2": "WETH"}
+//
+// Addresses are accepted regardless of their checksum.
+func LoadLabels(path string) (Labels, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	labels := make(Labels, len(entries))
+	for key, name := range entries {
+		address, err := common.ParseAddress(key, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		labels[address] = name
+	}
+	return labels, nil
+}
@@ -0,0 +1,161 @@
+// Package tracers implements execution tracers built on the vm.Hooks
+// interface.
+package tracers
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// DefaultBufferSize is the write buffer used when LogConfig leaves it unset
+const DefaultBufferSize = 64 * 1024
+
+// LogConfig configures the struct logs written by a JSONLogger
+type LogConfig struct {
+	DisableStack  bool // omit the stack from each entry
+	DisableMemory bool // omit the memory from each entry
+	BufferSize    int  // bytes buffered before writing to the output, DefaultBufferSize if zero
+}
+
+// StructLog is a single instruction step as written by JSONLogger
+type StructLog struct {
+	Pc         uint64         `json:"pc"`
+	Op         vm.OpCode      `json:"op"`
+	Gas        hexutil.Uint64 `json:"gas"`
+	GasCost    hexutil.Uint64 `json:"gasCost"`
+	Memory     hexutil.Bytes  `json:"memory,omitempty"`
+	MemorySize int            `json:"memSize"`
+	Stack      []hexutil.Big  `json:"stack"`
+	Depth      int            `json:"depth"`
+	OpName     string         `json:"opName"`
+	Err        string         `json:"error,omitempty"`
+}
+
+// executionSummary is the line written after the outermost frame halts
+type executionSummary struct {
+	Output  hexutil.Bytes  `json:"output"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Err     string         `json:"error,omitempty"`
+}
+
+// JSONLogger streams one JSON line per executed instruction to a writer.
+// Entries are encoded as they are produced, so memory use stays bounded by
+// the write buffer regardless of the length of the execution.
+type JSONLogger struct {
+	cfg LogConfig
+	out *bufio.Writer
+	enc *json.Encoder
+	err error // first write error, further output is dropped
+
+	// the latest step is held back until its gas cost is known
+	pending      StructLog
+	pendingScope *vm.ScopeContext
+	hasPending   bool
+}
+
+// NewJSONLogger creates a logger writing struct logs to w. A nil cfg uses
+// the defaults.
+func NewJSONLogger(w io.Writer, cfg *LogConfig) *JSONLogger {
+	l := &JSONLogger{}
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	if l.cfg.BufferSize <= 0 {
+		l.cfg.BufferSize = DefaultBufferSize
+	}
+	l.out = bufio.NewWriterSize(w, l.cfg.BufferSize)
+	l.enc = json.NewEncoder(l.out)
+	return l
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (l *JSONLogger) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnOpcode: l.onOpcode,
+		OnExit:   l.onExit,
+	}
+}
+
+// Flush writes any buffered output to the underlying writer
+func (l *JSONLogger) Flush() error {
+	if l.err != nil {
+		return l.err
+	}
+	l.err = l.out.Flush()
+	return l.err
+}
+
+// Err returns the first error encountered while writing
+func (l *JSONLogger) Err() error { return l.err }
+
+func (l *JSONLogger) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	l.flushPending("")
+
+	stack := l.pending.Stack[:0]
+	l.pending = StructLog{
+		Pc:         pc,
+		Op:         op,
+		Gas:        hexutil.Uint64(gas),
+		MemorySize: len(scope.Memory()),
+		Depth:      depth + 1,
+		OpName:     scope.OpName(op),
+	}
+	if !l.cfg.DisableStack {
+		// the previous entry's backing array is reused, the words are copied
+		if stack == nil {
+			stack = make([]hexutil.Big, 0, 16)
+		}
+		for i := 0; i < scope.StackLen(); i++ {
+			var word big.Int
+			if v := scope.StackAt(i); v != nil {
+				word.Set(v)
+			}
+			stack = append(stack, hexutil.Big(word))
+		}
+		l.pending.Stack = stack
+	}
+	if !l.cfg.DisableMemory && len(scope.Memory()) > 0 {
+		l.pending.Memory = append(hexutil.Bytes(nil), scope.Memory()...)
+	}
+	l.pendingScope = scope
+	l.hasPending = true
+}
+
+func (l *JSONLogger) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	var reason string
+	if err != nil {
+		reason = err.Error()
+	}
+	l.flushPending(reason)
+	if depth > 0 {
+		return
+	}
+	l.write(&executionSummary{Output: output, GasUsed: hexutil.Uint64(gasUsed), Err: reason})
+	l.Flush()
+}
+
+// flushPending completes the held back step with its gas cost and writes it
+func (l *JSONLogger) flushPending(reason string) {
+	if !l.hasPending {
+		return
+	}
+	l.hasPending = false
+	if left := l.pendingScope.Gas(); uint64(l.pending.Gas) > left {
+		l.pending.GasCost = hexutil.Uint64(uint64(l.pending.Gas) - left)
+	}
+	l.pending.Err = reason
+	l.write(&l.pending)
+	l.pendingScope = nil
+}
+
+func (l *JSONLogger) write(v interface{}) {
+	if l.err != nil {
+		return
+	}
+	l.err = l.enc.Encode(v)
+}
@@ -0,0 +1,136 @@
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/common/word"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// PrestateAccount is an account as it was when an execution first touched
+// it
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"` // nil if the state holds no balances
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"` // slots read or written
+}
+
+// PrestateTracer records the accounts and storage slots an execution
+// touches, with the values they held before it changed them
+type PrestateTracer struct {
+	state    vm.StateDB
+	accounts map[common.Address]*PrestateAccount
+}
+
+// NewPrestateTracer creates a tracer reading the prestate from statedb,
+// the state the traced execution runs against
+func NewPrestateTracer(statedb vm.StateDB) *PrestateTracer {
+	return &PrestateTracer{state: statedb, accounts: make(map[common.Address]*PrestateAccount)}
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (t *PrestateTracer) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter:         t.onEnter,
+		OnOpcode:        t.onOpcode,
+		OnStorageChange: t.onStorageChange,
+	}
+}
+
+// Accounts returns the touched accounts by address
+func (t *PrestateTracer) Accounts() map[common.Address]*PrestateAccount { return t.accounts }
+
+func (t *PrestateTracer) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	t.touch(from)
+	t.touch(to)
+}
+
+func (t *PrestateTracer) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	n := scope.StackLen()
+	switch {
+	case op == 0x54 && n >= 1: // SLOAD key
+		t.touchSlot(scope.Address(), scope.StackAt(n-1))
+	case op == 0x55 && n >= 2: // SSTORE key, above the value
+		t.touchSlot(scope.Address(), scope.StackAt(n-1))
+	case op == 0xff && n >= 1: // SELFDESTRUCT beneficiary
+		if beneficiary := scope.StackAt(n - 1); beneficiary != nil {
+			t.touch(word.ToAddress(beneficiary))
+		}
+	}
+}
+
+func (t *PrestateTracer) onStorageChange(address common.Address, key, prev, value common.Hash) {
+	acc := t.touch(address)
+	if _, ok := acc.Storage[key]; !ok {
+		acc.Storage[key] = prev
+	}
+}
+
+// touch records the account at addr unless already recorded
+func (t *PrestateTracer) touch(addr common.Address) *PrestateAccount {
+	if acc, ok := t.accounts[addr]; ok {
+		return acc
+	}
+	acc := &PrestateAccount{Storage: make(map[common.Hash]common.Hash)}
+	if t.state != nil {
+		acc.Nonce = t.state.GetNonce(addr)
+		acc.Code = append(hexutil.Bytes(nil), t.state.GetCode(addr)...)
+		if db, ok := t.state.(vm.BalanceStateDB); ok {
+			acc.Balance = (*hexutil.Big)(new(big.Int).Set(db.GetBalance(addr)))
+		}
+	}
+	t.accounts[addr] = acc
+	return acc
+}
+
+// touchSlot records a storage slot unless already recorded
+func (t *PrestateTracer) touchSlot(addr common.Address, slot *big.Int) {
+	if slot == nil {
+		return
+	}
+	acc, key := t.touch(addr), word.ToHash(slot)
+	if _, ok := acc.Storage[key]; !ok && t.state != nil {
+		acc.Storage[key] = t.state.GetState(addr, key)
+	}
+}
+
+// namedPrestateTracer is the prestateTracer of the registry
+type namedPrestateTracer struct {
+	*PrestateTracer
+	disableCode    bool
+	disableStorage bool
+}
+
+func newNamedPrestateTracer(env *Env, config json.RawMessage) (Tracer, error) {
+	var cfg struct {
+		DisableCode    bool `json:"disableCode"`
+		DisableStorage bool `json:"disableStorage"`
+	}
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+	if env.StateDB == nil {
+		return nil, errors.New("no state to read the prestate from")
+	}
+	return &namedPrestateTracer{PrestateTracer: NewPrestateTracer(env.StateDB), disableCode: cfg.DisableCode, disableStorage: cfg.DisableStorage}, nil
+}
+
+func (t *namedPrestateTracer) Result() (json.RawMessage, error) {
+	accounts := make(map[common.Address]*PrestateAccount, len(t.accounts))
+	for addr, acc := range t.accounts {
+		out := *acc
+		if t.disableCode {
+			out.Code = nil
+		}
+		if t.disableStorage || len(out.Storage) == 0 {
+			out.Storage = nil
+		}
+		accounts[addr] = &out
+	}
+	return json.Marshal(accounts)
+}
@@ -0,0 +1,118 @@
+package tracers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// Reentrancy is a call into a contract whose code is still executing in an
+// ancestor frame, typically a callback from an external call made before
+// the ancestor finished updating its state
+type Reentrancy struct {
+	Frame         int            // index of the re-entering frame in call order, 0 for the outermost
+	Depth         int            // depth of the re-entering frame
+	Address       common.Address // contract re-entered
+	AncestorDepth int            // depth of the closest frame of the contract still executing
+	Selector      []byte         // selector of the re-entering call, nil if the input is shorter
+	SameFunction  bool           // the ancestor was called with the same selector
+}
+
+func (r *Reentrancy) String() string {
+	if r.SameFunction {
+		return fmt.Sprintf("re-enters %s in the function running at depth %d", r.Address.Hex(), r.AncestorDepth)
+	}
+	return fmt.Sprintf("re-enters %s running at depth %d", r.Address.Hex(), r.AncestorDepth)
+}
+
+type reentrancyFrame struct {
+	address  common.Address
+	selector []byte
+}
+
+// ReentrancyTracer flags calls that re-enter a contract before an earlier
+// frame of that contract has completed. Plain recursion, where a contract
+// calls itself directly, is reported too.
+type ReentrancyTracer struct {
+	// OnReentrancy, if set, is called as soon as a re-entry is detected
+	OnReentrancy func(*Reentrancy)
+
+	frames   []reentrancyFrame
+	entered  int
+	findings []*Reentrancy
+}
+
+// NewReentrancyTracer creates a reentrancy detector
+func NewReentrancyTracer() *ReentrancyTracer {
+	return &ReentrancyTracer{}
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (t *ReentrancyTracer) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter: t.onEnter,
+		OnExit:  t.onExit,
+	}
+}
+
+// Findings returns the re-entries detected so far, in call order
+func (t *ReentrancyTracer) Findings() []*Reentrancy { return t.findings }
+
+// Annotate sets the Reentrancy field of the frames of a call tree recorded
+// alongside this tracer
+func (t *ReentrancyTracer) Annotate(root *CallFrame) {
+	byFrame := make(map[int]*Reentrancy, len(t.findings))
+	for _, r := range t.findings {
+		byFrame[r.Frame] = r
+	}
+	index := 0
+	var walk func(f *CallFrame)
+	walk = func(f *CallFrame) {
+		f.Reentrancy = byFrame[index]
+		index++
+		for _, call := range f.Calls {
+			walk(call)
+		}
+	}
+	if root != nil {
+		walk(root)
+	}
+}
+
+func (t *ReentrancyTracer) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	if depth == 0 {
+		t.frames, t.entered, t.findings = nil, 0, nil
+	}
+	var selector []byte
+	if len(input) >= 4 {
+		selector = append([]byte(nil), input[:4]...)
+	}
+	for i := len(t.frames) - 1; i >= 0; i-- {
+		if t.frames[i].address != to {
+			continue
+		}
+		r := &Reentrancy{
+			Frame:         t.entered,
+			Depth:         depth,
+			Address:       to,
+			AncestorDepth: i,
+			Selector:      selector,
+			SameFunction:  selector != nil && bytes.Equal(t.frames[i].selector, selector),
+		}
+		t.findings = append(t.findings, r)
+		if t.OnReentrancy != nil {
+			t.OnReentrancy(r)
+		}
+		break
+	}
+	t.frames = append(t.frames, reentrancyFrame{address: to, selector: selector})
+	t.entered++
+}
+
+func (t *ReentrancyTracer) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	if len(t.frames) > 0 {
+		t.frames = t.frames[:len(t.frames)-1]
+	}
+}
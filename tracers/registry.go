@@ -0,0 +1,236 @@
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/core/types"
+	"github.com/nutcas3/evm-golang/core/vm"
+)
+
+// ErrUnknownTracer is returned by New for a name no tracer registered
+var ErrUnknownTracer = errors.New("unknown tracer")
+
+// Tracer is a tracer created by name, whose findings are returned as JSON
+// once the traced execution has finished
+type Tracer interface {
+	// Hooks returns the hooks to install in vm.Config
+	Hooks() *vm.Hooks
+	// Result returns the findings of the latest execution
+	Result() (json.RawMessage, error)
+}
+
+// Env is what a tracer is created with besides its config
+type Env struct {
+	// StateDB is the state the traced execution runs against, read before
+	// the execution modifies it
+	StateDB vm.StateDB
+}
+
+// Definition registers a tracer under a name, so the CLI and the
+// execution service can select it with a string and a JSON config, as
+// with the tracer field of geth's debug_traceCall
+type Definition struct {
+	Name        string
+	Description string
+	// Options is the JSON Schema of the config accepted by New, nil if the
+	// tracer takes none
+	Options json.RawMessage
+	// New creates the tracer. config is the raw JSON config, empty if none
+	// was given.
+	New func(env *Env, config json.RawMessage) (Tracer, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Definition)
+)
+
+// Register adds a tracer definition. It panics if the name is empty or
+// already registered, or if New is nil, which are programming errors.
+func Register(def Definition) {
+	if def.Name == "" || def.New == nil {
+		panic(fmt.Sprintf("tracers: invalid definition of %q", def.Name))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[def.Name]; ok {
+		panic(fmt.Sprintf("tracers: %q registered twice", def.Name))
+	}
+	registry[def.Name] = def
+}
+
+// Lookup returns the definition of the tracer registered under name
+func Lookup(name string) (Definition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	def, ok := registry[name]
+	return def, ok
+}
+
+// Registered returns the definitions of all registered tracers, sorted by
+// name
+func Registered() []Definition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// New creates the tracer registered under name with the given JSON config.
+// A nil env is treated as an empty one.
+func New(name string, env *Env, config json.RawMessage) (Tracer, error) {
+	def, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTracer, name)
+	}
+	if env == nil {
+		env = &Env{}
+	}
+	t, err := def.New(env, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// decodeConfig decodes the JSON config of a tracer into v, rejecting
+// unknown options. An empty or null config leaves v unchanged.
+func decodeConfig(config json.RawMessage, v any) error {
+	if len(bytes.TrimSpace(config)) == 0 || bytes.Equal(bytes.TrimSpace(config), []byte("null")) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	Register(Definition{
+		Name:        "callTracer",
+		Description: "tree of call frames with their input, output, gas and errors",
+		Options:     json.RawMessage(`{"type":"object","properties":{"onlyTopCall":{"type":"boolean","description":"omit nested calls"},"withLog":{"type":"boolean","description":"include the logs emitted by each frame"}},"additionalProperties":false}`),
+		New:         newNamedCallTracer,
+	})
+	Register(Definition{
+		Name:        "prestateTracer",
+		Description: "state of the accounts and storage slots touched by the execution, as it was before",
+		Options:     json.RawMessage(`{"type":"object","properties":{"disableCode":{"type":"boolean","description":"omit contract code"},"disableStorage":{"type":"boolean","description":"omit storage slots"}},"additionalProperties":false}`),
+		New:         newNamedPrestateTracer,
+	})
+	Register(Definition{
+		Name:        "structLogger",
+		Description: "struct logs of every executed instruction, as written by evm --trace",
+		Options:     json.RawMessage(`{"type":"object","properties":{"disableStack":{"type":"boolean","description":"omit the stack"},"disableMemory":{"type":"boolean","description":"omit the memory"}},"additionalProperties":false}`),
+		New:         newNamedStructLogger,
+	})
+}
+
+// namedCallTracer is the callTracer of the registry
+type namedCallTracer struct {
+	*CallTracer
+	onlyTopCall bool
+	withLog     bool
+}
+
+func newNamedCallTracer(_ *Env, config json.RawMessage) (Tracer, error) {
+	var cfg struct {
+		OnlyTopCall bool `json:"onlyTopCall"`
+		WithLog     bool `json:"withLog"`
+	}
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+	return &namedCallTracer{CallTracer: NewCallTracer(), onlyTopCall: cfg.OnlyTopCall, withLog: cfg.WithLog}, nil
+}
+
+// callFrameJSON is a call frame as returned by callTracer
+type callFrameJSON struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Input   string           `json:"input"`
+	Output  string           `json:"output,omitempty"`
+	Gas     string           `json:"gas"`
+	GasUsed string           `json:"gasUsed"`
+	Error   string           `json:"error,omitempty"`
+	Logs    []*types.Log     `json:"logs,omitempty"`
+	Calls   []*callFrameJSON `json:"calls,omitempty"`
+}
+
+func (t *namedCallTracer) Result() (json.RawMessage, error) {
+	if t.Root() == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(t.frameJSON(t.Root()))
+}
+
+func (t *namedCallTracer) frameJSON(f *CallFrame) *callFrameJSON {
+	out := &callFrameJSON{
+		From:    f.From.Hex(),
+		To:      f.To.Hex(),
+		Input:   fmt.Sprintf("0x%x", f.Input),
+		Gas:     fmt.Sprintf("0x%x", f.Gas),
+		GasUsed: fmt.Sprintf("0x%x", f.GasUsed),
+	}
+	if len(f.Output) > 0 {
+		out.Output = fmt.Sprintf("0x%x", f.Output)
+	}
+	if f.Err != nil {
+		out.Error = f.Err.Error()
+	}
+	if t.withLog {
+		out.Logs = f.Logs
+	}
+	if !t.onlyTopCall {
+		for _, call := range f.Calls {
+			out.Calls = append(out.Calls, t.frameJSON(call))
+		}
+	}
+	return out
+}
+
+// namedStructLogger is the structLogger of the registry, buffering the
+// struct logs of a JSONLogger
+type namedStructLogger struct {
+	*JSONLogger
+	buf bytes.Buffer
+}
+
+func newNamedStructLogger(_ *Env, config json.RawMessage) (Tracer, error) {
+	var cfg LogConfig
+	var opts struct {
+		DisableStack  bool `json:"disableStack"`
+		DisableMemory bool `json:"disableMemory"`
+	}
+	if err := decodeConfig(config, &opts); err != nil {
+		return nil, err
+	}
+	cfg.DisableStack, cfg.DisableMemory = opts.DisableStack, opts.DisableMemory
+	t := &namedStructLogger{}
+	t.JSONLogger = NewJSONLogger(&t.buf, &cfg)
+	return t, nil
+}
+
+func (t *namedStructLogger) Result() (json.RawMessage, error) {
+	if err := t.Flush(); err != nil {
+		return nil, err
+	}
+	lines := []json.RawMessage{}
+	for _, line := range bytes.Split(bytes.TrimSpace(t.buf.Bytes()), []byte("\n")) {
+		if len(line) > 0 {
+			lines = append(lines, json.RawMessage(line))
+		}
+	}
+	return json.Marshal(lines)
+}
@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/tracers/tracepb"
+)
+
+// Hooks returns hooks passing the events of an execution to send as they
+// happen, with the stack and memory omitted as req asks. Unlike Server it
+// has a single consumer, called on the executing goroutine.
+func Hooks(req *tracepb.SubscribeRequest, send func(*tracepb.Event)) *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter: func(depth int, from, to common.Address, input []byte, gas uint64) {
+			send(enterEvent(depth, from, to, input, gas))
+		},
+		OnExit: func(depth int, output []byte, gasUsed uint64, err error) {
+			send(exitEvent(depth, output, gasUsed, err))
+		},
+		OnOpcode: func(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+			var stack [][]byte
+			if !req.GetDisableStack() {
+				stack = stackWords(scope)
+			}
+			memory := scope.Memory() // only its size is kept when disabled
+			if !req.GetDisableMemory() {
+				memory = append([]byte(nil), memory...)
+			}
+			send(opcodeEvent(pc, op, gas, depth, stack, memory, req))
+		},
+	}
+}
+
+func enterEvent(depth int, from, to common.Address, input []byte, gas uint64) *tracepb.Event {
+	return &tracepb.Event{Event: &tracepb.Event_Enter{Enter: &tracepb.Enter{
+		Depth: uint32(depth),
+		From:  from.Bytes(),
+		To:    to.Bytes(),
+		Input: append([]byte(nil), input...),
+		Gas:   gas,
+	}}}
+}
+
+func exitEvent(depth int, output []byte, gasUsed uint64, err error) *tracepb.Event {
+	exit := &tracepb.Exit{
+		Depth:   uint32(depth),
+		Output:  append([]byte(nil), output...),
+		GasUsed: gasUsed,
+	}
+	if err != nil {
+		exit.Error = err.Error()
+	}
+	return &tracepb.Event{Event: &tracepb.Event_Exit{Exit: exit}}
+}
+
+// opcodeEvent builds an opcode event, leaving out what req disables
+func opcodeEvent(pc uint64, op vm.OpCode, gas uint64, depth int, stack [][]byte, memory []byte, req *tracepb.SubscribeRequest) *tracepb.Event {
+	opcode := &tracepb.Opcode{
+		Pc:         pc,
+		Op:         uint32(op),
+		Gas:        gas,
+		Depth:      uint32(depth),
+		MemorySize: uint32(len(memory)),
+	}
+	if !req.GetDisableStack() {
+		opcode.Stack = stack
+	}
+	if !req.GetDisableMemory() {
+		opcode.Memory = memory
+	}
+	return &tracepb.Event{Event: &tracepb.Event_Opcode{Opcode: opcode}}
+}
+
+// stackWords returns the stack, bottom first, as big-endian words without
+// leading zeros
+func stackWords(scope *vm.ScopeContext) [][]byte {
+	stack := make([][]byte, scope.StackLen())
+	for i := range stack {
+		if word := scope.StackAt(i); word != nil {
+			stack[i] = word.Bytes()
+		}
+	}
+	return stack
+}
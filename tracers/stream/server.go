@@ -0,0 +1,179 @@
+// Package stream serves execution events to gRPC subscribers while the EVM
+// runs, using the protobuf schema in tracers/tracepb.
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/core/vm"
+	"github.com/nutcas3/evm-golang/tracers/tracepb"
+)
+
+// subscriberBuffer is the number of events queued per subscriber before
+// execution waits for it to catch up
+const subscriberBuffer = 1024
+
+type subscriber struct {
+	req    *tracepb.SubscribeRequest
+	events chan *tracepb.Event
+	done   <-chan struct{}
+}
+
+// Server implements tracepb.TraceService. Executions whose vm.Config uses
+// the server's Hooks stream their events to every connected subscriber.
+// A slow subscriber slows execution down rather than being dropped.
+type Server struct {
+	tracepb.UnimplementedTraceServiceServer
+
+	mu     sync.Mutex
+	subs   map[*subscriber]struct{}
+	joined chan struct{} // closed and replaced whenever a subscriber joins
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewServer creates a server without subscribers
+func NewServer() *Server {
+	return &Server{
+		subs:   make(map[*subscriber]struct{}),
+		joined: make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// Close ends every subscription once its queued events are sent. Clients
+// see the end of their stream.
+func (s *Server) Close() {
+	s.once.Do(func() { close(s.closed) })
+}
+
+// Hooks returns the hooks to install in vm.Config
+func (s *Server) Hooks() *vm.Hooks {
+	return &vm.Hooks{
+		OnEnter:  s.onEnter,
+		OnExit:   s.onExit,
+		OnOpcode: s.onOpcode,
+	}
+}
+
+// Subscribe streams events to the caller until its context is cancelled
+func (s *Server) Subscribe(req *tracepb.SubscribeRequest, stream tracepb.TraceService_SubscribeServer) error {
+	sub := &subscriber{
+		req:    req,
+		events: make(chan *tracepb.Event, subscriberBuffer),
+		done:   stream.Context().Done(),
+	}
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	close(s.joined)
+	s.joined = make(chan struct{})
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, sub)
+		s.mu.Unlock()
+	}()
+	for {
+		select {
+		case ev := <-sub.events:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		case <-sub.done:
+			return stream.Context().Err()
+		case <-s.closed:
+			for {
+				select {
+				case ev := <-sub.events:
+					if err := stream.Send(ev); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// WaitForSubscribers blocks until at least n subscribers are connected
+func (s *Server) WaitForSubscribers(ctx context.Context, n int) error {
+	for {
+		s.mu.Lock()
+		count, joined := len(s.subs), s.joined
+		s.mu.Unlock()
+		if count >= n {
+			return nil
+		}
+		select {
+		case <-joined:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// publish delivers an event to every subscriber. strip, when not nil,
+// returns the event for subscribers that disabled stack or memory.
+func (s *Server) publish(ev *tracepb.Event, strip func(*tracepb.SubscribeRequest) *tracepb.Event) {
+	s.mu.Lock()
+	subs := make([]*subscriber, 0, len(s.subs))
+	for sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		out := ev
+		if strip != nil {
+			out = strip(sub.req)
+		}
+		select {
+		case sub.events <- out:
+		case <-sub.done:
+		}
+	}
+}
+
+func (s *Server) hasSubscribers() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs) > 0
+}
+
+func (s *Server) onEnter(depth int, from, to common.Address, input []byte, gas uint64) {
+	if !s.hasSubscribers() {
+		return
+	}
+	s.publish(enterEvent(depth, from, to, input, gas), nil)
+}
+
+func (s *Server) onExit(depth int, output []byte, gasUsed uint64, err error) {
+	if !s.hasSubscribers() {
+		return
+	}
+	s.publish(exitEvent(depth, output, gasUsed, err), nil)
+}
+
+func (s *Server) onOpcode(pc uint64, op vm.OpCode, gas uint64, scope *vm.ScopeContext, depth int) {
+	if !s.hasSubscribers() {
+		return
+	}
+	stack := stackWords(scope)
+	memory := append([]byte(nil), scope.Memory()...)
+
+	// events are shared between subscribers, so each variant is built once
+	variants := make(map[[2]bool]*tracepb.Event, 1)
+	s.publish(nil, func(req *tracepb.SubscribeRequest) *tracepb.Event {
+		key := [2]bool{req.GetDisableStack(), req.GetDisableMemory()}
+		if ev, ok := variants[key]; ok {
+			return ev
+		}
+		ev := opcodeEvent(pc, op, gas, depth, stack, memory, req)
+		variants[key] = ev
+		return ev
+	})
+}
@@ -0,0 +1,5 @@
+// Package tracepb holds the protobuf schema of execution events and the
+// generated gRPC TraceService bindings.
+package tracepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative trace.proto
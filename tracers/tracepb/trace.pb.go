@@ -0,0 +1,512 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: trace.proto
+
+// Execution events emitted by the interpreter while it runs.
+
+package tracepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DisableStack  bool                   `protobuf:"varint,1,opt,name=disable_stack,json=disableStack,proto3" json:"disable_stack,omitempty"`    // omit stack words from opcode events
+	DisableMemory bool                   `protobuf:"varint,2,opt,name=disable_memory,json=disableMemory,proto3" json:"disable_memory,omitempty"` // omit memory contents from opcode events
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_trace_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trace_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubscribeRequest) GetDisableStack() bool {
+	if x != nil {
+		return x.DisableStack
+	}
+	return false
+}
+
+func (x *SubscribeRequest) GetDisableMemory() bool {
+	if x != nil {
+		return x.DisableMemory
+	}
+	return false
+}
+
+// Event is a single execution event.
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*Event_Enter
+	//	*Event_Exit
+	//	*Event_Opcode
+	Event         isEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_trace_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_trace_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Event) GetEvent() isEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Event) GetEnter() *Enter {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Enter); ok {
+			return x.Enter
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetExit() *Exit {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Exit); ok {
+			return x.Exit
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetOpcode() *Opcode {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Opcode); ok {
+			return x.Opcode
+		}
+	}
+	return nil
+}
+
+type isEvent_Event interface {
+	isEvent_Event()
+}
+
+type Event_Enter struct {
+	Enter *Enter `protobuf:"bytes,1,opt,name=enter,proto3,oneof"`
+}
+
+type Event_Exit struct {
+	Exit *Exit `protobuf:"bytes,2,opt,name=exit,proto3,oneof"`
+}
+
+type Event_Opcode struct {
+	Opcode *Opcode `protobuf:"bytes,3,opt,name=opcode,proto3,oneof"`
+}
+
+func (*Event_Enter) isEvent_Event() {}
+
+func (*Event_Exit) isEvent_Event() {}
+
+func (*Event_Opcode) isEvent_Event() {}
+
+// Enter is sent when a call frame starts executing.
+type Enter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Depth         uint32                 `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	From          []byte                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"` // 20-byte address
+	To            []byte                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`     // 20-byte address
+	Gas           uint64                 `protobuf:"varint,4,opt,name=gas,proto3" json:"gas,omitempty"`
+	Input         []byte                 `protobuf:"bytes,5,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enter) Reset() {
+	*x = Enter{}
+	mi := &file_trace_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enter) ProtoMessage() {}
+
+func (x *Enter) ProtoReflect() protoreflect.Message {
+	mi := &file_trace_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enter.ProtoReflect.Descriptor instead.
+func (*Enter) Descriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Enter) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *Enter) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *Enter) GetTo() []byte {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *Enter) GetGas() uint64 {
+	if x != nil {
+		return x.Gas
+	}
+	return 0
+}
+
+func (x *Enter) GetInput() []byte {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+// Exit is sent when a call frame halts.
+type Exit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Depth         uint32                 `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	Output        []byte                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	GasUsed       uint64                 `protobuf:"varint,3,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"` // empty on a normal halt
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Exit) Reset() {
+	*x = Exit{}
+	mi := &file_trace_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Exit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Exit) ProtoMessage() {}
+
+func (x *Exit) ProtoReflect() protoreflect.Message {
+	mi := &file_trace_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Exit.ProtoReflect.Descriptor instead.
+func (*Exit) Descriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Exit) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *Exit) GetOutput() []byte {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+func (x *Exit) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *Exit) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Opcode is sent before an instruction is executed.
+type Opcode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pc            uint64                 `protobuf:"varint,1,opt,name=pc,proto3" json:"pc,omitempty"`
+	Op            uint32                 `protobuf:"varint,2,opt,name=op,proto3" json:"op,omitempty"`
+	Gas           uint64                 `protobuf:"varint,3,opt,name=gas,proto3" json:"gas,omitempty"`
+	Depth         uint32                 `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`
+	Stack         [][]byte               `protobuf:"bytes,5,rep,name=stack,proto3" json:"stack,omitempty"` // big-endian words without leading zeros, bottom first
+	MemorySize    uint32                 `protobuf:"varint,6,opt,name=memory_size,json=memorySize,proto3" json:"memory_size,omitempty"`
+	Memory        []byte                 `protobuf:"bytes,7,opt,name=memory,proto3" json:"memory,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Opcode) Reset() {
+	*x = Opcode{}
+	mi := &file_trace_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Opcode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Opcode) ProtoMessage() {}
+
+func (x *Opcode) ProtoReflect() protoreflect.Message {
+	mi := &file_trace_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Opcode.ProtoReflect.Descriptor instead.
+func (*Opcode) Descriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Opcode) GetPc() uint64 {
+	if x != nil {
+		return x.Pc
+	}
+	return 0
+}
+
+func (x *Opcode) GetOp() uint32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *Opcode) GetGas() uint64 {
+	if x != nil {
+		return x.Gas
+	}
+	return 0
+}
+
+func (x *Opcode) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *Opcode) GetStack() [][]byte {
+	if x != nil {
+		return x.Stack
+	}
+	return nil
+}
+
+func (x *Opcode) GetMemorySize() uint32 {
+	if x != nil {
+		return x.MemorySize
+	}
+	return 0
+}
+
+func (x *Opcode) GetMemory() []byte {
+	if x != nil {
+		return x.Memory
+	}
+	return nil
+}
+
+var File_trace_proto protoreflect.FileDescriptor
+
+const file_trace_proto_rawDesc = "" +
+	"\n" +
+	"\vtrace.proto\x12\fevm.trace.v1\"^\n" +
+	"\x10SubscribeRequest\x12#\n" +
+	"\rdisable_stack\x18\x01 \x01(\bR\fdisableStack\x12%\n" +
+	"\x0edisable_memory\x18\x02 \x01(\bR\rdisableMemory\"\x97\x01\n" +
+	"\x05Event\x12+\n" +
+	"\x05enter\x18\x01 \x01(\v2\x13.evm.trace.v1.EnterH\x00R\x05enter\x12(\n" +
+	"\x04exit\x18\x02 \x01(\v2\x12.evm.trace.v1.ExitH\x00R\x04exit\x12.\n" +
+	"\x06opcode\x18\x03 \x01(\v2\x14.evm.trace.v1.OpcodeH\x00R\x06opcodeB\a\n" +
+	"\x05event\"i\n" +
+	"\x05Enter\x12\x14\n" +
+	"\x05depth\x18\x01 \x01(\rR\x05depth\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\fR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\fR\x02to\x12\x10\n" +
+	"\x03gas\x18\x04 \x01(\x04R\x03gas\x12\x14\n" +
+	"\x05input\x18\x05 \x01(\fR\x05input\"e\n" +
+	"\x04Exit\x12\x14\n" +
+	"\x05depth\x18\x01 \x01(\rR\x05depth\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\fR\x06output\x12\x19\n" +
+	"\bgas_used\x18\x03 \x01(\x04R\agasUsed\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\x9f\x01\n" +
+	"\x06Opcode\x12\x0e\n" +
+	"\x02pc\x18\x01 \x01(\x04R\x02pc\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\rR\x02op\x12\x10\n" +
+	"\x03gas\x18\x03 \x01(\x04R\x03gas\x12\x14\n" +
+	"\x05depth\x18\x04 \x01(\rR\x05depth\x12\x14\n" +
+	"\x05stack\x18\x05 \x03(\fR\x05stack\x12\x1f\n" +
+	"\vmemory_size\x18\x06 \x01(\rR\n" +
+	"memorySize\x12\x16\n" +
+	"\x06memory\x18\a \x01(\fR\x06memory2R\n" +
+	"\fTraceService\x12B\n" +
+	"\tSubscribe\x12\x1e.evm.trace.v1.SubscribeRequest\x1a\x13.evm.trace.v1.Event0\x01B/Z-github.com/nutcas3/evm-golang/tracers/tracepbb\x06proto3"
+
+var (
+	file_trace_proto_rawDescOnce sync.Once
+	file_trace_proto_rawDescData []byte
+)
+
+func file_trace_proto_rawDescGZIP() []byte {
+	file_trace_proto_rawDescOnce.Do(func() {
+		file_trace_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_trace_proto_rawDesc), len(file_trace_proto_rawDesc)))
+	})
+	return file_trace_proto_rawDescData
+}
+
+var file_trace_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_trace_proto_goTypes = []any{
+	(*SubscribeRequest)(nil), // 0: evm.trace.v1.SubscribeRequest
+	(*Event)(nil),            // 1: evm.trace.v1.Event
+	(*Enter)(nil),            // 2: evm.trace.v1.Enter
+	(*Exit)(nil),             // 3: evm.trace.v1.Exit
+	(*Opcode)(nil),           // 4: evm.trace.v1.Opcode
+}
+var file_trace_proto_depIdxs = []int32{
+	2, // 0: evm.trace.v1.Event.enter:type_name -> evm.trace.v1.Enter
+	3, // 1: evm.trace.v1.Event.exit:type_name -> evm.trace.v1.Exit
+	4, // 2: evm.trace.v1.Event.opcode:type_name -> evm.trace.v1.Opcode
+	0, // 3: evm.trace.v1.TraceService.Subscribe:input_type -> evm.trace.v1.SubscribeRequest
+	1, // 4: evm.trace.v1.TraceService.Subscribe:output_type -> evm.trace.v1.Event
+	4, // [4:5] is the sub-list for method output_type
+	3, // [3:4] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_trace_proto_init() }
+func file_trace_proto_init() {
+	if File_trace_proto != nil {
+		return
+	}
+	file_trace_proto_msgTypes[1].OneofWrappers = []any{
+		(*Event_Enter)(nil),
+		(*Event_Exit)(nil),
+		(*Event_Opcode)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trace_proto_rawDesc), len(file_trace_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_trace_proto_goTypes,
+		DependencyIndexes: file_trace_proto_depIdxs,
+		MessageInfos:      file_trace_proto_msgTypes,
+	}.Build()
+	File_trace_proto = out.File
+	file_trace_proto_goTypes = nil
+	file_trace_proto_depIdxs = nil
+}
@@ -0,0 +1,384 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// ErrMissingNode is returned when a partial trie needs a node its resolver
+// does not have
+var ErrMissingNode = errors.New("missing trie node")
+
+// Resolver returns the RLP encoding of the node with the given hash, and
+// whether it has it
+type Resolver func(hash common.Hash) ([]byte, bool)
+
+// Partial is a Merkle Patricia trie opened at a root and backed by a
+// resolver, such as the nodes reported by Commit or those of a witness.
+// Nodes are resolved as reads and updates reach them, so the resolver need
+// only hold the nodes on the paths of the keys used, and the root can be
+// recomputed after updates without the rest of the trie. Operations that
+// need a node the resolver lacks fail with ErrMissingNode.
+type Partial struct {
+	root    node
+	resolve Resolver
+}
+
+// node is a decoded trie node: nil for an empty trie, or one of the types
+// below
+type node any
+
+type (
+	// shortNode is a leaf or extension; leaf keys end with the terminator
+	shortNode struct {
+		key []byte // nibbles
+		val node
+	}
+	fullNode  [17]node    // branch: children by nibble, then the value
+	hashNode  common.Hash // a node not resolved yet
+	valueNode []byte
+)
+
+// terminator ends the nibble keys of leaves
+const terminator = 16
+
+// Open returns the partial trie with the given root, resolving its nodes
+// with resolve
+func Open(root common.Hash, resolve Resolver) *Partial {
+	t := &Partial{resolve: resolve}
+	if root != EmptyRoot {
+		t.root = hashNode(root)
+	}
+	return t
+}
+
+// Get returns the value of key, nil if it is not in the trie
+func (t *Partial) Get(key []byte) ([]byte, error) {
+	n, path := t.root, append(nibbles(key), terminator)
+	for {
+		switch cur := n.(type) {
+		case nil:
+			return nil, nil
+		case valueNode:
+			if len(path) == 0 {
+				return cur, nil
+			}
+			return nil, nil
+		case *shortNode:
+			if !bytes.HasPrefix(path, cur.key) {
+				return nil, nil
+			}
+			n, path = cur.val, path[len(cur.key):]
+		case *fullNode:
+			n, path = cur[path[0]], path[1:]
+		case hashNode:
+			resolved, err := t.resolveHash(cur)
+			if err != nil {
+				return nil, err
+			}
+			n = resolved
+		}
+	}
+}
+
+// Update sets the value of key. An empty value deletes the key.
+func (t *Partial) Update(key, value []byte) error {
+	path := append(nibbles(key), terminator)
+	var (
+		root node
+		err  error
+	)
+	if len(value) == 0 {
+		root, err = t.delete(t.root, path)
+	} else {
+		root, err = t.insert(t.root, path, valueNode(append([]byte(nil), value...)))
+	}
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// Hash returns the root hash of the trie. Subtrees that were not resolved
+// keep the hash they were referenced by.
+func (t *Partial) Hash() common.Hash {
+	switch n := t.root.(type) {
+	case nil:
+		return EmptyRoot
+	case hashNode:
+		return common.Hash(n)
+	}
+	return crypto.Keccak256Hash(encode(t.root))
+}
+
+// insert returns n with value set at path
+func (t *Partial) insert(n node, path []byte, value node) (node, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch cur := n.(type) {
+	case nil:
+		return &shortNode{key: path, val: value}, nil
+	case *shortNode:
+		match := prefixLen(path, cur.key)
+		if match == len(cur.key) {
+			child, err := t.insert(cur.val, path[match:], value)
+			if err != nil {
+				return nil, err
+			}
+			return &shortNode{key: cur.key, val: child}, nil
+		}
+		// branch where the keys diverge
+		branch := new(fullNode)
+		var err error
+		if branch[cur.key[match]], err = t.insert(nil, cur.key[match+1:], cur.val); err != nil {
+			return nil, err
+		}
+		if branch[path[match]], err = t.insert(nil, path[match+1:], value); err != nil {
+			return nil, err
+		}
+		if match == 0 {
+			return branch, nil
+		}
+		return &shortNode{key: path[:match], val: branch}, nil
+	case *fullNode:
+		child, err := t.insert(cur[path[0]], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		cpy := *cur
+		cpy[path[0]] = child
+		return &cpy, nil
+	case hashNode:
+		resolved, err := t.resolveHash(cur)
+		if err != nil {
+			return nil, err
+		}
+		return t.insert(resolved, path, value)
+	}
+	return nil, fmt.Errorf("trie: unexpected %T inserting", n)
+}
+
+// delete returns n without the value at path. Branches left with a single
+// child are collapsed, which resolves that child.
+func (t *Partial) delete(n node, path []byte) (node, error) {
+	switch cur := n.(type) {
+	case nil:
+		return nil, nil
+	case valueNode:
+		if len(path) == 0 {
+			return nil, nil
+		}
+		return cur, nil
+	case *shortNode:
+		if !bytes.HasPrefix(path, cur.key) {
+			return cur, nil
+		}
+		child, err := t.delete(cur.val, path[len(cur.key):])
+		if err != nil {
+			return nil, err
+		}
+		switch child := child.(type) {
+		case nil:
+			return nil, nil
+		case *shortNode:
+			// merge the extension with the short node below it
+			return &shortNode{key: concat(cur.key, child.key...), val: child.val}, nil
+		default:
+			return &shortNode{key: cur.key, val: child}, nil
+		}
+	case *fullNode:
+		child, err := t.delete(cur[path[0]], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		cpy := *cur
+		cpy[path[0]] = child
+		remaining := -1
+		for i, c := range cpy {
+			if c != nil {
+				if remaining >= 0 {
+					return &cpy, nil // two or more children left
+				}
+				remaining = i
+			}
+		}
+		if remaining < 0 {
+			return nil, nil
+		}
+		if remaining == terminator {
+			return &shortNode{key: []byte{terminator}, val: cpy[terminator]}, nil
+		}
+		// a single child: fold the branch into it
+		only := cpy[remaining]
+		if h, ok := only.(hashNode); ok {
+			if only, err = t.resolveHash(h); err != nil {
+				return nil, err
+			}
+		}
+		if short, ok := only.(*shortNode); ok {
+			return &shortNode{key: concat([]byte{byte(remaining)}, short.key...), val: short.val}, nil
+		}
+		return &shortNode{key: []byte{byte(remaining)}, val: only}, nil
+	case hashNode:
+		resolved, err := t.resolveHash(cur)
+		if err != nil {
+			return nil, err
+		}
+		return t.delete(resolved, path)
+	}
+	return nil, fmt.Errorf("trie: unexpected %T deleting", n)
+}
+
+// resolveHash loads and decodes the node with the given hash
+func (t *Partial) resolveHash(hash hashNode) (node, error) {
+	if t.resolve != nil {
+		if blob, ok := t.resolve(common.Hash(hash)); ok {
+			n, err := decodeNode(blob)
+			if err != nil {
+				return nil, fmt.Errorf("trie: node %s: %w", common.Hash(hash).Hex(), err)
+			}
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrMissingNode, common.Hash(hash).Hex())
+}
+
+// decodeNode decodes the RLP encoding of a node
+func decodeNode(blob []byte) (node, error) {
+	items, err := rlp.SplitList(blob)
+	if err != nil {
+		return nil, err
+	}
+	switch len(items) {
+	case 2:
+		_, compactKey, _, err := rlp.Split(items[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(compactKey) == 0 {
+			return nil, errors.New("empty short node key")
+		}
+		key := decompact(compactKey)
+		if key[len(key)-1] == terminator {
+			_, value, _, err := rlp.Split(items[1])
+			if err != nil {
+				return nil, err
+			}
+			return &shortNode{key: key, val: valueNode(value)}, nil
+		}
+		child, err := decodeRef(items[1])
+		if err != nil {
+			return nil, err
+		}
+		return &shortNode{key: key, val: child}, nil
+	case 17:
+		n := new(fullNode)
+		for i := range 16 {
+			if n[i], err = decodeRef(items[i]); err != nil {
+				return nil, err
+			}
+		}
+		_, value, _, err := rlp.Split(items[16])
+		if err != nil {
+			return nil, err
+		}
+		if len(value) > 0 {
+			n[terminator] = valueNode(value)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("node with %d items", len(items))
+}
+
+// decodeRef decodes a reference to a child: a hash, an embedded node or
+// nothing
+func decodeRef(item []byte) (node, error) {
+	isList, content, _, err := rlp.Split(item)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case isList:
+		return decodeNode(item)
+	case len(content) == 0:
+		return nil, nil
+	case len(content) == common.HashLength:
+		return hashNode(common.BytesToHash(content)), nil
+	}
+	return nil, fmt.Errorf("invalid child reference of %d bytes", len(content))
+}
+
+// encode returns the RLP encoding of a resolved node
+func encode(n node) []byte {
+	switch n := n.(type) {
+	case *shortNode:
+		if v, ok := n.val.(valueNode); ok {
+			return rlp.EncodeList(rlp.EncodeBytes(compact(n.key[:len(n.key)-1], true)), rlp.EncodeBytes(v))
+		}
+		return rlp.EncodeList(rlp.EncodeBytes(compact(n.key, false)), ref(n.val))
+	case *fullNode:
+		items := make([][]byte, 17)
+		for i := range 16 {
+			items[i] = ref(n[i])
+		}
+		items[16] = rlp.EmptyString
+		if v, ok := n[terminator].(valueNode); ok {
+			items[16] = rlp.EncodeBytes(v)
+		}
+		return rlp.EncodeList(items...)
+	}
+	panic(fmt.Sprintf("trie: cannot encode %T", n))
+}
+
+// ref returns how a parent refers to a child, as hasher.reference does
+func ref(n node) []byte {
+	switch n := n.(type) {
+	case nil:
+		return rlp.EmptyString
+	case hashNode:
+		return rlp.EncodeBytes(n[:])
+	}
+	enc := encode(n)
+	if len(enc) < 32 {
+		return enc
+	}
+	hash := crypto.Keccak256Hash(enc)
+	return rlp.EncodeBytes(hash[:])
+}
+
+// decompact decodes a hex-prefix encoded path to nibbles, appending the
+// terminator to leaf paths
+func decompact(compactKey []byte) []byte {
+	flag := compactKey[0] >> 4
+	path := nibbles(compactKey)
+	if flag&1 == 1 {
+		path = path[1:]
+	} else {
+		path = path[2:]
+	}
+	if flag&2 != 0 {
+		path = append(path, terminator)
+	}
+	return path
+}
+
+// prefixLen returns the length of the common prefix of a and b
+func prefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// concat returns a new slice holding a followed by b
+func concat(a []byte, b ...byte) []byte {
+	return append(append(make([]byte, 0, len(a)+len(b)), a...), b...)
+}
@@ -0,0 +1,222 @@
+// Package trie computes the root hash of Merkle Patricia tries, the
+// commitment Ethereum uses for its state, storage, transactions and
+// receipts.
+package trie
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/rlp"
+)
+
+// EmptyRoot is the root hash of an empty trie
+var EmptyRoot = crypto.Keccak256Hash(rlp.EmptyString)
+
+// Trie is an in-memory key-value set whose Merkle Patricia root can be
+// computed. Keys are used as given: secure tries, such as the state and
+// storage tries, must be filled with hashed keys.
+type Trie struct {
+	entries map[string][]byte
+}
+
+// New creates an empty trie
+func New() *Trie {
+	return &Trie{entries: make(map[string][]byte)}
+}
+
+// Update sets the value of key. An empty value deletes the key.
+func (t *Trie) Update(key, value []byte) {
+	if len(value) == 0 {
+		delete(t.entries, string(key))
+		return
+	}
+	t.entries[string(key)] = append([]byte(nil), value...)
+}
+
+// Len returns the number of keys in the trie
+func (t *Trie) Len() int { return len(t.entries) }
+
+// pair is a key, as nibbles, with its value
+type pair struct {
+	key   []byte
+	value []byte
+}
+
+// parallelThreshold is the number of keys from which the subtrees of the
+// upper branches are hashed concurrently
+const parallelThreshold = 256
+
+// parallelLevels is the number of branch levels whose subtrees are hashed
+// concurrently: up to 256 goroutines
+const parallelLevels = 2
+
+// Hash returns the root hash of the trie. Large tries are hashed on
+// several cores: the subtrees below the upper branches are independent.
+func (t *Trie) Hash() common.Hash {
+	return t.Commit(nil)
+}
+
+// Commit returns the root hash of the trie like Hash and calls onNode with
+// the hash and RLP encoding of every node referenced by hash, which is
+// what a database persists to load the trie again. Nodes shorter than a
+// hash are embedded in their parent instead. Children are reported before
+// their parents and the root last, from the calling goroutine, even when
+// subtrees were hashed concurrently. A nil onNode only computes the root.
+func (t *Trie) Commit(onNode func(hash common.Hash, node []byte)) common.Hash {
+	if len(t.entries) == 0 {
+		return EmptyRoot
+	}
+	pairs := make([]pair, 0, len(t.entries))
+	for key, value := range t.entries {
+		pairs = append(pairs, pair{key: nibbles([]byte(key)), value: value})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return bytes.Compare(pairs[i].key, pairs[j].key) < 0 })
+	h := &hasher{collect: onNode != nil}
+	if len(pairs) >= parallelThreshold && runtime.GOMAXPROCS(0) > 1 {
+		h.levels = parallelLevels
+	}
+	root := h.encodeNode(pairs, 0)
+	hash := crypto.Keccak256Hash(root)
+	if onNode != nil {
+		for _, n := range h.nodes {
+			onNode(n.hash, n.blob)
+		}
+		onNode(hash, root)
+	}
+	return hash
+}
+
+// hashedNode is a node referenced by hash, collected for Commit
+type hashedNode struct {
+	hash common.Hash
+	blob []byte
+}
+
+// hasher encodes the nodes of a trie, or of a subtree
+type hasher struct {
+	collect bool         // collect the nodes referenced by hash
+	nodes   []hashedNode // children before parents
+	levels  int          // branch levels left whose subtrees are hashed concurrently
+}
+
+// nibbles splits a key into half-bytes
+func nibbles(key []byte) []byte {
+	out := make([]byte, 2*len(key))
+	for i, b := range key {
+		out[2*i], out[2*i+1] = b>>4, b&0x0f
+	}
+	return out
+}
+
+// compact encodes a nibble path with the hex-prefix encoding
+func compact(path []byte, leaf bool) []byte {
+	flag := byte(0)
+	if leaf {
+		flag = 2
+	}
+	var out []byte
+	if len(path)%2 == 1 {
+		out = append(out, (flag+1)<<4|path[0])
+		path = path[1:]
+	} else {
+		out = append(out, flag<<4)
+	}
+	for i := 0; i < len(path); i += 2 {
+		out = append(out, path[i]<<4|path[i+1])
+	}
+	return out
+}
+
+// encodeNode returns the RLP encoding of the node holding pairs, whose keys
+// share their first depth nibbles and are sorted
+func (h *hasher) encodeNode(pairs []pair, depth int) []byte {
+	if len(pairs) == 1 {
+		return rlp.EncodeList(rlp.EncodeBytes(compact(pairs[0].key[depth:], true)), rlp.EncodeBytes(pairs[0].value))
+	}
+	// extension over the prefix shared by all keys
+	first, last := pairs[0].key, pairs[len(pairs)-1].key
+	shared := 0
+	for depth+shared < len(first) && depth+shared < len(last) && first[depth+shared] == last[depth+shared] {
+		shared++
+	}
+	if shared > 0 {
+		child := h.encodeNode(pairs, depth+shared)
+		return rlp.EncodeList(rlp.EncodeBytes(compact(first[depth:depth+shared], false)), h.reference(child))
+	}
+	// branch on the next nibble
+	items := make([][]byte, 17)
+	value := rlp.EmptyString
+	var children [16][]pair
+	for nibble, start := byte(0), 0; nibble < 16; nibble++ {
+		if start < len(pairs) && len(pairs[start].key) == depth {
+			value = rlp.EncodeBytes(pairs[start].value)
+			start++
+		}
+		end := start
+		for end < len(pairs) && pairs[end].key[depth] == nibble {
+			end++
+		}
+		children[nibble] = pairs[start:end]
+		start = end
+	}
+	if h.levels > 0 && len(pairs) >= parallelThreshold {
+		h.encodeChildrenParallel(&children, items, depth)
+	} else {
+		for nibble, child := range children {
+			if len(child) == 0 {
+				items[nibble] = rlp.EmptyString
+			} else {
+				items[nibble] = h.reference(h.encodeNode(child, depth+1))
+			}
+		}
+	}
+	items[16] = value
+	return rlp.EncodeList(items...)
+}
+
+// encodeChildrenParallel sets the references of a branch's children,
+// hashing each subtree on a goroutine of its own with a hasher of its own.
+// The nodes they collect are appended in nibble order, so Commit reports
+// the same nodes in the same order as a sequential run.
+func (h *hasher) encodeChildrenParallel(children *[16][]pair, items [][]byte, depth int) {
+	var (
+		wg  sync.WaitGroup
+		sub [16]*hasher
+	)
+	for nibble, child := range children {
+		if len(child) == 0 {
+			items[nibble] = rlp.EmptyString
+			continue
+		}
+		sub[nibble] = &hasher{collect: h.collect, levels: h.levels - 1}
+		wg.Add(1)
+		go func(nibble int, child []pair) {
+			defer wg.Done()
+			items[nibble] = sub[nibble].reference(sub[nibble].encodeNode(child, depth+1))
+		}(nibble, child)
+	}
+	wg.Wait()
+	for _, s := range sub {
+		if s != nil {
+			h.nodes = append(h.nodes, s.nodes...)
+		}
+	}
+}
+
+// reference returns how a parent refers to a child node: embedded when its
+// encoding is shorter than a hash, by hash otherwise
+func (h *hasher) reference(node []byte) []byte {
+	if len(node) < 32 {
+		return node
+	}
+	hash := crypto.Keccak256Hash(node)
+	if h.collect {
+		h.nodes = append(h.nodes, hashedNode{hash: hash, blob: node})
+	}
+	return rlp.EncodeBytes(hash[:])
+}
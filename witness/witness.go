@@ -0,0 +1,294 @@
+// Package witness collects execution witnesses, the parts of a pre-state an
+// execution touches along with the trie nodes proving them, and re-executes
+// statelessly from a witness alone, recomputing the resulting state root
+// without the rest of the state.
+package witness
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nutcas3/evm-golang/common"
+	"github.com/nutcas3/evm-golang/common/hexutil"
+	"github.com/nutcas3/evm-golang/core/state"
+	"github.com/nutcas3/evm-golang/crypto"
+	"github.com/nutcas3/evm-golang/rlp"
+	"github.com/nutcas3/evm-golang/trie"
+)
+
+// Witness is what a stateless execution needs: the accounts and storage
+// slots the execution accesses, the account and storage trie nodes on
+// their paths from the pre-state root and the code of those accounts
+type Witness struct {
+	Root     common.Hash                      `json:"root"`     // pre-state root
+	Accounts map[common.Address][]common.Hash `json:"accounts"` // with the storage keys accessed
+	Nodes    []hexutil.Bytes                  `json:"nodes"`
+	Codes    []hexutil.Bytes                  `json:"codes"`
+}
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// Collect runs execute on statedb, recording what it accesses, and returns
+// the witness re-executing it needs. The witness is checked before it is
+// returned: the state root it yields after the execution must be that of
+// statedb.
+func Collect(statedb *state.StateDB, execute func(*state.StateDB) error) (*Witness, error) {
+	nodes := make(map[common.Hash][]byte)
+	root := statedb.Commit(func(hash common.Hash, node []byte) { nodes[hash] = node })
+	accesses := statedb.RecordAccesses()
+	err := execute(statedb)
+	statedb.StopRecording()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Witness{Root: root, Accounts: make(map[common.Address][]common.Hash, len(accesses.Slots))}
+	for addr, slots := range accesses.Slots {
+		keys := make([]common.Hash, 0, len(slots))
+		for key := range slots {
+			keys = append(keys, key)
+		}
+		w.Accounts[addr] = keys
+	}
+	w.sortKeys()
+
+	// the nodes and codes kept are those re-execution uses
+	used := make(map[common.Hash][]byte)
+	resolve := func(hash common.Hash) ([]byte, bool) {
+		node, ok := nodes[hash]
+		if ok {
+			used[hash] = node
+		}
+		return node, ok
+	}
+	codes := make(map[common.Hash][]byte)
+	code := func(hash common.Hash) ([]byte, bool) {
+		c, ok := statedb.Codes().Get(hash)
+		if ok {
+			codes[hash] = c
+		}
+		return c, ok
+	}
+	if _, err := w.preState(resolve, code); err != nil {
+		return nil, err
+	}
+	post, err := w.postRoot(resolve, statedb, accesses.Reset)
+	if err != nil {
+		return nil, err
+	}
+	if want := statedb.Root(); post != want {
+		return nil, fmt.Errorf("witness: post-state root %s, state root %s", post.Hex(), want.Hex())
+	}
+	w.Nodes, w.Codes = sortedValues(used), sortedValues(codes)
+	return w, nil
+}
+
+// Execute re-executes statelessly: it runs execute on the pre-state held
+// by the witness and returns the state root after it, computed from the
+// witness alone. Executions accessing state the witness does not hold
+// fail, as do witnesses whose nodes or codes do not match their root.
+func (w *Witness) Execute(execute func(*state.StateDB) error) (common.Hash, error) {
+	nodes := make(map[common.Hash][]byte, len(w.Nodes))
+	for _, node := range w.Nodes {
+		nodes[crypto.Keccak256Hash(node)] = node
+	}
+	codes := make(map[common.Hash][]byte, len(w.Codes))
+	for _, c := range w.Codes {
+		codes[crypto.Keccak256Hash(c)] = c
+	}
+	resolve := func(hash common.Hash) ([]byte, bool) {
+		node, ok := nodes[hash]
+		return node, ok
+	}
+	code := func(hash common.Hash) ([]byte, bool) {
+		c, ok := codes[hash]
+		return c, ok
+	}
+	w.sortKeys()
+	statedb, err := w.preState(resolve, code)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	accesses := statedb.RecordAccesses()
+	err = execute(statedb)
+	statedb.StopRecording()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for addr, slots := range accesses.Slots {
+		keys, ok := w.Accounts[addr]
+		if !ok {
+			return common.Hash{}, fmt.Errorf("witness: account %s accessed but not witnessed", addr.Hex())
+		}
+		for key := range slots {
+			i := sort.Search(len(keys), func(i int) bool { return keys[i].Cmp(key) >= 0 })
+			if i == len(keys) || keys[i] != key {
+				return common.Hash{}, fmt.Errorf("witness: slot %s of %s accessed but not witnessed", key.Hex(), addr.Hex())
+			}
+		}
+	}
+	return w.postRoot(resolve, statedb, accesses.Reset)
+}
+
+// sortKeys sorts the storage keys of every account
+func (w *Witness) sortKeys() {
+	for _, keys := range w.Accounts {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+	}
+}
+
+// addresses returns the witnessed accounts in order, so the trie
+// operations, and the nodes they resolve, are the same on every run
+func (w *Witness) addresses() []common.Address {
+	addrs := make([]common.Address, 0, len(w.Accounts))
+	for addr := range w.Accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Cmp(addrs[j]) < 0 })
+	return addrs
+}
+
+// account is an account leaf of the state trie
+type account struct {
+	nonce       uint64
+	balance     *big.Int
+	storageRoot common.Hash
+	codeHash    common.Hash
+}
+
+// preState reads the witnessed accounts and slots from the pre-state tries
+// into a new state
+func (w *Witness) preState(resolve trie.Resolver, code func(common.Hash) ([]byte, bool)) (*state.StateDB, error) {
+	statedb := state.New()
+	accounts := trie.Open(w.Root, resolve)
+	for _, addr := range w.addresses() {
+		acc, err := readAccount(accounts, addr)
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			continue
+		}
+		statedb.CreateAccount(addr)
+		statedb.SetNonce(addr, acc.nonce)
+		statedb.AddBalance(addr, acc.balance)
+		if acc.codeHash != emptyCodeHash {
+			c, ok := code(acc.codeHash)
+			if !ok {
+				return nil, fmt.Errorf("witness: code %s of %s missing", acc.codeHash.Hex(), addr.Hex())
+			}
+			statedb.SetCode(addr, c)
+		}
+		storage := trie.Open(acc.storageRoot, resolve)
+		for _, key := range w.Accounts[addr] {
+			enc, err := storage.Get(crypto.Keccak256(key[:]))
+			if err != nil {
+				return nil, fmt.Errorf("witness: slot %s of %s: %w", key.Hex(), addr.Hex(), err)
+			}
+			if enc == nil {
+				continue
+			}
+			_, value, _, err := rlp.Split(enc)
+			if err != nil {
+				return nil, fmt.Errorf("witness: slot %s of %s: %w", key.Hex(), addr.Hex(), err)
+			}
+			statedb.SetState(addr, key, common.BytesToHash(value))
+		}
+	}
+	return statedb, nil
+}
+
+// postRoot applies the witnessed accounts and slots of post to the
+// pre-state tries and returns the resulting root. The storage of accounts
+// in reset is taken whole from post.
+func (w *Witness) postRoot(resolve trie.Resolver, post *state.StateDB, reset map[common.Address]struct{}) (common.Hash, error) {
+	accounts := trie.Open(w.Root, resolve)
+	for _, addr := range w.addresses() {
+		pre, err := readAccount(accounts, addr)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		key := crypto.Keccak256(addr[:])
+		if !post.Exist(addr) {
+			if pre != nil {
+				if err := accounts.Update(key, nil); err != nil {
+					return common.Hash{}, fmt.Errorf("witness: account %s: %w", addr.Hex(), err)
+				}
+			}
+			continue
+		}
+		storageRoot := post.StorageRoot(addr)
+		if _, ok := reset[addr]; !ok && pre != nil {
+			storage := trie.Open(pre.storageRoot, resolve)
+			for _, slot := range w.Accounts[addr] {
+				var value []byte
+				if v := post.GetState(addr, slot); !v.IsZero() {
+					value = rlp.EncodeBig(v.Big())
+				}
+				if err := storage.Update(crypto.Keccak256(slot[:]), value); err != nil {
+					return common.Hash{}, fmt.Errorf("witness: slot %s of %s: %w", slot.Hex(), addr.Hex(), err)
+				}
+			}
+			storageRoot = storage.Hash()
+		}
+		codeHash := post.GetCodeHash(addr)
+		err = accounts.Update(key, rlp.EncodeList(
+			rlp.EncodeUint(post.GetNonce(addr)),
+			rlp.EncodeBig(post.GetBalance(addr)),
+			rlp.EncodeBytes(storageRoot[:]),
+			rlp.EncodeBytes(codeHash[:]),
+		))
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("witness: account %s: %w", addr.Hex(), err)
+		}
+	}
+	return accounts.Hash(), nil
+}
+
+// readAccount returns the account leaf of addr, nil if there is none
+func readAccount(accounts *trie.Partial, addr common.Address) (*account, error) {
+	leaf, err := accounts.Get(crypto.Keccak256(addr[:]))
+	if err != nil {
+		return nil, fmt.Errorf("witness: account %s: %w", addr.Hex(), err)
+	}
+	if leaf == nil {
+		return nil, nil
+	}
+	items, err := rlp.SplitList(leaf)
+	if err != nil || len(items) != 4 {
+		return nil, fmt.Errorf("witness: account %s: %w", addr.Hex(), errors.Join(errInvalidAccount, err))
+	}
+	var fields [4][]byte
+	for i, item := range items {
+		if _, fields[i], _, err = rlp.Split(item); err != nil {
+			return nil, fmt.Errorf("witness: account %s: %w", addr.Hex(), err)
+		}
+	}
+	if len(fields[0]) > 8 || len(fields[2]) != common.HashLength || len(fields[3]) != common.HashLength {
+		return nil, fmt.Errorf("witness: account %s: %w", addr.Hex(), errInvalidAccount)
+	}
+	return &account{
+		nonce:       new(big.Int).SetBytes(fields[0]).Uint64(),
+		balance:     new(big.Int).SetBytes(fields[1]),
+		storageRoot: common.BytesToHash(fields[2]),
+		codeHash:    common.BytesToHash(fields[3]),
+	}, nil
+}
+
+var errInvalidAccount = errors.New("invalid account leaf")
+
+// sortedValues returns the values of m ordered by key
+func sortedValues(m map[common.Hash][]byte) []hexutil.Bytes {
+	keys := make([]common.Hash, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+	values := make([]hexutil.Bytes, len(keys))
+	for i, key := range keys {
+		values[i] = m[key]
+	}
+	return values
+}